@@ -0,0 +1,152 @@
+package main
+
+import (
+	"edd/export"
+	"edd/importer"
+	"edd/loader"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	var (
+		format      = flag.String("f", "ascii", "Target format (ascii, mermaid, plantuml, json, graphviz, d2)")
+		inputFormat = flag.String("input-format", "", "Input format (mermaid, plantuml, graphviz, d2) - auto-detect if not specified")
+		asciiQuotes = flag.Bool("ascii-quotes", false, "Replace smart quotes in imported labels with plain ASCII quotes")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: convert [-f format] [-input-format format] <dir|glob>...\n\n")
+		fmt.Fprintf(os.Stderr, "Converts each matching file and writes the result alongside the source.\n\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	loader.ASCIIQuotes = *asciiQuotes
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Error: at least one directory or glob pattern required\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	targetFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	files, err := collectFiles(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no input files found\n")
+		os.Exit(1)
+	}
+
+	_, failed := convertFiles(files, targetFormat, *inputFormat, os.Stdout)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// collectFiles expands the given directories and glob patterns into a sorted list of files.
+// Directories contribute every file with an extension known to the importer registry.
+func collectFiles(patterns []string) ([]string, error) {
+	registry := importer.NewImporterRegistry()
+	seen := make(map[string]bool)
+	var files []string
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			entries, err := os.ReadDir(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("reading directory %s: %w", pattern, err)
+			}
+			for _, entry := range entries {
+				if entry.IsDir() {
+					continue
+				}
+				if _, err := registry.GetImporterByExtension(filepath.Ext(entry.Name())); err == nil {
+					add(filepath.Join(pattern, entry.Name()))
+				}
+			}
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				add(match)
+			}
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// convertFiles converts each file to the target format, writing the output next to the source.
+// Failures are reported and skipped so that one bad file does not stop the batch.
+func convertFiles(files []string, format export.Format, inputFormat string, out io.Writer) (succeeded, failed int) {
+	exporter, err := export.NewExporter(format)
+	if err != nil {
+		fmt.Fprintf(out, "FAIL  %v\n", err)
+		return 0, len(files)
+	}
+
+	for _, file := range files {
+		outputFile, err := convertFile(file, exporter, inputFormat)
+		if err != nil {
+			fmt.Fprintf(out, "FAIL  %s: %v\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "OK    %s -> %s\n", file, outputFile)
+		succeeded++
+	}
+
+	fmt.Fprintf(out, "\n%d converted, %d failed\n", succeeded, failed)
+	return succeeded, failed
+}
+
+// convertFile converts a single file and returns the path of the written output.
+func convertFile(file string, exporter export.Exporter, inputFormat string) (string, error) {
+	d, err := loader.Load(file, inputFormat, 0)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := exporter.Export(d)
+	if err != nil {
+		return "", fmt.Errorf("exporting: %w", err)
+	}
+
+	outputFile := strings.TrimSuffix(file, filepath.Ext(file)) + exporter.GetFileExtension()
+	if outputFile == file {
+		return "", fmt.Errorf("output would overwrite the source file")
+	}
+
+	if err := os.WriteFile(outputFile, []byte(output), 0644); err != nil {
+		return "", fmt.Errorf("writing output: %w", err)
+	}
+
+	return outputFile, nil
+}
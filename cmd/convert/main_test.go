@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"edd/export"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertDirectoryToASCII(t *testing.T) {
+	dir := t.TempDir()
+
+	inputs := map[string]string{
+		"flow.mmd": "graph TD\n    A[Start] --> B[End]\n",
+		"seq.mmd":  "sequenceDiagram\n    participant Alice\n    participant Bob\n    Alice->>Bob: Hello\n",
+	}
+	for name, content := range inputs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	files, err := collectFiles([]string{dir})
+	if err != nil {
+		t.Fatalf("collectFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d: %v", len(files), files)
+	}
+
+	var out bytes.Buffer
+	succeeded, failed := convertFiles(files, export.FormatASCII, "", &out)
+	if succeeded != 2 || failed != 0 {
+		t.Fatalf("Expected 2 converted and 0 failed, got %d and %d\n%s", succeeded, failed, out.String())
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"flow.txt", []string{"Start", "End"}},
+		{"seq.txt", []string{"Alice", "Bob", "Hello"}},
+	}
+	for _, tt := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Errorf("Expected output %s: %v", tt.file, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("%s missing %q:\n%s", tt.file, want, content)
+			}
+		}
+	}
+}
+
+func TestConvertContinuesOnError(t *testing.T) {
+	dir := t.TempDir()
+
+	good := filepath.Join(dir, "good.mmd")
+	bad := filepath.Join(dir, "bad.mmd")
+	os.WriteFile(good, []byte("graph LR\n    A --> B\n"), 0644)
+	os.WriteFile(bad, []byte("pie title Pets\n"), 0644)
+
+	files, err := collectFiles([]string{filepath.Join(dir, "*.mmd")})
+	if err != nil {
+		t.Fatalf("collectFiles failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	succeeded, failed := convertFiles(files, export.FormatASCII, "", &out)
+	if succeeded != 1 || failed != 1 {
+		t.Errorf("Expected 1 converted and 1 failed, got %d and %d", succeeded, failed)
+	}
+	if !strings.Contains(out.String(), "FAIL  "+bad) {
+		t.Errorf("Expected failure report for %s, got:\n%s", bad, out.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "good.txt")); err != nil {
+		t.Errorf("Expected good.txt to be written: %v", err)
+	}
+}
+
+func TestConvertNormalizesByteOrderMarkAndCRLF(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "windows.mmd")
+	content := "\ufeffgraph TD\r\n    A[Start] --> B[End]\r\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	var out bytes.Buffer
+	if succeeded, failed := convertFiles([]string{file}, export.FormatASCII, "", &out); succeeded != 1 || failed != 0 {
+		t.Fatalf("Expected the file converted, got %d converted and %d failed\n%s", succeeded, failed, out.String())
+	}
+	result, err := os.ReadFile(filepath.Join(dir, "windows.txt"))
+	if err != nil {
+		t.Fatalf("Expected output: %v", err)
+	}
+	for _, want := range []string{"Start", "End"} {
+		if !strings.Contains(string(result), want) {
+			t.Errorf("Expected %q in output:\n%s", want, result)
+		}
+	}
+}
+
+func TestConvertSniffsOutlineSavedAsTxt(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ideas.txt")
+	if err := os.WriteFile(file, []byte("Trip\n  Packing\n    Tent\n  Route\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	var out bytes.Buffer
+	if succeeded, failed := convertFiles([]string{file}, export.FormatMermaid, "", &out); succeeded != 1 || failed != 0 {
+		t.Fatalf("Expected the outline converted, got %d converted and %d failed\n%s", succeeded, failed, out.String())
+	}
+	result, err := os.ReadFile(filepath.Join(dir, "ideas.mmd"))
+	if err != nil {
+		t.Fatalf("Expected output: %v", err)
+	}
+	for _, want := range []string{"Trip", "Packing", "Tent", "Route"} {
+		if !strings.Contains(string(result), want) {
+			t.Errorf("Expected %q in output:\n%s", want, result)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"edd/export"
+	"edd/loader"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		inputFile   = flag.String("i", "", "Input file path (edd JSON, or any importable format)")
+		format      = flag.String("f", "ascii", "Target format (ascii, mermaid, plantuml, json, graphviz, d2, ...)")
+		inputFormat = flag.String("input-format", "", "Input format (json, mermaid, plantuml, graphviz, d2) - auto-detect if not specified")
+		output      = flag.String("o", "", "Output file path (default: stdout)")
+		blockIndex  = flag.Int("block", 0, "Which diagram of a multi-diagram JSON file to export (1-based index, default the first)")
+		asciiQuotes = flag.Bool("ascii-quotes", false, "Replace smart quotes in imported labels with plain ASCII quotes")
+	)
+
+	flag.Parse()
+	loader.ASCIIQuotes = *asciiQuotes
+
+	if *inputFile == "" {
+		fmt.Fprintf(os.Stderr, "Error: input file required (-i)\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	targetFormat, err := export.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := exportFile(*inputFile, targetFormat, *inputFormat, *blockIndex)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Output result
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(result), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Successfully exported diagram to %s\n", *output)
+	} else {
+		fmt.Print(result)
+		if !strings.HasSuffix(result, "\n") {
+			fmt.Println()
+		}
+	}
+}
+
+// exportFile loads the diagram in file, or the given 1-based block of a
+// multi-diagram file, and returns it exported to format
+func exportFile(file string, format export.Format, inputFormat string, block int) (string, error) {
+	d, err := loader.Load(file, inputFormat, block)
+	if err != nil {
+		return "", err
+	}
+
+	exporter, err := export.NewExporter(format)
+	if err != nil {
+		return "", err
+	}
+	output, err := exporter.Export(d)
+	if err != nil {
+		return "", fmt.Errorf("exporting: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"edd/export"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportFileFromJSON(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "diagram.json")
+	content := `{"nodes": [{"id": 1, "text": ["Start"]}, {"id": 2, "text": ["End"]}],
+		"connections": [{"from": 1, "to": 2, "arrow": true}]}`
+	if err := os.WriteFile(input, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format export.Format
+		want   []string
+	}{
+		{export.FormatASCII, []string{"Start", "End", "▼"}},
+		{export.FormatMermaid, []string{"graph", "Start", "-->"}},
+		{export.FormatGraphviz, []string{"digraph", "Start", "->"}},
+	}
+	for _, tt := range tests {
+		output, err := exportFile(input, tt.format, "", 0)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s output missing %q:\n%s", tt.format, want, output)
+			}
+		}
+	}
+}
+
+func TestExportFileImportsOtherFormats(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "flow.mmd")
+	os.WriteFile(input, []byte("graph TD\n    A[Start] --> B[End]\n"), 0644)
+
+	output, err := exportFile(input, export.FormatPlantUML, "", 0)
+	if err != nil {
+		t.Fatalf("exportFile failed: %v", err)
+	}
+	if !strings.Contains(output, "@startuml") || !strings.Contains(output, "Start") {
+		t.Errorf("Expected the Mermaid diagram exported as PlantUML, got:\n%s", output)
+	}
+
+	if _, err := exportFile(input, export.FormatASCII, "json", 0); err == nil {
+		t.Errorf("Expected an error reading Mermaid as JSON")
+	}
+	if _, err := exportFile(filepath.Join(dir, "missing.json"), export.FormatASCII, "", 0); err == nil {
+		t.Errorf("Expected an error for a missing input file")
+	}
+}
+
+func TestExportFilePicksBlockOfCollection(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "diagrams.json")
+	content := `{"diagrams": [
+		{"nodes": [{"id": 1, "text": ["First"]}], "connections": []},
+		{"nodes": [{"id": 1, "text": ["Second"]}], "connections": []}
+	]}`
+	os.WriteFile(input, []byte(content), 0644)
+
+	output, err := exportFile(input, export.FormatMermaid, "", 0)
+	if err != nil {
+		t.Fatalf("exportFile failed: %v", err)
+	}
+	if !strings.Contains(output, "First") {
+		t.Errorf("Expected the first diagram without -block, got:\n%s", output)
+	}
+
+	output, err = exportFile(input, export.FormatMermaid, "", 2)
+	if err != nil {
+		t.Fatalf("exportFile with block 2 failed: %v", err)
+	}
+	if !strings.Contains(output, "Second") || strings.Contains(output, "First") {
+		t.Errorf("Expected only the second diagram with -block 2, got:\n%s", output)
+	}
+
+	if _, err := exportFile(input, export.FormatMermaid, "", 3); err == nil {
+		t.Errorf("Expected an error for a block past the end of the collection")
+	}
+}
@@ -1,8 +1,8 @@
 package main
 
 import (
-	"edd/importer"
-	"encoding/json"
+	"edd/export"
+	"edd/loader"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -11,12 +11,15 @@ import (
 
 func main() {
 	var (
-		inputFile = flag.String("i", "", "Input file path")
-		format    = flag.String("f", "", "Format (mermaid, plantuml, graphviz, d2) - auto-detect if not specified")
-		output    = flag.String("o", "", "Output file path (default: stdout)")
+		inputFile   = flag.String("i", "", "Input file path")
+		format      = flag.String("f", "", "Format (mermaid, plantuml, graphviz, d2) - auto-detect if not specified")
+		output      = flag.String("o", "", "Output file path (default: stdout)")
+		indent      = flag.Int("indent", export.JSONIndentFromEnv(), "JSON indent width in spaces, 0 for compact single-line output (default from "+export.JSONIndentEnv+")")
+		asciiQuotes = flag.Bool("ascii-quotes", false, "Replace smart quotes in imported labels with plain ASCII quotes")
 	)
 
 	flag.Parse()
+	loader.ASCIIQuotes = *asciiQuotes
 
 	if *inputFile == "" {
 		fmt.Fprintf(os.Stderr, "Error: input file required (-i)\n")
@@ -32,7 +35,7 @@ func main() {
 	}
 
 	// Create importer registry
-	registry := importer.NewImporterRegistry()
+	registry := loader.NewRegistry()
 
 	// Import the diagram
 	var diagram interface{}
@@ -48,7 +51,7 @@ func main() {
 	}
 
 	// Convert to JSON
-	jsonData, err := json.MarshalIndent(diagram, "", "  ")
+	jsonData, err := export.MarshalJSON(diagram, *indent)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error converting to JSON: %v\n", err)
 		os.Exit(1)
@@ -65,4 +68,4 @@ func main() {
 	} else {
 		fmt.Println(string(jsonData))
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,65 @@
+package diagram
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Collection holds several related diagrams stored together in one JSON file
+// under a top-level "diagrams" array.
+type Collection struct {
+	Diagrams []Diagram `json:"diagrams"`
+}
+
+// ParseCollection decodes a multi-diagram JSON file. ok is false, with no
+// error, when data is not a collection, so callers can fall back to reading a
+// single diagram.
+func ParseCollection(data []byte) (c *Collection, ok bool, err error) {
+	var probe struct {
+		Diagrams json.RawMessage `json:"diagrams"`
+	}
+	if json.Unmarshal(data, &probe) != nil || probe.Diagrams == nil {
+		return nil, false, nil
+	}
+
+	c = &Collection{}
+	if err := json.Unmarshal(probe.Diagrams, &c.Diagrams); err != nil {
+		return nil, true, fmt.Errorf("parsing diagrams array: %w", err)
+	}
+	if len(c.Diagrams) == 0 {
+		return nil, true, fmt.Errorf("diagrams array is empty")
+	}
+	return c, true, nil
+}
+
+// Diagram returns a copy of the diagram at the given 0-based index
+func (c *Collection) Diagram(index int) (*Diagram, error) {
+	if index < 0 || index >= len(c.Diagrams) {
+		return nil, fmt.Errorf("invalid diagram index %d (file has %d diagrams)", index+1, len(c.Diagrams))
+	}
+	return c.Diagrams[index].Clone(), nil
+}
+
+// Replace stores d at the given 0-based index
+func (c *Collection) Replace(index int, d *Diagram) error {
+	if index < 0 || index >= len(c.Diagrams) {
+		return fmt.Errorf("invalid diagram index %d (file has %d diagrams)", index+1, len(c.Diagrams))
+	}
+	c.Diagrams[index] = *d.Clone()
+	return nil
+}
+
+// Label describes the diagram at the given 0-based index for pickers, using
+// its metadata name when it has one.
+func (c *Collection) Label(index int) string {
+	d := c.Diagrams[index]
+	name := d.Metadata.Name
+	if name == "" {
+		name = fmt.Sprintf("Diagram %d", index+1)
+	}
+	diagramType := d.Type
+	if diagramType == "" {
+		diagramType = "box"
+	}
+	return fmt.Sprintf("%d. %s (%s, %d nodes)", index+1, name, diagramType, len(d.Nodes))
+}
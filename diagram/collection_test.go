@@ -0,0 +1,90 @@
+package diagram
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const twoDiagramFile = `{
+  "diagrams": [
+    {
+      "type": "box",
+      "metadata": {"name": "Overview"},
+      "nodes": [{"id": 1, "text": ["Web"]}, {"id": 2, "text": ["API"]}],
+      "connections": [{"from": 1, "to": 2}]
+    },
+    {
+      "type": "sequence",
+      "nodes": [{"id": 1, "text": ["Client"]}, {"id": 2, "text": ["Server"]}],
+      "connections": [{"from": 1, "to": 2, "label": "login"}]
+    }
+  ]
+}`
+
+func TestParseCollectionSelectsDiagram(t *testing.T) {
+	c, ok, err := ParseCollection([]byte(twoDiagramFile))
+	if err != nil || !ok {
+		t.Fatalf("Expected a collection, got ok=%v err=%v", ok, err)
+	}
+	if len(c.Diagrams) != 2 {
+		t.Fatalf("Expected 2 diagrams, got %d", len(c.Diagrams))
+	}
+
+	d, err := c.Diagram(1)
+	if err != nil {
+		t.Fatalf("Diagram(1) failed: %v", err)
+	}
+	if d.Type != "sequence" || d.Nodes[0].Text[0] != "Client" || d.Connections[0].Label != "login" {
+		t.Errorf("Expected the second diagram, got %+v", d)
+	}
+
+	if got := c.Label(0); got != "1. Overview (box, 2 nodes)" {
+		t.Errorf("Label(0) = %q", got)
+	}
+	if got := c.Label(1); got != "2. Diagram 2 (sequence, 2 nodes)" {
+		t.Errorf("Label(1) = %q", got)
+	}
+	if _, err := c.Diagram(2); err == nil {
+		t.Errorf("Expected an error for an out of range index")
+	}
+}
+
+func TestParseCollectionIgnoresSingleDiagrams(t *testing.T) {
+	for _, data := range []string{`{"nodes": [{"id": 1, "text": ["A"]}]}`, `graph TD`} {
+		if _, ok, err := ParseCollection([]byte(data)); ok || err != nil {
+			t.Errorf("Expected %q not to be a collection, got ok=%v err=%v", data, ok, err)
+		}
+	}
+
+	if _, ok, err := ParseCollection([]byte(`{"diagrams": []}`)); !ok || err == nil {
+		t.Errorf("Expected an empty diagrams array to be an error, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestCollectionReplaceKeepsOtherDiagrams(t *testing.T) {
+	c, _, err := ParseCollection([]byte(twoDiagramFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, _ := c.Diagram(1)
+	d.Nodes[1].Text = []string{"Auth"}
+	if err := c.Replace(1, d); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, ok, err := ParseCollection(data)
+	if err != nil || !ok {
+		t.Fatalf("Expected the saved collection to parse, got ok=%v err=%v", ok, err)
+	}
+	if reloaded.Diagrams[1].Nodes[1].Text[0] != "Auth" {
+		t.Errorf("Expected the edit to be saved, got %+v", reloaded.Diagrams[1].Nodes)
+	}
+	if reloaded.Diagrams[0].Metadata.Name != "Overview" || len(reloaded.Diagrams[0].Nodes) != 2 {
+		t.Errorf("Expected the first diagram to be untouched, got %+v", reloaded.Diagrams[0])
+	}
+}
@@ -0,0 +1,93 @@
+package diagram
+
+import "sort"
+
+// ConnectedComponents groups node IDs into connected components, ignoring
+// connection direction. Components are ordered by their lowest node ID and
+// the IDs within each are sorted, so the result is deterministic.
+func ConnectedComponents(nodes []Node, connections []Connection) [][]int {
+	adjacent := make(map[int][]int)
+	for _, conn := range connections {
+		if conn.From == conn.To {
+			continue
+		}
+		adjacent[conn.From] = append(adjacent[conn.From], conn.To)
+		adjacent[conn.To] = append(adjacent[conn.To], conn.From)
+	}
+
+	nodeIDs := make([]int, 0, len(nodes))
+	for _, node := range nodes {
+		nodeIDs = append(nodeIDs, node.ID)
+	}
+	sort.Ints(nodeIDs)
+
+	visited := make(map[int]bool)
+	var dfs func(nodeID int, component *[]int)
+	dfs = func(nodeID int, component *[]int) {
+		if visited[nodeID] {
+			return
+		}
+		visited[nodeID] = true
+		*component = append(*component, nodeID)
+		for _, neighbor := range adjacent[nodeID] {
+			dfs(neighbor, component)
+		}
+	}
+
+	components := make([][]int, 0)
+	for _, nodeID := range nodeIDs {
+		if !visited[nodeID] {
+			component := make([]int, 0)
+			dfs(nodeID, &component)
+			sort.Ints(component)
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// Neighborhood returns the sorted IDs of a node and every node directly
+// connected to it in either direction.
+func Neighborhood(connections []Connection, nodeID int) []int {
+	seen := map[int]bool{nodeID: true}
+	for _, conn := range connections {
+		if conn.From == nodeID {
+			seen[conn.To] = true
+		}
+		if conn.To == nodeID {
+			seen[conn.From] = true
+		}
+	}
+	ids := make([]int, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Subgraph returns a copy of the diagram holding only the given nodes and
+// the connections between them. Node and connection order is preserved.
+func (d *Diagram) Subgraph(nodeIDs []int) *Diagram {
+	keep := make(map[int]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		keep[id] = true
+	}
+
+	sub := d.Clone()
+	nodes := sub.Nodes[:0]
+	for _, node := range sub.Nodes {
+		if keep[node.ID] {
+			nodes = append(nodes, node)
+		}
+	}
+	sub.Nodes = nodes
+	connections := sub.Connections[:0]
+	for _, conn := range sub.Connections {
+		if keep[conn.From] && keep[conn.To] {
+			connections = append(connections, conn)
+		}
+	}
+	sub.Connections = connections
+	return sub
+}
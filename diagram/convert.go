@@ -0,0 +1,192 @@
+package diagram
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// sequenceNodeHints and sequenceConnectionHints only mean something in
+// sequence diagrams, so they are dropped when converting to a box diagram.
+var (
+	sequenceNodeHints       = []string{"lifeline-color", "lifeline-style"}
+	sequenceConnectionHints = []string{"activate", "activate_source", "deactivate"}
+)
+
+// ConvertToSequence turns a box diagram into a sequence diagram. Nodes become
+// participants ordered left to right by following the connections, and
+// connections become messages sent in that same order: a node's messages come
+// after every message it receives. A bidirectional connection becomes a
+// message and its reply. When the connections form a cycle there is no such
+// order; the nodes on the cycle keep their original order and cyclic is true
+// so the caller can warn that the message order is a guess.
+func ConvertToSequence(d *Diagram) (cyclic bool) {
+	if d == nil {
+		return false
+	}
+
+	position := make(map[int]int, len(d.Nodes))
+	for i, node := range d.Nodes {
+		position[node.ID] = i
+	}
+	inDegree := make(map[int]int, len(d.Nodes))
+	outgoing := make(map[int][]int)
+	for _, conn := range d.Connections {
+		if conn.From == conn.To {
+			continue
+		}
+		if _, ok := position[conn.From]; !ok {
+			continue
+		}
+		if _, ok := position[conn.To]; !ok {
+			continue
+		}
+		outgoing[conn.From] = append(outgoing[conn.From], conn.To)
+		inDegree[conn.To]++
+	}
+
+	// Topological order, taking the earliest ready node from the original
+	// order each time so unrelated nodes keep their relative positions
+	rank := make(map[int]int, len(d.Nodes))
+	for len(rank) < len(d.Nodes) {
+		next := -1
+		for i, node := range d.Nodes {
+			if _, done := rank[node.ID]; !done && inDegree[node.ID] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// Every remaining node is on a cycle; break it at the first one
+			cyclic = true
+			for i, node := range d.Nodes {
+				if _, done := rank[node.ID]; !done {
+					next = i
+					break
+				}
+			}
+		}
+		id := d.Nodes[next].ID
+		rank[id] = len(rank)
+		for _, to := range outgoing[id] {
+			inDegree[to]--
+		}
+	}
+
+	sort.SliceStable(d.Nodes, func(i, j int) bool {
+		return rank[d.Nodes[i].ID] < rank[d.Nodes[j].ID]
+	})
+
+	messages := append([]Connection(nil), d.Connections...)
+	sort.SliceStable(messages, func(i, j int) bool {
+		if rank[messages[i].From] != rank[messages[j].From] {
+			return rank[messages[i].From] < rank[messages[j].From]
+		}
+		return rank[messages[i].To] < rank[messages[j].To]
+	})
+
+	nextID := 0
+	for _, conn := range d.Connections {
+		if conn.ID >= nextID {
+			nextID = conn.ID + 1
+		}
+	}
+	d.Connections = d.Connections[:0]
+	for _, msg := range messages {
+		msg.Arrow = true
+		bidirectional := msg.Hints["bidirectional"] == "true"
+		msg.Hints = withoutHints(msg.Hints, "bidirectional")
+		d.Connections = append(d.Connections, msg)
+		if bidirectional {
+			d.Connections = append(d.Connections, Connection{
+				ID:    nextID,
+				From:  msg.To,
+				To:    msg.From,
+				Arrow: true,
+			})
+			nextID++
+		}
+	}
+
+	d.Type = string(DiagramTypeSequence)
+	return cyclic
+}
+
+// ConvertToBox turns a sequence diagram into a box diagram. All messages
+// between the same two participants become a single connection in the
+// direction of the first one, marked bidirectional if messages went both
+// ways, and labeled with each distinct message label. Hints that only apply
+// to sequence diagrams are removed.
+func ConvertToBox(d *Diagram) {
+	if d == nil {
+		return
+	}
+
+	type pair struct{ a, b int }
+	key := func(c Connection) pair {
+		if c.From > c.To {
+			return pair{c.To, c.From}
+		}
+		return pair{c.From, c.To}
+	}
+
+	var edges []Connection
+	var labels [][]string
+	index := make(map[pair]int)
+	for _, msg := range d.Connections {
+		k := key(msg)
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(edges)
+			edge := msg
+			edge.Arrow = true
+			edge.Label = ""
+			edge.Hints = withoutHints(msg.Hints, sequenceConnectionHints...)
+			edges = append(edges, edge)
+			labels = append(labels, nil)
+			i = len(edges) - 1
+		} else if msg.From != edges[i].From {
+			edges[i].Hints = withHint(edges[i].Hints, "bidirectional", "true")
+		}
+
+		if label := strings.TrimSpace(msg.Label); label != "" && !slices.Contains(labels[i], label) {
+			labels[i] = append(labels[i], label)
+		}
+	}
+	for i := range edges {
+		edges[i].Label = strings.Join(labels[i], ", ")
+	}
+	d.Connections = edges
+
+	for i := range d.Nodes {
+		d.Nodes[i].Hints = withoutHints(d.Nodes[i].Hints, sequenceNodeHints...)
+	}
+
+	d.Type = "box"
+}
+
+// withoutHints returns hints minus the given keys, or nil if none are left.
+// The original map is not modified.
+func withoutHints(hints map[string]string, keys ...string) map[string]string {
+	var result map[string]string
+	for k, v := range hints {
+		if slices.Contains(keys, k) {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// withHint returns a copy of hints with key set to value.
+func withHint(hints map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(hints)+1)
+	for k, v := range hints {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
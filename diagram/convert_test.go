@@ -0,0 +1,138 @@
+package diagram
+
+import (
+	"reflect"
+	"testing"
+)
+
+// messageOrder lists connections as from->to pairs, in order
+func messageOrder(d *Diagram) [][2]int {
+	var order [][2]int
+	for _, conn := range d.Connections {
+		order = append(order, [2]int{conn.From, conn.To})
+	}
+	return order
+}
+
+func TestConvertToSequence(t *testing.T) {
+	// Connections listed out of flow order, with a request/response pair
+	d := &Diagram{
+		Type: "box",
+		Nodes: []Node{
+			{ID: 3, Text: []string{"Database"}},
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"Server"}},
+		},
+		Connections: []Connection{
+			{ID: 0, From: 2, To: 3, Label: "query"},
+			{ID: 1, From: 1, To: 2, Label: "request", Hints: map[string]string{"bidirectional": "true"}},
+		},
+	}
+
+	if cyclic := ConvertToSequence(d); cyclic {
+		t.Error("Expected an acyclic diagram not to be reported as cyclic")
+	}
+
+	if !d.IsSequence() {
+		t.Errorf("Expected sequence type, got %q", d.Type)
+	}
+	var participants []int
+	for _, node := range d.Nodes {
+		participants = append(participants, node.ID)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(participants, want) {
+		t.Errorf("Participant order = %v, want %v", participants, want)
+	}
+	if got, want := messageOrder(d), [][2]int{{1, 2}, {2, 1}, {2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Message order = %v, want %v", got, want)
+	}
+	for _, msg := range d.Connections {
+		if !msg.Arrow {
+			t.Errorf("Expected message %d->%d to have an arrow", msg.From, msg.To)
+		}
+		if _, ok := msg.Hints["bidirectional"]; ok {
+			t.Errorf("Expected the bidirectional hint to be dropped from %d->%d", msg.From, msg.To)
+		}
+	}
+	if d.Connections[1].ID == d.Connections[0].ID || d.Connections[1].ID == d.Connections[2].ID {
+		t.Errorf("Expected the reply to get a new ID, got %d", d.Connections[1].ID)
+	}
+}
+
+func TestConvertToSequenceReportsCycles(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: 1}, {ID: 2}, {ID: 3}},
+		Connections: []Connection{
+			{ID: 0, From: 1, To: 2},
+			{ID: 1, From: 2, To: 3},
+			{ID: 2, From: 3, To: 1},
+		},
+	}
+
+	if cyclic := ConvertToSequence(d); !cyclic {
+		t.Error("Expected the cycle to be reported")
+	}
+	if len(d.Connections) != 3 {
+		t.Errorf("Expected all 3 connections to become messages, got %d", len(d.Connections))
+	}
+}
+
+func TestConvertToBox(t *testing.T) {
+	d := &Diagram{
+		Type: "sequence",
+		Nodes: []Node{
+			{ID: 1, Text: []string{"Client"}, Hints: map[string]string{"lifeline-style": "dashed"}},
+			{ID: 2, Text: []string{"Server"}},
+			{ID: 3, Text: []string{"Cache"}},
+		},
+		Connections: []Connection{
+			{ID: 0, From: 1, To: 2, Label: "GET /", Hints: map[string]string{"activate": "true"}},
+			{ID: 1, From: 2, To: 3, Label: "lookup"},
+			{ID: 2, From: 2, To: 1, Label: "200 OK"},
+			{ID: 3, From: 1, To: 2, Label: "GET /"},
+		},
+	}
+
+	ConvertToBox(d)
+
+	if d.IsSequence() {
+		t.Errorf("Expected box type, got %q", d.Type)
+	}
+	if got, want := messageOrder(d), [][2]int{{1, 2}, {2, 3}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Edges = %v, want %v", got, want)
+	}
+
+	request := d.Connections[0]
+	if request.Label != "GET /, 200 OK" {
+		t.Errorf("Expected distinct labels to be combined, got %q", request.Label)
+	}
+	if request.Hints["bidirectional"] != "true" {
+		t.Errorf("Expected messages both ways to give a bidirectional edge, hints %v", request.Hints)
+	}
+	if _, ok := request.Hints["activate"]; ok {
+		t.Errorf("Expected sequence-only hints to be dropped, hints %v", request.Hints)
+	}
+	if d.Connections[1].Hints["bidirectional"] == "true" {
+		t.Error("Expected a one-way message to stay one-way")
+	}
+	if len(d.Nodes[0].Hints) != 0 {
+		t.Errorf("Expected lifeline hints to be dropped, got %v", d.Nodes[0].Hints)
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: 1}, {ID: 2}},
+		Connections: []Connection{
+			{ID: 0, From: 1, To: 2, Label: "sync", Arrow: true, Hints: map[string]string{"bidirectional": "true"}},
+		},
+	}
+	original := d.Clone()
+
+	ConvertToSequence(d)
+	ConvertToBox(d)
+
+	if !reflect.DeepEqual(d.Connections, original.Connections) {
+		t.Errorf("Round trip changed connections:\ngot  %+v\nwant %+v", d.Connections, original.Connections)
+	}
+}
@@ -1,9 +1,16 @@
 // Package core contains the fundamental types used throughout the edd diagram renderer.
 package diagram
 
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
 // Point represents a 2D coordinate in the render.
 type Point struct {
-	X, Y int
+	X int `json:"x"`
+	Y int `json:"y"`
 }
 
 // Direction represents a cardinal direction.
@@ -57,6 +64,9 @@ type Node struct {
 	Y      int               `json:"-"` // Set by layout engine
 	Width  int               `json:"-"` // Calculated from text
 	Height int               `json:"-"` // Calculated from text
+
+	// Comment is an author's note, saved with the diagram but never drawn
+	Comment string `json:"comment,omitempty"`
 }
 
 // Center returns the center point of the node.
@@ -73,14 +83,100 @@ func (n Node) Contains(p Point) bool {
 		p.Y >= n.Y && p.Y < n.Y+n.Height
 }
 
+// IsText reports whether the node is a free-floating text label (the
+// "shape": "text" hint), drawn without a border.
+func (n Node) IsText() bool {
+	return n.Hints["shape"] == "text"
+}
+
 // Connection represents a directed edge between nodes.
 type Connection struct {
 	ID    int               `json:"id,omitempty"`    // Unique connection identifier  
 	From  int               `json:"from"`            // Source node ID
 	To    int               `json:"to"`              // Target node ID
-	Arrow bool              `json:"arrow,omitempty"` // Whether this connection should have an arrow
+	Arrow bool              `json:"arrow"`           // Whether this connection should have an arrow; saved only when false
 	Label string            `json:"label,omitempty"` // Optional label for the connection
 	Hints map[string]string `json:"hints,omitempty"` // Visual hints (style, color, etc.)
+	Path  []PathCell        `json:"path,omitempty"`  // Hand-built path, drawn as is when the "raw-path" hint is "true"
+
+	// Waypoints fix the route to run straight between each point in turn,
+	// from the first to the last, instead of being found by the router
+	Waypoints []Point `json:"waypoints,omitempty"`
+
+	// Comment is an author's note, saved with the diagram but never drawn
+	Comment string `json:"comment,omitempty"`
+
+	// Order places a sequence diagram message among the others, lowest
+	// first. Messages with the same Order, such as the default 0, are drawn
+	// in the order they are listed.
+	Order int `json:"order,omitempty"`
+}
+
+// connectionFields has Connection's fields without its JSON methods
+type connectionFields Connection
+
+// MarshalJSON encodes the connection leaving out the fields at their
+// defaults: empty ones, and the arrow unless it has been turned off
+func (c Connection) MarshalJSON() ([]byte, error) {
+	encoded := struct {
+		connectionFields
+		Arrow *bool `json:"arrow,omitempty"`
+	}{connectionFields: connectionFields(c)}
+	if !c.Arrow {
+		encoded.Arrow = &c.Arrow
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes a connection, giving it an arrow unless "arrow" is
+// false
+func (c *Connection) UnmarshalJSON(data []byte) error {
+	decoded := struct {
+		*connectionFields
+		Arrow *bool `json:"arrow"`
+	}{connectionFields: (*connectionFields)(c)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	c.Arrow = decoded.Arrow == nil || *decoded.Arrow
+	return nil
+}
+
+// PathCell is one character of a hand-built connection path, in the same
+// coordinates the layout places nodes in.
+type PathCell struct {
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Rune string `json:"rune"` // Character drawn at X,Y; empty leaves the cell blank
+}
+
+// HasRawPath reports whether the connection skips routing and is drawn from
+// its hand-built Path instead.
+func (c Connection) HasRawPath() bool {
+	return c.Hints["raw-path"] == "true" && len(c.Path) > 0
+}
+
+// RawPath returns the points of the connection's hand-built Path
+func (c Connection) RawPath() Path {
+	points := make([]Point, len(c.Path))
+	for i, cell := range c.Path {
+		points[i] = Point{X: cell.X, Y: cell.Y}
+	}
+	return Path{Points: points, Metadata: map[string]interface{}{"raw": true}}
+}
+
+// HasWaypoints reports whether the connection follows its Waypoints rather
+// than being routed
+func (c Connection) HasWaypoints() bool {
+	return len(c.Waypoints) >= 2
+}
+
+// WaypointPath returns the path through the connection's Waypoints
+func (c Connection) WaypointPath() Path {
+	return Path{
+		Points:   append([]Point(nil), c.Waypoints...),
+		Metadata: map[string]interface{}{"waypoints": true},
+	}
 }
 
 // DiagramType represents the type of diagram
@@ -99,6 +195,7 @@ type Diagram struct {
 	Connections []Connection      `json:"connections"`
 	Metadata    Metadata          `json:"metadata,omitempty"`
 	Hints       map[string]string `json:"hints,omitempty"`     // Diagram-level hints (layout, title, etc.)
+	Legend      map[string]string `json:"legend,omitempty"`    // Meaning of each connection color/style, keyed by color or style name
 }
 
 // GetType returns the diagram type as a DiagramType constant
@@ -119,6 +216,19 @@ func (d *Diagram) IsFlowchart() bool {
 	return d.GetType() == DiagramTypeFlowchart || d.Type == ""
 }
 
+// MessageOrder returns the indexes of the diagram's connections in the
+// order a sequence diagram draws them: by Order, and then as listed
+func (d *Diagram) MessageOrder() []int {
+	order := make([]int, len(d.Connections))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return d.Connections[order[i]].Order < d.Connections[order[j]].Order
+	})
+	return order
+}
+
 // Clone creates a deep copy of the diagram
 func (d *Diagram) Clone() *Diagram {
 	if d == nil {
@@ -139,6 +249,14 @@ func (d *Diagram) Clone() *Diagram {
 			clone.Hints[k] = v
 		}
 	}
+
+	// Deep copy legend if it exists
+	if d.Legend != nil {
+		clone.Legend = make(map[string]string)
+		for k, v := range d.Legend {
+			clone.Legend[k] = v
+		}
+	}
 	
 	// Deep copy nodes (need to copy the Text slice and Hints map)
 	for i, node := range d.Nodes {
@@ -151,6 +269,8 @@ func (d *Diagram) Clone() *Diagram {
 			Y:      node.Y,
 			Width:  node.Width,
 			Height: node.Height,
+
+			Comment: node.Comment,
 		}
 		// Deep copy hints map if it exists
 		if node.Hints != nil {
@@ -169,6 +289,9 @@ func (d *Diagram) Clone() *Diagram {
 			To:    conn.To,
 			Arrow: conn.Arrow,
 			Label: conn.Label,
+
+			Comment: conn.Comment,
+			Order:   conn.Order,
 		}
 		// Deep copy hints map if it exists
 		if conn.Hints != nil {
@@ -177,6 +300,12 @@ func (d *Diagram) Clone() *Diagram {
 				clone.Connections[i].Hints[k] = v
 			}
 		}
+		if conn.Waypoints != nil {
+			clone.Connections[i].Waypoints = append([]Point(nil), conn.Waypoints...)
+		}
+		if conn.Path != nil {
+			clone.Connections[i].Path = append([]PathCell(nil), conn.Path...)
+		}
 	}
 	
 	return clone
@@ -184,9 +313,47 @@ func (d *Diagram) Clone() *Diagram {
 
 // Metadata contains optional diagram metadata.
 type Metadata struct {
-	Name    string `json:"name,omitempty"`
-	Created string `json:"created,omitempty"`
-	Version string `json:"version,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Description string `json:"description,omitempty"`
+	Created     string `json:"created,omitempty"`
+	Version     string `json:"version,omitempty"`
+}
+
+// MetadataFields lists the metadata fields that can be edited by name.
+var MetadataFields = []string{"name", "author", "description", "created"}
+
+// Get returns the value of an editable metadata field.
+func (m Metadata) Get(field string) (string, error) {
+	switch field {
+	case "name":
+		return m.Name, nil
+	case "author":
+		return m.Author, nil
+	case "description":
+		return m.Description, nil
+	case "created":
+		return m.Created, nil
+	default:
+		return "", fmt.Errorf("unknown metadata field: %s", field)
+	}
+}
+
+// Set updates an editable metadata field. An empty value clears it.
+func (m *Metadata) Set(field, value string) error {
+	switch field {
+	case "name":
+		m.Name = value
+	case "author":
+		m.Author = value
+	case "description":
+		m.Description = value
+	case "created":
+		m.Created = value
+	default:
+		return fmt.Errorf("unknown metadata field: %s", field)
+	}
+	return nil
 }
 
 // Path represents a route through the render.
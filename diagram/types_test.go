@@ -127,6 +127,66 @@ func TestDiagramCloneWithNodeHints(t *testing.T) {
 	}
 }
 
+func TestDiagramCloneIsDeep(t *testing.T) {
+	original := &Diagram{
+		Type: "box",
+		Nodes: []Node{
+			{ID: 1, Text: []string{"A", "second line"}, Hints: map[string]string{"color": "red"}, X: 2, Y: 3, Width: 8, Height: 4},
+			{ID: 2, Text: []string{"B"}},
+		},
+		Connections: []Connection{
+			{
+				ID: 0, From: 1, To: 2, Arrow: true, Label: "go",
+				Hints:     map[string]string{"style": "dashed"},
+				Waypoints: []Point{{X: 5, Y: 6}},
+				Path:      []PathCell{{X: 1, Y: 1, Rune: "│"}},
+			},
+		},
+		Metadata: Metadata{Name: "Flow"},
+		Hints:    map[string]string{"layout": "horizontal"},
+		Legend:   map[string]string{"red": "errors"},
+	}
+	want := &Diagram{}
+	data, _ := json.Marshal(original)
+	json.Unmarshal(data, want)
+
+	clone := original.Clone()
+	if !reflect.DeepEqual(clone, original) {
+		t.Fatalf("Expected an equal clone, got %+v", clone)
+	}
+
+	// Mutate every slice and map the clone holds
+	clone.Type = "sequence"
+	clone.Nodes[0].Text[0] = "changed"
+	clone.Nodes[0].Text = append(clone.Nodes[0].Text, "extra")
+	clone.Nodes[0].Hints["color"] = "blue"
+	clone.Nodes[0].Hints["new"] = "hint"
+	clone.Nodes[1].ID = 9
+	clone.Nodes = append(clone.Nodes, Node{ID: 3})
+	clone.Connections[0].Hints["style"] = "dotted"
+	clone.Connections[0].Waypoints[0].X = 50
+	clone.Connections[0].Path[0].Rune = "─"
+	clone.Connections[0].Label = "stop"
+	clone.Connections = append(clone.Connections, Connection{From: 2, To: 1})
+	clone.Metadata.Name = "Other"
+	clone.Hints["layout"] = "vertical"
+	clone.Legend["red"] = "warnings"
+
+	got := &Diagram{}
+	data, _ = json.Marshal(original)
+	json.Unmarshal(data, got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mutating the clone changed the original:\n got  %+v\n want %+v", got, want)
+	}
+	if original.Nodes[0].X != 2 || original.Nodes[0].Width != 8 {
+		t.Errorf("Expected layout fields left alone, got %+v", original.Nodes[0])
+	}
+
+	if (*Diagram)(nil).Clone() != nil {
+		t.Error("Expected a nil diagram to clone to nil")
+	}
+}
+
 func TestNodeHintsOmitEmpty(t *testing.T) {
 	// Test that empty hints map is omitted from JSON
 	node := Node{
@@ -155,4 +215,47 @@ func TestNodeHintsOmitEmpty(t *testing.T) {
 	jsonStr = string(data)
 	// Note: empty map might still appear in JSON as "hints":{}, 
 	// but omitempty should handle nil case
-}
\ No newline at end of file
+}
+
+func TestConnectionJSONOmitsDefaults(t *testing.T) {
+	conn := Connection{ID: 1, From: 1, To: 2, Arrow: true, Hints: map[string]string{}}
+
+	data, err := json.Marshal(conn)
+	if err != nil {
+		t.Fatalf("Failed to marshal connection: %v", err)
+	}
+	if got, want := string(data), `{"id":1,"from":1,"to":2}`; got != want {
+		t.Errorf("Expected a default connection without arrow or hints, got %s want %s", got, want)
+	}
+
+	var loaded Connection
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Failed to unmarshal connection: %v", err)
+	}
+	conn.Hints = nil // Empty and missing hints are the same thing
+	if !reflect.DeepEqual(loaded, conn) {
+		t.Errorf("Expected the connection to reload identically, got %+v want %+v", loaded, conn)
+	}
+
+	// Turning the arrow off is the one case that is saved
+	conn = Connection{From: 2, To: 3, Label: "async", Hints: map[string]string{"style": "dashed"}}
+	data, err = json.Marshal(conn)
+	if err != nil {
+		t.Fatalf("Failed to marshal connection: %v", err)
+	}
+	if !strings.Contains(string(data), `"arrow":false`) {
+		t.Errorf("Expected an arrowless connection to save arrow false, got %s", data)
+	}
+	loaded = Connection{}
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Failed to unmarshal connection: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, conn) {
+		t.Errorf("Expected the arrowless connection to reload identically, got %+v want %+v", loaded, conn)
+	}
+
+	// Diagrams saved before arrows were left out still load theirs
+	if err := json.Unmarshal([]byte(`{"from":1,"to":2,"arrow":true}`), &loaded); err != nil || !loaded.Arrow {
+		t.Errorf("Expected an explicit arrow to load, got %+v (%v)", loaded, err)
+	}
+}
@@ -0,0 +1,1142 @@
+package editor
+
+import (
+	"edd/diagram"
+	"edd/export"
+	"edd/layout"
+	"edd/render"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// runCommand types a : command followed by Enter
+func runCommand(tui *TUIEditor, cmd string) {
+	tui.HandleKey(':')
+	for _, r := range cmd {
+		tui.HandleKey(r)
+	}
+	tui.HandleKey(13)
+}
+
+func newCommandTestEditor() *TUIEditor {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"Server"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+		},
+	})
+	return tui
+}
+
+func TestThemeCommand(t *testing.T) {
+	tui := newCommandTestEditor()
+
+	runCommand(tui, "theme dark")
+
+	if got := tui.GetDiagramHint("theme"); got != "dark" {
+		t.Fatalf("Expected theme hint 'dark', got %q", got)
+	}
+
+	theme, _ := render.GetTheme("dark")
+	output := tui.Render()
+	if !strings.Contains(output, render.GetColorCode(theme.NodeColor)) {
+		t.Errorf("Expected render to use resolved node color %s", theme.NodeColor)
+	}
+	if !strings.Contains(output, render.GetColorCode(theme.ConnectionColor)) {
+		t.Errorf("Expected render to use resolved connection color %s", theme.ConnectionColor)
+	}
+
+	// The stored diagram keeps only the theme, not the resolved colors
+	if tui.GetDiagram().Nodes[0].Hints["color"] != "" {
+		t.Errorf("Theme should not write colors into the diagram")
+	}
+}
+
+func TestThemeCommandSingleUndo(t *testing.T) {
+	tui := newCommandTestEditor()
+
+	// Preview several themes with Tab before applying
+	tui.HandleKey(':')
+	for _, r := range "theme" {
+		tui.HandleKey(r)
+	}
+	tui.HandleKey(9)
+	tui.HandleKey(9)
+	if got := tui.GetCommand(); got != "theme light" {
+		t.Errorf("Expected Tab to cycle to 'theme light', got %q", got)
+	}
+	if got := tui.GetDiagramHint("theme"); got != "light" {
+		t.Errorf("Expected preview to set theme 'light', got %q", got)
+	}
+	tui.HandleKey(13)
+
+	tui.Undo()
+	if got := tui.GetDiagramHint("theme"); got != "" {
+		t.Errorf("Expected one undo to remove the theme, got %q", got)
+	}
+}
+
+func TestThemePreviewCancel(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "theme ocean")
+
+	tui.HandleKey(':')
+	for _, r := range "theme" {
+		tui.HandleKey(r)
+	}
+	tui.HandleKey(9)
+	tui.HandleKey(27)
+
+	if got := tui.GetDiagramHint("theme"); got != "ocean" {
+		t.Errorf("Expected ESC to restore theme 'ocean', got %q", got)
+	}
+}
+
+func TestThemeCommandUnknown(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "theme nope")
+
+	if got := tui.GetDiagramHint("theme"); got != "" {
+		t.Errorf("Unknown theme should not be applied, got %q", got)
+	}
+	if result := tui.GetCommandResult(); !strings.HasPrefix(result, "Unknown theme") {
+		t.Errorf("Expected unknown theme message, got %q", result)
+	}
+}
+
+func TestExportPreview(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "export mermaid")
+
+	if tui.GetMode() != ModeJSON {
+		t.Fatalf("Expected preview to open the read-only view, got mode %v", tui.GetMode())
+	}
+	if format, _ := tui.GetExportRequest(); format != "" {
+		t.Errorf("Preview should not request a file export, got %q", format)
+	}
+
+	exporter, _ := export.NewExporter(export.FormatMermaid)
+	want, _ := exporter.Export(tui.GetDiagram())
+	if got := tui.GetViewText(); got != want {
+		t.Errorf("Expected view buffer to hold exporter output.\nGot:\n%s\nWant:\n%s", got, want)
+	}
+	if !strings.Contains(tui.Render(), "Client") {
+		t.Errorf("Expected preview to render the exported text")
+	}
+
+	// Closing the view discards the preview
+	tui.HandleKey(27)
+	if tui.GetMode() != ModeNormal || tui.GetViewText() != "" {
+		t.Errorf("Expected ESC to close the preview")
+	}
+}
+
+func TestExportWithFilenameStillRequestsExport(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "export plantuml out.puml")
+
+	format, filename := tui.GetExportRequest()
+	if format != "plantuml" || filename != "out.puml" {
+		t.Errorf("Expected export request plantuml/out.puml, got %q/%q", format, filename)
+	}
+	if tui.GetViewText() != "" {
+		t.Errorf("Export to a file should not open a preview")
+	}
+}
+
+func TestExportPreviewUnknownFormat(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "export nope")
+
+	if tui.GetMode() != ModeNormal {
+		t.Errorf("Expected unknown format to stay in normal mode, got %v", tui.GetMode())
+	}
+	if !strings.HasPrefix(tui.GetCommandResult(), "Error:") {
+		t.Errorf("Expected an error message, got %q", tui.GetCommandResult())
+	}
+}
+
+func TestMetaCommand(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "meta name Checkout Flow")
+	runCommand(tui, "meta author Dana")
+	runCommand(tui, "meta description How an order is paid for")
+
+	meta := tui.GetDiagram().Metadata
+	if meta.Name != "Checkout Flow" || meta.Author != "Dana" || meta.Description != "How an order is paid for" {
+		t.Fatalf("Unexpected metadata: %+v", meta)
+	}
+	if !tui.HasUnsavedChanges() {
+		t.Errorf("Editing metadata should mark the diagram as changed")
+	}
+
+	// Metadata survives a JSON round trip
+	data, err := json.Marshal(tui.GetDiagram())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var loaded diagram.Diagram
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if loaded.Metadata != meta {
+		t.Errorf("Metadata round trip mismatch: got %+v, want %+v", loaded.Metadata, meta)
+	}
+
+	// Each edit is undoable, and "-" clears a field
+	tui.Undo()
+	if got := tui.GetDiagram().Metadata.Description; got != "" {
+		t.Errorf("Expected undo to remove the description, got %q", got)
+	}
+	runCommand(tui, "meta author -")
+	if got := tui.GetDiagram().Metadata.Author; got != "" {
+		t.Errorf("Expected author to be cleared, got %q", got)
+	}
+}
+
+func TestMetaCommandUnknownField(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "meta colour blue")
+
+	if !strings.HasPrefix(tui.GetCommandResult(), "Unknown metadata field") {
+		t.Errorf("Expected unknown field message, got %q", tui.GetCommandResult())
+	}
+}
+
+func TestMergeCommand(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"API"}},
+			{ID: 3, Text: []string{"Gateway"}},
+			{ID: 4, Text: []string{"DB"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 1, To: 3, Arrow: true},
+			{ID: 2, From: 2, To: 3, Arrow: true},
+			{ID: 3, From: 3, To: 4, Arrow: true},
+		},
+	})
+
+	runCommand(tui, "merge 2 3")
+
+	d := tui.GetDiagram()
+	if len(d.Nodes) != 3 {
+		t.Fatalf("Expected 3 nodes after merge, got %d", len(d.Nodes))
+	}
+	if got := strings.Join(d.Nodes[1].Text, "/"); got != "API/Gateway" {
+		t.Errorf("Expected concatenated text, got %q", got)
+	}
+
+	// 1->2 and 1->3 collapse into one edge, 2->3 becomes a dropped self-loop
+	var edges []string
+	for _, conn := range d.Connections {
+		edges = append(edges, fmt.Sprintf("%d->%d", conn.From, conn.To))
+	}
+	if got := strings.Join(edges, " "); got != "1->2 2->4" {
+		t.Errorf("Unexpected connections after merge: %s", got)
+	}
+
+	// The whole merge is one undo step
+	tui.Undo()
+	if len(tui.GetDiagram().Nodes) != 4 || len(tui.GetDiagram().Connections) != 4 {
+		t.Errorf("Expected undo to restore the original diagram")
+	}
+}
+
+func TestMergeCommandKeepSelfLoops(t *testing.T) {
+	tui := newCommandTestEditor()
+	runCommand(tui, "merge! 1 2")
+
+	d := tui.GetDiagram()
+	if len(d.Connections) != 1 || d.Connections[0].From != 1 || d.Connections[0].To != 1 {
+		t.Errorf("Expected the connection to survive as a self-loop, got %+v", d.Connections)
+	}
+
+	runCommand(tui, "merge 1 9")
+	if !strings.HasPrefix(tui.GetCommandResult(), "Error: node 9 not found") {
+		t.Errorf("Expected missing node error, got %q", tui.GetCommandResult())
+	}
+}
+
+func TestMergeDropsTheMergedNodesSelfLoops(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"API"}},
+			{ID: 2, Text: []string{"Retry"}},
+			{ID: 3, Text: []string{"DB"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 2, To: 2, Arrow: true},
+			{ID: 1, From: 2, To: 3, Arrow: true},
+		},
+	})
+	tui.selected = 2
+
+	runCommand(tui, "merge 1 2")
+
+	d := tui.GetDiagram()
+	if len(d.Connections) != 1 || d.Connections[0].From != 1 || d.Connections[0].To != 3 {
+		t.Errorf("Expected only 1->3 left, got %+v", d.Connections)
+	}
+	if tui.selected != -1 {
+		t.Errorf("Expected the merged node's selection cleared, got %d", tui.selected)
+	}
+}
+
+func TestMergeKeepsParallelEdgesItDidNotRewire(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"API"}},
+			{ID: 3, Text: []string{"Gateway"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 1, To: 2, Arrow: true},
+			{ID: 2, From: 1, To: 3, Arrow: true},
+		},
+	})
+
+	runCommand(tui, "merge 2 3")
+
+	// The two existing 1->2 edges stay; only the rewired 1->3 duplicates them
+	d := tui.GetDiagram()
+	var ids []string
+	for _, conn := range d.Connections {
+		ids = append(ids, fmt.Sprintf("%d:%d->%d", conn.ID, conn.From, conn.To))
+	}
+	if got := strings.Join(ids, " "); got != "0:1->2 1:1->2" {
+		t.Errorf("Expected both parallel edges kept and the rewired one dropped, got %s", got)
+	}
+}
+
+func TestSortCommandMovesReturnsAfterCalls(t *testing.T) {
+	dashed := map[string]string{"style": "dashed"}
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"API"}},
+			{ID: 3, Text: []string{"DB"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 2, To: 1, Arrow: true, Label: "200 OK", Hints: dashed},
+			{ID: 1, From: 1, To: 2, Arrow: true, Label: "GET /users"},
+			{ID: 2, From: 2, To: 3, Arrow: true, Label: "query"},
+			{ID: 3, From: 3, To: 2, Arrow: true, Label: "rows", Hints: dashed},
+		},
+	})
+
+	runCommand(tui, "sort")
+
+	var labels []string
+	for _, conn := range tui.GetDiagram().Connections {
+		labels = append(labels, conn.Label)
+	}
+	if got := strings.Join(labels, ", "); got != "GET /users, 200 OK, query, rows" {
+		t.Errorf("Expected the return after its call, got %q", got)
+	}
+	if got := tui.GetCommandResult(); got != "Reordered 2 messages" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	// Sorting again changes nothing
+	runCommand(tui, "sort")
+	if got := tui.GetCommandResult(); got != "Messages already in order" {
+		t.Errorf("Expected no change on a second sort, got %q", got)
+	}
+
+	// One undo restores the original order
+	tui.Undo()
+	if got := tui.GetDiagram().Connections[0].Label; got != "200 OK" {
+		t.Errorf("Expected undo to restore the original order, got %q first", got)
+	}
+}
+
+func TestSortCommandLeavesAmbiguousReturns(t *testing.T) {
+	dashed := map[string]string{"style": "dashed"}
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"API"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 2, To: 1, Arrow: true, Label: "ok", Hints: dashed},
+			{ID: 1, From: 1, To: 2, Arrow: true, Label: "first"},
+			{ID: 2, From: 1, To: 2, Arrow: true, Label: "second"},
+		},
+	})
+
+	runCommand(tui, "sort")
+	if got := tui.GetDiagram().Connections[0].Label; got != "ok" {
+		t.Errorf("Expected a return with two candidate calls to stay put, got %q first", got)
+	}
+
+	box := newCommandTestEditor()
+	runCommand(box, "sort")
+	if !strings.HasPrefix(box.GetCommandResult(), "Error:") {
+		t.Errorf("Expected :sort to reject box diagrams, got %q", box.GetCommandResult())
+	}
+}
+
+func TestReorderCommandChangesDrawingOrderOnly(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"API"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true, Label: "login"},
+			{ID: 1, From: 1, To: 2, Arrow: true, Label: "fetch"},
+			{ID: 2, From: 2, To: 1, Arrow: true, Label: "done"},
+		},
+	})
+
+	drawnOrder := func() string {
+		output, err := render.NewRenderer().Render(tui.GetDiagram())
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		var labels []string
+		for _, line := range strings.Split(output, "\n") {
+			for _, label := range []string{"login", "fetch", "done"} {
+				if strings.Contains(line, label) {
+					labels = append(labels, label)
+				}
+			}
+		}
+		return strings.Join(labels, ", ")
+	}
+	if got := drawnOrder(); got != "login, fetch, done" {
+		t.Fatalf("Expected messages drawn as listed, got %q", got)
+	}
+
+	runCommand(tui, "reorder 3 1")
+	if got := tui.GetCommandResult(); got != "Moved message 3 to 1" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	if got := drawnOrder(); got != "done, login, fetch" {
+		t.Errorf("Expected the last message drawn first, got %q", got)
+	}
+	for i, label := range []string{"login", "fetch", "done"} {
+		if got := tui.GetDiagram().Connections[i].Label; got != label {
+			t.Errorf("Expected connection %d to stay %q, got %q", i, label, got)
+		}
+	}
+
+	// Positions count down the diagram as drawn, not down the list
+	runCommand(tui, "reorder 1 2")
+	if got := drawnOrder(); got != "login, done, fetch" {
+		t.Errorf("Expected positions taken from the drawn order, got %q", got)
+	}
+
+	tui.Undo()
+	if got := drawnOrder(); got != "done, login, fetch" {
+		t.Errorf("Expected undo to restore the previous order, got %q", got)
+	}
+
+	runCommand(tui, "reorder 1 4")
+	if !strings.HasPrefix(tui.GetCommandResult(), "Error:") {
+		t.Errorf("Expected an out of range position rejected, got %q", tui.GetCommandResult())
+	}
+	box := newCommandTestEditor()
+	runCommand(box, "reorder 1 2")
+	if !strings.HasPrefix(box.GetCommandResult(), "Error:") {
+		t.Errorf("Expected :reorder to reject box diagrams, got %q", box.GetCommandResult())
+	}
+}
+
+
+func TestMessagesAddedAfterReorderKeepTheirPlace(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"A"}},
+			{ID: 2, Text: []string{"B"}},
+		},
+	})
+	tui.AddConnection(1, 2, "first")
+	tui.AddConnection(2, 1, "second")
+	runCommand(tui, "reorder 2 1")
+
+	drawn := func() string {
+		var labels []string
+		for _, i := range tui.GetDiagram().MessageOrder() {
+			labels = append(labels, tui.GetDiagram().Connections[i].Label)
+		}
+		return strings.Join(labels, ", ")
+	}
+	if got := drawn(); got != "second, first" {
+		t.Fatalf("Expected the reorder to swap the messages, got %q", got)
+	}
+
+	// A new message goes at the bottom, not the top
+	tui.AddConnection(1, 2, "third")
+	if got := drawn(); got != "second, first, third" {
+		t.Errorf("Expected the added message drawn last, got %q", got)
+	}
+
+	// An inserted message is drawn just after the one it follows in the list
+	tui.InsertConnection(1, 2, 1, "reply")
+	if got := drawn(); got != "second, first, reply, third" {
+		t.Errorf("Expected the inserted message drawn after \"first\", got %q", got)
+	}
+	tui.InsertConnection(0, 1, 2, "start")
+	if got := drawn(); got != "start, second, first, reply, third" {
+		t.Errorf("Expected the message inserted before every other drawn first, got %q", got)
+	}
+}
+func newPresentTestEditor() *TUIEditor {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}},
+			{ID: 3, Text: []string{"Queue"}},
+			{ID: 4, Text: []string{"Worker"}},
+			{ID: 5, Text: []string{"Store"}},
+			{ID: 6, Text: []string{"Notes"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 4, To: 3, Arrow: true},
+			{ID: 2, From: 4, To: 5, Arrow: true},
+		},
+	})
+	return tui
+}
+
+// slideNodeIDs returns the IDs of the nodes on the current slide
+func slideNodeIDs(tui *TUIEditor) []int {
+	var ids []int
+	for _, node := range tui.PresentDiagram().Nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func TestPresentCommandPagesThroughComponents(t *testing.T) {
+	tui := newPresentTestEditor()
+
+	runCommand(tui, "present")
+	if tui.GetMode() != ModePresent {
+		t.Fatalf("Expected present mode, got %v", tui.GetMode())
+	}
+
+	expected := [][]int{{1, 2}, {3, 4, 5}, {6}}
+	for i, want := range expected {
+		if i > 0 {
+			tui.HandleKey(' ')
+		}
+		if got := slideNodeIDs(tui); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Slide %d shows nodes %v, want %v", i+1, got, want)
+		}
+	}
+
+	// Space on the last slide stays there
+	tui.HandleKey(' ')
+	if current, total := tui.GetPresentSlide(); current != 2 || total != 3 {
+		t.Errorf("Expected to stay on slide 3 of 3, got %d of %d", current+1, total)
+	}
+
+	// Backspace goes back, and only the slide's connections are shown
+	tui.HandleKey(127)
+	slide := tui.PresentDiagram()
+	if len(slide.Connections) != 2 {
+		t.Errorf("Expected 2 connections on slide 2, got %d", len(slide.Connections))
+	}
+
+	output := tui.Render()
+	if !strings.Contains(output, "Worker") || strings.Contains(output, "Web") {
+		t.Errorf("Expected only the second component to be rendered:\n%s", output)
+	}
+	if !strings.Contains(output, "[ 2 / 3 ]") {
+		t.Errorf("Expected slide counter in output:\n%s", output)
+	}
+
+	tui.HandleKey(27)
+	if tui.GetMode() != ModeNormal {
+		t.Errorf("Expected ESC to return to normal mode, got %v", tui.GetMode())
+	}
+	if len(tui.GetDiagram().Nodes) != 6 {
+		t.Errorf("Presenting should not modify the diagram")
+	}
+}
+
+func TestPresentFocusShowsNeighbours(t *testing.T) {
+	tui := newPresentTestEditor()
+
+	runCommand(tui, "present focus")
+
+	expected := [][]int{{1, 2}, {1, 2}, {3, 4}, {3, 4, 5}, {4, 5}, {6}}
+	for i, want := range expected {
+		if i > 0 {
+			tui.HandleKey(' ')
+		}
+		if got := slideNodeIDs(tui); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Slide %d shows nodes %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestGotoCommand(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	d := &diagram.Diagram{Type: "box"}
+	for i := 1; i <= 8; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{fmt.Sprintf("Step %d", i)}})
+		if i > 1 {
+			d.Connections = append(d.Connections, diagram.Connection{ID: i, From: i - 1, To: i, Arrow: true})
+		}
+	}
+	tui.SetDiagram(d)
+	tui.SetTerminalSize(80, 24)
+	tui.Render()
+
+	runCommand(tui, "goto 8")
+	if got := tui.GetSelectedNode(); got != 8 {
+		t.Fatalf("Expected node 8 to be selected, got %d", got)
+	}
+	bottom := tui.GetDiagramScrollOffset()
+	pos := tui.GetNodePositions()[8]
+	if bottom == 0 || pos.Y < bottom || pos.Y >= bottom+24-4 {
+		t.Errorf("Expected node 8 at line %d to be scrolled into view, offset is %d", pos.Y, bottom)
+	}
+
+	// The view stays put when the node is already visible
+	runCommand(tui, fmt.Sprintf("goto %d", 7))
+	if got := tui.GetDiagramScrollOffset(); got != bottom {
+		t.Errorf("Expected offset to stay at %d for a visible node, got %d", bottom, got)
+	}
+
+	runCommand(tui, "goto 1")
+	if got := tui.GetDiagramScrollOffset(); got != 0 {
+		t.Errorf("Expected offset 0 after going to the first node, got %d", got)
+	}
+
+	output := tui.Render()
+	if !strings.Contains(output, "╔") {
+		t.Errorf("Expected the node to be highlighted after :goto:\n%s", output)
+	}
+}
+
+func TestGotoCommandUnknownNode(t *testing.T) {
+	tui := newCommandTestEditor()
+
+	runCommand(tui, "goto 42")
+	if !strings.Contains(tui.GetCommandResult(), "42") {
+		t.Errorf("Expected an error naming the missing node, got %q", tui.GetCommandResult())
+	}
+	if tui.GetSelectedNode() != -1 {
+		t.Errorf("Expected selection to be unchanged, got %d", tui.GetSelectedNode())
+	}
+}
+
+func TestSwapCommandExchangesPositions(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Root"}},
+			{ID: 2, Text: []string{"Left"}},
+			{ID: 3, Text: []string{"Rest"}},
+			{ID: 4, Text: []string{"Leaf"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 1, To: 3, Arrow: true},
+			{ID: 2, From: 2, To: 4, Arrow: true},
+		},
+	})
+	tui.Render()
+	before := tui.GetNodePositions()
+	b2, b3 := before[2], before[3]
+	if b2 == b3 {
+		t.Fatalf("Expected nodes 2 and 3 in different places, both at %+v", b2)
+	}
+
+	runCommand(tui, "swap 2 3")
+	if got := tui.GetCommandResult(); got != "Swapped nodes 2 and 3" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	tui.Render()
+	after := tui.GetNodePositions()
+	if after[2] != b3 || after[3] != b2 {
+		t.Errorf("Expected nodes 2 and 3 to trade places, got 2 at %+v (was %+v) and 3 at %+v (was %+v)", after[2], b2, after[3], b3)
+	}
+
+	// Connections keep their endpoints and are routed to the new places
+	conns := tui.GetDiagram().Connections
+	if conns[2].From != 2 || conns[2].To != 4 {
+		t.Fatalf("Expected connections to be left alone, got %+v", conns[2])
+	}
+	start := tui.GetConnectionPaths()[2].Points[0]
+	if layout.Abs(start.X-(after[2].X+3)) > 3 {
+		t.Errorf("Expected the 2 -> 4 line to leave node 2 at %+v, starts at %+v", after[2], start)
+	}
+
+	tui.Undo()
+	tui.Render()
+	if got := tui.GetNodePositions(); got[2] != b2 || got[3] != b3 {
+		t.Errorf("Expected undo to restore the original positions, got %+v", got)
+	}
+}
+
+func TestAutolayoutCommandUnpinsNodes(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Start"}},
+			{ID: 2, Text: []string{"Middle"}},
+			{ID: 3, Text: []string{"End"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 2, To: 3, Arrow: true},
+		},
+	})
+	tui.Render()
+	auto := tui.GetNodePositions()
+
+	tui.GetDiagram().Nodes[1].Hints = map[string]string{"x": "40", "y": "20", "color": "red"}
+	tui.GetDiagram().Nodes[2].Hints = map[string]string{"y": "30"}
+	tui.SaveHistory()
+	tui.Render()
+	if got := tui.GetNodePositions(); got[2] == auto[2] || got[3] == auto[3] {
+		t.Fatalf("Expected the pinned nodes to move, got %+v (auto %+v)", got, auto)
+	}
+
+	runCommand(tui, "autolayout")
+	if got := tui.GetCommandResult(); got != "Returned 2 nodes to auto layout" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	tui.Render()
+	if got := tui.GetNodePositions(); got[1] != auto[1] || got[2] != auto[2] || got[3] != auto[3] {
+		t.Errorf("Expected the nodes back in the auto layout %+v, got %+v", auto, got)
+	}
+	if hints := tui.GetDiagram().Nodes[1].Hints; len(hints) != 1 || hints["color"] != "red" {
+		t.Errorf("Expected only the position hints removed, got %+v", hints)
+	}
+
+	runCommand(tui, "autolayout")
+	if got := tui.GetCommandResult(); got != "No pinned nodes" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	// One undo puts both pins back
+	tui.Undo()
+	if got := tui.GetDiagram().Nodes[1].Hints["x"]; got != "40" {
+		t.Errorf("Expected undo to restore the x pin, got %q", got)
+	}
+	if got := tui.GetDiagram().Nodes[2].Hints["y"]; got != "30" {
+		t.Errorf("Expected undo to restore the y pin, got %q", got)
+	}
+}
+
+func TestSwapCommandReordersParticipants(t *testing.T) {
+	tui := newCommandTestEditor()
+	tui.GetDiagram().Type = string(diagram.DiagramTypeSequence)
+
+	runCommand(tui, "swap 1 2")
+	nodes := tui.GetDiagram().Nodes
+	if nodes[0].ID != 2 || nodes[1].ID != 1 {
+		t.Errorf("Expected the participants to trade places, got %+v", nodes)
+	}
+	if c := tui.GetDiagram().Connections[0]; c.From != 1 || c.To != 2 {
+		t.Errorf("Expected the message to keep its endpoints, got %+v", c)
+	}
+
+	runCommand(tui, "swap 1 9")
+	if got := tui.GetCommandResult(); got != "Error: node 9 not found" {
+		t.Errorf("Unexpected result %q", got)
+	}
+}
+
+func TestWaypointsCommandReproducesRoute(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}},
+			{ID: 3, Text: []string{"Worker"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 1, To: 3, Arrow: true},
+		},
+	})
+	before := tui.Render()
+
+	runCommand(tui, "waypoints 1 3")
+	waypoints := tui.GetDiagram().Connections[1].Waypoints
+	if got := tui.GetCommandResult(); got != fmt.Sprintf("Stored %d waypoints for 1 → 3", len(waypoints)) {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	if len(waypoints) < 3 {
+		t.Fatalf("Expected the bent route's corners, got %+v", waypoints)
+	}
+	for i := 1; i < len(waypoints); i++ {
+		a, b := waypoints[i-1], waypoints[i]
+		if a.X != b.X && a.Y != b.Y {
+			t.Errorf("Expected straight runs between waypoints, got %+v then %+v", a, b)
+		}
+		if i+1 < len(waypoints) {
+			if c := waypoints[i+1]; (a.X == b.X && b.X == c.X) || (a.Y == b.Y && b.Y == c.Y) {
+				t.Errorf("Expected waypoint %+v to be a corner", b)
+			}
+		}
+	}
+
+	// The connection is now drawn from its waypoints, just as it was routed
+	if after := tui.Render(); after != before {
+		t.Errorf("Expected the waypoints to reproduce the route.\nBefore:\n%s\nAfter:\n%s", before, after)
+	}
+
+	runCommand(tui, "waypoints 3 1")
+	if got := tui.GetCommandResult(); got != "Error: no connection from 3 to 1" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	tui.Undo()
+	if got := tui.GetDiagram().Connections[1].Waypoints; got != nil {
+		t.Errorf("Expected undo to remove the waypoints, got %+v", got)
+	}
+}
+
+func TestCollapseAndExpandCommands(t *testing.T) {
+	tui := newCommandTestEditor()
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}, Hints: map[string]string{"group": "backend"}},
+			{ID: 3, Text: []string{"Worker"}, Hints: map[string]string{"group": "backend"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true},
+			{ID: 1, From: 2, To: 3, Arrow: true},
+		},
+	})
+
+	runCommand(tui, "collapse backend")
+	if got := tui.GetCommandResult(); got != "Collapsed backend" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	if got := tui.GetDiagram().Hints["collapsed"]; got != "backend" {
+		t.Errorf("Expected the group in the collapsed hint, got %q", got)
+	}
+	if output := tui.Render(); !strings.Contains(output, "backend [+]") || strings.Contains(output, "Worker") {
+		t.Errorf("Expected one summary box:\n%s", output)
+	}
+
+	runCommand(tui, "collapse frontend")
+	if got := tui.GetCommandResult(); got != `Error: no group "frontend"` {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	runCommand(tui, "expand backend")
+	if got := tui.GetCommandResult(); got != "Expanded backend" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	if _, ok := tui.GetDiagram().Hints["collapsed"]; ok {
+		t.Errorf("Expected the collapsed hint removed, got %+v", tui.GetDiagram().Hints)
+	}
+	if output := tui.Render(); !strings.Contains(output, "API") || !strings.Contains(output, "Worker") {
+		t.Errorf("Expected the members back:\n%s", output)
+	}
+
+	tui.Undo()
+	if got := tui.GetDiagram().Hints["collapsed"]; got != "backend" {
+		t.Errorf("Expected undo to collapse the group again, got %q", got)
+	}
+}
+
+func TestWrapCommandWrapsNodeTextAndPersists(t *testing.T) {
+	tui := newCommandTestEditor()
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{{ID: 1, Text: []string{"Validate the incoming request"}}},
+	})
+
+	runCommand(tui, "wrap 12")
+	if got := tui.GetCommandResult(); got != "Wrapping node text at 12 columns" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	output := tui.Render()
+	for _, line := range []string{"│ Validate the │", "│ incoming     │", "│ request      │"} {
+		if !strings.Contains(output, line) {
+			t.Errorf("Expected wrapped line %q:\n%s", line, output)
+		}
+	}
+	if got := tui.GetDiagram().Nodes[0].Text; len(got) != 1 {
+		t.Errorf("Expected the node text itself unchanged, got %q", got)
+	}
+
+	// The setting survives a save and load
+	data, err := json.Marshal(tui.GetDiagram())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded diagram.Diagram
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Hints["wrap"]; got != "12" {
+		t.Fatalf("Expected the wrap setting saved, got %q in %s", got, data)
+	}
+	reloaded := newCommandTestEditor()
+	reloaded.SetDiagram(&loaded)
+	if output := reloaded.Render(); !strings.Contains(output, "│ incoming     │") {
+		t.Errorf("Expected the loaded diagram to render wrapped:\n%s", output)
+	}
+
+	runCommand(tui, "wrap 0")
+	if got := tui.GetCommandResult(); got != "Node text wrapping off" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	if _, ok := tui.GetDiagram().Hints["wrap"]; ok {
+		t.Errorf("Expected the wrap setting removed, got %+v", tui.GetDiagram().Hints)
+	}
+	if output := tui.Render(); !strings.Contains(output, "│ Validate the incoming request │") {
+		t.Errorf("Expected unwrapped text:\n%s", output)
+	}
+
+	runCommand(tui, "wrap wide")
+	if got := tui.GetCommandResult(); got != "Invalid width: wide" {
+		t.Errorf("Unexpected result %q", got)
+	}
+}
+
+func TestTruncateCommandCutsLongWordsShort(t *testing.T) {
+	tui := newCommandTestEditor()
+	url := "https://example.com/" + strings.Repeat("x", 80)
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{{ID: 1, Text: []string{url}}},
+	})
+
+	runCommand(tui, "truncate 24")
+	if got := tui.GetCommandResult(); got != "Truncating node text at 24 columns" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	if output := tui.Render(); !strings.Contains(output, "│ https://example.com/xxx… │") {
+		t.Errorf("Expected the URL cut short with an ellipsis:\n%s", output)
+	}
+	if got := tui.GetDiagram().Hints["truncate"]; got != "24" {
+		t.Errorf("Expected the truncate setting saved, got %q", got)
+	}
+
+	runCommand(tui, "truncate 0")
+	if got := tui.GetCommandResult(); got != "Node text truncation off" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	if _, ok := tui.GetDiagram().Hints["truncate"]; ok {
+		t.Errorf("Expected the truncate setting removed, got %+v", tui.GetDiagram().Hints)
+	}
+}
+
+func TestFindCyclesCommandHighlightsTheLoop(t *testing.T) {
+	tui := newCommandTestEditor()
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Draft"}},
+			{ID: 2, Text: []string{"Review"}},
+			{ID: 3, Text: []string{"Revise"}},
+			{ID: 4, Text: []string{"Publish"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2, Arrow: true},
+			{From: 2, To: 3, Arrow: true},
+			{From: 3, To: 1, Arrow: true},
+			{From: 2, To: 4, Arrow: true},
+		},
+	})
+	redConnections := func() []int {
+		var red []int
+		for i, conn := range tui.renderDiagram().Connections {
+			if conn.Hints["color"] == "red" {
+				red = append(red, i)
+			}
+		}
+		return red
+	}
+
+	runCommand(tui, "find-cycles")
+	if got := tui.GetCommandResult(); !strings.HasPrefix(got, "Highlighted 3 connections on cycles") {
+		t.Errorf("Unexpected result %q", got)
+	}
+	if got := fmt.Sprint(redConnections()); got != "[0 1 2]" {
+		t.Errorf("Expected the three loop connections drawn red, got %s", got)
+	}
+	if _, ok := tui.GetDiagram().Connections[0].Hints["color"]; ok {
+		t.Error("Expected the highlight to leave the diagram itself unchanged")
+	}
+	if output := tui.Render(); !strings.Contains(output, render.ColorRed) {
+		t.Errorf("Expected red in the rendered diagram:\n%s", output)
+	}
+
+	// ESC turns the highlighting off
+	tui.HandleKey(27)
+	if got := redConnections(); len(got) != 0 {
+		t.Errorf("Expected no highlighting after ESC, got %v", got)
+	}
+
+	tui.GetDiagram().Connections = tui.GetDiagram().Connections[:2]
+	runCommand(tui, "find-cycles")
+	if got := tui.GetCommandResult(); got != "No cycles" {
+		t.Errorf("Unexpected result %q", got)
+	}
+}
+
+func TestCommentCommandStoresNotesThatAreNeverDrawn(t *testing.T) {
+	tui := newCommandTestEditor()
+
+	runCommand(tui, "comment 1 owned by the web team")
+	if got := tui.GetCommandResult(); got != "Commented node 1" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	runCommand(tui, "comment 1->2 retries twice")
+	if got := tui.GetCommandResult(); got != "Commented connection 1->2" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	runCommand(tui, "goto 2")
+	runCommand(tui, "comment needs auth")
+	if got := tui.GetCommandResult(); got != "Commented node 2" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	runCommand(tui, "comment 1")
+	if got := tui.GetCommandResult(); got != "Comment on node 1: owned by the web team" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	for _, text := range []string{"owned by", "retries", "needs auth"} {
+		if output := tui.Render(); strings.Contains(output, text) {
+			t.Errorf("Expected comment %q not to be drawn:\n%s", text, output)
+		}
+	}
+
+	// Comments survive a save and load
+	data, err := json.Marshal(tui.GetDiagram())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded diagram.Diagram
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Nodes[0].Comment != "owned by the web team" || loaded.Nodes[1].Comment != "needs auth" || loaded.Connections[0].Comment != "retries twice" {
+		t.Errorf("Expected the comments saved, got %s", data)
+	}
+	reloaded := newCommandTestEditor()
+	reloaded.SetDiagram(&loaded)
+	if output := reloaded.Render(); strings.Contains(output, "retries") || !strings.Contains(output, "Client") {
+		t.Errorf("Expected the loaded diagram drawn without its comments:\n%s", output)
+	}
+
+	runCommand(tui, "comment 1->2 -")
+	if got := tui.GetCommandResult(); got != "Cleared comment on connection 1->2" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	tui.Undo()
+	if got := tui.GetDiagram().Connections[0].Comment; got != "retries twice" {
+		t.Errorf("Expected undo to restore the comment, got %q", got)
+	}
+}
+
+func TestTemplateCommandInsertsSkeleton(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{})
+
+	runCommand(tui, "template three-tier")
+	if got := tui.GetCommandResult(); got != "Inserted three-tier (3 nodes, 2 connections)" {
+		t.Fatalf("Unexpected result %q", got)
+	}
+	d := tui.GetDiagram()
+	if d.Type != "box" || len(d.Nodes) != 3 || len(d.Connections) != 2 {
+		t.Fatalf("Expected a box diagram with 3 nodes and 2 connections, got %s with %d and %d", d.Type, len(d.Nodes), len(d.Connections))
+	}
+	if output := tui.Render(); !strings.Contains(output, "Frontend") || !strings.Contains(output, "Database") {
+		t.Errorf("Expected the template drawn:\n%s", output)
+	}
+
+	// A second copy is numbered after the first
+	runCommand(tui, "template three-tier")
+	d = tui.GetDiagram()
+	if len(d.Nodes) != 6 || len(d.Connections) != 4 {
+		t.Fatalf("Expected 6 nodes and 4 connections, got %d and %d", len(d.Nodes), len(d.Connections))
+	}
+	if d.Nodes[3].ID != 4 || d.Connections[2].From != 4 || d.Connections[2].To != 5 || d.Connections[2].ID != 2 {
+		t.Errorf("Expected the second copy renumbered, got nodes %+v connections %+v", d.Nodes, d.Connections)
+	}
+
+	runCommand(tui, "template request-response")
+	if got := tui.GetCommandResult(); got != "Error: request-response is a sequence template" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	runCommand(tui, "template nope")
+	if got := tui.GetCommandResult(); !strings.Contains(got, "unknown template") || !strings.Contains(got, "decision-flow") {
+		t.Errorf("Expected the available templates listed, got %q", got)
+	}
+
+	tui.Undo()
+	if got := len(tui.GetDiagram().Nodes); got != 3 {
+		t.Errorf("Expected undo to remove the second copy, got %d nodes", got)
+	}
+}
+
+func TestConnectCommandAddsConnectionByID(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"Server"}},
+		},
+	})
+
+	runCommand(tui, "connect 1 2 calls")
+	conns := tui.GetDiagram().Connections
+	if len(conns) != 1 || conns[0].From != 1 || conns[0].To != 2 || conns[0].Label != "calls" || !conns[0].Arrow {
+		t.Fatalf("Expected one arrow from 1 to 2 labelled calls, got %+v", conns)
+	}
+	if got := tui.GetCommandResult(); got != "Connected 1 -> 2" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	// Everything after the IDs is the label
+	runCommand(tui, "connect 2 1 sends it back")
+	if got := tui.GetDiagram().Connections[1].Label; got != "sends it back" {
+		t.Errorf("Expected a multi-word label, got %q", got)
+	}
+
+	// A repeat is bundled into the first connection, as in jump mode
+	runCommand(tui, "connect 1 2")
+	if got := tui.GetCommandResult(); got != "Bundled into the existing connection 1 -> 2" || len(tui.GetDiagram().Connections) != 2 {
+		t.Errorf("Expected the duplicate bundled, got %q", got)
+	}
+
+	runCommand(tui, "connect 1 999")
+	if got := tui.GetCommandResult(); got != "Error: no node with ID 999" {
+		t.Errorf("Expected an unknown ID rejected, got %q", got)
+	}
+	runCommand(tui, "connect one two")
+	if got := tui.GetCommandResult(); !strings.HasPrefix(got, "Usage:") {
+		t.Errorf("Expected usage for non-numeric IDs, got %q", got)
+	}
+
+	tui.Undo()
+	tui.Undo()
+	if got := len(tui.GetDiagram().Connections); got != 1 {
+		t.Errorf("Expected undo to remove the bundle and the reply, got %d connections", got)
+	}
+}
@@ -0,0 +1,110 @@
+package editor
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func newConnectionHintTestEditor() *TUIEditor {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"A"}},
+			{ID: 2, Text: []string{"B"}},
+		},
+		Connections: []diagram.Connection{{From: 1, To: 2, Arrow: true}},
+	})
+	tui.editingHintConn = 0
+	tui.SetMode(ModeHintMenu)
+	return tui
+}
+
+func TestCycleConnectionStyle(t *testing.T) {
+	tui := newConnectionHintTestEditor()
+
+	for _, style := range []string{"dashed", "dotted", "double"} {
+		tui.HandleKey('s')
+		if got := tui.GetDiagram().Connections[0].Hints["style"]; got != style {
+			t.Errorf("Expected style %q, got %q", style, got)
+		}
+	}
+
+	tui.HandleKey('s')
+	if style, ok := tui.GetDiagram().Connections[0].Hints["style"]; ok {
+		t.Errorf("Expected style to cycle back to solid, got %q", style)
+	}
+	if tui.GetMode() != ModeHintMenu {
+		t.Errorf("Cycling should stay in the hint menu")
+	}
+}
+
+func TestCycleConnectionColor(t *testing.T) {
+	tui := newConnectionHintTestEditor()
+
+	for _, color := range nodeColors[1:] {
+		tui.HandleKey('k')
+		if got := tui.GetDiagram().Connections[0].Hints["color"]; got != color {
+			t.Errorf("Expected color %q, got %q", color, got)
+		}
+	}
+
+	tui.HandleKey('k')
+	if _, ok := tui.GetDiagram().Connections[0].Hints["color"]; ok {
+		t.Errorf("Expected color to cycle back to default")
+	}
+
+	// Each step is undoable on its own
+	tui.Undo()
+	if got := tui.GetDiagram().Connections[0].Hints["color"]; got != nodeColors[len(nodeColors)-1] {
+		t.Errorf("Expected undo to restore %q, got %q", nodeColors[len(nodeColors)-1], got)
+	}
+}
+
+func newReturnTestEditor() *TUIEditor {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"Server"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true, Label: "request", Hints: map[string]string{"color": "green"}},
+		},
+	})
+	return tui
+}
+
+func TestReturnInheritsCallColor(t *testing.T) {
+	tui := newReturnTestEditor()
+
+	tui.AddConnection(2, 1, "response")
+	ret := tui.GetDiagram().Connections[1]
+	if ret.Hints["style"] != "dashed" {
+		t.Errorf("Expected the return to be dashed, got %q", ret.Hints["style"])
+	}
+	if ret.Hints["color"] != "green" {
+		t.Errorf("Expected the return to inherit the call's color, got %q", ret.Hints["color"])
+	}
+
+	// Inserted returns are styled the same way
+	tui = newReturnTestEditor()
+	tui.InsertConnection(1, 2, 1, "response")
+	if got := tui.GetDiagram().Connections[1].Hints["color"]; got != "green" {
+		t.Errorf("Expected the inserted return to inherit the call's color, got %q", got)
+	}
+}
+
+func TestReturnColorInheritanceOptOut(t *testing.T) {
+	tui := newReturnTestEditor()
+	tui.SetDiagramHint("inherit-return-color", "false")
+
+	tui.AddConnection(2, 1, "response")
+	ret := tui.GetDiagram().Connections[1]
+	if ret.Hints["style"] != "dashed" {
+		t.Errorf("Expected the return to still be dashed, got %q", ret.Hints["style"])
+	}
+	if color, ok := ret.Hints["color"]; ok {
+		t.Errorf("Expected no inherited color with the opt-out hint, got %q", color)
+	}
+}
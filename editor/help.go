@@ -31,6 +31,7 @@ func GetHelpText() string {
 			Name: "Connection Operations",
 			Commands: []HelpCommand{
 				{"c/C", "Connect nodes (C for continuous)"},
+				{"L", "Connect the two most recently added nodes"},
 				{"i/I", "Insert connection (I for continuous)"},
 				{"v", "Toggle activation (sequence diagrams)"},
 			},
@@ -51,10 +52,14 @@ func GetHelpText() string {
 		{
 			Name: "Navigation & View",
 			Commands: []HelpCommand{
+				{"]/[", "Select next/previous connected node"},
+				{"Enter", "Step on to the selected node's neighbors"},
 				{"J", "Toggle JSON view"},
+				{"~", "Toggle routing debug overlay"},
+				{"#", "Toggle node IDs on boxes"},
 			{"j/k", "Scroll down/up (line by line)"},
 			{"Ctrl+D/U", "Scroll down/up (half page)"},
-				{"t", "Toggle diagram type (sequence/box)"},
+				{"t", "Convert between sequence and box diagram"},
 				{"E", "Edit in external editor"},
 				{"?", "Show this help"},
 			},
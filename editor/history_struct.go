@@ -2,13 +2,17 @@ package editor
 
 import (
 	"edd/diagram"
+	"fmt"
+	"maps"
+	"slices"
 )
 
 // StructHistory manages undo/redo using direct struct storage (much faster than JSON)
 type StructHistory struct {
-	states  []*diagram.Diagram // Direct struct pointers
-	current int             // Current position in history
-	max     int             // Maximum number of states to keep
+	states       []*diagram.Diagram // Direct struct pointers
+	labels       []string           // The action that led to each state, e.g. "add node"
+	current      int                // Current position in history
+	max          int                // Maximum number of states to keep
 }
 
 // NewStructHistory creates a new struct-based history manager
@@ -17,28 +21,40 @@ func NewStructHistory(max int) *StructHistory {
 		max = 500
 	}
 	return &StructHistory{
-		states:  make([]*diagram.Diagram, 0, max),
-		current: -1,
-		max:     max,
+		states:       make([]*diagram.Diagram, 0, max),
+		labels:       make([]string, 0, max),
+		current:      -1,
+		max:          max,
 	}
 }
 
-// SaveState saves a new state (creates a deep copy)
-func (sh *StructHistory) SaveState(d *diagram.Diagram) error {
+// SaveState saves a new state (creates a deep copy). The optional label names
+// the action that produced it, such as "color node"; without one the state is
+// described by how it differs from the one before.
+func (sh *StructHistory) SaveState(d *diagram.Diagram, label ...string) error {
 	// Create a deep copy of the diagram
 	clone := d.Clone()
 	
 	// If we're not at the end, truncate everything after current
 	if sh.current < len(sh.states)-1 {
 		sh.states = sh.states[:sh.current+1]
+		sh.labels = sh.labels[:sh.current+1]
 	}
 	
-	// Add new state
+	// Add new state with its label
+	name := ""
+	if len(label) > 0 && label[0] != "" {
+		name = label[0]
+	} else if sh.current >= 0 {
+		name = describeChange(sh.states[sh.current], clone)
+	}
 	sh.states = append(sh.states, clone)
+	sh.labels = append(sh.labels, name)
 	
 	// If we exceed max, remove oldest
 	if len(sh.states) > sh.max {
 		sh.states = sh.states[1:]
+		sh.labels = sh.labels[1:]
 	} else {
 		sh.current++
 	}
@@ -56,6 +72,33 @@ func (sh *StructHistory) CanRedo() bool {
 	return sh.current < len(sh.states)-1
 }
 
+// UndoDescription returns a short description of the change Undo would
+// revert, such as "add node", or "" if there is nothing to undo
+func (sh *StructHistory) UndoDescription() string {
+	if !sh.CanUndo() {
+		return ""
+	}
+	return sh.labels[sh.current]
+}
+
+// RedoDescription returns a short description of the change Redo would
+// reapply, or "" if there is nothing to redo
+func (sh *StructHistory) RedoDescription() string {
+	if !sh.CanRedo() {
+		return ""
+	}
+	return sh.labels[sh.current+1]
+}
+
+// CurrentLabel returns the label of the current state, the action most
+// recently saved or returned to, or "" for the initial state
+func (sh *StructHistory) CurrentLabel() string {
+	if sh.current < 0 {
+		return ""
+	}
+	return sh.labels[sh.current]
+}
+
 // Undo goes back one state
 func (sh *StructHistory) Undo() (*diagram.Diagram, error) {
 	if !sh.CanUndo() {
@@ -83,10 +126,71 @@ func (sh *StructHistory) Redo() (*diagram.Diagram, error) {
 // Clear clears all history
 func (sh *StructHistory) Clear() {
 	sh.states = sh.states[:0]
+	sh.labels = sh.labels[:0]
 	sh.current = -1
 }
 
 // Stats returns current position and total states
 func (sh *StructHistory) Stats() (current, total int) {
 	return sh.current + 1, len(sh.states)
-}
\ No newline at end of file
+}
+
+// describeChange names the edit that turned prev into next, for undo and redo
+// messages. Added or deleted nodes take precedence over the connections that
+// go with them.
+func describeChange(prev, next *diagram.Diagram) string {
+	switch dn := len(next.Nodes) - len(prev.Nodes); {
+	case dn == 1:
+		return "add node"
+	case dn > 1:
+		return fmt.Sprintf("add %d nodes", dn)
+	case dn == -1:
+		return "delete node"
+	case dn < -1:
+		return fmt.Sprintf("delete %d nodes", -dn)
+	}
+	switch dc := len(next.Connections) - len(prev.Connections); {
+	case dc == 1:
+		return "add connection"
+	case dc > 1:
+		return fmt.Sprintf("add %d connections", dc)
+	case dc == -1:
+		return "delete connection"
+	case dc < -1:
+		return fmt.Sprintf("delete %d connections", -dc)
+	}
+	if prev.Type != next.Type {
+		return "change diagram type"
+	}
+
+	for i, node := range next.Nodes {
+		old := prev.Nodes[i]
+		switch {
+		case !slices.Equal(old.Text, node.Text):
+			return "edit node text"
+		case !maps.Equal(old.Hints, node.Hints):
+			return "change node hints"
+		case old.Comment != node.Comment:
+			return "edit comment"
+		case old.ID != node.ID:
+			return "reorder nodes"
+		}
+	}
+	for i, conn := range next.Connections {
+		old := prev.Connections[i]
+		switch {
+		case old.Label != conn.Label:
+			return "edit label"
+		case !maps.Equal(old.Hints, conn.Hints):
+			return "change connection hints"
+		case old.Comment != conn.Comment:
+			return "edit comment"
+		case old.From != conn.From || old.To != conn.To:
+			return "reconnect"
+		}
+	}
+	if !maps.Equal(prev.Hints, next.Hints) {
+		return "change diagram hints"
+	}
+	return "edit"
+}
@@ -73,6 +73,12 @@ func (r *RealRenderer) GetCursorPos() int {
 	return r.cursorPos
 }
 
+// SetLayoutSeed changes how symmetric graphs are laid out, as the -seed flag
+// does for exports, so the editor shows the same arrangement
+func (r *RealRenderer) SetLayoutSeed(seed int64) {
+	r.mainRenderer.SetLayoutSeed(seed)
+}
+
 // NewRealRenderer creates a renderer using our actual modules
 func NewRealRenderer() *RealRenderer {
 	// Use the actual refactored renderer that supports colors and proper separation
@@ -167,17 +173,48 @@ func (r *RealRenderer) RenderWithPositions(d *diagram.Diagram) (*NodePositions,
 }
 
 
+// ViewportMinNodes is the number of nodes from which the editor draws a box
+// diagram a screenful at a time through a cached layout, rather than
+// rendering all of it on every frame and showing the visible lines
+var ViewportMinNodes = 500
+
+// LayoutViewport lays out a box diagram to be drawn a window at a time
+func (r *RealRenderer) LayoutViewport(d *diagram.Diagram) (*render.ViewportLayout, error) {
+	flowchartRenderer := r.mainRenderer.GetFlowchartRenderer()
+	flowchartRenderer.SetEditState(r.editingNodeID, r.editText, r.cursorPos)
+	return flowchartRenderer.LayoutViewport(d)
+}
+
+// ToggleDebugOverlay turns the routing debug overlay, which marks the
+// virtual obstacles around nodes and ports with dots, on or off in box
+// diagrams, and reports whether it is now on
+func (r *RealRenderer) ToggleDebugOverlay() bool {
+	flowchartRenderer := r.mainRenderer.GetFlowchartRenderer()
+	flowchartRenderer.SetObstacleVisualization(!flowchartRenderer.ObstacleVisualization())
+	return flowchartRenderer.ObstacleVisualization()
+}
+
+// DebugOverlay reports whether the routing debug overlay is on
+func (r *RealRenderer) DebugOverlay() bool {
+	return r.mainRenderer.GetFlowchartRenderer().ObstacleVisualization()
+}
+
+// LayoutNodes returns a box diagram's nodes as the renderer lays them out
+func (r *RealRenderer) LayoutNodes(d *diagram.Diagram) ([]diagram.Node, error) {
+	return r.mainRenderer.GetFlowchartRenderer().LayoutNodes(d)
+}
+
+// Waypoints returns the corners of a box diagram connection's current route
+func (r *RealRenderer) Waypoints(d *diagram.Diagram, index int) ([]diagram.Point, error) {
+	return r.mainRenderer.GetFlowchartRenderer().Waypoints(d, index)
+}
+
 // renderSequenceWithPositions renders a sequence diagram and returns positions
 func (r *RealRenderer) renderSequenceWithPositions(d *diagram.Diagram) (*NodePositions, string, error) {
 	// If we're editing, create a copy of the diagram with the edited text
 	renderDiagram := d
 	if r.editingNodeID >= 0 {
-		// Make a shallow copy of the diagram
-		tempDiagram := *d
-		// Make a copy of the nodes slice
-		tempNodes := make([]diagram.Node, len(d.Nodes))
-		copy(tempNodes, d.Nodes)
-		tempDiagram.Nodes = tempNodes
+		tempDiagram := d.Clone()
 
 		// Update the text of the node being edited
 		for i := range tempDiagram.Nodes {
@@ -188,14 +225,10 @@ func (r *RealRenderer) renderSequenceWithPositions(d *diagram.Diagram) (*NodePos
 				break
 			}
 		}
-		renderDiagram = &tempDiagram
+		renderDiagram = tempDiagram
 	} else if r.EditingConnectionID >= 0 {
 		// If we're editing a connection label, create a copy with edited text
-		tempDiagram := *d
-		// Make a copy of the connections slice
-		tempConnections := make([]diagram.Connection, len(d.Connections))
-		copy(tempConnections, d.Connections)
-		tempDiagram.Connections = tempConnections
+		tempDiagram := d.Clone()
 
 		// Update the label of the connection being edited with cursor
 		if r.EditingConnectionID < len(tempDiagram.Connections) {
@@ -203,9 +236,13 @@ func (r *RealRenderer) renderSequenceWithPositions(d *diagram.Diagram) (*NodePos
 			labelWithCursor := r.buildLabelWithCursor(r.EditConnectionText, r.EditConnectionCursorPos)
 			tempDiagram.Connections[r.EditingConnectionID].Label = labelWithCursor
 		}
-		renderDiagram = &tempDiagram
+		renderDiagram = tempDiagram
 	}
 	
+	// Wrap participant names and resolve participant and theme colors into
+	// explicit hints
+	renderDiagram = render.ApplyTheme(render.ApplyParticipantColors(render.ApplyWrap(renderDiagram)))
+
 	// Create sequence renderer
 	seqRenderer := render.NewSequenceRenderer(r.capabilities)
 	
@@ -249,14 +286,12 @@ func (r *RealRenderer) renderSequenceWithPositions(d *diagram.Diagram) (*NodePos
 	}
 	
 	// Add message paths
-	for i, msg := range positionData.Messages {
-		if i < len(d.Connections) {
-			positions.ConnectionPaths[i] = diagram.Path{
-				Points: []diagram.Point{
-					{X: msg.FromX, Y: msg.Y},
-					{X: msg.ToX, Y: msg.Y},
-				},
-			}
+	for _, msg := range positionData.Messages {
+		positions.ConnectionPaths[msg.Index] = diagram.Path{
+			Points: []diagram.Point{
+				{X: msg.FromX, Y: msg.Y},
+				{X: msg.ToX, Y: msg.Y},
+			},
 		}
 	}
 	
@@ -270,6 +305,11 @@ func (r *RealRenderer) renderSequenceWithPositions(d *diagram.Diagram) (*NodePos
 		output = c.String()
 	}
 	
+	// Draw the legend below the diagram
+	if legend := render.RenderLegend(renderDiagram, coloredCanvas != nil); legend != "" {
+		output += "\n\n" + legend
+	}
+	
 	return positions, output, nil
 }
 
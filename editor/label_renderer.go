@@ -2,6 +2,7 @@ package editor
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -11,7 +12,8 @@ type LabelPosition struct {
 	Label     rune
 	ViewportX int
 	ViewportY int
-	IsFrom    bool // For connection mode, marks the FROM node
+	IsFrom    bool   // For connection mode, marks the FROM node
+	Text      string // Drawn instead of Label when set, as for node IDs
 }
 
 // CalculateLabelPositions computes where labels should be drawn in the viewport
@@ -83,42 +85,76 @@ func (e *TUIEditor) CalculateLabelPositions(hasScrollIndicator bool) []LabelPosi
 
 	// Process node labels (existing logic)
 	for nodeID, label := range e.jumpLabels {
-		if pos, ok := e.nodePositions[nodeID]; ok {
-			viewportY := 0
-			viewportX := pos.X
-
-			// Adjust X position based on diagram type
-			if e.diagram.Type == "sequence" && pos.Y < 7 {
-				// For sequence diagram participants, place label inside the box at left edge
-				// The box starts at pos.X, so we place the label at pos.X + 1
-				viewportX = pos.X + 1
-			} else if e.diagram.Type == "box" {
-				// For regular box diagrams, place inside the box corner
-				viewportX = pos.X + 2
-			} else {
-				// For sequence diagram elements below participants, use default position
-				viewportX = pos.X + 1
-			}
+		if viewportX, viewportY, ok := e.nodeLabelSpot(nodeID, hasScrollIndicator); ok {
+			positions = append(positions, LabelPosition{
+				NodeID:    nodeID,
+				Label:     label,
+				ViewportX: viewportX,
+				ViewportY: viewportY,
+				IsFrom:    e.jumpAction == JumpActionConnectTo && nodeID == e.selected,
+			})
+		}
+	}
 
-			// Calculate Y position using consolidated transformation logic
-			viewportY = e.TransformToViewport(pos.Y, hasScrollIndicator)
+	return positions
+}
 
-			// Only include if within viewport
-			if viewportY >= 1 && viewportY <= e.height-3 {
-				positions = append(positions, LabelPosition{
-					NodeID:    nodeID,
-					Label:     label,
-					ViewportX: viewportX,
-					ViewportY: viewportY,
-					IsFrom:    e.jumpAction == JumpActionConnectTo && nodeID == e.selected,
-				})
-			}
-		}
+// CalculateNodeIDPositions computes where the node ID overlay draws each
+// visible node's ID: where its jump label would go. It is empty when the
+// overlay is off and in the modes that draw over the diagram themselves.
+func (e *TUIEditor) CalculateNodeIDPositions(hasScrollIndicator bool) []LabelPosition {
+	if !e.showNodeIDs {
+		return nil
+	}
+	switch e.mode {
+	case ModeJump, ModeJSON, ModeHelp, ModePresent, ModeHintMenu:
+		return nil
 	}
 
+	var positions []LabelPosition
+	for _, node := range e.diagram.Nodes {
+		if viewportX, viewportY, ok := e.nodeLabelSpot(node.ID, hasScrollIndicator); ok {
+			positions = append(positions, LabelPosition{
+				NodeID:    node.ID,
+				Text:      strconv.Itoa(node.ID),
+				ViewportX: viewportX,
+				ViewportY: viewportY,
+			})
+		}
+	}
 	return positions
 }
 
+// nodeLabelSpot returns the 1-based terminal column and row a label for the
+// node is drawn at, on the top border of its box, and whether that row is
+// on screen
+func (e *TUIEditor) nodeLabelSpot(nodeID int, hasScrollIndicator bool) (int, int, bool) {
+	pos, ok := e.nodePositions[nodeID]
+	if !ok {
+		return 0, 0, false
+	}
+
+	// Adjust X position based on diagram type
+	var viewportX int
+	if e.diagram.Type == "sequence" && pos.Y < 7 {
+		// For sequence diagram participants, place label inside the box at left edge
+		// The box starts at pos.X, so we place the label at pos.X + 1
+		viewportX = pos.X + 1
+	} else if e.diagram.Type == "box" {
+		// For regular box diagrams, place inside the box corner
+		viewportX = pos.X + 2
+	} else {
+		// For sequence diagram elements below participants, use default position
+		viewportX = pos.X + 1
+	}
+
+	// Calculate Y position using consolidated transformation logic
+	viewportY := e.TransformToViewport(pos.Y, hasScrollIndicator)
+
+	// Only include if within viewport
+	return viewportX, viewportY, viewportY >= 1 && viewportY <= e.height-3
+}
+
 // RenderLabelsToString returns ANSI escape sequences to draw labels
 func RenderLabelsToString(positions []LabelPosition) string {
 	if len(positions) == 0 {
@@ -134,7 +170,10 @@ func RenderLabelsToString(positions []LabelPosition) string {
 		// Move to position
 		output.WriteString(fmt.Sprintf("\033[%d;%dH", pos.ViewportY, pos.ViewportX))
 
-		if pos.IsFrom {
+		if pos.Text != "" {
+			// Node ID - plainer than a jump label, which it never shares the screen with
+			output.WriteString("\033[36m" + pos.Text + "\033[0m")
+		} else if pos.IsFrom {
 			// This is the FROM node in connection mode
 			output.WriteString("\033[32;1mFROM\033[0m") // Green "FROM"
 		} else {
@@ -2,6 +2,8 @@ package editor
 
 import (
 	"edd/diagram"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -193,4 +195,60 @@ func TestRenderLabelsToString(t *testing.T) {
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
 		   len(s) > len(substr) && contains(s[1:], substr)
-}
\ No newline at end of file
+}
+func TestNodeIDOverlayMarksEachBox(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Type: "box",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}},
+			{ID: 12, Text: []string{"Database"}},
+		},
+		Connections: []diagram.Connection{{From: 1, To: 2}, {From: 2, To: 12}},
+	})
+	tui.SetTerminalSize(100, 40)
+
+	if positions := tui.CalculateNodeIDPositions(false); len(positions) != 0 {
+		t.Fatalf("Expected no IDs before the overlay is turned on, got %v", positions)
+	}
+	tui.handleNormalKey('#')
+	if !tui.ShowsNodeIDs() {
+		t.Fatal("Expected # to turn the node ID overlay on")
+	}
+
+	lines := strings.Split(tui.Render(), "\n")
+	positions := tui.CalculateNodeIDPositions(false)
+	if len(positions) != 3 {
+		t.Fatalf("Expected an ID for each of the 3 nodes, got %v", positions)
+	}
+	for _, pos := range positions {
+		if pos.Text != strconv.Itoa(pos.NodeID) {
+			t.Errorf("Node %d: expected its ID drawn, got %q", pos.NodeID, pos.Text)
+		}
+		box := tui.nodePositions[pos.NodeID]
+		if pos.ViewportX != box.X+2 || pos.ViewportY != box.Y+1 {
+			t.Errorf("Node %d: expected the ID at (%d,%d) inside the box's corner, got (%d,%d)",
+				pos.NodeID, box.X+2, box.Y+1, pos.ViewportX, pos.ViewportY)
+		}
+
+		// The ID covers the top border just right of the box's corner
+		row := []rune(lines[pos.ViewportY-1])
+		covered := string(row[pos.ViewportX-1 : pos.ViewportX-1+len(pos.Text)])
+		if row[pos.ViewportX-2] != '╭' || strings.Trim(covered, "─") != "" {
+			t.Errorf("Node %d: expected the ID over the top border after ╭, got %q", pos.NodeID, string(row))
+		}
+	}
+
+	// Jump labels take the same spots, so the IDs make way for them
+	tui.startJump(JumpActionSelect)
+	if positions := tui.CalculateNodeIDPositions(false); len(positions) != 0 {
+		t.Errorf("Expected no IDs in jump mode, got %v", positions)
+	}
+	tui.handleKey(27)
+
+	tui.handleNormalKey('#')
+	if positions := tui.CalculateNodeIDPositions(false); tui.ShowsNodeIDs() || len(positions) != 0 {
+		t.Errorf("Expected # to turn the overlay back off, got %v", positions)
+	}
+}
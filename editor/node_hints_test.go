@@ -0,0 +1,96 @@
+package editor
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func TestCycleNodeStyle(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{{ID: 1, Text: []string{"Box"}}},
+	})
+
+	want := []string{"sharp", "double", "thick", "dashed", "dotted", "rounded"}
+	for _, style := range want {
+		tui.cycleNodeStyle(1)
+		if got := tui.GetDiagram().Nodes[0].Hints["style"]; got != style {
+			t.Errorf("Expected style %q, got %q", style, got)
+		}
+	}
+}
+
+func TestCycleNodeColor(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{{ID: 1, Text: []string{"Box"}}},
+	})
+
+	for _, color := range nodeColors[1:] {
+		tui.cycleNodeColor(1)
+		if got := tui.GetDiagram().Nodes[0].Hints["color"]; got != color {
+			t.Errorf("Expected color %q, got %q", color, got)
+		}
+	}
+
+	tui.cycleNodeColor(1)
+	if _, ok := tui.GetDiagram().Nodes[0].Hints["color"]; ok {
+		t.Errorf("Expected color to cycle back to default")
+	}
+}
+
+func TestNodeHintMenuBrokenBorders(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{{ID: 1, Text: []string{"Box"}}},
+	})
+	tui.editingHintNode = 1
+	tui.SetMode(ModeHintMenu)
+
+	tui.HandleKey('e')
+	if got := tui.GetDiagram().Nodes[0].Hints["style"]; got != "dashed" {
+		t.Errorf("Expected [e] to set dashed, got %q", got)
+	}
+	tui.HandleKey('f')
+	if got := tui.GetDiagram().Nodes[0].Hints["style"]; got != "dotted" {
+		t.Errorf("Expected [f] to set dotted, got %q", got)
+	}
+}
+
+func TestHistoryLabelsNameLastOperation(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	if got := tui.history.CurrentLabel(); got != "" {
+		t.Errorf("Expected the initial state to have no label, got %q", got)
+	}
+
+	a := tui.AddNode([]string{"A"})
+	if got := tui.history.CurrentLabel(); got != "add node" {
+		t.Errorf("Expected label %q, got %q", "add node", got)
+	}
+	b := tui.AddNode([]string{"B"})
+	tui.AddConnection(a, b, "")
+	if got := tui.history.CurrentLabel(); got != "add connection" {
+		t.Errorf("Expected label %q, got %q", "add connection", got)
+	}
+
+	// Hint menu keys label their own change
+	tui.editingHintNode = a
+	tui.SetMode(ModeHintMenu)
+	tui.handleNodeHintInput('r')
+	if got := tui.history.CurrentLabel(); got != "color node" {
+		t.Errorf("Expected label %q, got %q", "color node", got)
+	}
+	tui.handleNodeHintInput('o')
+	if got := tui.history.CurrentLabel(); got != "style node text" {
+		t.Errorf("Expected label %q, got %q", "style node text", got)
+	}
+
+	// Undo names the labelled action, and the label follows the position
+	tui.Undo()
+	if got := tui.GetCommandResult(); got != "Undid: style node text" {
+		t.Errorf("Unexpected undo result %q", got)
+	}
+	if got := tui.history.CurrentLabel(); got != "color node" {
+		t.Errorf("Expected label %q after undo, got %q", "color node", got)
+	}
+}
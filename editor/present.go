@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"edd/diagram"
+	"fmt"
+)
+
+// StartPresent enters present mode with one slide per connected component,
+// or with byFocus one slide per node showing it and its direct neighbours.
+// It returns false, leaving the mode unchanged, if there is nothing to show.
+func (e *TUIEditor) StartPresent(byFocus bool) bool {
+	var slides [][]int
+	if byFocus {
+		for _, node := range e.diagram.Nodes {
+			slides = append(slides, diagram.Neighborhood(e.diagram.Connections, node.ID))
+		}
+	} else {
+		slides = diagram.ConnectedComponents(e.diagram.Nodes, e.diagram.Connections)
+	}
+	if len(slides) == 0 {
+		return false
+	}
+
+	e.SetMode(ModePresent)
+	e.presentSlides = slides
+	e.presentSlide = 0
+	return true
+}
+
+// NextSlide advances to the next slide, staying on the last one
+func (e *TUIEditor) NextSlide() {
+	if e.presentSlide < len(e.presentSlides)-1 {
+		e.presentSlide++
+	}
+}
+
+// PreviousSlide goes back to the previous slide, staying on the first one
+func (e *TUIEditor) PreviousSlide() {
+	if e.presentSlide > 0 {
+		e.presentSlide--
+	}
+}
+
+// GetPresentSlide returns the current slide index and the number of slides
+func (e *TUIEditor) GetPresentSlide() (current, total int) {
+	return e.presentSlide, len(e.presentSlides)
+}
+
+// PresentDiagram returns the part of the diagram shown on the current slide,
+// or nil when not presenting.
+func (e *TUIEditor) PresentDiagram() *diagram.Diagram {
+	if e.mode != ModePresent || len(e.presentSlides) == 0 {
+		return nil
+	}
+	return e.diagram.Subgraph(e.presentSlides[e.presentSlide])
+}
+
+// renderPresent renders the current slide with a page counter beneath it
+func (e *TUIEditor) renderPresent() string {
+	slide := e.PresentDiagram()
+	if slide == nil {
+		return ""
+	}
+	output, err := e.renderer.Render(slide)
+	if err != nil {
+		return fmt.Sprintf("Render error: %v\n", err)
+	}
+	return fmt.Sprintf("%s\n[ %d / %d ]", output, e.presentSlide+1, len(e.presentSlides))
+}
+
+// handlePresentKey processes keys in present mode
+func (e *TUIEditor) handlePresentKey(key rune) bool {
+	switch key {
+	case ' ', 'n', 'l', 'j':
+		e.NextSlide()
+	case 127, 8, 'p', 'h', 'k': // Backspace goes back
+		e.PreviousSlide()
+	case 27, 'q': // ESC or q to leave present mode
+		e.SetMode(ModeNormal)
+	}
+	return false
+}
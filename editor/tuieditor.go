@@ -2,10 +2,15 @@ package editor
 
 import (
 	"edd/diagram"
+	"edd/export"
+	"edd/layout"
+	"edd/render"
+	"edd/templates"
 	"encoding/json"
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -43,7 +48,10 @@ type TUIEditor struct {
 	commandBuffer []rune // Separate buffer for command mode
 
 	// Ed mascot
-	edd *EddCharacter
+	edd    *EddCharacter
+	hideEd bool // Mascot turned off, giving its rows to the diagram
+
+	showNodeIDs bool // Overlay each node's ID on its box outside jump mode
 
 	// Terminal state
 	width  int
@@ -52,9 +60,11 @@ type TUIEditor struct {
 	// Positions from last layout (for jump label positioning)
 	nodePositions   map[int]diagram.Point // Node ID -> position from last render
 	connectionPaths map[int]diagram.Path  // Connection index -> path from last render
+	lastRender      string                // Whole diagram as last drawn, before scrolling
 
 	// JSON view state
-	jsonScrollOffset int // Current scroll position in JSON view
+	jsonScrollOffset int    // Current scroll position in JSON view
+	viewText         string // Read-only text shown in the JSON view instead of the diagram JSON (export preview)
 
 	// Diagram view state
 	diagramScrollOffset int  // Current vertical scroll position in diagram view
@@ -76,6 +86,24 @@ type TUIEditor struct {
 	quitToPicker      bool   // Quit to picker (markdown mode only)
 	markdownMode      bool   // Whether editing in markdown mode
 	hasChanges        bool   // Track unsaved changes
+
+	// Theme preview state (Tab cycling in :theme)
+	themePreviewActive   bool   // A theme is being previewed but not yet applied
+	themePreviewOriginal string // Theme hint to restore if the preview is cancelled
+
+	// Present mode state
+	presentSlides [][]int // Node IDs shown on each slide
+	presentSlide  int     // Index of the slide being shown
+
+	// :goto highlight state
+	flashNode  int       // Node highlighted after :goto (-1 for none)
+	flashUntil time.Time // When the highlight ends
+
+	showCycles bool // Connections on cycles are drawn in red after :find-cycles
+
+	// Neighbor navigation state ([ and ] keys)
+	neighborAnchor   int // Node whose neighbors are being stepped through (-1 for none)
+	neighborSelected int // Node the last step selected
 }
 
 // NewTUIEditor creates a new TUI editor instance
@@ -86,6 +114,8 @@ func NewTUIEditor(renderer DiagramRenderer) *TUIEditor {
 		mode:                ModeNormal,
 		selected:            -1,
 		selectedConnection:  -1,
+		neighborAnchor:      -1,
+		neighborSelected:    -1,
 		jumpLabels:          make(map[int]rune),
 		connectionLabels:    make(map[int]rune),
 		activationStartConn: -1,
@@ -106,6 +136,7 @@ func NewTUIEditor(renderer DiagramRenderer) *TUIEditor {
 		diagramScrollOffset: 0,                    // Initialize diagram scroll offset
 		history:             NewStructHistory(500), // 500 states max for extensive editing
 		connectFrom:         -1,                   // Initialize test-only field
+		flashNode:           -1,
 	}
 
 	// Save initial empty state
@@ -190,6 +221,12 @@ func (e *TUIEditor) SetHasChanges(changed bool) {
 	e.hasChanges = changed
 }
 
+// The smallest terminal, in columns and lines, that Render draws the editor in
+const (
+	minTerminalWidth  = 20
+	minTerminalHeight = 5
+)
+
 // SetTerminalSize updates the terminal dimensions
 func (e *TUIEditor) SetTerminalSize(width, height int) {
 	e.width = width
@@ -234,6 +271,179 @@ func (e *TUIEditor) ScrollToBottom() {
 	// Labels will be reassigned on next render when scroll position is updated
 }
 
+// neighbors returns the IDs of the nodes joined to nodeID by a connection:
+// the targets of its outgoing connections, then the sources of its incoming
+// ones, each in connection order and listed once
+func (e *TUIEditor) neighbors(nodeID int) []int {
+	seen := map[int]bool{nodeID: true}
+	var ids []int
+	add := func(id int) {
+		if !seen[id] && e.findNode(id) != nil {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, conn := range e.diagram.Connections {
+		if conn.From == nodeID {
+			add(conn.To)
+		}
+	}
+	for _, conn := range e.diagram.Connections {
+		if conn.To == nodeID {
+			add(conn.From)
+		}
+	}
+	return ids
+}
+
+// StepNeighbor moves the selection to the next (delta 1) or previous
+// (delta -1) neighbor of the anchor node, wrapping around, and scrolls it
+// into view. The anchor is the selected node unless the selection came from
+// the last step, so repeated steps cycle through one node's neighbors; with
+// nothing selected the first node is selected.
+func (e *TUIEditor) StepNeighbor(delta int) {
+	if e.findNode(e.selected) == nil {
+		if len(e.diagram.Nodes) == 0 {
+			e.commandResult = "No nodes"
+			return
+		}
+		id := e.diagram.Nodes[0].ID
+		e.GotoNode(id)
+		e.neighborAnchor, e.neighborSelected = id, id
+		e.commandResult = fmt.Sprintf("Node %d", id)
+		return
+	}
+
+	if e.selected != e.neighborSelected || e.findNode(e.neighborAnchor) == nil {
+		e.neighborAnchor = e.selected
+	}
+	ids := e.neighbors(e.neighborAnchor)
+	if len(ids) == 0 {
+		e.commandResult = fmt.Sprintf("Node %d has no connections", e.neighborAnchor)
+		return
+	}
+
+	next := slices.Index(ids, e.selected) + delta
+	if e.selected == e.neighborAnchor && delta < 0 {
+		next = len(ids) - 1
+	}
+	next = (next + len(ids)) % len(ids)
+	e.GotoNode(ids[next])
+	e.neighborSelected = ids[next]
+	e.commandResult = fmt.Sprintf("Node %d (neighbor %d of %d of node %d)", ids[next], next+1, len(ids), e.neighborAnchor)
+}
+
+// EnterNeighbor makes the selected node the anchor, so the next step moves
+// on to its own neighbors
+func (e *TUIEditor) EnterNeighbor() {
+	if e.findNode(e.selected) == nil {
+		return
+	}
+	e.neighborAnchor, e.neighborSelected = e.selected, e.selected
+	e.commandResult = fmt.Sprintf("Neighbors of node %d", e.selected)
+}
+
+// GotoNode selects a node and scrolls the diagram view so it is visible,
+// centering it if it was off screen, then briefly highlights it
+func (e *TUIEditor) GotoNode(nodeID int) error {
+	node := e.findNode(nodeID)
+	if node == nil {
+		return fmt.Errorf("no node with ID %d", nodeID)
+	}
+	e.selected = nodeID
+	e.selectedConnection = -1
+
+	// Positions come from the last render; render now if the node is new
+	pos, ok := e.nodePositions[nodeID]
+	if !ok {
+		e.Render()
+		pos = e.nodePositions[nodeID]
+	}
+
+	visibleLines := e.diagramLines()
+	height := len(node.Text) + 2
+	if pos.Y < e.diagramScrollOffset || pos.Y+height > e.diagramScrollOffset+visibleLines {
+		e.diagramScrollOffset = pos.Y + height/2 - visibleLines/2
+		if e.diagramScrollOffset < 0 {
+			e.diagramScrollOffset = 0
+		}
+	}
+	// A pending auto-scroll to new content would undo this
+	e.diagramChanged = false
+
+	e.flashNode = nodeID
+	e.flashUntil = time.Now().Add(gotoFlashDuration)
+	return nil
+}
+
+// gotoFlashDuration is how long :goto highlights the node it jumped to
+const gotoFlashDuration = 800 * time.Millisecond
+
+// ExpireFlash ends a :goto highlight whose time is up. It returns true when
+// one ended, so the caller knows to redraw.
+func (e *TUIEditor) ExpireFlash() bool {
+	if e.flashNode < 0 || time.Now().Before(e.flashUntil) {
+		return false
+	}
+	e.flashNode = -1
+	return true
+}
+
+// FindCycles turns on highlighting of the connections that lie on directed
+// cycles, returning how many separate loops there are and how many
+// connections they take in. Highlighting stays on, following edits, until
+// ClearCycles; with no cycles found it is left off.
+func (e *TUIEditor) FindCycles() (loops, connections int) {
+	cycles := layout.FindCycles(e.diagram.Nodes, e.diagram.Connections)
+	for _, cycle := range cycles {
+		connections += len(cycle)
+	}
+	e.showCycles = len(cycles) > 0
+	return len(cycles), connections
+}
+
+// ClearCycles turns off the highlighting FindCycles turned on, reporting
+// whether it was on
+func (e *TUIEditor) ClearCycles() bool {
+	was := e.showCycles
+	e.showCycles = false
+	return was
+}
+
+// renderDiagram returns the diagram to draw: the edited diagram, or a copy
+// with the node being flashed drawn in a double border and, after
+// :find-cycles, the connections on cycles drawn in red
+func (e *TUIEditor) renderDiagram() *diagram.Diagram {
+	flashing := e.flashNode >= 0 && time.Now().Before(e.flashUntil)
+	var cycles [][]int
+	if e.showCycles {
+		cycles = layout.FindCycles(e.diagram.Nodes, e.diagram.Connections)
+	}
+	if !flashing && len(cycles) == 0 {
+		return e.diagram
+	}
+
+	d := e.diagram.Clone()
+	for i := range d.Nodes {
+		if flashing && d.Nodes[i].ID == e.flashNode {
+			if d.Nodes[i].Hints == nil {
+				d.Nodes[i].Hints = make(map[string]string)
+			}
+			d.Nodes[i].Hints[e.nodeStyleKey()] = "double"
+		}
+	}
+	for _, cycle := range cycles {
+		for _, index := range cycle {
+			conn := &d.Connections[index]
+			if conn.Hints == nil {
+				conn.Hints = make(map[string]string)
+			}
+			conn.Hints["color"] = "red"
+		}
+	}
+	return d
+}
+
 // Run starts the interactive editor loop
 func (e *TUIEditor) Run() error {
 	// Setup terminal
@@ -269,6 +479,11 @@ func (e *TUIEditor) Render() string {
 	// Debug: Print a marker to see if we're being called recursively
 	// fmt.Fprintf(os.Stderr, "DEBUG: Render() called\n")
 
+	// Below this size there's no room for the diagram, the status line and Ed
+	if e.width < minTerminalWidth || e.height < minTerminalHeight {
+		return fmt.Sprintf("Terminal too small (%dx%d)", e.width, e.height)
+	}
+
 	// If in JSON mode, render JSON instead
 	if e.mode == ModeJSON {
 		return e.renderJSON()
@@ -279,6 +494,11 @@ func (e *TUIEditor) Render() string {
 		return GetHelpText()
 	}
 
+	// If presenting, render only the current slide
+	if e.mode == ModePresent {
+		return e.renderPresent()
+	}
+
 	// If we have a real renderer that can provide positions, use it
 	if realRenderer, ok := e.renderer.(*RealRenderer); ok {
 		// Set edit state based on what we're editing
@@ -298,7 +518,16 @@ func (e *TUIEditor) Render() string {
 			realRenderer.SetConnectionEditState(-1, "", 0)
 		}
 
-		positions, output, err := realRenderer.RenderWithPositions(e.diagram)
+		var positions *NodePositions
+		var output string
+		var err error
+		if d := e.renderDiagram(); d.Type != "sequence" && len(d.Nodes) >= ViewportMinNodes {
+			positions, output, err = e.renderViewport(realRenderer, d)
+			e.lastRender = "" // Only the screenful was drawn
+		} else {
+			positions, output, err = realRenderer.RenderWithPositions(d)
+			e.lastRender = output
+		}
 		if err == nil && positions != nil {
 			// Store node positions and connection paths for jump label rendering
 			e.nodePositions = positions.Positions
@@ -307,7 +536,7 @@ func (e *TUIEditor) Render() string {
 			// Apply scroll offset if needed
 			lines := strings.Split(output, "\n")
 			totalLines := len(lines)
-			visibleLines := e.height - 4 // Reserve space for status, Ed, etc. (reduced by 1 for extra line)
+			visibleLines := e.diagramLines()
 
 			// For sequence diagrams, find the header size (participant boxes)
 			headerLines := 0
@@ -331,24 +560,10 @@ func (e *TUIEditor) Render() string {
 			}
 
 			// Check if content exceeds screen height
+			e.clampDiagramScroll(totalLines, visibleLines)
 			if totalLines > visibleLines {
 				maxScroll := totalLines - visibleLines
 
-				// Auto-scroll to bottom if diagram changed (new content added)
-				if e.diagramChanged {
-					// Scroll to bottom to show new content
-					e.diagramScrollOffset = maxScroll
-					// Clear the changed flag after handling it
-					e.diagramChanged = false
-				}
-
-				// Clamp scroll offset to valid range
-				if e.diagramScrollOffset < 0 {
-					e.diagramScrollOffset = 0
-				} else if e.diagramScrollOffset > maxScroll {
-					e.diagramScrollOffset = maxScroll
-				}
-
 				// Calculate visible window
 				startLine := e.diagramScrollOffset
 				endLine := startLine + visibleLines
@@ -456,10 +671,6 @@ func (e *TUIEditor) Render() string {
 						output = output + fmt.Sprintf("\n[↓ %d more lines below]", totalLines-endLine)
 					}
 				}
-			} else {
-				// Content fits on screen, reset scroll offset and clear changed flag
-				e.diagramScrollOffset = 0
-				e.diagramChanged = false
 			}
 
 			return output
@@ -475,6 +686,51 @@ func (e *TUIEditor) Render() string {
 	return RenderTUIWithRenderer(state, e.renderer)
 }
 
+// renderViewport draws only the screenful of a large box diagram at the
+// scroll offset, through a layout the renderer caches, and returns it as the
+// matching lines of an otherwise blank output the height of the whole
+// diagram with its legend and frame, so the scrolling in Render treats it
+// like a full render
+func (e *TUIEditor) renderViewport(r *RealRenderer, d *diagram.Diagram) (*NodePositions, string, error) {
+	view, err := r.LayoutViewport(d)
+	if err != nil {
+		return nil, "", err
+	}
+	height := view.Lines()
+	e.clampDiagramScroll(height, e.diagramLines())
+	top := e.diagramScrollOffset
+	window, err := view.RenderLines(top, e.diagramLines())
+	if err != nil {
+		return nil, "", err
+	}
+
+	lines := make([]string, height)
+	copy(lines[top:], strings.Split(window, "\n"))
+	positions := &NodePositions{Positions: view.Positions(), ConnectionPaths: view.Paths()}
+	return positions, strings.Join(lines, "\n"), nil
+}
+
+// clampDiagramScroll keeps the diagram scroll offset within content
+// totalLines long shown visibleLines at a time, jumping to the bottom to show
+// new content when the diagram has just changed
+func (e *TUIEditor) clampDiagramScroll(totalLines, visibleLines int) {
+	if totalLines <= visibleLines {
+		e.diagramScrollOffset = 0
+		e.diagramChanged = false
+		return
+	}
+	maxScroll := totalLines - visibleLines
+	if e.diagramChanged {
+		e.diagramScrollOffset = maxScroll
+		e.diagramChanged = false
+	}
+	if e.diagramScrollOffset < 0 {
+		e.diagramScrollOffset = 0
+	} else if e.diagramScrollOffset > maxScroll {
+		e.diagramScrollOffset = maxScroll
+	}
+}
+
 // GetState extracts the current state for stateless rendering
 func (e *TUIEditor) GetState() TUIState {
 	return TUIState{
@@ -511,6 +767,8 @@ func (e *TUIEditor) handleKey(key rune) bool {
 		return e.handleJSONKey(key)
 	case ModeHelp:
 		return e.handleHelpKey(key)
+	case ModePresent:
+		return e.handlePresentKey(key)
 	case ModeHintMenu:
 		e.HandleHintMenuInput(key)
 		return false
@@ -567,7 +825,7 @@ func (e *TUIEditor) AddNode(text []string) int {
 	e.diagramChanged = true
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("add node")
 
 	return newNode.ID
 }
@@ -592,19 +850,413 @@ func (e *TUIEditor) DeleteNode(nodeID int) {
 	e.diagram.Connections = newConnections
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("delete node")
+}
+
+// MergeNodes folds one node into another as a single undoable change. The
+// survivor gains the merged node's text and every connection to or from it.
+// Connections between the two, and the merged node's own self-loops, become
+// self-loops on the survivor, which are dropped unless keepSelfLoops is set.
+// In flowcharts, a rewired connection that duplicates another connection
+// (same endpoints and label) is dropped; connections the merge doesn't touch
+// are all kept, parallel edges included.
+func (e *TUIEditor) MergeNodes(intoID, fromID int, keepSelfLoops bool) error {
+	if intoID == fromID {
+		return fmt.Errorf("cannot merge node %d into itself", intoID)
+	}
+	intoIdx, fromIdx := -1, -1
+	for i, node := range e.diagram.Nodes {
+		switch node.ID {
+		case intoID:
+			intoIdx = i
+		case fromID:
+			fromIdx = i
+		}
+	}
+	if intoIdx < 0 {
+		return fmt.Errorf("node %d not found", intoID)
+	}
+	if fromIdx < 0 {
+		return fmt.Errorf("node %d not found", fromID)
+	}
+
+	into := &e.diagram.Nodes[intoIdx]
+	into.Text = append(append([]string{}, into.Text...), e.diagram.Nodes[fromIdx].Text...)
+
+	type edgeKey struct {
+		from, to int
+		label    string
+	}
+	seen := make(map[edgeKey]bool)
+	for _, conn := range e.diagram.Connections {
+		if conn.From != fromID && conn.To != fromID {
+			seen[edgeKey{conn.From, conn.To, conn.Label}] = true
+		}
+	}
+	dedupe := e.diagram.Type != string(diagram.DiagramTypeSequence)
+
+	var connections []diagram.Connection
+	for _, conn := range e.diagram.Connections {
+		involved := conn.From == fromID || conn.To == fromID
+		if conn.From == fromID {
+			conn.From = intoID
+		}
+		if conn.To == fromID {
+			conn.To = intoID
+		}
+		if involved && conn.From == conn.To && !keepSelfLoops {
+			continue
+		}
+		if involved && dedupe {
+			key := edgeKey{conn.From, conn.To, conn.Label}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		connections = append(connections, conn)
+	}
+	e.diagram.Connections = connections
+	e.diagram.Nodes = slices.Delete(e.diagram.Nodes, fromIdx, fromIdx+1)
+	if e.selected == fromID {
+		e.selected = -1
+	}
+
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("merge nodes")
+	return nil
+}
+
+// SwapNodes exchanges where two nodes are drawn, as a single undoable change;
+// their connections go with them and are routed again. In box diagrams each
+// node is pinned with "x"/"y" hints so its center lands on the other's; in
+// sequence diagrams the two participants trade places in the ordering.
+func (e *TUIEditor) SwapNodes(aID, bID int) error {
+	if aID == bID {
+		return fmt.Errorf("cannot swap node %d with itself", aID)
+	}
+	aIdx, bIdx := -1, -1
+	for i, node := range e.diagram.Nodes {
+		switch node.ID {
+		case aID:
+			aIdx = i
+		case bID:
+			bIdx = i
+		}
+	}
+	if aIdx < 0 {
+		return fmt.Errorf("node %d not found", aID)
+	}
+	if bIdx < 0 {
+		return fmt.Errorf("node %d not found", bID)
+	}
+
+	if e.diagram.Type == string(diagram.DiagramTypeSequence) {
+		e.diagram.Nodes[aIdx], e.diagram.Nodes[bIdx] = e.diagram.Nodes[bIdx], e.diagram.Nodes[aIdx]
+	} else {
+		var nodes []diagram.Node
+		var err error
+		if realRenderer, ok := e.renderer.(*RealRenderer); ok {
+			nodes, err = realRenderer.LayoutNodes(e.diagram)
+		} else {
+			nodes, err = render.NewRenderer().GetFlowchartRenderer().LayoutNodes(e.diagram)
+		}
+		if err != nil {
+			return fmt.Errorf("layout failed: %w", err)
+		}
+		placed := make(map[int]diagram.Node, len(nodes))
+		for _, node := range nodes {
+			placed[node.ID] = node
+		}
+		a, b := placed[aID], placed[bID]
+		pin := func(node *diagram.Node, moving, onto diagram.Node) {
+			if node.Hints == nil {
+				node.Hints = make(map[string]string)
+			}
+			center := onto.Center()
+			node.Hints["x"] = strconv.Itoa(center.X - moving.Width/2)
+			node.Hints["y"] = strconv.Itoa(center.Y - moving.Height/2)
+		}
+		pin(&e.diagram.Nodes[aIdx], a, b)
+		pin(&e.diagram.Nodes[bIdx], b, a)
+	}
+
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("swap nodes")
+	return nil
+}
+
+// ClearPinnedPositions removes every node's "x" and "y" hints, as a single
+// undoable change, so that hand-placed nodes rejoin the automatic layout. It
+// returns how many nodes were unpinned.
+func (e *TUIEditor) ClearPinnedPositions() int {
+	unpinned := 0
+	for i := range e.diagram.Nodes {
+		hints := e.diagram.Nodes[i].Hints
+		_, hasX := hints["x"]
+		_, hasY := hints["y"]
+		if !hasX && !hasY {
+			continue
+		}
+		delete(hints, "x")
+		delete(hints, "y")
+		unpinned++
+	}
+	if unpinned == 0 {
+		return 0
+	}
+
+	e.nodePositions = nil
+	e.connectionPaths = nil
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("auto layout")
+	return unpinned
+}
+
+// InsertTemplate adds the nodes and connections of a built-in template to
+// the diagram as a single undoable change, renumbering them after the
+// existing ones. An empty diagram takes on the template's type; a template of
+// the other type can't be added to a diagram that has nodes. It returns the
+// number of nodes and connections added.
+func (e *TUIEditor) InsertTemplate(name string) (nodes, connections int, err error) {
+	t, ok := templates.Get(name)
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(templates.Names(), ", "))
+	}
+
+	sequence := string(diagram.DiagramTypeSequence)
+	if len(e.diagram.Nodes) == 0 {
+		e.diagram.Type = t.Type
+	} else if (e.diagram.Type == sequence) != (t.Type == sequence) {
+		return 0, 0, fmt.Errorf("%s is a %s template", name, t.Type)
+	}
+
+	nodeOffset, connOffset := 0, 0
+	for _, node := range e.diagram.Nodes {
+		nodeOffset = max(nodeOffset, node.ID)
+	}
+	for _, conn := range e.diagram.Connections {
+		connOffset = max(connOffset, conn.ID+1)
+	}
+	for _, node := range t.Nodes {
+		node.ID += nodeOffset
+		e.diagram.Nodes = append(e.diagram.Nodes, node)
+	}
+	for _, conn := range t.Connections {
+		conn.ID += connOffset
+		conn.From += nodeOffset
+		conn.To += nodeOffset
+		e.diagram.Connections = append(e.diagram.Connections, conn)
+	}
+
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("insert template")
+	return len(t.Nodes), len(t.Connections), nil
+}
+
+// SetGroupCollapsed collapses a group of nodes (those with the same "group"
+// hint) into a single summary box, or expands it back into its members, by
+// listing it in or removing it from the diagram's "collapsed" hint
+func (e *TUIEditor) SetGroupCollapsed(group string, collapsed bool) error {
+	if !slices.Contains(render.GroupNames(e.diagram), group) {
+		return fmt.Errorf("no group %q", group)
+	}
+
+	var groups []string
+	for _, name := range render.CollapsedGroups(e.diagram) {
+		if name != group {
+			groups = append(groups, name)
+		}
+	}
+	if collapsed {
+		groups = append(groups, group)
+		slices.Sort(groups)
+	}
+	if strings.Join(groups, ",") == e.GetDiagramHint("collapsed") {
+		return nil
+	}
+	if len(groups) == 0 {
+		e.UnsetDiagramHint("collapsed")
+	} else {
+		e.SetDiagramHint("collapsed", strings.Join(groups, ","))
+	}
+	return nil
 }
 
-// isReturnConnection checks if a connection from B to A is likely a return/response
-// to an earlier connection from A to B (for auto-applying dashed style in sequence diagrams)
-func (e *TUIEditor) isReturnConnection(from, to int) bool {
+// ExtractWaypoints fixes the connection from one node to another to its
+// current automatic route, stored as the connection's Waypoints: its end
+// points and each corner. Editing the waypoints then adjusts the route from
+// where the router left it. It returns the number of waypoints stored.
+func (e *TUIEditor) ExtractWaypoints(fromID, toID int) (int, error) {
+	if e.diagram.Type == string(diagram.DiagramTypeSequence) {
+		return 0, fmt.Errorf("sequence messages are not routed")
+	}
+	index := -1
+	for i, conn := range e.diagram.Connections {
+		if conn.From == fromID && conn.To == toID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return 0, fmt.Errorf("no connection from %d to %d", fromID, toID)
+	}
+	if e.diagram.Connections[index].HasRawPath() {
+		return 0, fmt.Errorf("connection from %d to %d has a hand-built path", fromID, toID)
+	}
+
+	// Route it afresh, so any waypoints it already has are replaced
+	routed := e.diagram.Clone()
+	routed.Connections[index].Waypoints = nil
+	var points []diagram.Point
+	var err error
+	if realRenderer, ok := e.renderer.(*RealRenderer); ok {
+		points, err = realRenderer.Waypoints(routed, index)
+	} else {
+		points, err = render.NewRenderer().GetFlowchartRenderer().Waypoints(routed, index)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	e.diagram.Connections[index].Waypoints = points
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("store waypoints")
+	return len(points), nil
+}
+
+// SortMessages reorders the messages of a sequence diagram so every dashed
+// return follows the call it answers. A return is only tied to a call when
+// exactly one undashed message runs the opposite way between the same two
+// participants; everything else keeps its relative order. The change is one
+// undo step, and the number of messages that moved is returned.
+func (e *TUIEditor) SortMessages() (int, error) {
+	if e.diagram.Type != string(diagram.DiagramTypeSequence) {
+		return 0, fmt.Errorf(":sort only applies to sequence diagrams")
+	}
+
+	conns := e.diagram.Connections
+	after := make(map[int]int) // return index -> index of the call it must follow
+	for i, conn := range conns {
+		if conn.Hints["style"] != "dashed" {
+			continue
+		}
+		call := -1
+		for j, candidate := range conns {
+			if candidate.From == conn.To && candidate.To == conn.From && candidate.Hints["style"] != "dashed" {
+				if call >= 0 {
+					call = -1
+					break
+				}
+				call = j
+			}
+		}
+		if call >= 0 {
+			after[i] = call
+		}
+	}
+
+	// Stable topological order: repeatedly take the earliest message whose
+	// call, if it has one, has already been placed
+	drawn := e.diagram.MessageOrder()
+	placed := make([]bool, len(conns))
+	order := make([]int, 0, len(conns))
+	for len(order) < len(conns) {
+		for _, i := range drawn {
+			if placed[i] {
+				continue
+			}
+			if call, ok := after[i]; ok && !placed[call] {
+				continue
+			}
+			placed[i] = true
+			order = append(order, i)
+			break
+		}
+	}
+
+	moved := 0
+	sorted := make([]diagram.Connection, len(conns))
+	for pos, i := range order {
+		if i != drawn[pos] {
+			moved++
+		}
+		sorted[pos] = conns[i]
+		sorted[pos].Order = 0 // The sorted list is the drawing order
+	}
+	if moved == 0 {
+		return 0, nil
+	}
+
+	e.diagram.Connections = sorted
+	e.selectedConnection = -1
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("sort messages")
+	return moved, nil
+}
+
+// ReorderMessage moves the message drawn at position from of a sequence
+// diagram to position to, both counted from 1 down the diagram. Only the
+// messages' Order changes, renumbered to match the new sequence, so the
+// connection list keeps its order. The change is one undo step.
+func (e *TUIEditor) ReorderMessage(from, to int) error {
+	if e.diagram.Type != string(diagram.DiagramTypeSequence) {
+		return fmt.Errorf(":reorder only applies to sequence diagrams")
+	}
+	count := len(e.diagram.Connections)
+	if from < 1 || from > count || to < 1 || to > count {
+		return fmt.Errorf("messages are numbered 1 to %d", count)
+	}
+	if from == to {
+		return nil
+	}
+
+	drawn := e.diagram.MessageOrder()
+	moving := drawn[from-1]
+	drawn = append(drawn[:from-1], drawn[from:]...)
+	drawn = append(drawn[:to-1], append([]int{moving}, drawn[to-1:]...)...)
+	e.renumberMessages(drawn)
+
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("reorder messages")
+	return nil
+}
+
+// renumberMessages sets every connection's Order from 1 in the given drawn
+// order of connection indices
+func (e *TUIEditor) renumberMessages(drawn []int) {
+	for pos, i := range drawn {
+		e.diagram.Connections[i].Order = pos + 1
+	}
+}
+
+// lastMessageOrder returns the highest Order of any connection, 0 when no
+// message has been given one
+func (e *TUIEditor) lastMessageOrder() int {
+	last := 0
+	for _, conn := range e.diagram.Connections {
+		last = max(last, conn.Order)
+	}
+	return last
+}
+
+// findReturnedCall checks if a connection from B to A is likely a return/response
+// to an earlier connection from A to B (for auto-applying dashed style in sequence
+// diagrams), returning the index of that call or -1 if there is none
+func (e *TUIEditor) findReturnedCall(from, to int) int {
 	// Only apply this logic to sequence diagrams
 	if e.diagram.Type != "sequence" {
-		return false
+		return -1
 	}
 
 	// Look backwards through existing connections for an unreturned call from 'to' to 'from'
-	returnCount := 0
 	for i := len(e.diagram.Connections) - 1; i >= 0; i-- {
 		conn := e.diagram.Connections[i]
 
@@ -613,20 +1265,30 @@ func (e *TUIEditor) isReturnConnection(from, to int) bool {
 			// Check if this call already has a return (is it dashed?)
 			if conn.Hints == nil || conn.Hints["style"] != "dashed" {
 				// This looks like a call that needs a return
-				return true
+				return i
 			}
 		}
+	}
 
-		// If we see a return from 'from' to 'to', increment counter
-		// This helps handle multiple call-return pairs
-		if conn.From == from && conn.To == to {
-			if conn.Hints != nil && conn.Hints["style"] == "dashed" {
-				returnCount++
-			}
-		}
+	return -1
+}
+
+// applyReturnStyle dashes a connection that returns an earlier call and, unless
+// the diagram's inherit-return-color hint is "false", gives it the call's color
+// so the pair reads as one exchange
+func (e *TUIEditor) applyReturnStyle(conn *diagram.Connection) {
+	call := e.findReturnedCall(conn.From, conn.To)
+	if call < 0 {
+		return
 	}
+	conn.Hints["style"] = "dashed"
 
-	return false
+	if e.GetDiagramHint("inherit-return-color") == "false" {
+		return
+	}
+	if color := e.diagram.Connections[call].Hints["color"]; color != "" {
+		conn.Hints["color"] = color
+	}
 }
 
 
@@ -803,12 +1465,13 @@ func (e *TUIEditor) AddConnection(from, to int, label string) {
 	// In flowcharts, check for duplicate connections
 	if e.diagram.Type != string(diagram.DiagramTypeSequence) {
 		// Check for duplicate connections in the same direction only
-		for _, existing := range e.diagram.Connections {
+		for i, existing := range e.diagram.Connections {
 			if existing.From == from && existing.To == to {
-				// Connection already exists in this direction
+				// Connection already exists in this direction; bundle into it
 				if f, err := os.OpenFile("/tmp/edd_connections.log", os.O_APPEND|os.O_WRONLY, 0644); err == nil {
-					fmt.Fprintf(f, "  REJECTED: Duplicate connection in flowchart\n")
+					fmt.Fprintf(f, "  BUNDLED: Duplicate connection in flowchart\n")
 				}
+				e.bundleConnection(i)
 				return
 			}
 		}
@@ -839,9 +1502,7 @@ func (e *TUIEditor) AddConnection(from, to int, label string) {
 	}
 
 	// Auto-detect and apply dashed style for return connections in sequence diagrams
-	if e.isReturnConnection(from, to) {
-		conn.Hints["style"] = "dashed"
-	}
+	e.applyReturnStyle(&conn)
 
 	// Auto-detect activation/deactivation
 	if activate, deactivate := e.detectActivation(from, to, label); activate || deactivate {
@@ -853,6 +1514,11 @@ func (e *TUIEditor) AddConnection(from, to int, label string) {
 		}
 	}
 
+	// Once messages are ordered, a new one goes after the last of them
+	if last := e.lastMessageOrder(); last > 0 {
+		conn.Order = last + 1
+	}
+
 	e.diagram.Connections = append(e.diagram.Connections, conn)
 
 	if f, err := os.OpenFile("/tmp/edd_connections.log", os.O_APPEND|os.O_WRONLY, 0644); err == nil {
@@ -863,7 +1529,71 @@ func (e *TUIEditor) AddConnection(from, to int, label string) {
 	e.diagramChanged = true
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("add connection")
+}
+
+// ConnectByID connects two nodes given by ID, with an optional label, for
+// connecting without picking the ends in jump mode. It reports whether the
+// connection was bundled into an existing one between the same nodes.
+func (e *TUIEditor) ConnectByID(from, to int, label string) (bundled bool, err error) {
+	for _, id := range []int{from, to} {
+		if e.findNode(id) == nil {
+			return false, fmt.Errorf("no node with ID %d", id)
+		}
+	}
+	before := len(e.diagram.Connections)
+	e.AddConnection(from, to, label)
+	return len(e.diagram.Connections) == before, nil
+}
+
+// ConnectLastTwo connects the second most recently added node to the most
+// recent one, judged by their IDs, for building a diagram one node at a time
+// without picking both ends in jump mode
+func (e *TUIEditor) ConnectLastTwo() {
+	if len(e.diagram.Nodes) < 2 {
+		e.commandResult = "Need two nodes to connect"
+		return
+	}
+
+	ids := make([]int, len(e.diagram.Nodes))
+	for i, node := range e.diagram.Nodes {
+		ids[i] = node.ID
+	}
+	slices.Sort(ids)
+	from, to := ids[len(ids)-2], ids[len(ids)-1]
+
+	e.AddConnection(from, to, "")
+	e.commandResult = fmt.Sprintf("Connected %d -> %d", from, to)
+}
+
+// bundleConnection counts another edge into an existing flowchart connection
+// rather than drawing a parallel duplicate. The total is kept in the "count"
+// hint, which the renderer shows as a "×N" suffix on the label.
+func (e *TUIEditor) bundleConnection(index int) {
+	conn := &e.diagram.Connections[index]
+	count, err := strconv.Atoi(conn.Hints["count"])
+	if err != nil || count < 1 {
+		count = 1
+	}
+	count++
+	if conn.Hints == nil {
+		conn.Hints = make(map[string]string)
+	}
+	conn.Hints["count"] = strconv.Itoa(count)
+
+	e.commandResult = fmt.Sprintf("%s → %s already exists; bundled as ×%d (u to undo)",
+		e.connectionEndName(conn.From), e.connectionEndName(conn.To), count)
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("bundle connections")
+}
+
+// connectionEndName returns the first line of a node's text, or its ID
+func (e *TUIEditor) connectionEndName(nodeID int) string {
+	if node := e.findNode(nodeID); node != nil && len(node.Text) > 0 && node.Text[0] != "" {
+		return node.Text[0]
+	}
+	return fmt.Sprintf("node %d", nodeID)
 }
 
 // InsertConnection inserts a connection at a specific index
@@ -894,9 +1624,7 @@ func (e *TUIEditor) InsertConnection(index int, from, to int, label string) {
 	}
 
 	// Auto-detect and apply dashed style for return connections in sequence diagrams
-	if e.isReturnConnection(from, to) {
-		conn.Hints["style"] = "dashed"
-	}
+	e.applyReturnStyle(&conn)
 
 	// Auto-detect activation/deactivation
 	if activate, deactivate := e.detectActivation(from, to, label); activate || deactivate {
@@ -908,6 +1636,23 @@ func (e *TUIEditor) InsertConnection(index int, from, to int, label string) {
 		}
 	}
 
+	// Once messages are ordered, the new one is drawn just after the
+	// connection it is inserted after, so renumber them all around it
+	var drawn []int
+	if e.lastMessageOrder() > 0 {
+		pos := 0
+		for p, i := range e.diagram.MessageOrder() {
+			if i >= index {
+				i++ // Shifted along by the insertion
+			}
+			drawn = append(drawn, i)
+			if i == index-1 {
+				pos = p + 1
+			}
+		}
+		drawn = slices.Insert(drawn, pos, index)
+	}
+
 	// Insert at the specified position
 	if index >= len(e.diagram.Connections) {
 		// Append to end
@@ -917,12 +1662,13 @@ func (e *TUIEditor) InsertConnection(index int, from, to int, label string) {
 		e.diagram.Connections = append(e.diagram.Connections[:index],
 			append([]diagram.Connection{conn}, e.diagram.Connections[index:]...)...)
 	}
+	e.renumberMessages(drawn)
 
 	// Mark diagram as changed
 	e.diagramChanged = true
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("insert connection")
 }
 
 // DeleteConnection removes a connection by index
@@ -934,7 +1680,7 @@ func (e *TUIEditor) DeleteConnection(index int) {
 		)
 
 		// Save to history after modification
-		e.SaveHistory()
+		e.SaveHistory("delete connection")
 	}
 }
 
@@ -963,7 +1709,7 @@ func (e *TUIEditor) handleActivationSelection(connIndex int) {
 		e.activationStartFrom = -1
 		e.clearJumpLabels()
 		e.SetMode(ModeNormal)
-		e.SaveHistory()
+		e.SaveHistory("add activation")
 	}
 }
 
@@ -1045,7 +1791,7 @@ func (e *TUIEditor) deleteActivation(connIndex int) {
 	}
 
 	// Save to history
-	e.SaveHistory()
+	e.SaveHistory("delete activation")
 }
 
 // applyActivation applies activation hints between start and end connections
@@ -1079,7 +1825,7 @@ func (e *TUIEditor) UpdateNodeText(nodeID int, text []string) {
 	}
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("edit node text")
 }
 
 // StartEditingConnection begins editing a connection's label
@@ -1129,7 +1875,7 @@ func (e *TUIEditor) UpdateConnectionLabel(connIndex int, label string) {
 		e.diagram.Connections[connIndex].Label = label
 
 		// Save to history after modification
-		e.SaveHistory()
+		e.SaveHistory("edit label")
 	}
 }
 
@@ -1140,14 +1886,21 @@ func (e *TUIEditor) GetSelectedConnection() int {
 
 // renderJSON renders the diagram as formatted JSON
 func (e *TUIEditor) renderJSON() string {
-	// Marshal with indentation
-	jsonBytes, err := json.MarshalIndent(e.diagram, "", "  ")
-	if err != nil {
-		return fmt.Sprintf("Error rendering JSON: %v", err)
+	var text string
+	if e.viewText != "" {
+		// Showing an export preview
+		text = strings.TrimRight(e.viewText, "\n")
+	} else {
+		// Marshal with indentation
+		jsonBytes, err := json.MarshalIndent(e.diagram, "", "  ")
+		if err != nil {
+			return fmt.Sprintf("Error rendering JSON: %v", err)
+		}
+		text = string(jsonBytes)
 	}
 
 	// Split into lines for scrolling
-	lines := strings.Split(string(jsonBytes), "\n")
+	lines := strings.Split(text, "\n")
 
 	// Calculate visible lines (leave room for status)
 	visibleLines := e.height - 2
@@ -1199,6 +1952,56 @@ func (e *TUIEditor) GetJSONScrollOffset() int {
 	return e.jsonScrollOffset
 }
 
+// PreviewExport shows the diagram exported to format in the read-only JSON
+// view, without writing anything
+func (e *TUIEditor) PreviewExport(format string) error {
+	exportFormat, err := export.ParseFormat(format)
+	if err != nil {
+		return err
+	}
+	exporter, err := export.NewExporter(exportFormat)
+	if err != nil {
+		return err
+	}
+	var output string
+	if exportFormat == export.FormatASCII {
+		output, err = e.LastRender()
+	} else {
+		output, err = exporter.Export(e.diagram)
+	}
+	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	e.viewText = output
+	e.jsonScrollOffset = 0
+	e.SetMode(ModeJSON)
+	return nil
+}
+
+// LastRender returns the diagram exactly as the editor last drew it, whole
+// rather than scrolled to the screen, for an ascii export that matches what
+// is on screen. A diagram not yet drawn in full, such as one edited by a
+// script or too large to draw but a screenful at a time, is drawn now the
+// same way.
+func (e *TUIEditor) LastRender() (string, error) {
+	if e.lastRender != "" {
+		return e.lastRender, nil
+	}
+	if realRenderer, ok := e.renderer.(*RealRenderer); ok {
+		realRenderer.SetEditState(-1, "", 0)
+		realRenderer.SetConnectionEditState(-1, "", 0)
+		_, output, err := realRenderer.RenderWithPositions(e.renderDiagram())
+		return output, err
+	}
+	return e.renderer.Render(e.renderDiagram())
+}
+
+// GetViewText returns the text shown by an export preview, if any
+func (e *TUIEditor) GetViewText() string {
+	return e.viewText
+}
+
 // ScrollJSON scrolls the JSON view
 func (e *TUIEditor) ScrollJSON(delta int) {
 	e.jsonScrollOffset += delta
@@ -1207,35 +2010,46 @@ func (e *TUIEditor) ScrollJSON(delta int) {
 
 // Undo undoes the last action
 func (e *TUIEditor) Undo() {
-	if diagram, err := e.history.Undo(); err == nil && diagram != nil {
-		e.diagram = diagram
-		// Clear any selection
-		e.selected = -1
-		e.selectedConnection = -1
-		e.clearJumpLabels()
-		// Clear cached positions to force re-render
-		e.nodePositions = nil
-		e.connectionPaths = nil
+	description := e.history.UndoDescription()
+	diagram, err := e.history.Undo()
+	if err != nil || diagram == nil {
+		e.commandResult = "Nothing to undo"
+		return
 	}
+	e.commandResult = "Undid: " + description
+	e.diagram = diagram
+	// Clear any selection
+	e.selected = -1
+	e.selectedConnection = -1
+	e.clearJumpLabels()
+	// Clear cached positions to force re-render
+	e.nodePositions = nil
+	e.connectionPaths = nil
 }
 
 // Redo redoes the next action
 func (e *TUIEditor) Redo() {
-	if diagram, err := e.history.Redo(); err == nil && diagram != nil {
-		e.diagram = diagram
-		// Clear any selection
-		e.selected = -1
-		e.selectedConnection = -1
-		e.clearJumpLabels()
-		// Clear cached positions to force re-render
-		e.nodePositions = nil
-		e.connectionPaths = nil
+	description := e.history.RedoDescription()
+	diagram, err := e.history.Redo()
+	if err != nil || diagram == nil {
+		e.commandResult = "Nothing to redo"
+		return
 	}
+	e.commandResult = "Redid: " + description
+	e.diagram = diagram
+	// Clear any selection
+	e.selected = -1
+	e.selectedConnection = -1
+	e.clearJumpLabels()
+	// Clear cached positions to force re-render
+	e.nodePositions = nil
+	e.connectionPaths = nil
 }
 
-// SaveHistory saves the current state to history (call after modifications)
-func (e *TUIEditor) SaveHistory() {
-	e.history.SaveState(e.diagram)
+// SaveHistory saves the current state to history (call after modifications),
+// optionally labelled with the action for undo and redo messages
+func (e *TUIEditor) SaveHistory(label ...string) {
+	e.history.SaveState(e.diagram, label...)
 }
 
 // GetHistoryStats returns undo/redo statistics
@@ -1266,6 +2080,8 @@ func (e *TUIEditor) HandleKey(key rune) bool {
 		return e.handleJSONKey(key)
 	case ModeHelp:
 		return e.handleHelpKey(key)
+	case ModePresent:
+		return e.handlePresentKey(key)
 	case ModeHintMenu:
 		e.HandleHintMenuInput(key)
 		return false
@@ -1346,7 +2162,7 @@ func (e *TUIEditor) isNodeVisible(nodeID int) bool {
 	// Check if the node is in the visible viewport
 	// Calculate visible range based on scroll offset
 	visibleStart := e.diagramScrollOffset
-	visibleEnd := e.diagramScrollOffset + e.height - 4 // Account for UI elements
+	visibleEnd := e.diagramScrollOffset + e.diagramLines()
 
 	// Special handling for sequence diagrams with sticky headers
 	if e.diagram.Type == "sequence" && e.diagramScrollOffset > 0 {
@@ -1375,7 +2191,7 @@ func (e *TUIEditor) isNodeVisible(nodeID int) bool {
 			return true
 		}
 		// For non-participant nodes, adjust visible range for the space taken by headers
-		visibleEnd = e.diagramScrollOffset + e.height - 4 - 8 // Subtract header space
+		visibleEnd = e.diagramScrollOffset + e.diagramLines() - 8 // Subtract header space
 	}
 
 	// Check if node's Y position is within visible range
@@ -1387,7 +2203,33 @@ func (e *TUIEditor) isNodeVisible(nodeID int) bool {
 	return nodeBottom >= visibleStart && nodeTop < visibleEnd
 }
 
-// isConnectionVisible checks if a connection arrow is actually visible in the viewport
+// nearestNode returns the node whose box is nearest the cell (x, y), in the
+// coordinates of nodePositions, for connecting by coordinate: a cell inside
+// a box gives that node, and an empty cell snaps to the closest box within
+// radius cells of it. Boxes are sized as the renderer sizes them. ok is false
+// when no box is that close.
+func (e *TUIEditor) nearestNode(x, y int, radius int) (int, bool) {
+	if e.diagram == nil {
+		return 0, false
+	}
+	best, bestDistance, bestSteps := 0, radius+1, 0
+	for _, node := range render.CalculateNodeDimensions(render.ApplyFixedSizes(render.ApplyWrap(e.diagram)).Nodes) {
+		pos, ok := e.nodePositions[node.ID]
+		if !ok {
+			continue
+		}
+		dx := max(pos.X-x, x-(pos.X+node.Width-1), 0)
+		dy := max(pos.Y-y, y-(pos.Y+node.Height-1), 0)
+		distance, steps := max(dx, dy), dx+dy
+		if distance < bestDistance || (distance == bestDistance && steps < bestSteps) {
+			best, bestDistance, bestSteps = node.ID, distance, steps
+		}
+	}
+	return best, bestDistance <= radius
+}
+
+// isConnectionVisible checks if any part of a connection is visible in the
+// viewport, so that a jump label drawn on it can be seen
 func (e *TUIEditor) isConnectionVisible(connIndex int) bool {
 	if connIndex >= len(e.diagram.Connections) {
 		return false
@@ -1401,17 +2243,61 @@ func (e *TUIEditor) isConnectionVisible(connIndex int) bool {
 		return e.isNodeVisible(conn.From) || e.isNodeVisible(conn.To)
 	}
 
-	// Get the middle point of the path (where the label would be placed)
-	midPoint := path.Points[len(path.Points)/2]
+	_, visible := e.ConnectionLabelPoint(connIndex, path.Points[len(path.Points)-1])
+	return visible
+}
+
+// ConnectionLabelPoint returns where on a connection's path, in diagram
+// coordinates, its jump label can be drawn: preferred when that is on screen,
+// otherwise the arrow end, otherwise the on-screen cell of the path nearest
+// the arrow end. It returns false when the whole path is scrolled off.
+func (e *TUIEditor) ConnectionLabelPoint(connIndex int, preferred diagram.Point) (diagram.Point, bool) {
+	path, ok := e.connectionPaths[connIndex]
+	if !ok || len(path.Points) == 0 {
+		return diagram.Point{}, false
+	}
+	if e.isRowVisible(preferred.Y) {
+		return preferred, true
+	}
+
+	// Walk the path back from the arrow end, one cell at a time so that a
+	// segment crossing the viewport counts even when both its ends are off
+	points := path.Points
+	for i := len(points) - 1; i > 0; i-- {
+		for p := points[i]; p != points[i-1]; {
+			if e.isRowVisible(p.Y) {
+				return p, true
+			}
+			p.X += sign(points[i-1].X - p.X)
+			p.Y += sign(points[i-1].Y - p.Y)
+		}
+	}
+	if e.isRowVisible(points[0].Y) {
+		return points[0], true
+	}
+	return diagram.Point{}, false
+}
 
-	// Convert to viewport coordinates
-	viewportY := e.TransformToViewport(midPoint.Y, false)
+// sign returns -1, 0 or 1 for negative, zero and positive n
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
 
-	// Check if this Y position is within the visible area
-	// Terminal height minus status lines (4 lines reserved)
-	visibleBottom := e.height - 4
+// isRowVisible checks if a diagram row is drawn in the viewport, and not
+// hidden behind the sticky participant headers of a scrolled sequence diagram
+func (e *TUIEditor) isRowVisible(diagramY int) bool {
+	viewportY := e.TransformToViewport(diagramY, false)
+	if e.diagram.Type == "sequence" && e.diagramScrollOffset > 0 && diagramY >= 7 && diagramY < e.diagramScrollOffset {
+		return false // Scrolled up under the headers
+	}
 
-	return viewportY >= 1 && viewportY <= visibleBottom
+	return viewportY >= 1 && viewportY <= e.diagramLines()
 }
 
 // assignJumpLabels assigns single-character labels to visible nodes and connections
@@ -1624,6 +2510,7 @@ const (
 	ModeJSON                 // JSON view mode
 	ModeHintMenu             // Editing connection hints
 	ModeHelp                 // Help display mode
+	ModePresent              // Read-only slide show
 )
 
 // String returns the mode name for display
@@ -1645,6 +2532,8 @@ func (m Mode) String() string {
 		return "HINTS"
 	case ModeHelp:
 		return "HELP"
+	case ModePresent:
+		return "PRESENT"
 	default:
 		return "UNKNOWN"
 	}
@@ -1671,6 +2560,17 @@ const (
 func (e *TUIEditor) SetMode(mode Mode) {
 	e.mode = mode
 
+	// An export preview only lasts while the view is open
+	if mode != ModeJSON {
+		e.viewText = ""
+	}
+
+	// Slides are rebuilt each time present mode starts
+	if mode != ModePresent {
+		e.presentSlides = nil
+		e.presentSlide = 0
+	}
+
 	// Clear jump labels when leaving jump mode
 	if mode != ModeJump {
 		e.jumpLabels = make(map[int]rune)
@@ -2004,6 +2904,38 @@ func (e *TUIEditor) GetDiagramScrollOffset() int {
 	return e.diagramScrollOffset
 }
 
+// SetShowEd turns the Ed mascot on or off. Without it the diagram gets the
+// rows Ed would have used.
+func (e *TUIEditor) SetShowEd(show bool) {
+	e.hideEd = !show
+}
+
+// ShowsEd reports whether the Ed mascot is drawn
+func (e *TUIEditor) ShowsEd() bool {
+	return !e.hideEd
+}
+
+// ToggleNodeIDs turns the node ID overlay on or off, returning whether it is
+// now on
+func (e *TUIEditor) ToggleNodeIDs() bool {
+	e.showNodeIDs = !e.showNodeIDs
+	return e.showNodeIDs
+}
+
+// ShowsNodeIDs reports whether node IDs are overlaid on their boxes
+func (e *TUIEditor) ShowsNodeIDs() bool {
+	return e.showNodeIDs
+}
+
+// diagramLines returns how many terminal rows show the diagram, the rest
+// being kept for the status line and Ed
+func (e *TUIEditor) diagramLines() int {
+	if e.hideEd {
+		return e.height - 2
+	}
+	return e.height - 4
+}
+
 // GetEddFrame returns Ed's current animation frame
 func (e *TUIEditor) GetEddFrame() string {
 	return e.edd.GetFrame(e.mode)
@@ -2239,7 +3171,7 @@ func (e *TUIEditor) reorderParticipant(nodeID int, newPosition int) {
 	e.connectionPaths = nil
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("reorder participants")
 }
 
 // HandleTextInput processes text input in insert/edit modes
@@ -2248,19 +3180,20 @@ func (e *TUIEditor) HandleTextInput(key rune) {
 	e.handleTextKey(key)
 }
 
-// ToggleDiagramType switches between sequence and box diagram types
+// ToggleDiagramType switches between sequence and box diagram types,
+// converting connections to ordered messages or messages back to edges
 func (e *TUIEditor) ToggleDiagramType() {
-	e.history.SaveState(e.diagram)
-	currentType := e.diagram.Type
-	if currentType == "" {
-		currentType = "box"
-	}
-
-	if currentType == "sequence" {
-		e.diagram.Type = "box"
+	if e.diagram.IsSequence() {
+		diagram.ConvertToBox(e.diagram)
+		e.commandResult = "Converted to box diagram"
+	} else if diagram.ConvertToSequence(e.diagram) {
+		e.commandResult = "Converted to sequence diagram (connections form a cycle, message order is approximate)"
 	} else {
-		e.diagram.Type = "sequence"
+		e.commandResult = "Converted to sequence diagram"
 	}
+	e.hasChanges = true
+	e.diagramChanged = true
+	e.SaveHistory("convert diagram type")
 }
 
 // HandleJumpInput processes jump label selection for both nodes and connections
@@ -2293,10 +3226,21 @@ func (e *TUIEditor) ClearCommand() {
 	e.exportFilename = ""
 }
 
+// ExecuteCommand runs a : command given as text, without the colon, as if
+// it had been typed, for running commands from a script. Its result and any
+// save, export or quit request are left to be read as usual.
+func (e *TUIEditor) ExecuteCommand(cmd string) {
+	e.ClearCommand()
+	e.SetMode(ModeCommand)
+	e.commandBuffer = []rune(cmd)
+	e.ProcessCommand()
+}
+
 // ProcessCommand processes the completed command when Enter is pressed
 func (e *TUIEditor) ProcessCommand() {
 	cmd := strings.TrimSpace(string(e.commandBuffer))
 	if cmd == "" {
+		e.cancelThemePreview()
 		e.SetMode(ModeNormal)
 		return
 	}
@@ -2308,6 +3252,11 @@ func (e *TUIEditor) ProcessCommand() {
 		return
 	}
 
+	// A theme preview only survives into the :theme command itself
+	if parts[0] != "theme" {
+		e.cancelThemePreview()
+	}
+
 	switch parts[0] {
 	case "w", "write":
 		// Save command
@@ -2363,14 +3312,20 @@ func (e *TUIEditor) ProcessCommand() {
 		e.SetMode(ModeNormal)
 
 	case "e", "export":
-		// Export command
+		// Export command; without a filename, preview the output instead
 		if len(parts) < 2 {
 			e.commandResult = "Usage: :export <format> [filename]"
+		} else if len(parts) == 2 {
+			if err := e.PreviewExport(parts[1]); err != nil {
+				e.commandResult = "Error: " + err.Error()
+				e.SetMode(ModeNormal)
+			} else {
+				e.commandResult = fmt.Sprintf("Preview: %s (ESC to close)", parts[1])
+			}
+			return
 		} else {
 			e.exportFormat = parts[1]
-			if len(parts) > 2 {
-				e.exportFilename = parts[2]
-			}
+			e.exportFilename = parts[2]
 		}
 		e.SetMode(ModeNormal)
 
@@ -2399,12 +3354,435 @@ func (e *TUIEditor) ProcessCommand() {
 		}
 		e.SetMode(ModeNormal)
 
+	case "meta":
+		// Show or edit diagram metadata
+		if len(parts) < 2 {
+			var fields []string
+			for _, field := range diagram.MetadataFields {
+				value, _ := e.diagram.Metadata.Get(field)
+				if value == "" {
+					value = "-"
+				}
+				fields = append(fields, fmt.Sprintf("%s=%s", field, value))
+			}
+			e.commandResult = "Metadata: " + strings.Join(fields, ", ")
+		} else if len(parts) == 2 {
+			// Show a single field
+			if value, err := e.diagram.Metadata.Get(parts[1]); err != nil {
+				e.commandResult = fmt.Sprintf("Unknown metadata field: %s (available: %s)", parts[1], strings.Join(diagram.MetadataFields, ", "))
+			} else {
+				e.commandResult = fmt.Sprintf("%s = %s", parts[1], value)
+			}
+		} else {
+			// Values may contain spaces; "-" clears the field
+			value := strings.Join(parts[2:], " ")
+			if value == "-" {
+				value = ""
+			}
+			if err := e.SetMetadata(parts[1], value); err != nil {
+				e.commandResult = fmt.Sprintf("Unknown metadata field: %s (available: %s)", parts[1], strings.Join(diagram.MetadataFields, ", "))
+			} else if value == "" {
+				e.commandResult = "Cleared " + parts[1]
+			} else {
+				e.commandResult = fmt.Sprintf("Set %s = %s", parts[1], value)
+			}
+		}
+		e.SetMode(ModeNormal)
+
+	case "comment":
+		// Show or set the author's note on a node, a connection, or the
+		// selected element; comments are saved but never drawn
+		var comment *string
+		var owner string
+		ok := false
+		args := parts[1:]
+		if len(args) > 0 {
+			if comment, owner, ok = e.commentTarget(args[0]); ok {
+				args = args[1:]
+			}
+		}
+		if !ok {
+			comment, owner, ok = e.selectedCommentTarget()
+		}
+		switch text := strings.Join(args, " "); {
+		case !ok:
+			e.commandResult = "Usage: :comment [<id>|<from>-><to>] [text|-]"
+		case (text == "" || text == "-") && *comment == "":
+			e.commandResult = "No comment on " + owner
+		case text == "":
+			e.commandResult = fmt.Sprintf("Comment on %s: %s", owner, *comment)
+		case text == "-":
+			*comment = ""
+			e.hasChanges = true
+			e.SaveHistory("clear comment")
+			e.commandResult = "Cleared comment on " + owner
+		default:
+			*comment = text
+			e.hasChanges = true
+			e.SaveHistory("edit comment")
+			e.commandResult = "Commented " + owner
+		}
+		e.SetMode(ModeNormal)
+
+	case "merge", "merge!":
+		// Merge the second node into the first; merge! keeps self-loops
+		// created by connections between the two
+		if len(parts) != 3 {
+			e.commandResult = "Usage: :merge <into-id> <from-id>"
+			e.SetMode(ModeNormal)
+			return
+		}
+		into, err1 := strconv.Atoi(parts[1])
+		from, err2 := strconv.Atoi(parts[2])
+		if err1 != nil || err2 != nil {
+			e.commandResult = "Usage: :merge <into-id> <from-id>"
+		} else if err := e.MergeNodes(into, from, parts[0] == "merge!"); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else {
+			e.commandResult = fmt.Sprintf("Merged node %d into %d", from, into)
+		}
+		e.SetMode(ModeNormal)
+
+	case "add":
+		// Add a node; \n in the text starts a new line
+		if len(parts) < 2 {
+			e.commandResult = "Usage: :add <text>"
+		} else {
+			text := strings.Split(strings.Join(parts[1:], " "), `\n`)
+			e.commandResult = fmt.Sprintf("Added node %d", e.AddNode(text))
+			e.hasChanges = true
+		}
+		e.SetMode(ModeNormal)
+
+	case "hint":
+		// Show, set or clear a hint on a node or connection
+		var hints *map[string]string
+		var owner string
+		ok := len(parts) >= 3
+		if ok {
+			hints, owner, ok = e.hintTarget(parts[1])
+		}
+		switch {
+		case !ok:
+			e.commandResult = "Usage: :hint <id>|<from>-><to> <key> [value|-]"
+		case len(parts) == 3:
+			if value, set := (*hints)[parts[2]]; set {
+				e.commandResult = fmt.Sprintf("%s on %s = %s", parts[2], owner, value)
+			} else {
+				e.commandResult = fmt.Sprintf("No %s hint on %s", parts[2], owner)
+			}
+		case len(parts) == 4 && parts[3] == "-":
+			delete(*hints, parts[2])
+			e.hasChanges = true
+			e.diagramChanged = true
+			e.SaveHistory("clear hint")
+			e.commandResult = fmt.Sprintf("Cleared %s on %s", parts[2], owner)
+		default:
+			if *hints == nil {
+				*hints = make(map[string]string)
+			}
+			value := strings.Join(parts[3:], " ")
+			(*hints)[parts[2]] = value
+			e.hasChanges = true
+			e.diagramChanged = true
+			e.SaveHistory("set hint")
+			e.commandResult = fmt.Sprintf("Set %s = %s on %s", parts[2], value, owner)
+		}
+		e.SetMode(ModeNormal)
+
+	case "connect":
+		// Connect two nodes by ID, for scripting and macros
+		if len(parts) < 3 {
+			e.commandResult = "Usage: :connect <from-id> <to-id> [label]"
+			e.SetMode(ModeNormal)
+			return
+		}
+		from, err1 := strconv.Atoi(parts[1])
+		to, err2 := strconv.Atoi(parts[2])
+		label := strings.Join(parts[3:], " ")
+		if err1 != nil || err2 != nil {
+			e.commandResult = "Usage: :connect <from-id> <to-id> [label]"
+		} else if bundled, err := e.ConnectByID(from, to, label); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else if bundled {
+			e.commandResult = fmt.Sprintf("Bundled into the existing connection %d -> %d", from, to)
+		} else {
+			e.commandResult = fmt.Sprintf("Connected %d -> %d", from, to)
+		}
+		e.SetMode(ModeNormal)
+
+	case "swap":
+		// Exchange where two nodes are drawn
+		if len(parts) != 3 {
+			e.commandResult = "Usage: :swap <id> <id>"
+			e.SetMode(ModeNormal)
+			return
+		}
+		a, err1 := strconv.Atoi(parts[1])
+		b, err2 := strconv.Atoi(parts[2])
+		if err1 != nil || err2 != nil {
+			e.commandResult = "Usage: :swap <id> <id>"
+		} else if err := e.SwapNodes(a, b); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else {
+			e.commandResult = fmt.Sprintf("Swapped nodes %d and %d", a, b)
+		}
+		e.SetMode(ModeNormal)
+
+	case "autolayout":
+		// Drop manual positions so every node is placed by the layout again
+		if n := e.ClearPinnedPositions(); n == 0 {
+			e.commandResult = "No pinned nodes"
+		} else if n == 1 {
+			e.commandResult = "Returned 1 node to auto layout"
+		} else {
+			e.commandResult = fmt.Sprintf("Returned %d nodes to auto layout", n)
+		}
+		e.SetMode(ModeNormal)
+
+	case "collapse", "expand":
+		// Show a group as one summary box, or show its members again
+		if len(parts) != 2 {
+			e.commandResult = fmt.Sprintf("Usage: :%s <group> (groups: %s)", parts[0], strings.Join(render.GroupNames(e.diagram), ", "))
+		} else if err := e.SetGroupCollapsed(parts[1], parts[0] == "collapse"); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else if parts[0] == "collapse" {
+			e.commandResult = fmt.Sprintf("Collapsed %s", parts[1])
+		} else {
+			e.commandResult = fmt.Sprintf("Expanded %s", parts[1])
+		}
+		e.SetMode(ModeNormal)
+
+	case "waypoints":
+		// Pin a connection to its current route so it can be edited by hand
+		if len(parts) != 3 {
+			e.commandResult = "Usage: :waypoints <from-id> <to-id>"
+			e.SetMode(ModeNormal)
+			return
+		}
+		from, err1 := strconv.Atoi(parts[1])
+		to, err2 := strconv.Atoi(parts[2])
+		if err1 != nil || err2 != nil {
+			e.commandResult = "Usage: :waypoints <from-id> <to-id>"
+		} else if count, err := e.ExtractWaypoints(from, to); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else {
+			e.commandResult = fmt.Sprintf("Stored %d waypoints for %d → %d", count, from, to)
+		}
+		e.SetMode(ModeNormal)
+
+	case "goto":
+		// Select a node by ID and scroll it into view
+		if len(parts) != 2 {
+			e.commandResult = "Usage: :goto <node-id>"
+		} else if id, err := strconv.Atoi(parts[1]); err != nil {
+			e.commandResult = "Usage: :goto <node-id>"
+		} else if err := e.GotoNode(id); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else {
+			e.commandResult = fmt.Sprintf("Node %d", id)
+		}
+		e.SetMode(ModeNormal)
+
+	case "find-cycles":
+		// Highlight the connections that loop back on themselves
+		if len(parts) == 2 && parts[1] == "off" {
+			if e.ClearCycles() {
+				e.commandResult = "Cycle highlighting off"
+			} else {
+				e.commandResult = "No cycles highlighted"
+			}
+		} else if len(parts) != 1 {
+			e.commandResult = "Usage: :find-cycles [off]"
+		} else if loops, connections := e.FindCycles(); loops == 0 {
+			e.commandResult = "No cycles"
+		} else {
+			e.commandResult = fmt.Sprintf("Highlighted %d connections on cycles in red (ESC or :find-cycles off to clear)", connections)
+		}
+		e.SetMode(ModeNormal)
+
+	case "sort":
+		// Move returns after the calls they answer
+		if moved, err := e.SortMessages(); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else if moved == 0 {
+			e.commandResult = "Messages already in order"
+		} else {
+			e.commandResult = fmt.Sprintf("Reordered %d messages", moved)
+		}
+		e.SetMode(ModeNormal)
+
+	case "reorder":
+		// Move one message up or down the sequence
+		if len(parts) != 3 {
+			e.commandResult = "Usage: :reorder <message> <position>"
+			e.SetMode(ModeNormal)
+			return
+		}
+		from, err1 := strconv.Atoi(parts[1])
+		to, err2 := strconv.Atoi(parts[2])
+		if err1 != nil || err2 != nil {
+			e.commandResult = "Usage: :reorder <message> <position>"
+		} else if err := e.ReorderMessage(from, to); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else {
+			e.commandResult = fmt.Sprintf("Moved message %d to %d", from, to)
+		}
+		e.SetMode(ModeNormal)
+
+	case "present":
+		// Page through the diagram one component (or focus) at a time
+		byFocus := len(parts) > 1 && parts[1] == "focus"
+		if len(parts) > 1 && !byFocus {
+			e.commandResult = "Usage: :present [focus]"
+			e.SetMode(ModeNormal)
+		} else if !e.StartPresent(byFocus) {
+			e.commandResult = "Nothing to present"
+			e.SetMode(ModeNormal)
+		}
+
+	case "theme":
+		// Apply a color theme
+		if len(parts) < 2 {
+			e.cancelThemePreview()
+			current := e.GetDiagramHint("theme")
+			if current == "" {
+				current = "default"
+			}
+			e.commandResult = fmt.Sprintf("Theme: %s (available: %s)", current, strings.Join(render.ThemeNames(), ", "))
+		} else if err := e.ApplyTheme(parts[1]); err != nil {
+			e.commandResult = fmt.Sprintf("Unknown theme: %s (available: %s)", parts[1], strings.Join(render.ThemeNames(), ", "))
+		} else {
+			e.commandResult = "Theme: " + parts[1]
+		}
+		e.SetMode(ModeNormal)
+
+	case "template":
+		// Add a prebuilt skeleton to start editing from
+		if len(parts) != 2 {
+			e.commandResult = fmt.Sprintf("Usage: :template <name> (available: %s)", strings.Join(templates.Names(), ", "))
+		} else if nodes, connections, err := e.InsertTemplate(parts[1]); err != nil {
+			e.commandResult = "Error: " + err.Error()
+		} else {
+			e.commandResult = fmt.Sprintf("Inserted %s (%d nodes, %d connections)", parts[1], nodes, connections)
+		}
+		e.SetMode(ModeNormal)
+
+	case "wrap":
+		// Wrap node text at a maximum width, or stop wrapping with 0
+		if len(parts) < 2 {
+			if width := render.WrapWidth(e.diagram); width > 0 {
+				e.commandResult = fmt.Sprintf("Wrapping node text at %d columns", width)
+			} else {
+				e.commandResult = "Usage: :wrap <columns> (0 turns wrapping off)"
+			}
+		} else if width, err := strconv.Atoi(parts[1]); err != nil || width < 0 {
+			e.commandResult = "Invalid width: " + parts[1]
+		} else if width == 0 {
+			e.UnsetDiagramHint("wrap")
+			e.commandResult = "Node text wrapping off"
+		} else {
+			e.SetDiagramHint("wrap", strconv.Itoa(width))
+			e.commandResult = fmt.Sprintf("Wrapping node text at %d columns", width)
+		}
+		e.SetMode(ModeNormal)
+
+	case "truncate":
+		// Cut node text short at a maximum width, or stop truncating with 0
+		if len(parts) < 2 {
+			if width := render.TruncateWidth(e.diagram); width > 0 {
+				e.commandResult = fmt.Sprintf("Truncating node text at %d columns", width)
+			} else {
+				e.commandResult = "Usage: :truncate <columns> (0 turns truncation off)"
+			}
+		} else if width, err := strconv.Atoi(parts[1]); err != nil || width < 0 {
+			e.commandResult = "Invalid width: " + parts[1]
+		} else if width == 0 {
+			e.UnsetDiagramHint("truncate")
+			e.commandResult = "Node text truncation off"
+		} else {
+			e.SetDiagramHint("truncate", strconv.Itoa(width))
+			e.commandResult = fmt.Sprintf("Truncating node text at %d columns", width)
+		}
+		e.SetMode(ModeNormal)
+
 	default:
 		e.commandResult = "Unknown command: " + parts[0]
 		e.SetMode(ModeNormal)
 	}
 }
 
+// ApplyTheme sets the diagram's color theme as a single undoable change
+func (e *TUIEditor) ApplyTheme(name string) error {
+	if _, ok := render.GetTheme(name); !ok {
+		e.cancelThemePreview()
+		return fmt.Errorf("unknown theme: %s", name)
+	}
+
+	// Any preview only touched the live diagram, so history still holds the
+	// pre-preview state and the change below is recorded as one step
+	e.themePreviewActive = false
+
+	if name == "default" {
+		if e.GetDiagramHint("theme") == "" {
+			return nil
+		}
+		e.UnsetDiagramHint("theme")
+		return nil
+	}
+	e.SetDiagramHint("theme", name)
+	return nil
+}
+
+// cycleThemePreview advances the :theme argument to the next built-in theme
+// and previews it without recording history
+func (e *TUIEditor) cycleThemePreview() {
+	parts := strings.Fields(string(e.commandBuffer))
+	if len(parts) == 0 || parts[0] != "theme" {
+		return
+	}
+
+	if !e.themePreviewActive {
+		e.themePreviewActive = true
+		e.themePreviewOriginal = e.GetDiagramHint("theme")
+	}
+
+	current := "default"
+	if len(parts) > 1 {
+		current = parts[1]
+	} else if e.themePreviewOriginal != "" {
+		current = e.themePreviewOriginal
+	}
+	next := render.NextTheme(current)
+
+	e.commandBuffer = []rune("theme " + next)
+	e.setThemeHintForPreview(next)
+}
+
+// cancelThemePreview restores the theme that was active before Tab cycling began
+func (e *TUIEditor) cancelThemePreview() {
+	if !e.themePreviewActive {
+		return
+	}
+	e.themePreviewActive = false
+	e.setThemeHintForPreview(e.themePreviewOriginal)
+}
+
+// setThemeHintForPreview changes the theme hint without touching history or the changes flag
+func (e *TUIEditor) setThemeHintForPreview(name string) {
+	if name == "" || name == "default" {
+		if e.diagram.Hints != nil {
+			delete(e.diagram.Hints, "theme")
+		}
+	} else {
+		if e.diagram.Hints == nil {
+			e.diagram.Hints = make(map[string]string)
+		}
+		e.diagram.Hints["theme"] = name
+	}
+	e.diagramChanged = true
+}
+
 // SetDiagramHint sets a diagram-level hint
 func (e *TUIEditor) SetDiagramHint(key, value string) {
 	if e.diagram.Hints == nil {
@@ -2421,7 +3799,7 @@ func (e *TUIEditor) SetDiagramHint(key, value string) {
 	e.diagramChanged = true
 
 	// Save to history after modification
-	e.SaveHistory()
+	e.SaveHistory("set " + key)
 }
 
 // UnsetDiagramHint removes a diagram-level hint
@@ -2438,8 +3816,84 @@ func (e *TUIEditor) UnsetDiagramHint(key string) {
 		e.diagramChanged = true
 
 		// Save to history after modification
-		e.SaveHistory()
+		e.SaveHistory("unset " + key)
+	}
+}
+
+// SetMetadata updates a diagram metadata field (name, author, description, created)
+func (e *TUIEditor) SetMetadata(field, value string) error {
+	if err := e.diagram.Metadata.Set(field, value); err != nil {
+		return err
+	}
+	e.hasChanges = true
+	e.SaveHistory("set " + field)
+	return nil
+}
+
+// commentTarget resolves the target of :comment, a node ID such as "3" or a
+// connection given by its endpoints such as "1->2", to a pointer to the
+// comment and a description of its owner
+func (e *TUIEditor) commentTarget(arg string) (*string, string, bool) {
+	if from, to, ok := strings.Cut(arg, "->"); ok {
+		fromID, err1 := strconv.Atoi(from)
+		toID, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil {
+			return nil, "", false
+		}
+		for i, conn := range e.diagram.Connections {
+			if conn.From == fromID && conn.To == toID {
+				return &e.diagram.Connections[i].Comment, "connection " + arg, true
+			}
+		}
+		return nil, "", false
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, "", false
+	}
+	if node := e.findNode(id); node != nil {
+		return &node.Comment, fmt.Sprintf("node %d", id), true
 	}
+	return nil, "", false
+}
+
+// hintTarget returns the hints of the node or connection named by arg, as
+// for commentTarget, and a description of their owner
+func (e *TUIEditor) hintTarget(arg string) (*map[string]string, string, bool) {
+	if from, to, ok := strings.Cut(arg, "->"); ok {
+		fromID, err1 := strconv.Atoi(from)
+		toID, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil {
+			return nil, "", false
+		}
+		for i, conn := range e.diagram.Connections {
+			if conn.From == fromID && conn.To == toID {
+				return &e.diagram.Connections[i].Hints, "connection " + arg, true
+			}
+		}
+		return nil, "", false
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, "", false
+	}
+	if node := e.findNode(id); node != nil {
+		return &node.Hints, fmt.Sprintf("node %d", id), true
+	}
+	return nil, "", false
+}
+
+// selectedCommentTarget returns the comment of the selected node or
+// connection and a description of its owner
+func (e *TUIEditor) selectedCommentTarget() (*string, string, bool) {
+	if node := e.findNode(e.selected); e.selected >= 0 && node != nil {
+		return &node.Comment, fmt.Sprintf("node %d", node.ID), true
+	}
+	if i := e.selectedConnection; i >= 0 && i < len(e.diagram.Connections) {
+		conn := &e.diagram.Connections[i]
+		return &conn.Comment, fmt.Sprintf("connection %d->%d", conn.From, conn.To), true
+	}
+	return nil, "", false
 }
 
 // GetDiagramHint gets a diagram-level hint value
@@ -2501,17 +3955,114 @@ func (e *TUIEditor) TransformToViewport(diagramY int, hasScrollIndicator bool) i
 // Methods from node_hints.go
 // ============================================
 
+// findNode returns the node with the given ID, or nil if it doesn't exist
+func (e *TUIEditor) findNode(nodeID int) *diagram.Node {
+	for i := range e.diagram.Nodes {
+		if e.diagram.Nodes[i].ID == nodeID {
+			return &e.diagram.Nodes[i]
+		}
+	}
+	return nil
+}
+
 // Available node styles in cycle order
+var nodeStyles = []string{"rounded", "sharp", "double", "thick", "dashed", "dotted"}
 
 // Available node colors in cycle order
+var nodeColors = []string{"", "red", "green", "yellow", "blue", "magenta", "cyan"}
 
 // cycleNodeStyle cycles through available node styles
+func (e *TUIEditor) cycleNodeStyle(nodeID int) {
+	node := e.findNode(nodeID)
+	if node == nil {
+		return
+	}
+	next := nodeStyles[(slices.Index(nodeStyles, e.getNodeStyle(node))+1)%len(nodeStyles)]
+	if node.Hints == nil {
+		node.Hints = make(map[string]string)
+	}
+	node.Hints[e.nodeStyleKey()] = next
+	e.SaveHistory("style node")
+}
 
 // cycleNodeColor cycles through available node colors
+func (e *TUIEditor) cycleNodeColor(nodeID int) {
+	node := e.findNode(nodeID)
+	if node == nil {
+		return
+	}
+	next := nodeColors[(slices.Index(nodeColors, e.getNodeColor(node))+1)%len(nodeColors)]
+	if next == "" {
+		delete(node.Hints, "color")
+	} else {
+		if node.Hints == nil {
+			node.Hints = make(map[string]string)
+		}
+		node.Hints["color"] = next
+	}
+	e.SaveHistory("color node")
+}
 
 // getNodeStyle returns the style hint for a node
+func (e *TUIEditor) getNodeStyle(node *diagram.Node) string {
+	if style := node.Hints[e.nodeStyleKey()]; style != "" {
+		return style
+	}
+	return "rounded"
+}
 
 // getNodeColor returns the color hint for a node
+func (e *TUIEditor) getNodeColor(node *diagram.Node) string {
+	return node.Hints["color"]
+}
+
+// nodeStyleKey returns the hint key holding box style for the current diagram type
+func (e *TUIEditor) nodeStyleKey() string {
+	if e.diagram.Type == string(diagram.DiagramTypeSequence) {
+		return "box-style"
+	}
+	return "style"
+}
+
+// Available connection styles in cycle order ("" is solid)
+var connectionStyles = []string{"", "dashed", "dotted", "double"}
+
+// cycleConnectionStyle cycles a connection through solid, dashed, dotted
+// and double
+func (e *TUIEditor) cycleConnectionStyle(connIndex int) {
+	if connIndex < 0 || connIndex >= len(e.diagram.Connections) {
+		return
+	}
+	conn := &e.diagram.Connections[connIndex]
+	next := connectionStyles[(slices.Index(connectionStyles, conn.Hints["style"])+1)%len(connectionStyles)]
+	if next == "" {
+		delete(conn.Hints, "style")
+	} else {
+		if conn.Hints == nil {
+			conn.Hints = make(map[string]string)
+		}
+		conn.Hints["style"] = next
+	}
+	e.SaveHistory("style connection")
+}
+
+// cycleConnectionColor cycles a connection through the same colors as nodes
+func (e *TUIEditor) cycleConnectionColor(connIndex int) {
+	if connIndex < 0 || connIndex >= len(e.diagram.Connections) {
+		return
+	}
+	conn := &e.diagram.Connections[connIndex]
+	next := nodeColors[(slices.Index(nodeColors, conn.Hints["color"])+1)%len(nodeColors)]
+	if next == "" {
+		delete(conn.Hints, "color")
+	} else {
+		if conn.Hints == nil {
+			conn.Hints = make(map[string]string)
+		}
+		conn.Hints["color"] = next
+	}
+	e.SaveHistory("color connection")
+}
 
 // ============================================
 // Methods from keys.go
@@ -2527,7 +4078,7 @@ func (e *TUIEditor) handleNormalKey(key rune) bool {
 		return true
 
 	case ':': // Enter command mode
-		e.SetMode(ModeCommand)
+		e.StartCommand()
 
 
 	case 'a': // Add node
@@ -2541,6 +4092,9 @@ func (e *TUIEditor) handleNormalKey(key rune) bool {
 		}
 		e.StartConnect()
 
+	case 'L': // Link the two most recently added nodes
+		e.ConnectLastTwo()
+
 	case 'C': // Connect (continuous)
 		if f, err := os.OpenFile("/tmp/edd_connect.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
 			fmt.Fprintf(f, "\n[%s] 'C' key pressed\n", time.Now().Format("15:04:05"))
@@ -2581,6 +4135,22 @@ func (e *TUIEditor) handleNormalKey(key rune) bool {
 	case 'J': // JSON view (capital J)
 		e.SetMode(ModeJSON)
 
+	case '~': // Toggle the routing debug overlay
+		if realRenderer, ok := e.renderer.(*RealRenderer); ok {
+			if realRenderer.ToggleDebugOverlay() {
+				e.commandResult = "Debug overlay on (· marks obstacles the router avoids, (n) a hub's connection count)"
+			} else {
+				e.commandResult = "Debug overlay off"
+			}
+		}
+
+	case '#': // Toggle the node ID overlay
+		if e.ToggleNodeIDs() {
+			e.commandResult = "Showing node IDs"
+		} else {
+			e.commandResult = "Node IDs hidden"
+		}
+
 	case 't': // Toggle diagram type
 		e.ToggleDiagramType()
 
@@ -2608,12 +4178,23 @@ func (e *TUIEditor) handleNormalKey(key rune) bool {
 	case 'G': // Go to bottom
 		e.ScrollToBottom()
 
+	case ']': // Select the next neighbor along a connection
+		e.StepNeighbor(1)
+
+	case '[': // Select the previous neighbor
+		e.StepNeighbor(-1)
+
+	case 13, 10: // Enter - move on to the selected neighbor's neighbors
+		e.EnterNeighbor()
+
 
 	case 27: // ESC - if we have a previous jump action, restart that jump mode
 		if e.previousJumpAction != 0 {
 			action := e.previousJumpAction
 			e.previousJumpAction = 0 // Clear it
 			e.startJump(action)      // Restart jump mode with the same action
+		} else if e.ClearCycles() {
+			e.commandResult = "Cycle highlighting off"
 		}
 	}
 
@@ -2747,8 +4328,12 @@ func (e *TUIEditor) handleTextKey(key rune) bool {
 func (e *TUIEditor) handleCommandKey(key rune) bool {
 	switch key {
 	case 27: // ESC - cancel command
+		e.cancelThemePreview()
 		e.SetMode(ModeNormal)
 
+	case 9: // Tab - cycle theme preview
+		e.cycleThemePreview()
+
 	case 127, 8: // Backspace
 		if len(e.commandBuffer) > 0 {
 			e.commandBuffer = e.commandBuffer[:len(e.commandBuffer)-1]
@@ -2912,7 +4497,8 @@ func (e *TUIEditor) commitText() {
 	// Get text as lines for multi-line support
 	lines := e.GetTextAsLines()
 
-	// Trim empty lines at the end
+	// Trim empty lines at the end; blank lines between text are kept as
+	// empty rows in the box
 	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
 		lines = lines[:len(lines)-1]
 	}
@@ -3130,51 +4716,71 @@ func (e *TUIEditor) handleNodeHintInput(key rune) {
 		} else {
 			node.Hints["box-style"] = "rounded"
 		}
-		e.SaveHistory()
+		e.SaveHistory("style node")
 	case 'b': // Sharp for flowcharts, Sharp box for sequence
 		if !isSequence {
 			node.Hints["style"] = "sharp"
 		} else {
 			node.Hints["box-style"] = "sharp"
 		}
-		e.SaveHistory()
+		e.SaveHistory("style node")
 	case 'c': // Double for flowcharts, Double box for sequence
 		if !isSequence {
 			node.Hints["style"] = "double"
 		} else {
 			node.Hints["box-style"] = "double"
 		}
-		e.SaveHistory()
+		e.SaveHistory("style node")
 	case 'd': // Thick for flowcharts, Thick box for sequence
 		if !isSequence {
 			node.Hints["style"] = "thick"
 		} else {
 			node.Hints["box-style"] = "thick"
 		}
-		e.SaveHistory()
+		e.SaveHistory("style node")
+	case 'e': // Dashed border
+		if !isSequence {
+			node.Hints["style"] = "dashed"
+		} else {
+			node.Hints["box-style"] = "dashed"
+		}
+		e.SaveHistory("style node")
+	case 'f': // Dotted border
+		if !isSequence {
+			node.Hints["style"] = "dotted"
+		} else {
+			node.Hints["box-style"] = "dotted"
+		}
+		e.SaveHistory("style node")
 
 	// Color options
 	case 'r': // Red
 		node.Hints["color"] = "red"
-		e.SaveHistory()
+		e.SaveHistory("color node")
 	case 'g': // Green
 		node.Hints["color"] = "green"
-		e.SaveHistory()
+		e.SaveHistory("color node")
 	case 'y': // Yellow
 		node.Hints["color"] = "yellow"
-		e.SaveHistory()
+		e.SaveHistory("color node")
 	case 'u': // Blue
 		node.Hints["color"] = "blue"
-		e.SaveHistory()
+		e.SaveHistory("color node")
 	case 'm': // Magenta
 		node.Hints["color"] = "magenta"
-		e.SaveHistory()
+		e.SaveHistory("color node")
 	case 'n': // Cyan
 		node.Hints["color"] = "cyan"
-		e.SaveHistory()
+		e.SaveHistory("color node")
 	case 'w': // Default (no color)
 		delete(node.Hints, "color")
-		e.SaveHistory()
+		e.SaveHistory("color node")
+
+	// Quick cyclers
+	case 's': // Next style
+		e.cycleNodeStyle(node.ID)
+	case 'k': // Next color
+		e.cycleNodeColor(node.ID)
 
 	// Text style options (only for flowcharts)
 	case 'o': // Toggle bold
@@ -3184,7 +4790,7 @@ func (e *TUIEditor) handleNodeHintInput(key rune) {
 			} else {
 				node.Hints["bold"] = "true"
 			}
-			e.SaveHistory()
+			e.SaveHistory("style node text")
 		}
 	case 'i': // Toggle italic
 		if !isSequence {
@@ -3193,7 +4799,7 @@ func (e *TUIEditor) handleNodeHintInput(key rune) {
 			} else {
 				node.Hints["italic"] = "true"
 			}
-			e.SaveHistory()
+			e.SaveHistory("style node text")
 		}
 	case 't': // Toggle text alignment (center/left)
 		if !isSequence {
@@ -3202,7 +4808,7 @@ func (e *TUIEditor) handleNodeHintInput(key rune) {
 			} else {
 				node.Hints["text-align"] = "center"
 			}
-			e.SaveHistory()
+			e.SaveHistory("style node text")
 		}
 
 	// Shadow options (only for flowcharts)
@@ -3212,13 +4818,13 @@ func (e *TUIEditor) handleNodeHintInput(key rune) {
 			if node.Hints["shadow-density"] == "" {
 				node.Hints["shadow-density"] = "light"
 			}
-			e.SaveHistory()
+			e.SaveHistory("shadow node")
 		}
 	case 'x': // No shadow
 		if !isSequence {
 			delete(node.Hints, "shadow")
 			delete(node.Hints, "shadow-density")
-			e.SaveHistory()
+			e.SaveHistory("shadow node")
 		}
 	case 'l': // Shadow density for flowcharts only
 		if !isSequence {
@@ -3227,118 +4833,118 @@ func (e *TUIEditor) handleNodeHintInput(key rune) {
 			} else {
 				node.Hints["shadow-density"] = "light"
 			}
-			e.SaveHistory()
+			e.SaveHistory("shadow node")
 		}
 
 	// Lifeline style options (uppercase for sequence diagrams)
 	case 'A': // Solid lifeline (default)
 		if isSequence {
 			delete(node.Hints, "lifeline-style") // Remove to use default (solid)
-			e.SaveHistory()
+			e.SaveHistory("style lifeline")
 		}
 	case 'B': // Dashed lifeline
 		if isSequence {
 			node.Hints["lifeline-style"] = "dashed"
-			e.SaveHistory()
+			e.SaveHistory("style lifeline")
 		}
 	case 'C': // Dotted lifeline
 		if isSequence {
 			node.Hints["lifeline-style"] = "dotted"
-			e.SaveHistory()
+			e.SaveHistory("style lifeline")
 		}
 	case 'D': // Double lifeline
 		if isSequence {
 			node.Hints["lifeline-style"] = "double"
-			e.SaveHistory()
+			e.SaveHistory("style lifeline")
 		}
 
 	// Lifeline color options (uppercase for sequence diagrams)
 	case 'R': // Red lifeline
 		if isSequence {
 			node.Hints["lifeline-color"] = "red"
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 	case 'G': // Green lifeline
 		if isSequence {
 			node.Hints["lifeline-color"] = "green"
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 	case 'Y': // Yellow lifeline
 		if isSequence {
 			node.Hints["lifeline-color"] = "yellow"
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 	case 'U': // Blue lifeline
 		if isSequence {
 			node.Hints["lifeline-color"] = "blue"
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 	case 'M': // Magenta lifeline
 		if isSequence {
 			node.Hints["lifeline-color"] = "magenta"
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 	case 'N': // Cyan lifeline
 		if isSequence {
 			node.Hints["lifeline-color"] = "cyan"
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 	case 'W': // Default lifeline color (no color)
 		if isSequence {
 			delete(node.Hints, "lifeline-color")
-			e.SaveHistory()
+			e.SaveHistory("color lifeline")
 		}
 
 	// Layout position hints (only for flowcharts)
 	case '1': // Top-left
 		if !isSequence {
 			node.Hints["position"] = "top-left"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '2': // Top-center
 		if !isSequence {
 			node.Hints["position"] = "top-center"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '3': // Top-right
 		if !isSequence {
 			node.Hints["position"] = "top-right"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '4': // Middle-left
 		if !isSequence {
 			node.Hints["position"] = "middle-left"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '5': // Center
 		if !isSequence {
 			node.Hints["position"] = "center"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '6': // Middle-right
 		if !isSequence {
 			node.Hints["position"] = "middle-right"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '7': // Bottom-left
 		if !isSequence {
 			node.Hints["position"] = "bottom-left"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '8': // Bottom-center
 		if !isSequence {
 			node.Hints["position"] = "bottom-center"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '9': // Bottom-right
 		if !isSequence {
 			node.Hints["position"] = "bottom-right"
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 	case '0': // Clear position hint
 		if !isSequence {
 			delete(node.Hints, "position")
-			e.SaveHistory()
+			e.SaveHistory("position node")
 		}
 
 	case 27: // ESC - exit to normal mode or back to jump mode
@@ -3379,41 +4985,47 @@ func (e *TUIEditor) handleConnectionHintInput(key rune) {
 	// Style options for connections
 	case 'a': // Solid (default)
 		delete(conn.Hints, "style") // Remove to use default
-		e.SaveHistory()
+		e.SaveHistory("style connection")
 	case 'b': // Dashed
 		conn.Hints["style"] = "dashed"
-		e.SaveHistory()
+		e.SaveHistory("style connection")
 	case 'c': // Dotted
 		conn.Hints["style"] = "dotted"
-		e.SaveHistory()
+		e.SaveHistory("style connection")
 	case 'd': // Double (only for flowcharts)
 		if !isSequence {
 			conn.Hints["style"] = "double"
-			e.SaveHistory()
+			e.SaveHistory("style connection")
 		}
 
 	// Color options
 	case 'r': // Red
 		conn.Hints["color"] = "red"
-		e.SaveHistory()
+		e.SaveHistory("color connection")
 	case 'g': // Green
 		conn.Hints["color"] = "green"
-		e.SaveHistory()
+		e.SaveHistory("color connection")
 	case 'y': // Yellow
 		conn.Hints["color"] = "yellow"
-		e.SaveHistory()
+		e.SaveHistory("color connection")
 	case 'u': // Blue
 		conn.Hints["color"] = "blue"
-		e.SaveHistory()
+		e.SaveHistory("color connection")
 	case 'm': // Magenta
 		conn.Hints["color"] = "magenta"
-		e.SaveHistory()
+		e.SaveHistory("color connection")
 	case 'n': // Cyan
 		conn.Hints["color"] = "cyan"
-		e.SaveHistory()
+		e.SaveHistory("color connection")
 	case 'w': // White/default
 		delete(conn.Hints, "color") // Remove to use default
-		e.SaveHistory()
+		e.SaveHistory("color connection")
+
+	// Quick cyclers
+	case 's': // Next style (solid/dashed/dotted/double)
+		e.cycleConnectionStyle(e.editingHintConn)
+	case 'k': // Next color
+		e.cycleConnectionColor(e.editingHintConn)
 
 	// Text style options
 	case 'o': // Toggle bold
@@ -3422,14 +5034,14 @@ func (e *TUIEditor) handleConnectionHintInput(key rune) {
 		} else {
 			conn.Hints["bold"] = "true"
 		}
-		e.SaveHistory()
+		e.SaveHistory("style label")
 	case 'i': // Toggle italic
 		if conn.Hints["italic"] == "true" {
 			delete(conn.Hints, "italic")
 		} else {
 			conn.Hints["italic"] = "true"
 		}
-		e.SaveHistory()
+		e.SaveHistory("style label")
 
 	// Flow direction hints (only for flowcharts)
 	case 'f': // Cycle through flow directions
@@ -3447,7 +5059,7 @@ func (e *TUIEditor) handleConnectionHintInput(key rune) {
 			default:
 				conn.Hints["flow"] = "right" // Start with right
 			}
-			e.SaveHistory()
+			e.SaveHistory("set connection flow")
 		}
 
 	case 27: // ESC - exit to normal mode or back to jump mode
@@ -3548,7 +5160,7 @@ func (e *TUIEditor) getNodeHintMenuDisplay() string {
 
 		menuLines = []string{
 			"Participant: " + nodeText + " | box=" + boxStyle + "/" + color + " | lifeline=" + lifelineStyle + "/" + lifelineColor,
-			"Box: [a]Round [b]Sharp [c]Double [d]Thick | [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear",
+			"Box: [a]Round [b]Sharp [c]Double [d]Thick [e]Dash [f]Dot [s]Cycle | [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear [k]Cycle",
 			"Line: [A]Solid [B]Dash [C]Dot [D]Double | [R]Red [G]Green [Y]Yellow [U]Blue [M]Magenta [N]Cyan [W]Clear",
 			"[ESC]Back [Enter]Done",
 		}
@@ -3556,7 +5168,7 @@ func (e *TUIEditor) getNodeHintMenuDisplay() string {
 		// Full menu for flowcharts
 		menuLines = []string{
 			"Node: " + nodeText + " | style=" + style + ", color=" + color,
-			"Style: [a]Rounded [b]Sharp [c]Double [d]Thick | Color: [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear",
+			"Style: [a]Rounded [b]Sharp [c]Double [d]Thick [e]Dashed [f]Dotted [s]Cycle | Color: [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear [k]Cycle",
 			"Text: [o]Bold(" + bold + ") [i]Italic(" + italic + ") [t]Center(" + textAlign + ") | Shadow: [z]Add [x]Remove [l]Density",
 			"Position: [1-9]Grid [0]Auto | [ESC]Back [Enter]Done",
 		}
@@ -3644,14 +5256,14 @@ func (e *TUIEditor) getConnectionHintMenuDisplay() string {
 	if e.diagram.Type == string(diagram.DiagramTypeSequence) {
 		menuLines = []string{
 			"Message: " + fromText + " → " + toText + " | style=" + style + ", color=" + color,
-			"Style: [a]Solid [b]Dashed [c]Dotted | Color: [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear",
+			"Style: [a]Solid [b]Dashed [c]Dotted [s]Cycle | Color: [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear [k]Cycle",
 			"Text: [o]Bold(" + bold + ") [i]Italic(" + italic + ") | [ESC]Back [Enter]Done",
 		}
 	} else {
 		// Full menu for flowcharts
 		menuLines = []string{
 			"Connection: " + fromText + " → " + toText + " | style=" + style + ", color=" + color,
-			"Style: [a]Solid [b]Dashed [c]Dotted [d]Double | Color: [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear",
+			"Style: [a]Solid [b]Dashed [c]Dotted [d]Double [s]Cycle | Color: [r]Red [g]Green [y]Yellow [u]Blue [m]Magenta [n]Cyan [w]Clear [k]Cycle",
 			"Options: [o]Bold(" + bold + ") [i]Italic(" + italic + ") [f]Flow(" + flow + ") | [ESC]Back [Enter]Done",
 		}
 	}
@@ -163,4 +163,95 @@ func TestConnectionInlineEditing(t *testing.T) {
 	if len(output) == 0 {
 		t.Error("Expected non-empty render output")
 	}
-}
\ No newline at end of file
+}
+func TestConnectLastTwoLinksNewestNodes(t *testing.T) {
+	ed := NewTUIEditor(NewRealRenderer())
+	ed.SetDiagram(&diagram.Diagram{Type: "box"})
+
+	ed.HandleKey('L')
+	if got := ed.GetCommandResult(); got != "Need two nodes to connect" || len(ed.GetDiagram().Connections) != 0 {
+		t.Fatalf("Expected nothing connected with no nodes, got %q", got)
+	}
+
+	first := ed.AddNode([]string{"Start"})
+	second := ed.AddNode([]string{"Middle"})
+	ed.HandleKey('L')
+
+	conns := ed.GetDiagram().Connections
+	if len(conns) != 1 || conns[0].From != first || conns[0].To != second || !conns[0].Arrow {
+		t.Fatalf("Expected one arrow from %d to %d, got %+v", first, second, conns)
+	}
+	if got := ed.GetCommandResult(); got != "Connected 1 -> 2" {
+		t.Errorf("Unexpected result %q", got)
+	}
+
+	// The newest node links to the one before it, not the first one
+	third := ed.AddNode([]string{"End"})
+	ed.HandleKey('L')
+	conns = ed.GetDiagram().Connections
+	if len(conns) != 2 || conns[1].From != second || conns[1].To != third {
+		t.Errorf("Expected a connection from %d to %d, got %+v", second, third, conns)
+	}
+
+	ed.Undo()
+	if len(ed.GetDiagram().Connections) != 1 {
+		t.Errorf("Expected undo to remove the quick connection")
+	}
+}
+
+func TestNeighborKeysFollowConnections(t *testing.T) {
+	ed := NewTUIEditor(NewRealRenderer())
+	ed.SetDiagram(&diagram.Diagram{
+		Type: "box",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Hub"}},
+			{ID: 2, Text: []string{"Left"}},
+			{ID: 3, Text: []string{"Right"}},
+			{ID: 4, Text: []string{"Source"}},
+			{ID: 5, Text: []string{"Leaf"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 4, To: 1, Arrow: true},
+			{From: 1, To: 2, Arrow: true},
+			{From: 1, To: 3, Arrow: true},
+			{From: 3, To: 5, Arrow: true},
+		},
+	})
+	ed.Render()
+
+	// With nothing selected the first node is picked
+	ed.HandleKey(']')
+	if got := ed.GetSelectedNode(); got != 1 {
+		t.Fatalf("Expected the first node selected, got %d", got)
+	}
+
+	// Outgoing targets come first, in connection order, then sources
+	for _, want := range []int{2, 3, 4, 2} {
+		ed.HandleKey(']')
+		if got := ed.GetSelectedNode(); got != want {
+			t.Fatalf("Expected next neighbor %d, got %d", want, got)
+		}
+	}
+	if got := ed.GetCommandResult(); got != "Node 2 (neighbor 1 of 3 of node 1)" {
+		t.Errorf("Unexpected result %q", got)
+	}
+	ed.HandleKey('[')
+	if got := ed.GetSelectedNode(); got != 4 {
+		t.Errorf("Expected the previous neighbor to wrap to 4, got %d", got)
+	}
+
+	// Enter moves on to the selected node's own neighbors
+	ed.HandleKey('[')
+	ed.HandleKey(13)
+	ed.HandleKey(']')
+	if got := ed.GetSelectedNode(); got != 5 {
+		t.Errorf("Expected to step from 3 on to 5, got %d", got)
+	}
+
+	// Selecting another way re-anchors on that node
+	ed.GotoNode(2)
+	ed.HandleKey(']')
+	if got := ed.GetSelectedNode(); got != 1 {
+		t.Errorf("Expected the only neighbor of 2, got %d", got)
+	}
+}
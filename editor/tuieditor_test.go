@@ -484,6 +484,34 @@ func TestPreventDuplicateConnections(t *testing.T) {
 		t.Errorf("Reverse connection has wrong label! Expected 'reverse', got '%s'", conns[1].Label)
 	}
 }
+
+func TestDuplicateConnectionIsBundled(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	id1 := tui.AddNode([]string{"Web"})
+	id2 := tui.AddNode([]string{"API"})
+	tui.AddConnection(id1, id2, "calls")
+	tui.GetCommandResult()
+
+	tui.AddConnection(id1, id2, "")
+	if got := tui.GetCommandResult(); got != "Web → API already exists; bundled as ×2 (u to undo)" {
+		t.Errorf("Expected a message about the duplicate, got %q", got)
+	}
+	conns := tui.GetDiagram().Connections
+	if len(conns) != 1 || conns[0].Hints["count"] != "2" || conns[0].Label != "calls" {
+		t.Fatalf("Expected the duplicate bundled into the first connection, got %+v", conns)
+	}
+
+	tui.AddConnection(id1, id2, "")
+	if got := tui.GetDiagram().Connections[0].Hints["count"]; got != "3" {
+		t.Errorf("Expected a third edge to raise the count to 3, got %q", got)
+	}
+
+	tui.Undo()
+	if got := tui.GetDiagram().Connections[0].Hints["count"]; got != "2" {
+		t.Errorf("Expected undo to take the count back to 2, got %q", got)
+	}
+}
+
 func TestAllowMultipleUniqueConnections(t *testing.T) {
 	renderer := NewRealRenderer()
 	tui := NewTUIEditor(renderer)
@@ -1269,6 +1297,202 @@ func TestConnectionLabelAssignment(t *testing.T) {
 	}
 }
 
+func TestConnectionLabelWhenMidpointScrolledOff(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Top"}},
+			{ID: 2, Text: []string{"Bottom"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2, Arrow: true},
+		},
+	}
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(d)
+	tui.SetTerminalSize(80, 20)
+
+	// A long connection traced cell by cell, whose midpoint (row 31) is above
+	// the viewport while its arrow end (row 58) is on screen
+	var points []diagram.Point
+	for y := 3; y <= 58; y++ {
+		points = append(points, diagram.Point{X: 5, Y: y})
+	}
+	tui.nodePositions = map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 0, Y: 59}}
+	tui.connectionPaths = map[int]diagram.Path{0: {Points: points}}
+	tui.diagramScrollOffset = 45
+
+	tui.startJump(JumpActionDelete)
+	if _, ok := tui.GetConnectionLabels()[0]; !ok {
+		t.Fatalf("Expected the connection to get a label, got %v", tui.GetConnectionLabels())
+	}
+	if p, ok := tui.ConnectionLabelPoint(0, points[len(points)/2]); !ok || p != points[len(points)-1] {
+		t.Errorf("Expected the label moved to the arrow end, got %v (ok=%v)", p, ok)
+	}
+	tui.handleKey(27)
+
+	// A path of corners only, passing through the viewport with both ends off
+	tui.connectionPaths = map[int]diagram.Path{0: {Points: []diagram.Point{{X: 5, Y: 3}, {X: 5, Y: 100}}}}
+	tui.startJump(JumpActionDelete)
+	if _, ok := tui.GetConnectionLabels()[0]; !ok {
+		t.Errorf("Expected a connection crossing the viewport to get a label")
+	}
+	if p, ok := tui.ConnectionLabelPoint(0, diagram.Point{X: 5, Y: 100}); !ok || p.Y < 44 || p.Y > 60 {
+		t.Errorf("Expected an on-screen point, got %v (ok=%v)", p, ok)
+	}
+	tui.handleKey(27)
+
+	// Scrolled past the whole connection
+	tui.diagramScrollOffset = 120
+	tui.startJump(JumpActionDelete)
+	if len(tui.GetConnectionLabels()) != 0 {
+		t.Errorf("Expected no label for a connection scrolled off, got %v", tui.GetConnectionLabels())
+	}
+}
+
+func TestNearestNodeSnapsClicksNearABox(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"Server"}},
+		},
+	}
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(d)
+	// Boxes 10x3: "Client" spans columns 2-11, "Server" columns 30-39
+	tui.nodePositions = map[int]diagram.Point{1: {X: 2, Y: 2}, 2: {X: 30, Y: 2}}
+
+	tests := []struct {
+		name   string
+		x, y   int
+		want   int
+		wantOK bool
+	}{
+		{"inside a box", 5, 3, 1, true},
+		{"just right of a box", 13, 3, 1, true},
+		{"just above a box", 35, 0, 2, true},
+		{"diagonally off a corner", 29, 5, 2, true},
+		{"closer to the second box", 25, 3, 2, true},
+		{"too far from either", 20, 3, 0, false},
+		{"far below", 5, 12, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tui.nearestNode(tt.x, tt.y, 5)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("nearestNode(%d, %d, 5) = %d, %v; want %d, %v", tt.x, tt.y, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAsciiExportMatchesTheEditorsRender(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "box",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Gateway"}, Hints: map[string]string{"color": "cyan", "style": "double"}},
+			{ID: 2, Text: []string{"Orders"}, Hints: map[string]string{"shape": "cylinder"}},
+			{ID: 3, Text: []string{"Billing"}, Hints: map[string]string{"box-style": "thick"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2, Arrow: true, Label: "place", Hints: map[string]string{"thickness": "thick", "color": "red"}},
+			{From: 1, To: 3, Arrow: true, Hints: map[string]string{"style": "dashed"}},
+		},
+	}
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(d)
+	tui.SetTerminalSize(120, 50)
+	screen := tui.Render()
+
+	if err := tui.PreviewExport("ascii"); err != nil {
+		t.Fatalf("PreviewExport failed: %v", err)
+	}
+	exported := tui.GetViewText()
+	if exported != screen {
+		t.Errorf("Expected the ascii export to be the diagram as drawn on screen\ngot:\n%s\nwant:\n%s", exported, screen)
+	}
+	if !strings.Contains(exported, "━") || !strings.Contains(exported, "\033[") {
+		t.Errorf("Expected the export to keep the thick line and colors drawn on screen:\n%s", exported)
+	}
+
+	// A diagram a script edits is never drawn on screen; it is drawn for the
+	// export the same way
+	unseen := NewTUIEditor(NewRealRenderer())
+	unseen.SetDiagram(d)
+	if got, err := unseen.LastRender(); err != nil || got != screen {
+		t.Errorf("Expected an undrawn diagram exported as the editor would draw it (%v)\ngot:\n%s\nwant:\n%s", err, got, screen)
+	}
+}
+
+func TestLargeDiagramRendersOnlyTheViewport(t *testing.T) {
+	d := &diagram.Diagram{}
+	for i := 1; i <= 20; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{fmt.Sprintf("Step %d", i)}})
+		if i > 1 {
+			d.Connections = append(d.Connections, diagram.Connection{From: i - 1, To: i, Arrow: true})
+		}
+	}
+	render := func(minNodes int) (string, map[int]diagram.Point) {
+		defer func(saved int) { ViewportMinNodes = saved }(ViewportMinNodes)
+		ViewportMinNodes = minNodes
+		tui := NewTUIEditor(NewRealRenderer())
+		tui.SetDiagram(d)
+		tui.SetTerminalSize(80, 24)
+		tui.ScrollDiagram(37)
+		return tui.Render(), tui.nodePositions
+	}
+
+	full, fullPositions := render(1000)
+	windowed, positions := render(1)
+	if !strings.Contains(full, "more lines above") || !strings.Contains(full, "Step 7") {
+		t.Fatalf("Expected a scrolled view of the middle of the chain:\n%s", full)
+	}
+	if windowed != full {
+		t.Errorf("Expected the viewport render to match the full render\ngot:\n%s\nwant:\n%s", windowed, full)
+	}
+	if len(positions) != len(d.Nodes) {
+		t.Errorf("Expected every node position kept for jumping, got %v", positions)
+	}
+	for id, p := range fullPositions {
+		if positions[id] != p {
+			t.Errorf("Expected node %d at %v, got %v", id, p, positions[id])
+		}
+	}
+}
+
+func TestLargeDiagramViewportKeepsTheLegendAndFrame(t *testing.T) {
+	d := &diagram.Diagram{
+		Hints:  map[string]string{"frame": "true", "title": "Chain"},
+		Legend: map[string]string{"dashed": "optional"},
+	}
+	for i := 1; i <= 20; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{fmt.Sprintf("Step %d", i)}})
+		if i > 1 {
+			d.Connections = append(d.Connections, diagram.Connection{From: i - 1, To: i, Arrow: true, Hints: map[string]string{"style": "dashed"}})
+		}
+	}
+	render := func(minNodes, scroll int) string {
+		defer func(saved int) { ViewportMinNodes = saved }(ViewportMinNodes)
+		ViewportMinNodes = minNodes
+		tui := NewTUIEditor(NewRealRenderer())
+		tui.SetDiagram(d)
+		tui.SetTerminalSize(80, 24)
+		tui.Render()
+		tui.ScrollDiagram(scroll)
+		return tui.Render()
+	}
+
+	// The top of the frame, then the legend at the bottom
+	for scroll, want := range map[int]string{-1000: "┌─ Chain ", 1000: "optional"} {
+		full, windowed := render(1000, scroll), render(1, scroll)
+		if !strings.Contains(full, want) {
+			t.Fatalf("Expected %q in view scrolled %d:\n%s", want, scroll, full)
+		}
+		if windowed != full {
+			t.Errorf("Expected the viewport render scrolled %d to match the full render\ngot:\n%s\nwant:\n%s", scroll, windowed, full)
+		}
+	}
+}
+
 // ============================================
 // Tests from multiline_render_test.go
 // ============================================
@@ -1445,6 +1669,41 @@ func TestMultilineEditingAndRendering(t *testing.T) {
 // Tests from multiline_test.go
 // ============================================
 
+func TestMultilineInteriorBlankLineSurvives(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	nodeID := tui.AddNode([]string{"Initial"})
+	tui.selected = nodeID
+	tui.SetMode(ModeEdit)
+
+	// "Title", a blank line, "Body", then blank lines left at the end
+	tui.textBuffer = []rune{}
+	tui.cursorPos = 0
+	for _, key := range []rune{'T', 'i', 't', 'l', 'e', 14, 14, 'B', 'o', 'd', 'y', 14, 14} {
+		tui.handleTextKey(key)
+	}
+	tui.commitText()
+	tui.SetMode(ModeNormal)
+
+	want := []string{"Title", "", "Body"}
+	text := tui.diagram.Nodes[0].Text
+	if strings.Join(text, "|") != strings.Join(want, "|") {
+		t.Fatalf("Expected text %q, got %q", want, text)
+	}
+
+	// The blank line is an empty row inside the box
+	lines := strings.Split(tui.Render(), "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, "│ Title │") {
+			continue
+		}
+		if i+2 >= len(lines) || !strings.Contains(lines[i+1], "│       │") || !strings.Contains(lines[i+2], "│ Body  │") {
+			t.Errorf("Expected an empty row between Title and Body, got:\n%s", strings.Join(lines, "\n"))
+		}
+		return
+	}
+	t.Errorf("Expected the node to be drawn, got:\n%s", strings.Join(lines, "\n"))
+}
+
 func TestMultilineEditing(t *testing.T) {
 	renderer := NewRealRenderer()
 	tui := NewTUIEditor(renderer)
@@ -1823,6 +2082,47 @@ func TestClearBetweenModes(t *testing.T) {
 // Tests from render_test.go
 // ============================================
 
+func TestRenderTooSmallTerminal(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes:       []diagram.Node{{ID: 1, Text: []string{"A"}}, {ID: 2, Text: []string{"B"}}},
+		Connections: []diagram.Connection{{From: 1, To: 2, Arrow: true}},
+	})
+
+	for _, size := range [][2]int{{0, 0}, {1, 1}, {-4, 10}, {80, 2}} {
+		tui.SetTerminalSize(size[0], size[1])
+		want := fmt.Sprintf("Terminal too small (%dx%d)", size[0], size[1])
+		if got := tui.Render(); got != want {
+			t.Errorf("Size %v: expected %q, got %q", size, want, got)
+		}
+	}
+
+	tui.SetTerminalSize(80, 24)
+	if output := tui.Render(); !strings.Contains(output, "│ A │") {
+		t.Errorf("Expected the diagram once the terminal is big enough:\n%s", output)
+	}
+}
+
+func TestHidingEdGivesRowsToDiagram(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	d := &diagram.Diagram{Type: "box"}
+	for i := 1; i <= 8; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{fmt.Sprintf("Step %d", i)}})
+		if i > 1 {
+			d.Connections = append(d.Connections, diagram.Connection{From: i - 1, To: i, Arrow: true})
+		}
+	}
+	tui.SetDiagram(d)
+	tui.SetTerminalSize(80, 20)
+
+	withEd := len(strings.Split(tui.Render(), "\n"))
+	tui.SetShowEd(false)
+	withoutEd := len(strings.Split(tui.Render(), "\n"))
+	if tui.ShowsEd() || withoutEd != withEd+2 {
+		t.Errorf("Expected two more diagram rows without Ed, got %d and %d", withEd, withoutEd)
+	}
+}
+
 func TestRenderEmptyState(t *testing.T) {
 	state := TUIState{
 		Diagram: &diagram.Diagram{},
@@ -2494,3 +2794,140 @@ func TestNodeDeletionStillWorks(t *testing.T) {
 		}
 	}
 }
+
+func TestContentLargerThanTerminalIsFullyRendered(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	tui.SetTerminalSize(30, 12)
+
+	d := &diagram.Diagram{Type: "box"}
+	for i := 0; i < 6; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{fmt.Sprintf("Service number %d", i)}})
+		if i > 0 {
+			d.Connections = append(d.Connections, diagram.Connection{ID: i - 1, From: i - 1, To: i, Arrow: true})
+		}
+	}
+	tui.SetDiagram(d)
+	tui.Render()
+
+	// The canvas is sized to the content, not the terminal, so every node is
+	// laid out and drawn even though most of it is scrolled out of view
+	if got := len(tui.GetNodePositions()); got != len(d.Nodes) {
+		t.Errorf("Expected positions for all %d nodes, got %d", len(d.Nodes), got)
+	}
+
+	output, err := NewRealRenderer().Render(d)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) <= 12 {
+		t.Fatalf("Expected the diagram to be taller than the terminal, got %d lines", len(lines))
+	}
+	for _, node := range d.Nodes {
+		if !strings.Contains(output, node.Text[0]) {
+			t.Errorf("Expected %q in the rendered canvas:\n%s", node.Text[0], output)
+		}
+	}
+
+	// Scrolling to the last node shows it whole
+	if err := tui.GotoNode(5); err != nil {
+		t.Fatalf("GotoNode failed: %v", err)
+	}
+	if view := tui.Render(); !strings.Contains(view, "Service number 5") {
+		t.Errorf("Expected the last node after scrolling:\n%s", view)
+	}
+}
+
+func TestTildeTogglesDebugOverlay(t *testing.T) {
+	renderer := NewRealRenderer()
+	tui := NewTUIEditor(renderer)
+	tui.SetDiagram(&diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}},
+		},
+		Connections: []diagram.Connection{{ID: 0, From: 1, To: 2, Arrow: true}},
+	})
+
+	if strings.Contains(tui.Render(), "·") {
+		t.Fatalf("Expected no obstacle markers before the overlay is on")
+	}
+
+	tui.HandleKey('~')
+	if !renderer.DebugOverlay() {
+		t.Fatalf("Expected ~ to turn the debug overlay on")
+	}
+	if got := tui.GetCommandResult(); !strings.HasPrefix(got, "Debug overlay on") {
+		t.Errorf("Unexpected result %q", got)
+	}
+	if output := tui.Render(); !strings.Contains(output, "·") {
+		t.Errorf("Expected obstacle markers with the overlay on:\n%s", output)
+	}
+
+	tui.HandleKey('~')
+	if renderer.DebugOverlay() {
+		t.Errorf("Expected a second ~ to turn the overlay off")
+	}
+	if strings.Contains(tui.Render(), "·") {
+		t.Errorf("Expected the markers to go once the overlay is off")
+	}
+}
+
+func TestUndoRedoDiagramTypeConversion(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+	a := tui.AddNode([]string{"A"})
+	b := tui.AddNode([]string{"B"})
+	tui.AddConnection(a, b, "")
+
+	tui.ToggleDiagramType()
+	if !tui.GetDiagram().IsSequence() {
+		t.Fatalf("Expected a sequence diagram after the toggle")
+	}
+	tui.Undo()
+	if got := tui.GetCommandResult(); got != "Undid: convert diagram type" || tui.GetDiagram().IsSequence() {
+		t.Errorf("Expected undo to give back the box diagram, got %q", got)
+	}
+	tui.Redo()
+	if got := tui.GetCommandResult(); got != "Redid: convert diagram type" || !tui.GetDiagram().IsSequence() {
+		t.Errorf("Expected redo to convert again, got %q", got)
+	}
+
+	// An edit after the conversion undoes on its own, keeping the conversion
+	tui.AddNode([]string{"C"})
+	tui.Undo()
+	if d := tui.GetDiagram(); !d.IsSequence() || len(d.Nodes) != 2 {
+		t.Errorf("Expected undo to keep the conversion and drop C, got %s with %d nodes", d.Type, len(d.Nodes))
+	}
+}
+
+func TestUndoRedoReportWhatChanged(t *testing.T) {
+	tui := NewTUIEditor(NewRealRenderer())
+
+	tui.HandleKey('u')
+	if got := tui.GetCommandResult(); got != "Nothing to undo" {
+		t.Errorf("Expected undo at the start of history to say so, got %q", got)
+	}
+
+	a := tui.AddNode([]string{"A"})
+	b := tui.AddNode([]string{"B"})
+	tui.AddConnection(a, b, "")
+
+	tui.HandleKey('u')
+	if got := tui.GetCommandResult(); got != "Undid: add connection" {
+		t.Errorf("Unexpected undo result %q", got)
+	}
+	tui.HandleKey('u')
+	if got := tui.GetCommandResult(); got != "Undid: add node" {
+		t.Errorf("Unexpected undo result %q", got)
+	}
+
+	tui.HandleKey(18) // Ctrl+R
+	if got := tui.GetCommandResult(); got != "Redid: add node" {
+		t.Errorf("Unexpected redo result %q", got)
+	}
+	tui.HandleKey(18)
+	tui.HandleKey(18)
+	if got := tui.GetCommandResult(); got != "Nothing to redo" {
+		t.Errorf("Expected redo at the end of history to say so, got %q", got)
+	}
+}
@@ -27,8 +27,15 @@ func (e *D2Exporter) Export(d *diagram.Diagram) (string, error) {
 	var sb strings.Builder
 
 	// Add title comment if diagram has metadata
+	comments := metadataComments(d.Metadata, "#")
 	if d.Metadata.Name != "" {
-		sb.WriteString(fmt.Sprintf("# %s\n\n", d.Metadata.Name))
+		sb.WriteString(fmt.Sprintf("# %s\n", d.Metadata.Name))
+	}
+	for _, line := range comments {
+		sb.WriteString(line + "\n")
+	}
+	if d.Metadata.Name != "" || len(comments) > 0 {
+		sb.WriteString("\n")
 	}
 
 	// Process nodes
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"edd/diagram"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// sourceMarker tags the comment line that carries the embedded edd JSON
+const sourceMarker = "edd-source:"
+
+// CommentPrefix returns the line comment syntax for a format
+func CommentPrefix(format Format) string {
+	switch format {
+	case FormatMermaid:
+		return "%%"
+	case FormatPlantUML:
+		return "'"
+	default:
+		return "#"
+	}
+}
+
+// EmbedSource appends the diagram's edd JSON to exported output as a trailing
+// comment, so the file can be loaded back without losing anything the target
+// format can't express. SVG output, which has no line comments, carries it in
+// a metadata element instead. JSON output is returned unchanged.
+func EmbedSource(output string, format Format, d *diagram.Diagram) (string, error) {
+	if format == FormatJSON {
+		return output, nil
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("encoding source: %w", err)
+	}
+
+	if end := strings.LastIndex(output, svgEnd); end >= 0 && isSVG(output) {
+		var escaped strings.Builder
+		if err := xml.EscapeText(&escaped, data); err != nil {
+			return "", fmt.Errorf("encoding source: %w", err)
+		}
+		return output[:end] + svgMetadataStart + escaped.String() + svgMetadataEnd + "\n" + output[end:], nil
+	}
+
+	output = strings.TrimRight(output, "\n")
+	return output + "\n" + CommentPrefix(format) + " " + sourceMarker + " " + string(data) + "\n", nil
+}
+
+// The metadata element that carries the edd JSON in an SVG document
+const (
+	svgEnd           = "</svg>"
+	svgMetadataStart = `<metadata id="edd-source">`
+	svgMetadataEnd   = "</metadata>"
+)
+
+// isSVG reports whether output is an SVG document, optionally preceded by an
+// XML declaration
+func isSVG(output string) bool {
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "<?xml") {
+		if end := strings.Index(output, "?>"); end >= 0 {
+			output = strings.TrimSpace(output[end+2:])
+		}
+	}
+	return strings.HasPrefix(output, "<svg")
+}
+
+// extractSVGSource decodes edd JSON embedded in an SVG metadata element
+func extractSVGSource(content string) (*diagram.Diagram, bool) {
+	start := strings.LastIndex(content, svgMetadataStart)
+	if start < 0 {
+		return nil, false
+	}
+	end := strings.Index(content[start:], svgMetadataEnd)
+	if end < 0 {
+		return nil, false
+	}
+
+	var metadata struct {
+		Text string `xml:",chardata"`
+	}
+	if err := xml.Unmarshal([]byte(content[start:start+end+len(svgMetadataEnd)]), &metadata); err != nil {
+		return nil, false
+	}
+	var d diagram.Diagram
+	if err := json.Unmarshal([]byte(metadata.Text), &d); err != nil {
+		return nil, false
+	}
+	return &d, true
+}
+
+// ExtractEmbeddedSource looks for edd JSON embedded by EmbedSource and decodes
+// it. It reports false if the content carries no embedded source.
+func ExtractEmbeddedSource(content string) (*diagram.Diagram, bool) {
+	if isSVG(content) {
+		return extractSVGSource(content)
+	}
+
+	lines := strings.Split(content, "\n")
+
+	// The source is appended last, so search from the end
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		for _, prefix := range []string{"%%", "'", "#"} {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			rest := strings.TrimSpace(strings.TrimPrefix(line, prefix))
+			if !strings.HasPrefix(rest, sourceMarker) {
+				continue
+			}
+			var d diagram.Diagram
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(rest, sourceMarker)), &d); err != nil {
+				return nil, false
+			}
+			return &d, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,130 @@
+package export_test
+
+import (
+	"edd/diagram"
+	"edd/export"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEmbedSourceRoundTrip(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Start"}, Hints: map[string]string{"color": "red"}},
+			{ID: 2, Text: []string{"End", "line two"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 0, From: 1, To: 2, Arrow: true, Label: "go", Hints: map[string]string{"style": "dashed"}},
+		},
+		Hints: map[string]string{"layout": "horizontal"},
+	}
+
+	tests := []struct {
+		format export.Format
+		prefix string
+	}{
+		{export.FormatMermaid, "%% edd-source: "},
+		{export.FormatPlantUML, "' edd-source: "},
+		{export.FormatASCII, "# edd-source: "},
+		{export.FormatD2, "# edd-source: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			exporter, err := export.NewExporter(tt.format)
+			if err != nil {
+				t.Fatalf("NewExporter failed: %v", err)
+			}
+			output, err := exporter.Export(d)
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+
+			embedded, err := export.EmbedSource(output, tt.format, d)
+			if err != nil {
+				t.Fatalf("EmbedSource failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimRight(embedded, "\n"), "\n")
+			if last := lines[len(lines)-1]; !strings.HasPrefix(last, tt.prefix) {
+				t.Errorf("Expected trailing comment starting %q, got %q", tt.prefix, last)
+			}
+			if !strings.HasPrefix(embedded, strings.TrimRight(output, "\n")) {
+				t.Errorf("Embedding should keep the exported output intact")
+			}
+
+			got, ok := export.ExtractEmbeddedSource(embedded)
+			if !ok {
+				t.Fatalf("Expected embedded source to be found")
+			}
+			if !reflect.DeepEqual(got, d) {
+				t.Errorf("Round trip mismatch:\ngot  %+v\nwant %+v", got, d)
+			}
+		})
+	}
+}
+
+func TestEmbedSourceInSVG(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"<Start> & go"}},
+			{ID: 2, Text: []string{"End"}},
+		},
+		Connections: []diagram.Connection{{ID: 0, From: 1, To: 2, Arrow: true, Label: "a -- b -->"}},
+	}
+	svg := `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+  <rect width="10" height="10"/>
+</svg>
+`
+
+	embedded, err := export.EmbedSource(svg, export.Format("svg"), d)
+	if err != nil {
+		t.Fatalf("EmbedSource failed: %v", err)
+	}
+	if strings.Contains(embedded, "# edd-source") {
+		t.Errorf("Expected no line comment in SVG output:\n%s", embedded)
+	}
+
+	// The result must still be a well-formed document with the source
+	// inside the svg element
+	var doc struct {
+		XMLName  xml.Name `xml:"svg"`
+		Metadata struct {
+			ID   string `xml:"id,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"metadata"`
+	}
+	if err := xml.Unmarshal([]byte(embedded), &doc); err != nil {
+		t.Fatalf("Embedded SVG does not parse: %v\n%s", err, embedded)
+	}
+	if doc.Metadata.ID != "edd-source" || !strings.Contains(doc.Metadata.Text, `"label":"a -- b`) {
+		t.Errorf("Expected the source in the metadata element, got %+v", doc.Metadata)
+	}
+
+	got, ok := export.ExtractEmbeddedSource(embedded)
+	if !ok {
+		t.Fatalf("Expected embedded source to be found")
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Errorf("Round trip mismatch:\ngot  %+v\nwant %+v", got, d)
+	}
+}
+
+func TestExtractEmbeddedSourceMissing(t *testing.T) {
+	if _, ok := export.ExtractEmbeddedSource("graph TD\n    A --> B\n%% just a comment\n"); ok {
+		t.Errorf("Expected no embedded source")
+	}
+	if _, ok := export.ExtractEmbeddedSource("# edd-source: {not json"); ok {
+		t.Errorf("Expected malformed embedded source to be ignored")
+	}
+}
+
+func TestEmbedSourceJSONUnchanged(t *testing.T) {
+	output := `{"nodes":[]}`
+	got, err := export.EmbedSource(output, export.FormatJSON, &diagram.Diagram{})
+	if err != nil || got != output {
+		t.Errorf("Expected JSON output unchanged, got %q (err %v)", got, err)
+	}
+}
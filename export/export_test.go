@@ -3,6 +3,8 @@ package export_test
 import (
 	"edd/diagram"
 	"edd/export"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -20,6 +22,7 @@ func TestParseFormat(t *testing.T) {
 		{"mmd", export.FormatMermaid, false},
 		{"plantuml", export.FormatPlantUML, false},
 		{"puml", export.FormatPlantUML, false},
+		{"plantuml-component", export.FormatPlantUMLComponent, false},
 		{"invalid", "", true},
 		{"", "", true},
 	}
@@ -147,6 +150,110 @@ func TestMermaidExporter_Flowchart(t *testing.T) {
 	}
 }
 
+func TestMermaidExporterColorClassesAreStable(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "box",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"A"}, Hints: map[string]string{"color": "yellow"}},
+			{ID: 2, Text: []string{"B"}, Hints: map[string]string{"color": "red"}},
+			{ID: 3, Text: []string{"C"}, Hints: map[string]string{"color": "blue"}},
+			{ID: 4, Text: []string{"D"}, Hints: map[string]string{"color": "green"}},
+		},
+	}
+
+	first, err := export.NewMermaidExporter().Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	yellow, red := strings.Index(first, "classDef yellowStyle"), strings.Index(first, "classDef redStyle")
+	if yellow < 0 || red < 0 || yellow > red {
+		t.Errorf("Expected classes defined in the order nodes use them, got:\n%s", first)
+	}
+	for i := 0; i < 20; i++ {
+		if again, _ := export.NewMermaidExporter().Export(d); again != first {
+			t.Fatalf("Expected identical output on every export, got:\n%s\nthen:\n%s", first, again)
+		}
+	}
+}
+
+func TestSortedExportIgnoresInputOrder(t *testing.T) {
+	build := func(nodeOrder []int, connOrder []int) *diagram.Diagram {
+		nodes := map[int]diagram.Node{
+			1: {ID: 1, Text: []string{"API"}, Hints: map[string]string{"color": "red"}},
+			2: {ID: 2, Text: []string{"DB"}, Hints: map[string]string{"color": "blue"}},
+			3: {ID: 3, Text: []string{"Cache"}},
+		}
+		conns := map[int]diagram.Connection{
+			1: {ID: 1, From: 1, To: 2, Label: "query"},
+			2: {ID: 2, From: 1, To: 3},
+			3: {ID: 3, From: 3, To: 2, Hints: map[string]string{"style": "dashed"}},
+		}
+		d := &diagram.Diagram{Type: "box"}
+		for _, id := range nodeOrder {
+			d.Nodes = append(d.Nodes, nodes[id])
+		}
+		for _, id := range connOrder {
+			d.Connections = append(d.Connections, conns[id])
+		}
+		return d
+	}
+
+	exporters := map[string]func() export.Exporter{
+		"mermaid": func() export.Exporter {
+			e := export.NewMermaidExporter()
+			e.SetSortByID(true)
+			return e
+		},
+		"plantuml": func() export.Exporter {
+			e := export.NewPlantUMLExporter()
+			e.SetSortByID(true)
+			return e
+		},
+		"plantuml-component": func() export.Exporter {
+			e := export.NewPlantUMLComponentExporter()
+			e.SetSortByID(true)
+			return e
+		},
+	}
+	for name, newExporter := range exporters {
+		t.Run(name, func(t *testing.T) {
+			ordered := build([]int{1, 2, 3}, []int{1, 2, 3})
+			first, err := newExporter().Export(ordered)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, _ := newExporter().Export(ordered)
+			if first != second {
+				t.Errorf("Expected identical output for the same diagram:\n%s\n---\n%s", first, second)
+			}
+
+			shuffled, err := newExporter().Export(build([]int{3, 1, 2}, []int{3, 1, 2}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shuffled != first {
+				t.Errorf("Expected reordered input to export the same:\n%s\n---\n%s", first, shuffled)
+			}
+		})
+	}
+
+	// Sequence diagrams are never reordered, their order is their meaning
+	seq := &diagram.Diagram{
+		Type:  "sequence",
+		Nodes: []diagram.Node{{ID: 2, Text: []string{"Bob"}}, {ID: 1, Text: []string{"Alice"}}},
+		Connections: []diagram.Connection{
+			{ID: 2, From: 2, To: 1, Label: "first"},
+			{ID: 1, From: 1, To: 2, Label: "second"},
+		},
+	}
+	sorter := export.NewMermaidExporter()
+	sorter.SetSortByID(true)
+	output, _ := sorter.Export(seq)
+	if strings.Index(output, "first") > strings.Index(output, "second") || strings.Index(output, "Bob") > strings.Index(output, "Alice") {
+		t.Errorf("Expected sequence order kept:\n%s", output)
+	}
+}
+
 func TestPlantUMLExporter_Sequence(t *testing.T) {
 	d := &diagram.Diagram{
 		Type: "sequence",
@@ -189,6 +296,93 @@ func TestPlantUMLExporter_Sequence(t *testing.T) {
 	}
 }
 
+func TestWebSequenceExporter(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Alice"}},
+			{ID: 2, Text: []string{"Bob"}, Hints: map[string]string{"note": "checks the cache"}},
+			{ID: 3, Text: []string{"Auth Service"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 1, From: 1, To: 2, Arrow: true, Label: "hello", Hints: map[string]string{"activate": "true"}},
+			{ID: 2, From: 2, To: 3, Arrow: true, Label: "verify"},
+			{ID: 3, From: 2, To: 1, Arrow: true, Label: "hi", Hints: map[string]string{"style": "dashed", "deactivate": "true"}},
+		},
+	}
+	d.Metadata.Name = "Greeting"
+
+	got, err := export.NewWebSequenceExporter().Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	expected := `title Greeting
+participant Alice
+participant Bob
+participant "Auth Service" as P3
+note right of Bob: checks the cache
+
+Alice->+Bob: hello
+Bob->P3: verify
+Bob-->-Alice: hi
+`
+	if got != expected {
+		t.Errorf("Export mismatch.\nGot:\n%s\nWant:\n%s", got, expected)
+	}
+
+	format, err := export.ParseFormat("wsd")
+	if err != nil || format != export.FormatWebSequence {
+		t.Errorf("ParseFormat(wsd) = %v, %v", format, err)
+	}
+	if _, err := export.NewWebSequenceExporter().Export(&diagram.Diagram{Nodes: d.Nodes}); err == nil {
+		t.Errorf("Expected box diagrams to be rejected")
+	}
+}
+
+func TestPlantUMLComponentExporter(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web App"}, Hints: map[string]string{"icon": "server"}},
+			{ID: 2, Text: []string{"Users"}, Hints: map[string]string{"icon": "database"}},
+			{ID: 3, Text: []string{"Orders"}, Hints: map[string]string{"shape": "cylinder"}},
+			{ID: 4, Text: []string{"Auth"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 1, From: 1, To: 2, Label: "reads"},
+			{ID: 2, From: 1, To: 3, Hints: map[string]string{"style": "dashed"}},
+			{ID: 3, From: 1, To: 4},
+		},
+	}
+
+	exporter := export.NewPlantUMLComponentExporter()
+	result, err := exporter.Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	expectedParts := []string{
+		"@startuml",
+		"node \"Web App\" as N1",
+		"database \"Users\" as N2",
+		"database \"Orders\" as N3", // From the shape when there is no icon
+		"component \"Auth\" as N4",
+		"N1 --> N2 : reads",
+		"N1 ..> N3",
+		"N1 --> N4",
+		"@enduml",
+	}
+
+	for _, part := range expectedParts {
+		if !strings.Contains(result, part) {
+			t.Errorf("Expected result to contain %q, but it didn't.\nGot:\n%s", part, result)
+		}
+	}
+	if strings.Contains(result, "componentStyle rectangle") {
+		t.Errorf("Expected component styles to be left to PlantUML.\nGot:\n%s", result)
+	}
+}
+
 func TestASCIIExporter(t *testing.T) {
 	d := &diagram.Diagram{
 		Nodes: []diagram.Node{
@@ -213,6 +407,408 @@ func TestASCIIExporter(t *testing.T) {
 	if !strings.Contains(result, "Box2") {
 		t.Error("Expected result to contain Box2")
 	}
+	if !strings.Contains(result, "[link]") {
+		t.Errorf("Expected the connection label to be drawn, got:\n%s", result)
+	}
+}
+
+func TestJSONExporterIndent(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "box",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"A"}},
+		},
+	}
+
+	compact, err := export.NewJSONExporterWithIndent(0).Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if strings.Contains(compact, "\n") || strings.Contains(compact, "  ") {
+		t.Errorf("Expected compact single-line JSON, got:\n%s", compact)
+	}
+
+	wide, err := export.NewJSONExporterWithIndent(4).Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !strings.Contains(wide, "\n    \"type\": \"box\"") {
+		t.Errorf("Expected top-level fields indented by 4 spaces, got:\n%s", wide)
+	}
+	if !strings.Contains(wide, "\n"+strings.Repeat(" ", 16)+"\"A\"") {
+		t.Errorf("Expected nested fields indented by 4 spaces per level, got:\n%s", wide)
+	}
+}
+
+func TestJSONIndentFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", export.DefaultJSONIndent},
+		{"4", 4},
+		{"0", 0},
+		{"tabs", export.DefaultJSONIndent},
+		{"-1", export.DefaultJSONIndent},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(export.JSONIndentEnv, tt.value)
+		if got := export.JSONIndentFromEnv(); got != tt.want {
+			t.Errorf("%s=%q: got indent %d, want %d", export.JSONIndentEnv, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTempJSONIndentFromEnv(t *testing.T) {
+	t.Setenv(export.JSONIndentEnv, "4")
+	t.Setenv(export.TempJSONIndentEnv, "")
+	if got := export.TempJSONIndentFromEnv(); got != 4 {
+		t.Errorf("Expected temp files to follow %s when unset, got %d", export.JSONIndentEnv, got)
+	}
+
+	t.Setenv(export.TempJSONIndentEnv, "0")
+	if got := export.TempJSONIndentFromEnv(); got != 0 {
+		t.Errorf("Expected %s=0 to give compact temp files, got %d", export.TempJSONIndentEnv, got)
+	}
+}
+
+func TestJSONHintsSerializeInKeyOrder(t *testing.T) {
+	hints := map[string]string{}
+	for _, key := range []string{"style", "color", "weight", "bold", "to-side", "from-side", "layer"} {
+		hints[key] = "x"
+	}
+	d := &diagram.Diagram{
+		Type:        "box",
+		Nodes:       []diagram.Node{{ID: 1, Text: []string{"A"}, Hints: hints}},
+		Connections: []diagram.Connection{{From: 1, To: 1, Hints: hints}},
+		Hints:       hints,
+	}
+
+	want := `{"bold":"x","color":"x","from-side":"x","layer":"x","style":"x","to-side":"x","weight":"x"}`
+	first, err := export.NewJSONExporterWithIndent(0).Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if strings.Count(first, want) != 3 {
+		t.Errorf("Expected every hints map with sorted keys, got:\n%s", first)
+	}
+	for i := 0; i < 20; i++ {
+		if again, _ := export.NewJSONExporterWithIndent(0).Export(d); again != first {
+			t.Fatalf("Expected identical output on every export, got:\n%s\nthen:\n%s", first, again)
+		}
+	}
+}
+
+// fakeExporter is a custom format registered by TestRegisterExporter
+type fakeExporter struct{}
+
+func (fakeExporter) Export(d *diagram.Diagram) (string, error) {
+	return fmt.Sprintf("fake: %d nodes", len(d.Nodes)), nil
+}
+func (fakeExporter) GetFileExtension() string { return ".fake" }
+func (fakeExporter) GetFormatName() string    { return "Fake" }
+
+func TestLayoutJSONExporter(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "box",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}},
+		},
+		Connections: []diagram.Connection{
+			{ID: 7, From: 1, To: 2},
+		},
+	}
+
+	output, err := export.NewLayoutJSONExporter().Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	var doc export.LayoutDocument
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, output)
+	}
+	if doc.Type != "box" || len(doc.Nodes) != 2 || len(doc.Connections) != 1 {
+		t.Fatalf("Expected 2 nodes and 1 connection, got:\n%s", output)
+	}
+	for _, field := range []string{`"x"`, `"y"`, `"width"`, `"height"`, `"points"`} {
+		if !strings.Contains(output, field) {
+			t.Errorf("Expected field %s in output:\n%s", field, output)
+		}
+	}
+
+	boxes := make(map[int]export.LayoutNode)
+	for _, node := range doc.Nodes {
+		if node.X < 0 || node.Y < 0 || node.Width <= 0 || node.Height <= 0 {
+			t.Errorf("Node %d has an invalid box %+v", node.ID, node)
+		}
+		boxes[node.ID] = node
+	}
+
+	conn := doc.Connections[0]
+	if conn.Index != 0 || conn.ID != 7 || conn.From != 1 || conn.To != 2 {
+		t.Errorf("Unexpected connection %+v", conn)
+	}
+	if len(conn.Points) < 2 {
+		t.Fatalf("Expected the path to have at least 2 points, got %+v", conn.Points)
+	}
+	near := func(p export.LayoutPoint, n export.LayoutNode) bool {
+		return p.X >= n.X-1 && p.X <= n.X+n.Width && p.Y >= n.Y-1 && p.Y <= n.Y+n.Height
+	}
+	if first := conn.Points[0]; !near(first, boxes[1]) {
+		t.Errorf("Expected the path to start at node 1 %+v, got %+v", boxes[1], first)
+	}
+	if last := conn.Points[len(conn.Points)-1]; !near(last, boxes[2]) {
+		t.Errorf("Expected the path to end at node 2 %+v, got %+v", boxes[2], last)
+	}
+
+	format, err := export.ParseFormat("layout-json")
+	if err != nil || format != export.FormatLayoutJSON {
+		t.Errorf("ParseFormat(layout-json) = %v, %v", format, err)
+	}
+}
+
+func TestLayoutJSONExporterSequence(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Client"}},
+			{ID: 2, Text: []string{"Server"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2, Label: "request"},
+			{From: 2, To: 1, Label: "response"},
+		},
+	}
+
+	doc, err := export.NewLayoutJSONExporter().Layout(d)
+	if err != nil {
+		t.Fatalf("Layout failed: %v", err)
+	}
+	if len(doc.Nodes) != 2 || len(doc.Connections) != 2 {
+		t.Fatalf("Expected 2 participants and 2 messages, got %+v", doc)
+	}
+	first, second := doc.Connections[0], doc.Connections[1]
+	if len(first.Points) != 2 || first.Points[0].Y != first.Points[1].Y {
+		t.Errorf("Expected a horizontal message, got %+v", first.Points)
+	}
+	if second.Points[0].Y <= first.Points[0].Y {
+		t.Errorf("Expected the response below the request, got %+v then %+v", first.Points, second.Points)
+	}
+	if first.Points[0].X != second.Points[1].X {
+		t.Errorf("Expected both messages to meet the client's lifeline, got %+v and %+v", first.Points, second.Points)
+	}
+}
+
+func TestRegisterExporter(t *testing.T) {
+	export.RegisterExporter("test-fake", func() export.Exporter { return fakeExporter{} })
+
+	format, err := export.ParseFormat("test-fake")
+	if err != nil {
+		t.Fatalf("ParseFormat did not recognize the registered format: %v", err)
+	}
+
+	exporter, err := export.NewExporter(format)
+	if err != nil {
+		t.Fatalf("NewExporter failed for the registered format: %v", err)
+	}
+	result, err := exporter.Export(&diagram.Diagram{Nodes: []diagram.Node{{ID: 1}, {ID: 2}}})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if result != "fake: 2 nodes" {
+		t.Errorf("Expected output from the registered exporter, got %q", result)
+	}
+
+	found := false
+	for _, f := range export.GetAvailableFormats() {
+		if f == format {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected registered format in GetAvailableFormats")
+	}
+	if got := export.GetFormatDescriptions()[format]; got != "Fake" {
+		t.Errorf("Expected description %q, got %q", "Fake", got)
+	}
+
+	// Built-in names can't be taken over
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a built-in format name to panic")
+		}
+	}()
+	export.RegisterExporter("mermaid", func() export.Exporter { return fakeExporter{} })
+}
+
+func TestPagedExporterSplitsWideDiagram(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Orders service"}},
+			{ID: 2, Text: []string{"Billing service"}},
+			{ID: 3, Text: []string{"Shipping service"}},
+			{ID: 4, Text: []string{"Returns service"}},
+		},
+	}
+	full, err := export.NewASCIIExporter().Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(full, "\n"), "\n")
+	width := 0
+	for _, line := range lines {
+		width = max(width, len([]rune(line)))
+	}
+	if width <= 80 || width > 160 {
+		t.Fatalf("Expected the diagram to be between 80 and 160 columns wide, got %d", width)
+	}
+
+	exporter := export.NewPagedExporter()
+	if err := exporter.SetPageSize(80, 50); err != nil {
+		t.Fatal(err)
+	}
+	result, err := exporter.Export(d)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	pages := strings.Split(result, "\n\n--- page ")
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages, got:\n%s", result)
+	}
+	if !strings.HasPrefix(pages[0], "--- page 1 of 2 (row 1, column 1) ---\n") || !strings.HasPrefix(pages[1], "2 of 2 (row 1, column 2) ---\n") {
+		t.Errorf("Expected page markers, got:\n%s", result)
+	}
+	if !strings.Contains(pages[0], "Orders service") || strings.Contains(pages[0], "Returns service") {
+		t.Errorf("Expected the first page to hold the left of the diagram, got:\n%s", pages[0])
+	}
+	if !strings.Contains(pages[1], "Returns service") || strings.Contains(pages[1], "Orders service") {
+		t.Errorf("Expected the second page to hold the right of the diagram, got:\n%s", pages[1])
+	}
+
+	// Side by side, the pages give back the full rendering
+	left := strings.Split(strings.TrimRight(pages[0], "\n"), "\n")[1:]
+	right := strings.Split(strings.TrimRight(pages[1], "\n"), "\n")[1:]
+	for i, line := range lines {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		if r != "" {
+			l += strings.Repeat(" ", 80-len([]rune(l)))
+		}
+		if got, want := l+r, strings.TrimRight(line, " "); got != want {
+			t.Errorf("Line %d reassembles to %q, want %q", i, got, want)
+		}
+	}
+
+	if err := exporter.SetPageSize(0, 50); err == nil {
+		t.Error("Expected a zero page width to be rejected")
+	}
+}
+
+func TestStyleSheetColorsAllNodes(t *testing.T) {
+	sheet, err := export.ParseStyleSheet([]byte(`{
+		"node": {"color": "blue", "style": "rounded"},
+		"node#2": {"color": "red"},
+		"connection#1->2": {"style": "dashed"},
+		"diagram": {"theme": "dark"}
+	}`))
+	if err != nil {
+		t.Fatalf("ParseStyleSheet failed: %v", err)
+	}
+
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}},
+			{ID: 2, Text: []string{"API"}, Hints: map[string]string{"color": "green", "shape": "cylinder"}},
+			{ID: 3, Text: []string{"DB"}},
+		},
+		Connections: []diagram.Connection{{From: 1, To: 2}, {From: 2, To: 3}},
+	}
+	if err := sheet.Apply(d); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	for _, node := range d.Nodes {
+		want := "blue"
+		if node.ID == 2 {
+			want = "red" // The node's own selector wins over the one for all nodes
+		}
+		if node.Hints["color"] != want || node.Hints["style"] != "rounded" {
+			t.Errorf("Node %d: expected color %s and rounded style, got %v", node.ID, want, node.Hints)
+		}
+	}
+	if d.Nodes[1].Hints["shape"] != "cylinder" {
+		t.Errorf("Expected hints the sheet doesn't set kept, got %v", d.Nodes[1].Hints)
+	}
+	if d.Connections[0].Hints["style"] != "dashed" || d.Connections[1].Hints != nil {
+		t.Errorf("Expected only the 1->2 connection dashed, got %v and %v", d.Connections[0].Hints, d.Connections[1].Hints)
+	}
+	if d.Hints["theme"] != "dark" {
+		t.Errorf("Expected the diagram hint set, got %v", d.Hints)
+	}
+
+	if _, err := export.ParseStyleSheet([]byte(`{"box": {"color": "red"}}`)); err == nil {
+		t.Errorf("Expected an unknown selector to be rejected")
+	}
+}
+
+func TestStyleSheetExportRoundTrips(t *testing.T) {
+	styled := &diagram.Diagram{
+		Hints: map[string]string{"layout": "horizontal"},
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Web"}, Hints: map[string]string{"color": "cyan"}},
+			{ID: 2, Text: []string{"Cache"}, Hints: map[string]string{"color": "cyan", "shape": "cylinder"}},
+		},
+		Connections: []diagram.Connection{{From: 1, To: 2, Hints: map[string]string{"thickness": "thick"}}},
+	}
+
+	exporter, err := export.NewExporter(export.FormatStyleSheet)
+	if err != nil {
+		t.Fatalf("NewExporter failed: %v", err)
+	}
+	out, err := exporter.Export(styled)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	var doc map[string]map[string]string
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("Expected JSON, got %v:\n%s", err, out)
+	}
+	if doc["node"]["color"] != "cyan" || doc["node"]["shape"] != "" || doc["node#2"]["shape"] != "cylinder" {
+		t.Errorf("Expected the shared color under node and the shape under node#2, got:\n%s", out)
+	}
+	if doc["connection"]["thickness"] != "thick" || doc["diagram"]["layout"] != "horizontal" {
+		t.Errorf("Expected connection and diagram hints, got:\n%s", out)
+	}
+
+	sheet, err := export.ParseStyleSheet([]byte(out))
+	if err != nil {
+		t.Fatalf("ParseStyleSheet failed on exported sheet: %v", err)
+	}
+	plain := &diagram.Diagram{
+		Nodes:       []diagram.Node{{ID: 1, Text: []string{"Web"}}, {ID: 2, Text: []string{"Cache"}}},
+		Connections: []diagram.Connection{{From: 1, To: 2}},
+	}
+	if err := sheet.Apply(plain); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	for i := range plain.Nodes {
+		if fmt.Sprint(plain.Nodes[i].Hints) != fmt.Sprint(styled.Nodes[i].Hints) {
+			t.Errorf("Node %d: expected hints %v, got %v", plain.Nodes[i].ID, styled.Nodes[i].Hints, plain.Nodes[i].Hints)
+		}
+	}
+	if fmt.Sprint(plain.Connections[0].Hints) != fmt.Sprint(styled.Connections[0].Hints) || fmt.Sprint(plain.Hints) != fmt.Sprint(styled.Hints) {
+		t.Errorf("Expected connection and diagram hints restored, got %v and %v", plain.Connections[0].Hints, plain.Hints)
+	}
 }
 
 func TestExporterFileExtensions(t *testing.T) {
@@ -223,6 +819,7 @@ func TestExporterFileExtensions(t *testing.T) {
 		{export.FormatASCII, ".txt"},
 		{export.FormatMermaid, ".mmd"},
 		{export.FormatPlantUML, ".puml"},
+		{export.FormatStyleSheet, ".style.json"},
 	}
 
 	for _, tt := range tests {
@@ -4,6 +4,8 @@ package export
 import (
 	"edd/diagram"
 	"fmt"
+	"sort"
+	"sync"
 )
 
 // Format represents an export format
@@ -16,12 +18,22 @@ const (
 	FormatMermaid Format = "mermaid"
 	// FormatPlantUML exports to PlantUML syntax
 	FormatPlantUML Format = "plantuml"
+	// FormatPlantUMLComponent exports to PlantUML component/deployment syntax
+	FormatPlantUMLComponent Format = "plantuml-component"
 	// FormatJSON exports to JSON (edd native format)
 	FormatJSON Format = "json"
 	// FormatGraphviz exports to Graphviz DOT syntax
 	FormatGraphviz Format = "graphviz"
 	// FormatD2 exports to D2 syntax
 	FormatD2 Format = "d2"
+	// FormatLayoutJSON exports the computed node positions and connection paths as JSON
+	FormatLayoutJSON Format = "layout-json"
+	// FormatWebSequence exports sequence diagrams to websequencediagrams.com syntax
+	FormatWebSequence Format = "websequencediagrams"
+	// FormatPages exports ASCII/Unicode art tiled across fixed-size pages for printing
+	FormatPages Format = "pages"
+	// FormatStyleSheet exports the hints a diagram uses as a reusable style sheet
+	FormatStyleSheet Format = "stylesheet"
 )
 
 // Exporter interface for different export formats
@@ -43,13 +55,26 @@ func NewExporter(format Format) (Exporter, error) {
 		return NewMermaidExporter(), nil
 	case FormatPlantUML:
 		return NewPlantUMLExporter(), nil
+	case FormatPlantUMLComponent:
+		return NewPlantUMLComponentExporter(), nil
 	case FormatJSON:
 		return NewJSONExporter(), nil
 	case FormatGraphviz:
 		return NewGraphvizExporter(), nil
 	case FormatD2:
 		return NewD2Exporter(), nil
+	case FormatLayoutJSON:
+		return NewLayoutJSONExporter(), nil
+	case FormatWebSequence:
+		return NewWebSequenceExporter(), nil
+	case FormatPages:
+		return NewPagedExporter(), nil
+	case FormatStyleSheet:
+		return NewStyleSheetExporter(), nil
 	default:
+		if factory := registeredFactory(format); factory != nil {
+			return factory(), nil
+		}
 		return nil, fmt.Errorf("unsupported export format: %s", format)
 	}
 }
@@ -63,37 +88,109 @@ func ParseFormat(s string) (Format, error) {
 		return FormatMermaid, nil
 	case "plantuml", "puml", "p":
 		return FormatPlantUML, nil
+	case "plantuml-component", "puml-component", "component":
+		return FormatPlantUMLComponent, nil
 	case "json", "j":
 		return FormatJSON, nil
 	case "graphviz", "dot", "gv", "g":
 		return FormatGraphviz, nil
 	case "d2", "d":
 		return FormatD2, nil
+	case "layout-json", "layout":
+		return FormatLayoutJSON, nil
+	case "websequencediagrams", "websequence", "wsd":
+		return FormatWebSequence, nil
+	case "pages", "paged":
+		return FormatPages, nil
+	case "stylesheet", "style-sheet", "styles":
+		return FormatStyleSheet, nil
 	default:
+		if registeredFactory(Format(s)) != nil {
+			return Format(s), nil
+		}
 		return "", fmt.Errorf("unknown format: %s", s)
 	}
 }
 
-// GetAvailableFormats returns a list of all available export formats
+// GetAvailableFormats returns a list of all available export formats,
+// built-in formats first and then registered ones by name
 func GetAvailableFormats() []Format {
-	return []Format{
+	return append([]Format{
 		FormatASCII,
 		FormatMermaid,
 		FormatPlantUML,
+		FormatPlantUMLComponent,
 		FormatJSON,
 		FormatGraphviz,
 		FormatD2,
-	}
+		FormatLayoutJSON,
+		FormatWebSequence,
+		FormatPages,
+		FormatStyleSheet,
+	}, registeredFormats()...)
 }
 
 // GetFormatDescriptions returns human-readable descriptions of all formats
 func GetFormatDescriptions() map[Format]string {
-	return map[Format]string{
-		FormatASCII:    "ASCII/Unicode art (edd native format)",
-		FormatMermaid:  "Mermaid diagram syntax (for Markdown)",
-		FormatPlantUML: "PlantUML diagram syntax",
-		FormatJSON:     "JSON (edd data format)",
-		FormatGraphviz: "Graphviz DOT syntax",
-		FormatD2:       "D2 diagram syntax",
+	descriptions := map[Format]string{
+		FormatASCII:             "ASCII/Unicode art (edd native format)",
+		FormatMermaid:           "Mermaid diagram syntax (for Markdown)",
+		FormatPlantUML:          "PlantUML diagram syntax",
+		FormatPlantUMLComponent: "PlantUML component/deployment diagram syntax",
+		FormatJSON:              "JSON (edd data format)",
+		FormatGraphviz:          "Graphviz DOT syntax",
+		FormatD2:                "D2 diagram syntax",
+		FormatLayoutJSON:        "JSON of computed node positions and connection paths",
+		FormatWebSequence:       "WebSequenceDiagrams syntax (sequence diagrams)",
+		FormatPages:             "ASCII/Unicode art split into fixed-size pages for printing",
+		FormatStyleSheet:        "JSON style sheet of the hints used, by element",
+	}
+	for _, format := range registeredFormats() {
+		descriptions[format] = registeredFactory(format)().GetFormatName()
+	}
+	return descriptions
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[Format]func() Exporter)
+)
+
+// RegisterExporter adds an export format under the given name so that
+// ParseFormat and NewExporter recognize it. It is meant to be called from an
+// init function, for example in a file behind a build tag. It panics if the
+// name is empty or already taken, or if factory is nil.
+func RegisterExporter(name string, factory func() Exporter) {
+	if name == "" {
+		panic("export: RegisterExporter called with an empty name")
+	}
+	if factory == nil {
+		panic("export: RegisterExporter factory for " + name + " is nil")
 	}
-}
\ No newline at end of file
+	if _, err := ParseFormat(name); err == nil {
+		panic("export: RegisterExporter called twice for " + name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[Format(name)] = factory
+}
+
+// registeredFactory returns the factory registered for format, or nil
+func registeredFactory(format Format) func() Exporter {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[format]
+}
+
+// registeredFormats returns the registered format names in sorted order
+func registeredFormats() []Format {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	formats := make([]Format, 0, len(registry))
+	for format := range registry {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i] < formats[j] })
+	return formats
+}
@@ -29,6 +29,15 @@ func (e *GraphvizExporter) Export(d *diagram.Diagram) (string, error) {
 	// Start digraph
 	sb.WriteString("digraph G {\n")
 
+	// Diagram name becomes the graph label; other metadata as comments
+	for _, line := range metadataComments(d.Metadata, "  //") {
+		sb.WriteString(line + "\n")
+	}
+	if d.Metadata.Name != "" {
+		sb.WriteString(fmt.Sprintf("  label=\"%s\";\n", e.escapeLabel(d.Metadata.Name)))
+		sb.WriteString("  labelloc=t;\n")
+	}
+
 	// Global attributes for better appearance
 	sb.WriteString("  rankdir=TB;\n")
 	sb.WriteString("  node [shape=box];\n")
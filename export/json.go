@@ -3,19 +3,43 @@ package export
 import (
 	"edd/diagram"
 	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
 )
 
+// JSONIndentEnv names the environment variable that sets the indent width
+// of saved and exported JSON. "0" writes compact single-line JSON.
+const JSONIndentEnv = "EDD_JSON_INDENT"
+
+// DefaultJSONIndent is the number of spaces JSON is indented by by default
+const DefaultJSONIndent = 2
+
+// TempJSONIndentEnv names the environment variable that sets the indent width
+// of the temporary JSON files edd edits markdown blocks and collection
+// diagrams through. It falls back to EDD_JSON_INDENT when unset.
+const TempJSONIndentEnv = "EDD_TEMP_JSON_INDENT"
+
 // JSONExporter exports diagrams to JSON format
-type JSONExporter struct{}
+type JSONExporter struct {
+	indent int // Spaces per indent level; 0 for compact output
+}
 
-// NewJSONExporter creates a new JSON exporter
+// NewJSONExporter creates a new JSON exporter using the indent width from
+// the environment
 func NewJSONExporter() *JSONExporter {
-	return &JSONExporter{}
+	return &JSONExporter{indent: JSONIndentFromEnv()}
+}
+
+// NewJSONExporterWithIndent creates a JSON exporter that indents by the given
+// number of spaces, or writes compact single-line JSON when indent is 0
+func NewJSONExporterWithIndent(indent int) *JSONExporter {
+	return &JSONExporter{indent: indent}
 }
 
 // Export converts a diagram to JSON
 func (e *JSONExporter) Export(d *diagram.Diagram) (string, error) {
-	data, err := json.MarshalIndent(d, "", "  ")
+	data, err := MarshalJSON(d, e.indent)
 	if err != nil {
 		return "", err
 	}
@@ -30,4 +54,38 @@ func (e *JSONExporter) GetFileExtension() string {
 // GetFormatName returns the format name
 func (e *JSONExporter) GetFormatName() string {
 	return "JSON"
-}
\ No newline at end of file
+}
+
+// MarshalJSON encodes v indented by the given number of spaces, or as compact
+// single-line JSON when indent is 0 or less
+func MarshalJSON(v interface{}, indent int) ([]byte, error) {
+	if indent <= 0 {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", strings.Repeat(" ", indent))
+}
+
+// JSONIndentFromEnv returns the indent width set by EDD_JSON_INDENT, or
+// DefaultJSONIndent if it is unset or not a non-negative number
+func JSONIndentFromEnv() int {
+	return indentFromEnv(JSONIndentEnv, DefaultJSONIndent)
+}
+
+// TempJSONIndentFromEnv returns the indent width set by EDD_TEMP_JSON_INDENT,
+// or JSONIndentFromEnv if it is unset or not a non-negative number
+func TempJSONIndentFromEnv() int {
+	return indentFromEnv(TempJSONIndentEnv, JSONIndentFromEnv())
+}
+
+// indentFromEnv reads a non-negative indent width from the named variable
+func indentFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	indent, err := strconv.Atoi(value)
+	if err != nil || indent < 0 {
+		return fallback
+	}
+	return indent
+}
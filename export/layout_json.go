@@ -0,0 +1,143 @@
+package export
+
+import (
+	"edd/diagram"
+	"edd/layout"
+	"edd/render"
+	"fmt"
+)
+
+// LayoutJSONExporter exports the computed geometry of a diagram: where each
+// node is drawn and the points each connection's line passes through, in the
+// character cells of the rendered output. It is meant for tools that want to
+// draw or annotate edd's layout themselves.
+type LayoutJSONExporter struct {
+	renderer *render.Renderer
+	indent   int
+}
+
+// NewLayoutJSONExporter creates a new layout JSON exporter using the indent
+// width from the environment
+func NewLayoutJSONExporter() *LayoutJSONExporter {
+	return &LayoutJSONExporter{
+		renderer: render.NewRenderer(),
+		indent:   JSONIndentFromEnv(),
+	}
+}
+
+// LayoutDocument is the JSON document written by LayoutJSONExporter
+type LayoutDocument struct {
+	Type        string             `json:"type"`
+	Nodes       []LayoutNode       `json:"nodes"`
+	Connections []LayoutConnection `json:"connections"`
+}
+
+// LayoutNode is a node's computed box, top-left corner first
+type LayoutNode struct {
+	ID     int `json:"id"`
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// LayoutConnection is a connection's routed line. Index is its position in
+// the diagram's connections, which identifies it even when IDs repeat.
+type LayoutConnection struct {
+	Index  int           `json:"index"`
+	ID     int           `json:"id"`
+	From   int           `json:"from"`
+	To     int           `json:"to"`
+	Points []LayoutPoint `json:"points"`
+}
+
+// LayoutPoint is one cell on a connection's line
+type LayoutPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Export lays out the diagram and encodes its geometry as JSON
+func (e *LayoutJSONExporter) Export(d *diagram.Diagram) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("diagram is nil")
+	}
+
+	doc, err := e.Layout(d)
+	if err != nil {
+		return "", err
+	}
+	data, err := MarshalJSON(doc, e.indent)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Layout computes the geometry that Export encodes
+func (e *LayoutJSONExporter) Layout(d *diagram.Diagram) (*LayoutDocument, error) {
+	doc := &LayoutDocument{
+		Type:        string(d.GetType()),
+		Nodes:       []LayoutNode{},
+		Connections: []LayoutConnection{},
+	}
+
+	if d.GetType() == diagram.DiagramTypeSequence {
+		positions := layout.NewSequenceLayout().ComputePositions(d)
+		for _, node := range d.Nodes {
+			if pos, ok := positions.Participants[node.ID]; ok {
+				doc.Nodes = append(doc.Nodes, LayoutNode{ID: node.ID, X: pos.X, Y: pos.Y, Width: pos.Width, Height: pos.Height})
+			}
+		}
+		for i, msg := range positions.Messages {
+			if i >= len(d.Connections) {
+				break
+			}
+			conn := d.Connections[i]
+			doc.Connections = append(doc.Connections, LayoutConnection{
+				Index:  i,
+				ID:     conn.ID,
+				From:   conn.From,
+				To:     conn.To,
+				Points: []LayoutPoint{{X: msg.FromX, Y: msg.Y}, {X: msg.ToX, Y: msg.Y}},
+			})
+		}
+		return doc, nil
+	}
+
+	nodes, paths, err := e.renderer.GetFlowchartRenderer().Geometry(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lay out diagram: %w", err)
+	}
+	for _, node := range nodes {
+		doc.Nodes = append(doc.Nodes, LayoutNode{ID: node.ID, X: node.X, Y: node.Y, Width: node.Width, Height: node.Height})
+	}
+	for i, conn := range d.Connections {
+		path, ok := paths[i]
+		if !ok {
+			continue // Hidden inside a collapsed group
+		}
+		points := []LayoutPoint{}
+		for _, p := range path.Points {
+			points = append(points, LayoutPoint{X: p.X, Y: p.Y})
+		}
+		doc.Connections = append(doc.Connections, LayoutConnection{
+			Index:  i,
+			ID:     conn.ID,
+			From:   conn.From,
+			To:     conn.To,
+			Points: points,
+		})
+	}
+	return doc, nil
+}
+
+// GetFileExtension returns the file extension for layout JSON
+func (e *LayoutJSONExporter) GetFileExtension() string {
+	return ".layout.json"
+}
+
+// GetFormatName returns the format name
+func (e *LayoutJSONExporter) GetFormatName() string {
+	return "Layout JSON"
+}
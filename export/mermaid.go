@@ -7,13 +7,21 @@ import (
 )
 
 // MermaidExporter exports diagrams to Mermaid syntax
-type MermaidExporter struct{}
+type MermaidExporter struct {
+	sortByID bool
+}
 
 // NewMermaidExporter creates a new Mermaid exporter
 func NewMermaidExporter() *MermaidExporter {
 	return &MermaidExporter{}
 }
 
+// SetSortByID makes flowcharts list their nodes and connections in ID order
+// rather than the order they were added in, for stable diffs
+func (e *MermaidExporter) SetSortByID(enabled bool) {
+	e.sortByID = enabled
+}
+
 // Export converts the diagram to Mermaid syntax
 func (e *MermaidExporter) Export(d *diagram.Diagram) (string, error) {
 	if d == nil {
@@ -24,6 +32,10 @@ func (e *MermaidExporter) Export(d *diagram.Diagram) (string, error) {
 		return "", fmt.Errorf("diagram has no nodes")
 	}
 
+	if e.sortByID {
+		d = sortedByID(d)
+	}
+
 	// Determine diagram type and export accordingly
 	if d.Type == "sequence" {
 		return e.exportSequence(d)
@@ -34,7 +46,7 @@ func (e *MermaidExporter) Export(d *diagram.Diagram) (string, error) {
 // exportSequence exports a sequence diagram to Mermaid syntax
 func (e *MermaidExporter) exportSequence(d *diagram.Diagram) (string, error) {
 	var sb strings.Builder
-	sb.WriteString("sequenceDiagram\n")
+	e.writeMetadata(&sb, d.Metadata, "sequenceDiagram")
 
 	// Create participant declarations
 	// Map node IDs to participant names for easier reference
@@ -59,7 +71,8 @@ func (e *MermaidExporter) exportSequence(d *diagram.Diagram) (string, error) {
 	activationStack := []string{}
 
 	// Add connections as messages
-	for _, conn := range d.Connections {
+	for _, index := range d.MessageOrder() {
+		conn := d.Connections[index]
 		fromID, ok := nodeMap[conn.From]
 		if !ok {
 			continue // Skip invalid connections
@@ -179,10 +192,22 @@ func (e *MermaidExporter) exportSequence(d *diagram.Diagram) (string, error) {
 	return sb.String(), nil
 }
 
+// writeMetadata writes the diagram header, preceded by a front matter title
+// when the diagram has a name and followed by the other metadata as comments
+func (e *MermaidExporter) writeMetadata(sb *strings.Builder, m diagram.Metadata, header string) {
+	if m.Name != "" {
+		sb.WriteString(fmt.Sprintf("---\ntitle: %s\n---\n", m.Name))
+	}
+	sb.WriteString(header + "\n")
+	for _, line := range metadataComments(m, "    %%") {
+		sb.WriteString(line + "\n")
+	}
+}
+
 // exportFlowchart exports a flowchart/box diagram to Mermaid syntax
 func (e *MermaidExporter) exportFlowchart(d *diagram.Diagram) (string, error) {
 	var sb strings.Builder
-	sb.WriteString("graph TD\n")
+	e.writeMetadata(&sb, d.Metadata, "graph TD")
 
 	// Create node declarations
 	nodeMap := make(map[int]string)
@@ -222,7 +247,7 @@ func (e *MermaidExporter) exportFlowchart(d *diagram.Diagram) (string, error) {
 		if hints := conn.Hints; hints != nil {
 			if style := hints["style"]; style == "dashed" {
 				connStyle = "-.->"
-			} else if style == "bold" {
+			} else if style == "bold" || style == "thick" || hints["thickness"] == "thick" {
 				connStyle = "==>"
 			}
 		}
@@ -238,7 +263,10 @@ func (e *MermaidExporter) exportFlowchart(d *diagram.Diagram) (string, error) {
 
 	// Add color class definitions if any nodes have color hints (flowchart only)
 	if d.Type == "box" {
+		// Classes are defined in the order nodes first use them, so the
+		// output is the same every time
 		colorClasses := make(map[string]bool)
+		var classOrder []string
 		var nodeClasses []string
 
 		for _, node := range d.Nodes {
@@ -247,6 +275,7 @@ func (e *MermaidExporter) exportFlowchart(d *diagram.Diagram) (string, error) {
 				className := e.getColorClassName(color)
 				if !colorClasses[className] {
 					colorClasses[className] = true
+					classOrder = append(classOrder, className)
 				}
 				nodeID := fmt.Sprintf("N%d", node.ID)
 				nodeClasses = append(nodeClasses, fmt.Sprintf("    class %s %s", nodeID, className))
@@ -256,7 +285,7 @@ func (e *MermaidExporter) exportFlowchart(d *diagram.Diagram) (string, error) {
 		// Add class definitions
 		if len(colorClasses) > 0 {
 			sb.WriteString("\n")
-			for className := range colorClasses {
+			for _, className := range classOrder {
 				sb.WriteString(e.getClassDefinition(className))
 				sb.WriteString("\n")
 			}
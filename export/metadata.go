@@ -0,0 +1,22 @@
+package export
+
+import (
+	"edd/diagram"
+	"fmt"
+)
+
+// metadataComments returns comment lines describing the metadata fields that
+// have no native syntax in a format (everything but the name).
+func metadataComments(m diagram.Metadata, prefix string) []string {
+	var lines []string
+	if m.Author != "" {
+		lines = append(lines, fmt.Sprintf("%s Author: %s", prefix, m.Author))
+	}
+	if m.Description != "" {
+		lines = append(lines, fmt.Sprintf("%s Description: %s", prefix, m.Description))
+	}
+	if m.Created != "" {
+		lines = append(lines, fmt.Sprintf("%s Created: %s", prefix, m.Created))
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package export_test
+
+import (
+	"edd/diagram"
+	"edd/export"
+	"edd/importer"
+	"strings"
+	"testing"
+)
+
+func metadataTestDiagram() *diagram.Diagram {
+	return &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Cart"}},
+			{ID: 2, Text: []string{"Payment"}},
+		},
+		Connections: []diagram.Connection{{From: 1, To: 2, Arrow: true}},
+		Metadata: diagram.Metadata{
+			Name:        "Checkout",
+			Author:      "Dana",
+			Description: "Order payment flow",
+		},
+	}
+}
+
+func TestExportersIncludeMetadata(t *testing.T) {
+	tests := []struct {
+		format export.Format
+		want   []string
+	}{
+		{export.FormatMermaid, []string{"---\ntitle: Checkout\n---\ngraph TD\n", "%% Author: Dana", "%% Description: Order payment flow"}},
+		{export.FormatPlantUML, []string{"@startuml\ntitle Checkout\n", "' Author: Dana"}},
+		{export.FormatGraphviz, []string{`label="Checkout";`, "// Author: Dana"}},
+		{export.FormatD2, []string{"# Checkout\n# Author: Dana\n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			exporter, _ := export.NewExporter(tt.format)
+			output, err := exporter.Export(metadataTestDiagram())
+			if err != nil {
+				t.Fatalf("Export failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
+
+func TestMermaidTitleRoundTrip(t *testing.T) {
+	output, err := export.NewMermaidExporter().Export(metadataTestDiagram())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	imported, err := importer.NewImporterRegistry().Import(output)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if imported.Metadata.Name != "Checkout" {
+		t.Errorf("Expected title to import as name, got %q", imported.Metadata.Name)
+	}
+	if len(imported.Nodes) != 2 {
+		t.Errorf("Expected front matter not to disturb the nodes, got %d", len(imported.Nodes))
+	}
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"edd/diagram"
+	"edd/render"
+	"fmt"
+	"strings"
+)
+
+// Default page size for paged export, in columns and lines
+const (
+	DefaultPageWidth  = 80
+	DefaultPageHeight = 50
+)
+
+// PagedExporter renders a diagram like ASCIIExporter and tiles the result
+// across fixed-size pages, so a diagram too large for one sheet can be
+// printed and assembled
+type PagedExporter struct {
+	renderer   *render.Renderer
+	pageWidth  int
+	pageHeight int
+}
+
+// NewPagedExporter creates a paged exporter using the default page size
+func NewPagedExporter() *PagedExporter {
+	return &PagedExporter{
+		renderer:   render.NewRenderer(),
+		pageWidth:  DefaultPageWidth,
+		pageHeight: DefaultPageHeight,
+	}
+}
+
+// SetPageSize sets the number of columns and lines on each page
+func (e *PagedExporter) SetPageSize(width, height int) error {
+	if width < 1 || height < 1 {
+		return fmt.Errorf("page size must be positive, got %dx%d", width, height)
+	}
+	e.pageWidth, e.pageHeight = width, height
+	return nil
+}
+
+// Export renders the diagram and emits its pages left to right, then top to
+// bottom. Each page is preceded by a marker line giving its number and its
+// row and column in the grid, e.g. "--- page 2 of 4 (row 1, column 2) ---".
+func (e *PagedExporter) Export(d *diagram.Diagram) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("diagram is nil")
+	}
+
+	output, err := e.renderer.Render(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to render diagram: %w", err)
+	}
+
+	pages := render.Paginate(output, e.pageWidth, e.pageHeight)
+	var sb strings.Builder
+	for i, page := range pages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("--- page %d of %d (row %d, column %d) ---\n", i+1, len(pages), page.Row+1, page.Col+1))
+		sb.WriteString(page.Content + "\n")
+	}
+	return sb.String(), nil
+}
+
+// GetFileExtension returns the recommended file extension
+func (e *PagedExporter) GetFileExtension() string {
+	return ".txt"
+}
+
+// GetFormatName returns the format name
+func (e *PagedExporter) GetFormatName() string {
+	return "Paged ASCII/Unicode Art"
+}
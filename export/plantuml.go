@@ -7,13 +7,21 @@ import (
 )
 
 // PlantUMLExporter exports diagrams to PlantUML syntax
-type PlantUMLExporter struct{}
+type PlantUMLExporter struct {
+	sortByID bool
+}
 
 // NewPlantUMLExporter creates a new PlantUML exporter
 func NewPlantUMLExporter() *PlantUMLExporter {
 	return &PlantUMLExporter{}
 }
 
+// SetSortByID makes box diagrams list their nodes and connections in ID
+// order rather than the order they were added in, for stable diffs
+func (e *PlantUMLExporter) SetSortByID(enabled bool) {
+	e.sortByID = enabled
+}
+
 // Export converts the diagram to PlantUML syntax
 func (e *PlantUMLExporter) Export(d *diagram.Diagram) (string, error) {
 	if d == nil {
@@ -24,6 +32,10 @@ func (e *PlantUMLExporter) Export(d *diagram.Diagram) (string, error) {
 		return "", fmt.Errorf("diagram has no nodes")
 	}
 
+	if e.sortByID {
+		d = sortedByID(d)
+	}
+
 	// Determine diagram type and export accordingly
 	if d.Type == "sequence" {
 		return e.exportSequence(d)
@@ -35,6 +47,7 @@ func (e *PlantUMLExporter) Export(d *diagram.Diagram) (string, error) {
 func (e *PlantUMLExporter) exportSequence(d *diagram.Diagram) (string, error) {
 	var sb strings.Builder
 	sb.WriteString("@startuml\n")
+	e.writeMetadata(&sb, d.Metadata)
 
 	// Add skinparam for better appearance
 	sb.WriteString("skinparam backgroundColor white\n")
@@ -85,7 +98,8 @@ func (e *PlantUMLExporter) exportSequence(d *diagram.Diagram) (string, error) {
 	activationStack := []string{}
 
 	// Add connections as messages
-	for _, conn := range d.Connections {
+	for _, index := range d.MessageOrder() {
+		conn := d.Connections[index]
 		fromID, ok := nodeMap[conn.From]
 		if !ok {
 			continue
@@ -180,10 +194,21 @@ func (e *PlantUMLExporter) exportSequence(d *diagram.Diagram) (string, error) {
 	return sb.String(), nil
 }
 
+// writeMetadata writes the diagram name as a title and the rest as comments
+func (e *PlantUMLExporter) writeMetadata(sb *strings.Builder, m diagram.Metadata) {
+	if m.Name != "" {
+		sb.WriteString(fmt.Sprintf("title %s\n", m.Name))
+	}
+	for _, line := range metadataComments(m, "'") {
+		sb.WriteString(line + "\n")
+	}
+}
+
 // exportActivity exports a box/flowchart diagram
 func (e *PlantUMLExporter) exportActivity(d *diagram.Diagram) (string, error) {
 	var sb strings.Builder
 	sb.WriteString("@startuml\n")
+	e.writeMetadata(&sb, d.Metadata)
 	sb.WriteString("!theme plain\n")
 	sb.WriteString("skinparam backgroundColor white\n")
 	sb.WriteString("skinparam componentStyle rectangle\n\n")
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"edd/diagram"
+	"fmt"
+	"strings"
+)
+
+// PlantUMLComponentExporter exports box diagrams as PlantUML component and
+// deployment diagrams, declaring each node with the element keyword that
+// matches what it represents (database, node, cloud, ...) instead of a plain
+// component. Sequence diagrams are exported as by PlantUMLExporter.
+type PlantUMLComponentExporter struct {
+	PlantUMLExporter
+}
+
+// NewPlantUMLComponentExporter creates a new PlantUML component exporter
+func NewPlantUMLComponentExporter() *PlantUMLComponentExporter {
+	return &PlantUMLComponentExporter{}
+}
+
+// componentElements maps icon hint values to PlantUML element keywords
+var componentElements = map[string]string{
+	"database":   "database",
+	"db":         "database",
+	"server":     "node",
+	"node":       "node",
+	"cloud":      "cloud",
+	"queue":      "queue",
+	"actor":      "actor",
+	"user":       "actor",
+	"person":     "actor",
+	"storage":    "storage",
+	"folder":     "folder",
+	"file":       "artifact",
+	"artifact":   "artifact",
+	"interface":  "interface",
+	"collection": "collections",
+	"frame":      "frame",
+	"component":  "component",
+}
+
+// Export converts the diagram to PlantUML component diagram syntax
+func (e *PlantUMLComponentExporter) Export(d *diagram.Diagram) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("diagram is nil")
+	}
+
+	if len(d.Nodes) == 0 {
+		return "", fmt.Errorf("diagram has no nodes")
+	}
+
+	if e.sortByID {
+		d = sortedByID(d)
+	}
+
+	if d.Type == "sequence" {
+		return e.exportSequence(d)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("@startuml\n")
+	e.writeMetadata(&sb, d.Metadata)
+	sb.WriteString("skinparam backgroundColor white\n\n")
+
+	nodeMap := make(map[int]string)
+	for _, node := range d.Nodes {
+		nodeID := fmt.Sprintf("N%d", node.ID)
+		nodeMap[node.ID] = nodeID
+
+		style := ""
+		if color := node.Hints["color"]; color != "" {
+			style = fmt.Sprintf(" #%s", e.mapColorToHex(color))
+		}
+
+		sb.WriteString(fmt.Sprintf("%s \"%s\" as %s%s\n", componentElement(node), e.getNodeLabel(node), nodeID, style))
+	}
+
+	if len(d.Connections) > 0 {
+		sb.WriteString("\n")
+	}
+
+	for _, conn := range d.Connections {
+		fromID, fromExists := nodeMap[conn.From]
+		toID, toExists := nodeMap[conn.To]
+		if !fromExists || !toExists {
+			continue
+		}
+
+		arrowStyle := "-->"
+		if style := conn.Hints["style"]; style == "dashed" || style == "dotted" {
+			arrowStyle = "..>"
+		}
+
+		if conn.Label != "" {
+			sb.WriteString(fmt.Sprintf("%s %s %s : %s\n", fromID, arrowStyle, toID, conn.Label))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s %s %s\n", fromID, arrowStyle, toID))
+		}
+	}
+
+	sb.WriteString("@enduml\n")
+	return sb.String(), nil
+}
+
+// componentElement picks the PlantUML element keyword for a node from its
+// icon hint, falling back to its shape and then to a plain component.
+func componentElement(node diagram.Node) string {
+	if element, ok := componentElements[strings.ToLower(node.Hints["icon"])]; ok {
+		return element
+	}
+	switch node.Hints["shape"] {
+	case "cylinder":
+		return "database"
+	case "circle":
+		return "interface"
+	}
+	return "component"
+}
+
+// GetFormatName returns the format name
+func (e *PlantUMLComponentExporter) GetFormatName() string {
+	return "PlantUML component"
+}
@@ -0,0 +1,36 @@
+package export
+
+import (
+	"edd/diagram"
+	"sort"
+)
+
+// sortedByID returns a copy of a flowchart with its nodes and connections in
+// ID order, so the export doesn't depend on the order they were added in.
+// Connections sharing an ID are ordered by their endpoints and label. A
+// sequence diagram is returned as is, as its order is the order of its
+// participants and messages.
+func sortedByID(d *diagram.Diagram) *diagram.Diagram {
+	if d.Type == string(diagram.DiagramTypeSequence) {
+		return d
+	}
+
+	sorted := d.Clone()
+	sort.SliceStable(sorted.Nodes, func(i, j int) bool {
+		return sorted.Nodes[i].ID < sorted.Nodes[j].ID
+	})
+	sort.SliceStable(sorted.Connections, func(i, j int) bool {
+		a, b := sorted.Connections[i], sorted.Connections[j]
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Label < b.Label
+	})
+	return sorted
+}
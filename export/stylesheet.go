@@ -0,0 +1,228 @@
+package export
+
+import (
+	"edd/diagram"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Style sheet selectors. A sheet maps each selector to the hints it sets:
+//
+//	diagram                    the diagram's own hints
+//	node                       every node
+//	node#<id>                  the node with that ID
+//	connection                 every connection
+//	connection#<from>-><to>    every connection from one node to another
+const (
+	SelectorDiagram    = "diagram"
+	SelectorNode       = "node"
+	SelectorConnection = "connection"
+)
+
+// StyleSheet holds a diagram's hints apart from its content, so the same look
+// can be applied to other diagrams. It is written as a JSON object of
+// selectors to hint maps.
+type StyleSheet map[string]map[string]string
+
+// StyleSheetExporter exports the hints a diagram uses as a style sheet
+type StyleSheetExporter struct {
+	indent int
+}
+
+// NewStyleSheetExporter creates a new style sheet exporter using the indent
+// width from the environment
+func NewStyleSheetExporter() *StyleSheetExporter {
+	return &StyleSheetExporter{indent: JSONIndentFromEnv()}
+}
+
+// Export collects the diagram's hints into a style sheet and encodes it as JSON
+func (e *StyleSheetExporter) Export(d *diagram.Diagram) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("diagram is nil")
+	}
+	data, err := MarshalJSON(NewStyleSheet(d), e.indent)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetFileExtension returns the file extension for style sheets
+func (e *StyleSheetExporter) GetFileExtension() string {
+	return ".style.json"
+}
+
+// GetFormatName returns the format name
+func (e *StyleSheetExporter) GetFormatName() string {
+	return "Style sheet (JSON of hints by element)"
+}
+
+// NewStyleSheet collects every hint the diagram uses. A hint all nodes, or
+// all connections, set to the same value goes under the node or connection
+// selector; the rest go under the selector of the element that sets them.
+func NewStyleSheet(d *diagram.Diagram) StyleSheet {
+	sheet := make(StyleSheet)
+	if len(d.Hints) > 0 {
+		sheet[SelectorDiagram] = copyHints(d.Hints)
+	}
+
+	nodeHints := make([]map[string]string, len(d.Nodes))
+	nodeSelectors := make([]string, len(d.Nodes))
+	for i, node := range d.Nodes {
+		nodeHints[i] = node.Hints
+		nodeSelectors[i] = fmt.Sprintf("%s#%d", SelectorNode, node.ID)
+	}
+	collectHints(sheet, SelectorNode, nodeSelectors, nodeHints)
+
+	connHints := make([]map[string]string, len(d.Connections))
+	connSelectors := make([]string, len(d.Connections))
+	for i, conn := range d.Connections {
+		connHints[i] = conn.Hints
+		connSelectors[i] = fmt.Sprintf("%s#%d->%d", SelectorConnection, conn.From, conn.To)
+	}
+	collectHints(sheet, SelectorConnection, connSelectors, connHints)
+
+	return sheet
+}
+
+// collectHints adds the hints of a kind of element to the sheet, shared ones
+// under the kind's selector and the rest under each element's own
+func collectHints(sheet StyleSheet, kind string, selectors []string, hints []map[string]string) {
+	if len(hints) == 0 {
+		return
+	}
+
+	shared := copyHints(hints[0])
+	for _, h := range hints[1:] {
+		for key, value := range shared {
+			if h[key] != value {
+				delete(shared, key)
+			}
+		}
+	}
+	if len(shared) > 0 {
+		sheet[kind] = shared
+	}
+
+	for i, h := range hints {
+		for key, value := range h {
+			if _, ok := shared[key]; ok {
+				continue
+			}
+			if sheet[selectors[i]] == nil {
+				sheet[selectors[i]] = make(map[string]string)
+			}
+			sheet[selectors[i]][key] = value
+		}
+	}
+}
+
+// copyHints returns a copy of a hint map, empty rather than nil
+func copyHints(hints map[string]string) map[string]string {
+	copied := make(map[string]string, len(hints))
+	for key, value := range hints {
+		copied[key] = value
+	}
+	return copied
+}
+
+// ParseStyleSheet decodes a style sheet from JSON, rejecting selectors it
+// doesn't recognize
+func ParseStyleSheet(data []byte) (StyleSheet, error) {
+	var sheet StyleSheet
+	if err := json.Unmarshal(data, &sheet); err != nil {
+		return nil, fmt.Errorf("invalid style sheet: %w", err)
+	}
+	for selector := range sheet {
+		if _, _, err := parseSelector(selector); err != nil {
+			return nil, err
+		}
+	}
+	return sheet, nil
+}
+
+// Apply sets the sheet's hints on the diagram, replacing any the diagram
+// already sets. Hints for every node or connection are applied before those
+// for particular ones, so the more specific selector wins. Selectors naming
+// elements the diagram doesn't have are ignored.
+func (s StyleSheet) Apply(d *diagram.Diagram) error {
+	selectors := make([]string, 0, len(s))
+	for selector := range s {
+		selectors = append(selectors, selector)
+	}
+	sort.Slice(selectors, func(i, j int) bool {
+		if a, b := strings.Contains(selectors[i], "#"), strings.Contains(selectors[j], "#"); a != b {
+			return b
+		}
+		return selectors[i] < selectors[j]
+	})
+
+	for _, selector := range selectors {
+		kind, match, err := parseSelector(selector)
+		if err != nil {
+			return err
+		}
+		hints := s[selector]
+		switch kind {
+		case SelectorDiagram:
+			d.Hints = setHints(d.Hints, hints)
+		case SelectorNode:
+			for i := range d.Nodes {
+				if match(d.Nodes[i].ID, 0) {
+					d.Nodes[i].Hints = setHints(d.Nodes[i].Hints, hints)
+				}
+			}
+		case SelectorConnection:
+			for i := range d.Connections {
+				if match(d.Connections[i].From, d.Connections[i].To) {
+					d.Connections[i].Hints = setHints(d.Connections[i].Hints, hints)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setHints copies hints into dst, creating it if needed
+func setHints(dst, hints map[string]string) map[string]string {
+	if len(hints) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(hints))
+	}
+	for key, value := range hints {
+		dst[key] = value
+	}
+	return dst
+}
+
+// parseSelector splits a selector into the kind of element it applies to and
+// a test of whether a node ID, or a connection's ends, match it
+func parseSelector(selector string) (string, func(a, b int) bool, error) {
+	all := func(a, b int) bool { return true }
+	kind, target, specific := strings.Cut(selector, "#")
+	switch {
+	case kind == SelectorDiagram && !specific:
+		return kind, all, nil
+	case kind == SelectorNode && !specific:
+		return kind, all, nil
+	case kind == SelectorConnection && !specific:
+		return kind, all, nil
+	case kind == SelectorNode:
+		if id, err := strconv.Atoi(target); err == nil {
+			return kind, func(a, b int) bool { return a == id }, nil
+		}
+	case kind == SelectorConnection:
+		from, to, ok := strings.Cut(target, "->")
+		fromID, err1 := strconv.Atoi(from)
+		toID, err2 := strconv.Atoi(to)
+		if ok && err1 == nil && err2 == nil {
+			return kind, func(a, b int) bool { return a == fromID && b == toID }, nil
+		}
+	}
+	return "", nil, fmt.Errorf("invalid style sheet selector %q (want diagram, node, node#<id>, connection or connection#<from>-><to>)", selector)
+}
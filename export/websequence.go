@@ -0,0 +1,123 @@
+package export
+
+import (
+	"edd/diagram"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// WebSequenceExporter exports sequence diagrams to the text syntax of
+// websequencediagrams.com, e.g. "Alice->+Bob: hello"
+type WebSequenceExporter struct{}
+
+// NewWebSequenceExporter creates a new WebSequenceDiagrams exporter
+func NewWebSequenceExporter() *WebSequenceExporter {
+	return &WebSequenceExporter{}
+}
+
+// wsdPlainName matches participant names usable in messages as they are
+var wsdPlainName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Export converts the sequence diagram to WebSequenceDiagrams syntax.
+// Participants are declared in diagram order, with an alias when their name
+// can't appear in a message as is. A node's "note" hint becomes a note to the
+// right of it. Activation hints map to "+" and "-" on the message arrow where
+// the syntax has them, and to activate/deactivate lines otherwise.
+func (e *WebSequenceExporter) Export(d *diagram.Diagram) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("diagram is nil")
+	}
+	if len(d.Nodes) == 0 {
+		return "", fmt.Errorf("diagram has no nodes")
+	}
+	if d.Type != "sequence" {
+		return "", fmt.Errorf("WebSequenceDiagrams export only supports sequence diagrams")
+	}
+
+	var sb strings.Builder
+	if d.Metadata.Name != "" {
+		sb.WriteString(fmt.Sprintf("title %s\n", d.Metadata.Name))
+	}
+	for _, line := range metadataComments(d.Metadata, "#") {
+		sb.WriteString(line + "\n")
+	}
+
+	names := make(map[int]string)
+	for _, node := range d.Nodes {
+		label := strings.Join(node.Text, "\\n")
+		if label == "" {
+			label = fmt.Sprintf("Node%d", node.ID)
+		}
+		if wsdPlainName.MatchString(label) {
+			names[node.ID] = label
+			sb.WriteString(fmt.Sprintf("participant %s\n", label))
+		} else {
+			names[node.ID] = fmt.Sprintf("P%d", node.ID)
+			sb.WriteString(fmt.Sprintf("participant \"%s\" as P%d\n", label, node.ID))
+		}
+	}
+	for _, node := range d.Nodes {
+		if note := node.Hints["note"]; note != "" {
+			sb.WriteString(fmt.Sprintf("note right of %s: %s\n", names[node.ID], note))
+		}
+	}
+
+	if len(d.Connections) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, index := range d.MessageOrder() {
+		conn := d.Connections[index]
+		from, ok := names[conn.From]
+		if !ok {
+			continue
+		}
+		to, ok := names[conn.To]
+		if !ok {
+			continue
+		}
+		hints := conn.Hints
+
+		if hints["activate_source"] == "true" {
+			sb.WriteString(fmt.Sprintf("activate %s\n", from))
+		}
+
+		arrow := "->"
+		if hints["style"] == "dashed" {
+			arrow = "-->"
+		}
+		// An arrow can carry only one of "+" (activate the target) and "-"
+		// (deactivate the sender)
+		activate, deactivate := hints["activate"] == "true", hints["deactivate"] == "true"
+		if activate {
+			arrow += "+"
+		} else if deactivate {
+			arrow += "-"
+		}
+
+		if conn.Label != "" {
+			sb.WriteString(fmt.Sprintf("%s%s%s: %s\n", from, arrow, to, strings.ReplaceAll(conn.Label, "\n", "\\n")))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s%s%s\n", from, arrow, to))
+		}
+
+		if activate && deactivate {
+			sb.WriteString(fmt.Sprintf("deactivate %s\n", from))
+		}
+		if hints["deactivate_target"] == "true" {
+			sb.WriteString(fmt.Sprintf("deactivate %s\n", to))
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// GetFileExtension returns the file extension for WebSequenceDiagrams text
+func (e *WebSequenceExporter) GetFileExtension() string {
+	return ".wsd"
+}
+
+// GetFormatName returns the format name
+func (e *WebSequenceExporter) GetFormatName() string {
+	return "WebSequenceDiagrams"
+}
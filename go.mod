@@ -2,6 +2,8 @@ module edd
 
 go 1.24.0
 
+require golang.org/x/text v0.29.0
+
 require (
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/term v0.35.0 // indirect
@@ -0,0 +1,418 @@
+package importer
+
+import (
+	"edd/diagram"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ASCIIImporter reads diagrams drawn the way edd renders them: rounded
+// Unicode boxes joined by orthogonal lines, with a junction on the border
+// where a connection leaves its source and an arrowhead where it enters its
+// target. This makes it possible to edit a diagram that was pasted into a
+// document as text.
+type ASCIIImporter struct{}
+
+// NewASCIIImporter creates a new ASCII art importer
+func NewASCIIImporter() *ASCIIImporter {
+	return &ASCIIImporter{}
+}
+
+// Directions a line cell connects to, as a bit set
+const (
+	dirUp = 1 << iota
+	dirRight
+	dirDown
+	dirLeft
+)
+
+// lineDirections lists the line-drawing characters edd routes connections
+// with and the directions each one joins.
+var lineDirections = map[rune]int{
+	'─': dirLeft | dirRight,
+	'│': dirUp | dirDown,
+	'┌': dirRight | dirDown,
+	'┐': dirLeft | dirDown,
+	'└': dirUp | dirRight,
+	'┘': dirUp | dirLeft,
+	'╭': dirRight | dirDown,
+	'╮': dirLeft | dirDown,
+	'╰': dirUp | dirRight,
+	'╯': dirUp | dirLeft,
+	'├': dirUp | dirRight | dirDown,
+	'┤': dirUp | dirLeft | dirDown,
+	'┬': dirLeft | dirRight | dirDown,
+	'┴': dirLeft | dirRight | dirUp,
+	'┼': dirUp | dirRight | dirDown | dirLeft,
+}
+
+// arrowDirections maps each arrowhead to the direction it points.
+var arrowDirections = map[rune]int{
+	'▶': dirRight,
+	'◀': dirLeft,
+	'▼': dirDown,
+	'▲': dirUp,
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// CanImport checks if the content looks like an edd box diagram
+func (a *ASCIIImporter) CanImport(content string) bool {
+	return strings.Contains(content, "╭") && strings.Contains(content, "╯") && strings.Contains(content, "│")
+}
+
+// Import converts rendered box diagram text to an edd diagram
+func (a *ASCIIImporter) Import(content string) (*diagram.Diagram, error) {
+	grid := newCharGrid(ansiEscape.ReplaceAllString(content, ""))
+	boxes := grid.findBoxes()
+	if len(boxes) == 0 {
+		return nil, fmt.Errorf("no boxes found")
+	}
+
+	d := &diagram.Diagram{Type: "box"}
+	for i, b := range boxes {
+		d.Nodes = append(d.Nodes, diagram.Node{
+			ID:   i,
+			Text: grid.boxText(b),
+		})
+	}
+
+	// Several ports of a box can lead to the same target over shared line
+	// segments; keep one connection per pair, with whichever label was found
+	type edge struct{ from, to int }
+	index := make(map[edge]int)
+	for i, b := range boxes {
+		for _, port := range grid.ports(b) {
+			for _, end := range grid.trace(i, port) {
+				from, to := i, end.box
+				if !end.arrow && to < from {
+					from, to = to, from
+				}
+				if existing, ok := index[edge{from, to}]; ok {
+					if d.Connections[existing].Label == "" {
+						d.Connections[existing].Label = end.label
+					}
+					continue
+				}
+				index[edge{from, to}] = len(d.Connections)
+				d.Connections = append(d.Connections, diagram.Connection{
+					ID:    len(d.Connections),
+					From:  from,
+					To:    to,
+					Arrow: end.arrow,
+					Label: end.label,
+				})
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// GetFormatName returns the format name
+func (a *ASCIIImporter) GetFormatName() string {
+	return "ASCII"
+}
+
+// GetFileExtensions returns common file extensions
+func (a *ASCIIImporter) GetFileExtensions() []string {
+	return []string{".txt"}
+}
+
+// charGrid holds the diagram text as rows of runes, with a record of which
+// cells belong to a box once boxes have been found.
+type charGrid struct {
+	rows  [][]rune
+	owner map[[2]int]int // Cell to index of the box covering it
+}
+
+// box is the rectangle covered by one node, borders included.
+type box struct {
+	left, top, right, bottom int
+}
+
+// tracePoint is a cell reached while following a line, with the direction of
+// travel and the label passed on the way.
+type tracePoint struct {
+	x, y, dir int
+	label     string
+}
+
+// traceEnd is a box a line leads to.
+type traceEnd struct {
+	box   int
+	arrow bool
+	label string
+}
+
+func newCharGrid(content string) *charGrid {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	g := &charGrid{owner: make(map[[2]int]int)}
+	for _, line := range lines {
+		g.rows = append(g.rows, []rune(line))
+	}
+	return g
+}
+
+// at returns the rune at x, y, or a space outside the text.
+func (g *charGrid) at(x, y int) rune {
+	if y < 0 || y >= len(g.rows) || x < 0 || x >= len(g.rows[y]) {
+		return ' '
+	}
+	return g.rows[y][x]
+}
+
+// findBoxes locates every complete rounded box, in reading order, and marks
+// the cells each one covers.
+func (g *charGrid) findBoxes() []box {
+	var boxes []box
+	for y, row := range g.rows {
+		for x, r := range row {
+			if r != '╭' {
+				continue
+			}
+			if _, taken := g.owner[[2]int{x, y}]; taken {
+				continue
+			}
+			b, ok := g.boxAt(x, y)
+			if !ok {
+				continue
+			}
+			for cy := b.top; cy <= b.bottom; cy++ {
+				for cx := b.left; cx <= b.right; cx++ {
+					g.owner[[2]int{cx, cy}] = len(boxes)
+				}
+			}
+			boxes = append(boxes, b)
+		}
+	}
+	return boxes
+}
+
+// boxAt follows the border of a box whose top-left corner is at x, y.
+func (g *charGrid) boxAt(x, y int) (box, bool) {
+	horizontal := func(r rune) bool { return r == '─' || r == '┬' || r == '┴' || r == '┼' }
+	vertical := func(r rune) bool { return r == '│' || r == '├' || r == '┤' || r == '┼' }
+
+	right := x + 1
+	for horizontal(g.at(right, y)) {
+		right++
+	}
+	if g.at(right, y) != '╮' {
+		return box{}, false
+	}
+
+	bottom := y + 1
+	for vertical(g.at(x, bottom)) {
+		bottom++
+	}
+	if g.at(x, bottom) != '╰' || bottom == y+1 {
+		return box{}, false
+	}
+
+	for cy := y + 1; cy < bottom; cy++ {
+		if !vertical(g.at(right, cy)) {
+			return box{}, false
+		}
+	}
+	for cx := x + 1; cx < right; cx++ {
+		if !horizontal(g.at(cx, bottom)) {
+			return box{}, false
+		}
+	}
+	if g.at(right, bottom) != '╯' {
+		return box{}, false
+	}
+	return box{left: x, top: y, right: right, bottom: bottom}, true
+}
+
+// boxText returns the trimmed text lines inside a box, without the blank
+// padding rows at the top and bottom.
+func (g *charGrid) boxText(b box) []string {
+	var lines []string
+	for y := b.top + 1; y < b.bottom; y++ {
+		var line []rune
+		for x := b.left + 1; x < b.right; x++ {
+			line = append(line, g.at(x, y))
+		}
+		lines = append(lines, strings.TrimSpace(string(line)))
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// ports returns where lines leave a box: junctions on its border whose stroke
+// points outward. Each one is given as the first cell outside the box and the
+// direction of travel.
+func (g *charGrid) ports(b box) []tracePoint {
+	var ports []tracePoint
+	for x := b.left + 1; x < b.right; x++ {
+		if lineDirections[g.at(x, b.top)]&dirUp != 0 {
+			ports = append(ports, tracePoint{x: x, y: b.top - 1, dir: dirUp})
+		}
+		if lineDirections[g.at(x, b.bottom)]&dirDown != 0 {
+			ports = append(ports, tracePoint{x: x, y: b.bottom + 1, dir: dirDown})
+		}
+	}
+	for y := b.top + 1; y < b.bottom; y++ {
+		if lineDirections[g.at(b.left, y)]&dirLeft != 0 {
+			ports = append(ports, tracePoint{x: b.left - 1, y: y, dir: dirLeft})
+		}
+		if lineDirections[g.at(b.right, y)]&dirRight != 0 {
+			ports = append(ports, tracePoint{x: b.right + 1, y: y, dir: dirRight})
+		}
+	}
+	return ports
+}
+
+// trace follows the line leaving box source at start through every branch,
+// returning the boxes it reaches. Arrowheads mark directed connections; if
+// the line has none, the boxes whose borders it runs into are returned as
+// undirected ends instead.
+func (g *charGrid) trace(source int, start tracePoint) []traceEnd {
+	var arrows, borders []traceEnd
+	visited := make(map[[3]int]bool)
+	queue := []tracePoint{start}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		key := [3]int{p.x, p.y, p.dir}
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+
+		if b, ok := g.owner[[2]int{p.x, p.y}]; ok {
+			if b != source {
+				borders = append(borders, traceEnd{box: b, label: p.label})
+			}
+			continue
+		}
+
+		r := g.at(p.x, p.y)
+		if dir, ok := arrowDirections[r]; ok {
+			if dir != p.dir {
+				continue
+			}
+			nx, ny := step(p.x, p.y, dir)
+			if b, ok := g.owner[[2]int{nx, ny}]; ok {
+				arrows = append(arrows, traceEnd{box: b, arrow: true, label: p.label})
+			}
+			continue
+		}
+
+		// Inline labels sit in brackets on horizontal runs
+		if (p.dir == dirRight && r == '[') || (p.dir == dirLeft && r == ']') {
+			if next, ok := g.skipLabel(p); ok {
+				queue = append(queue, next)
+			}
+			continue
+		}
+
+		dirs := lineDirections[r]
+		if dirs&opposite(p.dir) == 0 {
+			continue
+		}
+		if p.label == "" && r == '│' {
+			p.label = g.besideLabel(p.x, p.y)
+		}
+		if r == '┼' {
+			// Crossing lines don't join; carry straight on
+			dirs = p.dir
+		}
+		for _, dir := range []int{dirUp, dirRight, dirDown, dirLeft} {
+			if dirs&dir == 0 || dir == opposite(p.dir) {
+				continue
+			}
+			nx, ny := step(p.x, p.y, dir)
+			queue = append(queue, tracePoint{x: nx, y: ny, dir: dir, label: p.label})
+		}
+	}
+
+	if len(arrows) > 0 {
+		return arrows
+	}
+	return borders
+}
+
+// skipLabel reads a bracketed label starting at p and returns the cell just
+// past it, carrying the label text.
+func (g *charGrid) skipLabel(p tracePoint) (tracePoint, bool) {
+	closing, dx := ']', 1
+	if p.dir == dirLeft {
+		closing, dx = '[', -1
+	}
+	for x := p.x + dx; x >= 0 && x < len(g.rows[p.y]); x += dx {
+		if g.at(x, p.y) != closing {
+			continue
+		}
+		lo, hi := p.x, x
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		label := strings.TrimSpace(string(g.rows[p.y][lo+1 : hi]))
+		if p.label != "" {
+			label = p.label
+		}
+		return tracePoint{x: x + dx, y: p.y, dir: p.dir, label: label}, true
+	}
+	return tracePoint{}, false
+}
+
+// besideLabel returns the bracketed label drawn one space to the right or
+// left of a vertical line at x, y, if there is one.
+func (g *charGrid) besideLabel(x, y int) string {
+	if g.at(x+1, y) == ' ' && g.at(x+2, y) == '[' {
+		for end := x + 3; end < len(g.rows[y]); end++ {
+			if g.at(end, y) == ']' {
+				return strings.TrimSpace(string(g.rows[y][x+3 : end]))
+			}
+		}
+	}
+	if g.at(x-1, y) == ' ' && g.at(x-2, y) == ']' {
+		for start := x - 3; start >= 0; start-- {
+			if g.at(start, y) == '[' {
+				return strings.TrimSpace(string(g.rows[y][start+1 : x-2]))
+			}
+		}
+	}
+	return ""
+}
+
+// step moves one cell in dir.
+func step(x, y, dir int) (int, int) {
+	switch dir {
+	case dirUp:
+		return x, y - 1
+	case dirDown:
+		return x, y + 1
+	case dirLeft:
+		return x - 1, y
+	default:
+		return x + 1, y
+	}
+}
+
+// opposite returns the direction pointing back the way dir came.
+func opposite(dir int) int {
+	switch dir {
+	case dirUp:
+		return dirDown
+	case dirDown:
+		return dirUp
+	case dirLeft:
+		return dirRight
+	default:
+		return dirLeft
+	}
+}
@@ -0,0 +1,21 @@
+package importer
+
+import "fmt"
+
+// ImportError reports a syntax problem at a position in the imported source,
+// so it can be found and fixed there
+type ImportError struct {
+	Format string // Name of the format being imported, e.g. "Mermaid"
+	Line   int    // 1-based line number in the source
+	Col    int    // 1-based column, or 0 if only the line is known
+	Msg    string // What is wrong
+}
+
+// Error formats the position and message, e.g.
+// "Mermaid line 3, column 7: malformed arrow"
+func (e *ImportError) Error() string {
+	if e.Col > 0 {
+		return fmt.Sprintf("%s line %d, column %d: %s", e.Format, e.Line, e.Col, e.Msg)
+	}
+	return fmt.Sprintf("%s line %d: %s", e.Format, e.Line, e.Msg)
+}
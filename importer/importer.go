@@ -3,6 +3,7 @@ package importer
 import (
 	"edd/diagram"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -23,7 +24,8 @@ type Importer interface {
 
 // ImporterRegistry manages available importers
 type ImporterRegistry struct {
-	importers []Importer
+	importers   []Importer
+	asciiQuotes bool
 }
 
 // NewImporterRegistry creates a new importer registry
@@ -33,18 +35,55 @@ func NewImporterRegistry() *ImporterRegistry {
 			NewMermaidImporter(),
 			NewPlantUMLImporter(),
 			NewGraphvizImporter(),
+			NewASCIIImporter(), // Before D2, whose detection accepts most text with arrows
 			NewD2Importer(),
+			NewOutlineImporter(), // Last, as almost any indented text is an outline
 		},
 	}
 }
 
-// Register adds a new importer to the registry
-func (r *ImporterRegistry) Register(importer Importer) {
-	r.importers = append(r.importers, importer)
+// Register adds an importer for a new input format. ImportWithFormat selects
+// it by name, and auto-detection uses detector, or the importer's own
+// CanImport if detector is nil. Registered importers are tried before the
+// built-in ones, so a specific detector isn't shadowed by a lenient format.
+func (r *ImporterRegistry) Register(name string, detector func(string) bool, importer Importer) {
+	if detector == nil {
+		detector = importer.CanImport
+	}
+	r.importers = append([]Importer{&registeredImporter{
+		Importer: importer,
+		name:     name,
+		detector: detector,
+	}}, r.importers...)
+}
+
+// registeredImporter gives an importer added with Register its chosen name
+// and detector
+type registeredImporter struct {
+	Importer
+	name     string
+	detector func(string) bool
+}
+
+// CanImport reports whether the registered detector accepts the content
+func (i *registeredImporter) CanImport(content string) bool {
+	return i.detector(content)
+}
+
+// GetFormatName returns the name the importer was registered under
+func (i *registeredImporter) GetFormatName() string {
+	return i.name
+}
+
+// SetASCIIQuotes controls whether imported labels have smart quotes replaced
+// with plain ASCII quotes. Composition of accented letters always happens.
+func (r *ImporterRegistry) SetASCIIQuotes(enabled bool) {
+	r.asciiQuotes = enabled
 }
 
 // DetectFormat attempts to detect the format of the given content
 func (r *ImporterRegistry) DetectFormat(content string) (Importer, error) {
+	content = NormalizeSource(content)
 	for _, imp := range r.importers {
 		if imp.CanImport(content) {
 			return imp, nil
@@ -53,28 +92,42 @@ func (r *ImporterRegistry) DetectFormat(content string) (Importer, error) {
 	return nil, fmt.Errorf("unable to detect format")
 }
 
-// Import attempts to import content using auto-detection
+// Import attempts to import content using auto-detection. Like
+// ImportWithFormat, it accepts content with a byte order mark or CRLF line
+// endings, which are normalized before parsing.
 func (r *ImporterRegistry) Import(content string) (*diagram.Diagram, error) {
+	content = NormalizeSource(content)
 	importer, err := r.DetectFormat(content)
 	if err != nil {
 		return nil, err
 	}
-	return importer.Import(content)
+	return r.normalized(importer.Import(content))
 }
 
 // ImportWithFormat imports content using a specific format
 func (r *ImporterRegistry) ImportWithFormat(content, format string) (*diagram.Diagram, error) {
+	content = NormalizeSource(content)
 	format = strings.ToLower(format)
 
 	for _, imp := range r.importers {
 		if strings.ToLower(imp.GetFormatName()) == format {
-			return imp.Import(content)
+			return r.normalized(imp.Import(content))
 		}
 	}
 
 	return nil, fmt.Errorf("unknown format: %s", format)
 }
 
+// normalized applies label normalization to a successful import, so every
+// caller sees the same text regardless of which importer produced it.
+func (r *ImporterRegistry) normalized(d *diagram.Diagram, err error) (*diagram.Diagram, error) {
+	if err != nil {
+		return nil, err
+	}
+	NormalizeLabels(d, r.asciiQuotes)
+	return d, nil
+}
+
 // GetAvailableFormats returns a list of available import formats
 func (r *ImporterRegistry) GetAvailableFormats() []string {
 	formats := make([]string, len(r.importers))
@@ -82,4 +135,46 @@ func (r *ImporterRegistry) GetAvailableFormats() []string {
 		formats[i] = imp.GetFormatName()
 	}
 	return formats
-}
\ No newline at end of file
+}
+
+// GetImporterByExtension returns the importer that handles the given file extension
+func (r *ImporterRegistry) GetImporterByExtension(ext string) (Importer, error) {
+	ext = strings.ToLower(ext)
+
+	for _, imp := range r.importers {
+		for _, e := range imp.GetFileExtensions() {
+			if e == ext {
+				return imp, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no importer for extension: %s", ext)
+}
+
+// GetImporterForFile returns the importer for a file with the given extension
+// and content. When several importers claim the extension, as the ASCII and
+// outline importers both claim .txt, the first whose detector accepts the
+// content is chosen, falling back to the first to claim it.
+func (r *ImporterRegistry) GetImporterForFile(ext, content string) (Importer, error) {
+	ext = strings.ToLower(ext)
+	content = NormalizeSource(content)
+
+	var claimed []Importer
+	for _, imp := range r.importers {
+		if slices.Contains(imp.GetFileExtensions(), ext) {
+			claimed = append(claimed, imp)
+		}
+	}
+	if len(claimed) == 0 {
+		return nil, fmt.Errorf("no importer for extension: %s", ext)
+	}
+	if len(claimed) > 1 {
+		for _, imp := range claimed {
+			if imp.CanImport(content) {
+				return imp, nil
+			}
+		}
+	}
+	return claimed[0], nil
+}
@@ -0,0 +1,256 @@
+package importer
+
+import (
+	"edd/diagram"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// listImporter reads one node per line after a "nodes:" header
+type listImporter struct{}
+
+func (listImporter) CanImport(content string) bool { return false }
+func (listImporter) GetFormatName() string         { return "List" }
+func (listImporter) GetFileExtensions() []string   { return []string{".list"} }
+
+func (listImporter) Import(content string) (*diagram.Diagram, error) {
+	d := &diagram.Diagram{Type: "box"}
+	for i, line := range strings.Split(strings.TrimPrefix(content, "nodes:\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{line}})
+		}
+	}
+	return d, nil
+}
+
+func TestRegisterCustomImporter(t *testing.T) {
+	registry := NewImporterRegistry()
+	registry.Register("nodelist", func(content string) bool {
+		return strings.HasPrefix(content, "nodes:\n")
+	}, listImporter{})
+
+	content := "nodes:\nWeb -> cache\nDatabase\n"
+
+	// Explicit format uses the registered name, not the importer's own
+	d, err := registry.ImportWithFormat(content, "NodeList")
+	if err != nil {
+		t.Fatalf("ImportWithFormat failed: %v", err)
+	}
+	if len(d.Nodes) != 2 || d.Nodes[0].Text[0] != "Web -> cache" {
+		t.Errorf("Expected the custom importer's nodes, got %+v", d.Nodes)
+	}
+
+	// Detection uses the registered detector, ahead of the built-in formats
+	d, err = registry.Import(content)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(d.Nodes) != 2 {
+		t.Errorf("Expected detection to pick the custom importer, got %+v", d.Nodes)
+	}
+
+	// Content the detector rejects still reaches the built-in importers
+	d, err = registry.Import("graph TD\n    A --> B\n")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(d.Connections) != 1 {
+		t.Errorf("Expected Mermaid to import the connection, got %+v", d.Connections)
+	}
+}
+
+func TestRegisterWithoutDetectorUsesCanImport(t *testing.T) {
+	registry := NewImporterRegistry()
+	registry.Register("nodelist", nil, listImporter{})
+
+	if imp, err := registry.DetectFormat("nodes:\nA\n"); err == nil && imp.GetFormatName() == "nodelist" {
+		t.Errorf("Expected the importer's own CanImport to decline the content")
+	}
+	if _, err := registry.ImportWithFormat("nodes:\nA\n", "nodelist"); err != nil {
+		t.Errorf("Expected explicit format to work without a detector: %v", err)
+	}
+}
+
+func TestMermaidMalformedArrowReportsLine(t *testing.T) {
+	content := "---\ntitle: Checkout\n---\ngraph TD\n    A --> B\n    B -> C\n"
+
+	_, err := NewMermaidImporter().Import(content)
+	var importErr *ImportError
+	if !errors.As(err, &importErr) {
+		t.Fatalf("Expected an ImportError, got %v", err)
+	}
+	if importErr.Format != "Mermaid" || importErr.Line != 6 || importErr.Col != 7 {
+		t.Errorf("Expected Mermaid line 6, column 7, got %+v", importErr)
+	}
+	if got := err.Error(); !strings.HasPrefix(got, "Mermaid line 6, column 7: malformed arrow \"->\"") {
+		t.Errorf("Unexpected message %q", got)
+	}
+
+	// The registry passes the error through unchanged
+	if _, err := NewImporterRegistry().Import(content); !errors.As(err, &importErr) {
+		t.Errorf("Expected the registry to return the ImportError, got %v", err)
+	}
+}
+
+func TestMermaidUnsupportedDiagramTypesAreNamed(t *testing.T) {
+	sources := map[string]string{
+		"gantt":           "gantt\n    title Launch\n    section Build\n    Design :a1, 2024-01-01, 7d\n",
+		"pie":             "pie title Pets\n    \"Dogs\" : 386\n    \"Cats\" : 85\n",
+		"classDiagram":    "classDiagram\n    Animal <|-- Duck\n",
+		"stateDiagram-v2": "stateDiagram-v2\n    [*] --> Still\n    Still --> [*]\n",
+		"erDiagram":       "---\ntitle: Orders\n---\nerDiagram\n    CUSTOMER ||--o{ ORDER : places\n",
+		"journey":         "journey\n    title My day\n    section Work\n      Code: 5: Me\n",
+		"gitGraph":        "gitGraph\n    commit\n    branch develop\n",
+		"mindmap":         "mindmap\n  root((edd))\n    Export\n",
+	}
+
+	for keyword, content := range sources {
+		want := "unsupported Mermaid diagram type: " + keyword
+		if _, err := NewMermaidImporter().Import(content); err == nil || err.Error() != want {
+			t.Errorf("%s: expected error %q, got %v", keyword, want, err)
+		}
+
+		// Detection hands them to the Mermaid importer rather than guessing
+		if _, err := NewImporterRegistry().Import(content); err == nil || err.Error() != want {
+			t.Errorf("%s: expected the registry to report %q, got %v", keyword, want, err)
+		}
+	}
+}
+
+func TestMermaidArrowsInTextAreNotErrors(t *testing.T) {
+	content := "graph LR\n    A[x -> y]\n    A -->|a -> b| B\n    B -.-> C\n    C ==> D\n"
+	if _, err := NewMermaidImporter().Import(content); err != nil {
+		t.Errorf("Expected arrows inside labels to be ignored, got %v", err)
+	}
+}
+
+func TestMermaidEdgeStylesBecomeHints(t *testing.T) {
+	content := `graph TD
+    A -.-> B
+    B ==> C
+    C --> D
+    D --> E
+    linkStyle default stroke:#0f0
+    linkStyle 2 stroke:#ff0000,stroke-width:4px
+    linkStyle 3 stroke:blue,stroke-dasharray: 2 2;
+`
+	d, err := NewMermaidImporter().Import(content)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(d.Connections) != 4 {
+		t.Fatalf("Expected 4 connections, got %d", len(d.Connections))
+	}
+
+	want := []map[string]string{
+		{"style": "dashed", "color": "green"},
+		{"style": "thick", "color": "green"},
+		{"style": "thick", "color": "red"},
+		{"style": "dotted", "color": "blue"},
+	}
+	for i, hints := range want {
+		for key, value := range hints {
+			if got := d.Connections[i].Hints[key]; got != value {
+				t.Errorf("Connection %d: expected %s %q, got %q", i, key, value, got)
+			}
+		}
+	}
+	if len(d.Nodes) != 5 {
+		t.Errorf("Expected linkStyle lines not to create nodes, got %d nodes", len(d.Nodes))
+	}
+}
+
+func TestMermaidSequenceMessageWithoutText(t *testing.T) {
+	content := "sequenceDiagram\n    Alice->>Bob: Hello\n    Bob->>Alice\n    Note right of Bob: a > b\n"
+
+	_, err := NewMermaidImporter().Import(content)
+	var importErr *ImportError
+	if !errors.As(err, &importErr) || importErr.Line != 3 {
+		t.Fatalf("Expected an ImportError on line 3, got %v", err)
+	}
+}
+
+func TestOutlineImportsThreeLevels(t *testing.T) {
+	content := "Product\n  Design\n    Wireframes\n    Prototype\n  Build\n    - API\n\nLaunch\n"
+
+	d, err := NewImporterRegistry().Import(content)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	var names []string
+	for _, n := range d.Nodes {
+		names = append(names, n.Text[0])
+	}
+	if got := strings.Join(names, ","); got != "Product,Design,Wireframes,Prototype,Build,API,Launch" {
+		t.Fatalf("Unexpected nodes %s", got)
+	}
+
+	var edges []string
+	for _, c := range d.Connections {
+		if !c.Arrow {
+			t.Errorf("Expected connection %d to be an arrow", c.ID)
+		}
+		edges = append(edges, names[c.From]+">"+names[c.To])
+	}
+	if got := strings.Join(edges, ","); got != "Product>Design,Design>Wireframes,Design>Prototype,Product>Build,Build>API" {
+		t.Errorf("Unexpected connections %s", got)
+	}
+}
+
+func TestOutlineInconsistentIndentation(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		line    int
+		msg     string
+	}{
+		{"uneven", "Root\n  Child\n   Odd\n", 3, "indentation of 3 spaces is not a multiple of the 2 spaces used on line 2"},
+		{"tabs and spaces", "Root\n\tChild\n  Other\n", 3, "indented with 2 spaces but line 2 uses 1 tab"},
+		{"skipped level", "Root\n  Child\n      Deep\n", 3, "indented 2 levels below the line above; nest one level at a time"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewOutlineImporter().Import(tc.content)
+			var importErr *ImportError
+			if !errors.As(err, &importErr) {
+				t.Fatalf("Expected an ImportError, got %v", err)
+			}
+			if importErr.Line != tc.line || importErr.Msg != tc.msg {
+				t.Errorf("Expected line %d %q, got %+v", tc.line, tc.msg, importErr)
+			}
+		})
+	}
+}
+
+func TestOutlineDetectionLeavesOtherFormats(t *testing.T) {
+	outline := NewOutlineImporter()
+	for _, content := range []string{
+		"graph TD\n    A --> B\n",
+		"a -> b\n  b -> c\n",
+		"╭───╮\n│ A │\n╰───╯\n",
+		"Just one line\n",
+		"Flat\nList\n",
+	} {
+		if outline.CanImport(content) {
+			t.Errorf("Expected %q not to be detected as an outline", content)
+		}
+	}
+}
+
+func TestImporterForFileSniffsContentOfSharedExtension(t *testing.T) {
+	registry := NewImporterRegistry()
+	for content, want := range map[string]string{
+		"Trip\n  Packing\n  Route\n": "Outline",
+		"╭───╮\n│ A │\n╰───╯\n":      "ASCII",
+		"graph TD\n    A --> B\n":    "ASCII",
+	} {
+		imp, err := registry.GetImporterForFile(".txt", content)
+		if err != nil || imp.GetFormatName() != want {
+			t.Errorf("Expected %q in a .txt file picked for %s, got %v (%v)", content, want, imp, err)
+		}
+	}
+	if imp, err := registry.GetImporterForFile(".mmd", "Trip\n  Packing\n"); err != nil || imp.GetFormatName() != "Mermaid" {
+		t.Errorf("Expected an unshared extension to decide alone, got %v (%v)", imp, err)
+	}
+}
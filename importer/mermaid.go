@@ -4,6 +4,7 @@ import (
 	"edd/diagram"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -17,7 +18,7 @@ func NewMermaidImporter() *MermaidImporter {
 
 // CanImport checks if the content is a Mermaid diagram
 func (m *MermaidImporter) CanImport(content string) bool {
-	content = strings.TrimSpace(content)
+	_, content = splitMermaidFrontMatter(content)
 	// Check for common Mermaid diagram types
 	return strings.HasPrefix(content, "graph ") ||
 		strings.HasPrefix(content, "flowchart ") ||
@@ -26,21 +27,80 @@ func (m *MermaidImporter) CanImport(content string) bool {
 		strings.Contains(content, "graph TD") ||
 		strings.Contains(content, "graph TB") ||
 		strings.Contains(content, "graph RL") ||
-		strings.Contains(content, "graph BT")
+		strings.Contains(content, "graph BT") ||
+		unsupportedMermaidTypes[mermaidDiagramType(content)]
+}
+
+// unsupportedMermaidTypes holds the keywords opening the Mermaid diagram types
+// edd can't import, so that they are reported by name instead of failing to
+// parse as something else
+var unsupportedMermaidTypes = map[string]bool{
+	"classDiagram": true, "classDiagram-v2": true, "stateDiagram": true, "stateDiagram-v2": true,
+	"erDiagram": true, "journey": true, "gantt": true, "pie": true, "quadrantChart": true,
+	"requirementDiagram": true, "gitGraph": true, "mindmap": true, "timeline": true,
+	"zenuml": true, "sankey-beta": true, "xychart-beta": true, "block-beta": true,
+	"packet-beta": true, "kanban": true, "architecture-beta": true, "radar-beta": true,
+	"C4Context": true, "C4Container": true, "C4Component": true, "C4Dynamic": true, "C4Deployment": true,
+}
+
+// mermaidDiagramType returns the diagram type keyword a Mermaid body opens
+// with, the first word of its first line
+func mermaidDiagramType(content string) string {
+	line, _, _ := strings.Cut(content, "\n")
+	if fields := strings.Fields(line); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
 }
 
 // Import converts Mermaid content to edd diagram
 func (m *MermaidImporter) Import(content string) (*diagram.Diagram, error) {
-	content = strings.TrimSpace(content)
+	source := content
+	title, content := splitMermaidFrontMatter(content)
+
+	// Line numbers in errors count from the top of the original source
+	firstLine := 1 + strings.Count(source[:strings.Index(source, content)], "\n")
 
 	// Determine diagram type
+	var d *diagram.Diagram
+	var err error
 	if strings.HasPrefix(content, "sequenceDiagram") {
-		return m.importSequenceDiagram(content)
+		d, err = m.importSequenceDiagram(content, firstLine)
 	} else if strings.HasPrefix(content, "graph") || strings.HasPrefix(content, "flowchart") {
-		return m.importFlowchart(content)
+		d, err = m.importFlowchart(content, firstLine)
+	} else if keyword := mermaidDiagramType(content); keyword != "" {
+		return nil, fmt.Errorf("unsupported Mermaid diagram type: %s", keyword)
+	} else {
+		return nil, fmt.Errorf("unsupported Mermaid diagram type")
+	}
+
+	if err == nil && title != "" {
+		d.Metadata.Name = title
+	}
+	return d, err
+}
+
+// splitMermaidFrontMatter separates a leading "---" front matter block from
+// the diagram body, returning its title (if any) and the trimmed body
+func splitMermaidFrontMatter(content string) (title, body string) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "---") {
+		return "", content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "---" {
+			return title, strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+		}
+		if value, ok := strings.CutPrefix(line, "title:"); ok {
+			title = strings.TrimSpace(value)
+		}
 	}
 
-	return nil, fmt.Errorf("unsupported Mermaid diagram type")
+	// Unterminated front matter; leave the content alone
+	return "", content
 }
 
 // GetFormatName returns the format name
@@ -53,8 +113,23 @@ func (m *MermaidImporter) GetFileExtensions() []string {
 	return []string{".mmd", ".mermaid"}
 }
 
-// importSequenceDiagram imports a Mermaid sequence diagram
-func (m *MermaidImporter) importSequenceDiagram(content string) (*diagram.Diagram, error) {
+// mermaidSequenceKeywords start sequence diagram lines that aren't messages
+var mermaidSequenceKeywords = []string{
+	"note ", "loop", "alt", "else", "opt", "par", "and", "critical", "break",
+	"rect", "end", "activate ", "deactivate ", "autonumber", "title", "box", "create ", "destroy ",
+	"link ", "links ",
+}
+
+// malformedFlowchartArrow matches a one-character shaft, such as "->" or "=>",
+// where Mermaid needs at least two
+var malformedFlowchartArrow = regexp.MustCompile(`(^|[^-=.<])[-=]>`)
+
+// edgeLabelPattern matches a "|text|" edge label
+var edgeLabelPattern = regexp.MustCompile(`\|[^|]*\|`)
+
+// importSequenceDiagram imports a Mermaid sequence diagram. firstLine is the
+// source line number of the "sequenceDiagram" line, for error positions.
+func (m *MermaidImporter) importSequenceDiagram(content string, firstLine int) (*diagram.Diagram, error) {
 	d := &diagram.Diagram{
 		Type: "sequence",
 	}
@@ -64,8 +139,8 @@ func (m *MermaidImporter) importSequenceDiagram(content string) (*diagram.Diagra
 	nextID := 0
 
 	lines := strings.Split(content, "\n")
-	for _, line := range lines[1:] { // Skip the "sequenceDiagram" line
-		line = strings.TrimSpace(line)
+	for i, rawLine := range lines[1:] { // Skip the "sequenceDiagram" line
+		line := strings.TrimSpace(rawLine)
 		if line == "" || strings.HasPrefix(line, "%%") {
 			continue // Skip empty lines and comments
 		}
@@ -150,6 +225,18 @@ func (m *MermaidImporter) importSequenceDiagram(content string) (*diagram.Diagra
 				}
 
 				d.Connections = append(d.Connections, conn)
+			} else if arrow := strings.Index(line, ">"); arrow > 0 && !hasMermaidKeyword(line) {
+				// Looks like a message but doesn't parse as one
+				msg := "malformed message, expected \"From->>To: text\""
+				if !strings.Contains(line, ":") {
+					msg = "message is missing \": text\" after the participant"
+				}
+				return nil, &ImportError{
+					Format: m.GetFormatName(),
+					Line:   firstLine + i + 1,
+					Col:    strings.Index(rawLine, line) + 1,
+					Msg:    msg,
+				}
 			}
 		}
 	}
@@ -157,8 +244,37 @@ func (m *MermaidImporter) importSequenceDiagram(content string) (*diagram.Diagra
 	return d, nil
 }
 
-// importFlowchart imports a Mermaid flowchart/graph
-func (m *MermaidImporter) importFlowchart(content string) (*diagram.Diagram, error) {
+// blankNodeText replaces the text inside a node declaration with spaces, so
+// arrows in labels aren't mistaken for connections. The length is kept so
+// columns still line up with the source.
+func blankNodeText(declaration string) string {
+	open := strings.IndexAny(declaration, "[({>")
+	if open < 0 {
+		return declaration
+	}
+	return declaration[:open] + strings.Repeat(" ", len(declaration)-open)
+}
+
+// blankText replaces text with the same number of spaces
+func blankText(text string) string {
+	return strings.Repeat(" ", len(text))
+}
+
+// hasMermaidKeyword reports whether a sequence diagram line starts with a
+// keyword rather than a message
+func hasMermaidKeyword(line string) bool {
+	lower := strings.ToLower(line)
+	for _, keyword := range mermaidSequenceKeywords {
+		if strings.HasPrefix(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// importFlowchart imports a Mermaid flowchart/graph. firstLine is the source
+// line number of the "graph" or "flowchart" line, for error positions.
+func (m *MermaidImporter) importFlowchart(content string, firstLine int) (*diagram.Diagram, error) {
 	d := &diagram.Diagram{
 		Type: "box",
 	}
@@ -184,9 +300,13 @@ func (m *MermaidImporter) importFlowchart(content string) (*diagram.Diagram, err
 	// Track current subgraph
 	currentSubgraph := ""
 
+	// linkStyle lines refer to connections by their position in the whole
+	// diagram, so they are applied once every connection has been read
+	var linkStyles []string
+
 	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	for i, rawLine := range lines {
+		line := strings.TrimSpace(rawLine)
 		if line == "" || strings.HasPrefix(line, "%%") {
 			continue
 		}
@@ -208,6 +328,11 @@ func (m *MermaidImporter) importFlowchart(content string) (*diagram.Diagram, err
 			continue
 		}
 
+		if strings.HasPrefix(line, "linkStyle ") {
+			linkStyles = append(linkStyles, line)
+			continue
+		}
+
 		// Check for notes
 		if matches := notePattern.FindStringSubmatch(line); matches != nil {
 			nodeID := matches[1]
@@ -225,6 +350,22 @@ func (m *MermaidImporter) importFlowchart(content string) (*diagram.Diagram, err
 			continue
 		}
 
+		// Arrows need a shaft of at least two characters; outside of node
+		// text, a shorter one is a mistake rather than something to skip
+		bare := edgeLabelPattern.ReplaceAllStringFunc(nodePattern.ReplaceAllStringFunc(line, blankNodeText), blankText)
+		if loc := malformedFlowchartArrow.FindStringIndex(bare); loc != nil {
+			col := loc[0] + 1
+			if bare[loc[0]] != '-' && bare[loc[0]] != '=' {
+				col++
+			}
+			return nil, &ImportError{
+				Format: m.GetFormatName(),
+				Line:   firstLine + i,
+				Col:    strings.Index(rawLine, line) + col,
+				Msg:    fmt.Sprintf("malformed arrow %q, use \"-->\" or \"==>\"", line[col-1:col+1]),
+			}
+		}
+
 		// Check for node declarations
 		if matches := nodePattern.FindAllStringSubmatch(line, -1); matches != nil {
 			for _, match := range matches {
@@ -346,5 +487,113 @@ func (m *MermaidImporter) importFlowchart(content string) (*diagram.Diagram, err
 		}
 	}
 
+	applyMermaidLinkStyles(d, linkStyles)
 	return d, nil
-}
\ No newline at end of file
+}
+
+// linkStylePattern matches "linkStyle <default|0,1,...> <properties>"
+var linkStylePattern = regexp.MustCompile(`^linkStyle\s+(default|\d+(?:\s*,\s*\d+)*)\s+(.+?);?$`)
+
+// applyMermaidLinkStyles turns linkStyle directives into connection hints:
+// the stroke color becomes a "color" hint, and a wide stroke or a dash
+// pattern becomes a "thick", "dashed" or "dotted" style. A "default"
+// directive applies to every connection, and the numbered ones after it.
+func applyMermaidLinkStyles(d *diagram.Diagram, lines []string) {
+	apply := func(conn *diagram.Connection, hints map[string]string) {
+		for key, value := range hints {
+			conn.Hints[key] = value
+		}
+	}
+
+	var numbered [][2]string
+	for _, line := range lines {
+		matches := linkStylePattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		if matches[1] == "default" {
+			hints := mermaidLinkStyleHints(matches[2])
+			for i := range d.Connections {
+				apply(&d.Connections[i], hints)
+			}
+			continue
+		}
+		numbered = append(numbered, [2]string{matches[1], matches[2]})
+	}
+
+	for _, style := range numbered {
+		hints := mermaidLinkStyleHints(style[1])
+		for _, index := range strings.Split(style[0], ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(index))
+			if err == nil && i < len(d.Connections) {
+				apply(&d.Connections[i], hints)
+			}
+		}
+	}
+}
+
+// mermaidLinkStyleHints converts linkStyle CSS properties, such as
+// "stroke:#f00,stroke-width:4px,stroke-dasharray: 5 5", to connection hints
+func mermaidLinkStyleHints(properties string) map[string]string {
+	hints := make(map[string]string)
+
+	// Commas separate properties, but may also separate dash lengths
+	var props []string
+	for _, part := range strings.Split(properties, ",") {
+		if !strings.Contains(part, ":") && len(props) > 0 {
+			props[len(props)-1] += " " + part
+			continue
+		}
+		props = append(props, part)
+	}
+
+	for _, prop := range props {
+		name, value, ok := strings.Cut(prop, ":")
+		if !ok {
+			continue
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		switch name {
+		case "stroke":
+			hints["color"] = mermaidColor(value)
+		case "stroke-width":
+			if width, err := strconv.Atoi(strings.TrimSuffix(value, "px")); err == nil && width >= 3 {
+				hints["style"] = "thick"
+			}
+		case "stroke-dasharray":
+			// Short dashes read as dots
+			if dash, err := strconv.Atoi(strings.Fields(value + " ")[0]); err == nil && dash <= 2 {
+				hints["style"] = "dotted"
+			} else {
+				hints["style"] = "dashed"
+			}
+		}
+	}
+	return hints
+}
+
+// mermaidColor maps a CSS color to the nearest color name edd draws with,
+// keeping colors it doesn't recognize without their "#"
+func mermaidColor(value string) string {
+	value = strings.ToLower(strings.TrimPrefix(value, "#"))
+	if len(value) == 3 && strings.Trim(value, "0123456789abcdef") == "" {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	switch value {
+	case "ff0000", "red":
+		return "red"
+	case "00ff00", "008000", "green":
+		return "green"
+	case "0000ff", "blue":
+		return "blue"
+	case "ffff00", "ffcc00", "yellow":
+		return "yellow"
+	case "ff00ff", "magenta":
+		return "magenta"
+	case "00ffff", "cyan":
+		return "cyan"
+	case "ffffff", "white":
+		return "white"
+	}
+	return value
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"edd/diagram"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// smartQuotes maps typographic quotes to their ASCII equivalents. Editors and
+// word processors substitute these automatically, so they turn up in pasted
+// diagram sources.
+var smartQuotes = strings.NewReplacer(
+	"\u2018", "'", // ‘
+	"\u2019", "'", // ’
+	"\u201A", "'", // ‚
+	"\u201B", "'", // ‛
+	"\u201C", `"`, // “
+	"\u201D", `"`, // ”
+	"\u201E", `"`, // „
+	"\u201F", `"`, // ‟
+	"\u2032", "'", // ′
+	"\u2033", `"`, // ″
+)
+
+// NormalizeSource prepares text for line-based parsing: a leading UTF-8 byte
+// order mark is dropped and Windows (CRLF) and old Mac (CR) line endings
+// become LF.
+func NormalizeSource(content string) string {
+	content = strings.TrimPrefix(content, "\uFEFF")
+	if !strings.Contains(content, "\r") {
+		return content
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\r", "\n")
+}
+
+// NormalizeLabels rewrites node text and connection labels so each visible
+// character is a single rune where Unicode allows. Text is put in
+// Normalization Form C, so decomposed accents (a letter followed by a
+// combining mark) are composed into their precomposed form, which keeps
+// width calculations in line with what the terminal draws. When asciiQuotes
+// is set, smart quotes are also replaced with plain ASCII quotes.
+func NormalizeLabels(d *diagram.Diagram, asciiQuotes bool) {
+	if d == nil {
+		return
+	}
+	for i := range d.Nodes {
+		for j, line := range d.Nodes[i].Text {
+			d.Nodes[i].Text[j] = normalizeLabel(line, asciiQuotes)
+		}
+	}
+	for i := range d.Connections {
+		d.Connections[i].Label = normalizeLabel(d.Connections[i].Label, asciiQuotes)
+	}
+}
+
+// normalizeLabel puts s in Unicode Normalization Form C, composing
+// combining marks onto the preceding letter wherever a precomposed character
+// exists, and optionally transliterates smart quotes. Marks with no
+// precomposed form are kept as they are.
+func normalizeLabel(s string, asciiQuotes bool) string {
+	if asciiQuotes {
+		s = smartQuotes.Replace(s)
+	}
+	return norm.NFC.String(s)
+}
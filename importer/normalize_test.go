@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func TestImportComposesDecomposedAccents(t *testing.T) {
+	registry := NewImporterRegistry()
+	// Labels typed with combining marks rather than precomposed letters
+	content := "graph TD\n    A[Café]\n    B[Naïve]\n    A -->|résumé| B\n"
+
+	d, err := registry.Import(content)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(d.Nodes) != 2 || len(d.Connections) != 1 {
+		t.Fatalf("Expected 2 nodes and 1 connection, got %d and %d", len(d.Nodes), len(d.Connections))
+	}
+
+	if got, want := d.Nodes[0].Text[0], "Café"; got != want {
+		t.Errorf("Node text = %q, want %q", got, want)
+	}
+	if got, want := d.Nodes[1].Text[0], "Naïve"; got != want {
+		t.Errorf("Node text = %q, want %q", got, want)
+	}
+	if got, want := d.Connections[0].Label, "résumé"; got != want {
+		t.Errorf("Connection label = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeLabel(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		asciiQuotes bool
+		want        string
+	}{
+		{"plain text", "Start", false, "Start"},
+		{"decomposed accent", "é", false, "é"},
+		{"two marks", "ệ", false, "ệ"},
+		{"greek accent", "ά", false, "ά"},
+		{"hangul jamo", "가", false, "가"},
+		{"marks out of order", "ậ", false, "ậ"},
+		{"mark without precomposed form", "x́", false, "x́"},
+		{"leading mark", "́a", false, "́a"},
+		{"smart quotes kept", "“OK”", false, "“OK”"},
+		{"smart quotes replaced", "“it’s”", true, `"it's"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeLabel(tt.input, tt.asciiQuotes); got != tt.want {
+				t.Errorf("normalizeLabel(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportWithFormatReplacesSmartQuotes(t *testing.T) {
+	registry := NewImporterRegistry()
+	registry.SetASCIIQuotes(true)
+
+	d, err := registry.ImportWithFormat("graph TD\n    A[“Quoted”]\n    B[Don’t]\n    A --> B\n", "mermaid")
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if got, want := d.Nodes[0].Text[0], `"Quoted"`; got != want {
+		t.Errorf("Node text = %q, want %q", got, want)
+	}
+	if got, want := d.Nodes[1].Text[0], "Don't"; got != want {
+		t.Errorf("Node text = %q, want %q", got, want)
+	}
+}
+
+func TestImportWindowsLineEndingsAndBOM(t *testing.T) {
+	registry := NewImporterRegistry()
+	tests := []struct {
+		name    string
+		content string
+		format  string
+	}{
+		{"mermaid detected", "\uFEFFgraph TD\r\n    A[Start]\r\n    B[End]\r\n    A --> B\r\n", ""},
+		{"mermaid explicit", "\uFEFFgraph TD\r\n    A[Start]\r\n    B[End]\r\n    A --> B\r\n", "mermaid"},
+		{"plantuml detected", "\uFEFF@startuml\r\nparticipant Start\r\nparticipant End\r\nStart -> End: go\r\n@enduml\r\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d *diagram.Diagram
+			var err error
+			if tt.format == "" {
+				d, err = registry.Import(tt.content)
+			} else {
+				d, err = registry.ImportWithFormat(tt.content, tt.format)
+			}
+			if err != nil {
+				t.Fatalf("Import failed: %v", err)
+			}
+			if len(d.Nodes) != 2 || len(d.Connections) != 1 {
+				t.Fatalf("Expected 2 nodes and 1 connection, got %d and %d", len(d.Nodes), len(d.Connections))
+			}
+			for i, want := range []string{"Start", "End"} {
+				if got := d.Nodes[i].Text[0]; got != want {
+					t.Errorf("Node %d text = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeSource(t *testing.T) {
+	if got, want := NormalizeSource("\uFEFFa\r\nb\rc\n"), "a\nb\nc\n"; got != want {
+		t.Errorf("NormalizeSource = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"edd/diagram"
+	"fmt"
+	"strings"
+)
+
+// OutlineImporter imports an indented outline as a tree, for quick mind
+// maps. Each line becomes a node connected from the nearest line above it
+// that is indented one level less.
+type OutlineImporter struct{}
+
+// NewOutlineImporter creates a new outline importer
+func NewOutlineImporter() *OutlineImporter {
+	return &OutlineImporter{}
+}
+
+// CanImport checks if the content looks like an outline: several plain lines
+// starting unindented, at least one of them nested, and no diagram syntax.
+// Indentation mistakes are left for Import to report.
+func (o *OutlineImporter) CanImport(content string) bool {
+	lines := 0
+	nested := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		if lines == 0 && indented {
+			return false
+		}
+		if strings.ContainsAny(line, "{}[];|<>╭╮╰╯┌┐└┘│─") || strings.Contains(line, "--") || strings.Contains(line, "=>") {
+			return false
+		}
+		lines++
+		nested = nested || indented
+	}
+	if lines < 2 || !nested {
+		return false
+	}
+
+	first := strings.TrimSpace(content)
+	for _, marker := range []string{"@startuml", "graph", "digraph", "flowchart", "sequenceDiagram"} {
+		if strings.HasPrefix(first, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// Import converts the outline to a box diagram. Lines may start with a "-",
+// "*" or "+" bullet, which is dropped. Indentation must use either tabs or
+// spaces throughout, in steps of the width first used.
+func (o *OutlineImporter) Import(content string) (*diagram.Diagram, error) {
+	d := &diagram.Diagram{Type: "box"}
+
+	var (
+		unit    string // Indentation of the first nested line
+		unitRef int    // Line the unit was taken from
+		parents []int  // Node ID at each depth above the current line
+	)
+	for i, line := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		line = strings.TrimRight(line, " \t\r")
+		text := strings.TrimLeft(line, " \t")
+		if text == "" {
+			continue
+		}
+		indent := line[:len(line)-len(text)]
+
+		depth := 0
+		if indent != "" {
+			if strings.Contains(indent, " ") && strings.Contains(indent, "\t") {
+				return nil, &ImportError{Format: "Outline", Line: lineNum, Col: 1,
+					Msg: "indentation mixes tabs and spaces"}
+			}
+			if unit == "" {
+				unit, unitRef = indent, lineNum
+			}
+			if indent[0] != unit[0] {
+				return nil, &ImportError{Format: "Outline", Line: lineNum, Col: 1,
+					Msg: fmt.Sprintf("indented with %s but line %d uses %s", describeIndent(indent), unitRef, describeIndent(unit))}
+			}
+			if len(indent)%len(unit) != 0 {
+				return nil, &ImportError{Format: "Outline", Line: lineNum, Col: len(indent) + 1,
+					Msg: fmt.Sprintf("indentation of %s is not a multiple of the %s used on line %d",
+						describeIndent(indent), describeIndent(unit), unitRef)}
+			}
+			depth = len(indent) / len(unit)
+		}
+		if depth > len(parents) {
+			return nil, &ImportError{Format: "Outline", Line: lineNum, Col: len(indent) + 1,
+				Msg: fmt.Sprintf("indented %d levels below the line above; nest one level at a time", depth-len(parents)+1)}
+		}
+
+		for _, bullet := range []string{"- ", "* ", "+ "} {
+			if strings.HasPrefix(text, bullet) {
+				text = strings.TrimSpace(text[len(bullet):])
+				break
+			}
+		}
+
+		id := len(d.Nodes)
+		d.Nodes = append(d.Nodes, diagram.Node{ID: id, Text: []string{text}})
+		parents = append(parents[:depth], id)
+		if depth > 0 {
+			d.Connections = append(d.Connections, diagram.Connection{
+				ID:    len(d.Connections),
+				From:  parents[depth-1],
+				To:    id,
+				Arrow: true,
+			})
+		}
+	}
+
+	if len(d.Nodes) == 0 {
+		return nil, fmt.Errorf("outline has no items")
+	}
+	return d, nil
+}
+
+// describeIndent names a run of indentation, e.g. "2 spaces" or "1 tab"
+func describeIndent(indent string) string {
+	kind := "space"
+	if indent[0] == '\t' {
+		kind = "tab"
+	}
+	if len(indent) != 1 {
+		kind += "s"
+	}
+	return fmt.Sprintf("%d %s", len(indent), kind)
+}
+
+// GetFormatName returns the format name
+func (o *OutlineImporter) GetFormatName() string {
+	return "Outline"
+}
+
+// GetFileExtensions returns common file extensions
+func (o *OutlineImporter) GetFileExtensions() []string {
+	return []string{".outline", ".txt"}
+}
@@ -0,0 +1,108 @@
+package layout
+
+import "edd/diagram"
+
+// DefaultAlignTolerance is the largest center offset, in cells, that
+// AlignNearlyStraight will remove when a diagram asks for alignment.
+const DefaultAlignTolerance = 2
+
+// AlignNearlyStraight nudges connected nodes whose centers are offset by at
+// most tolerance cells across the direction of the connection, so the router
+// can draw a straight line instead of a small jog. Side-by-side nodes are
+// aligned vertically and stacked nodes horizontally. For each connection the
+// target is moved if it can be, otherwise the source; a node is moved at most
+// once, never onto another node or past the top or left edge, and nodes
+// already aligned by an earlier connection or pinned by "x"/"y" hints stay
+// put. The input slice is not modified.
+func AlignNearlyStraight(nodes []diagram.Node, connections []diagram.Connection, tolerance int) []diagram.Node {
+	result := make([]diagram.Node, len(nodes))
+	copy(result, nodes)
+	if tolerance <= 0 {
+		return result
+	}
+
+	index := make(map[int]int, len(result))
+	for i, node := range result {
+		index[node.ID] = i
+	}
+
+	fixed := make(map[int]bool)
+	for i, node := range result {
+		if _, _, hasX, hasY := PinnedPosition(node); hasX || hasY {
+			fixed[i] = true
+		}
+	}
+	for _, conn := range connections {
+		if conn.From == conn.To {
+			continue
+		}
+		si, ok := index[conn.From]
+		if !ok {
+			continue
+		}
+		ti, ok := index[conn.To]
+		if !ok {
+			continue
+		}
+
+		dx, dy, ok := alignmentOffset(result[si], result[ti], tolerance)
+		if !ok {
+			continue
+		}
+
+		// Prefer nudging the target toward the source, then the reverse
+		switch {
+		case !fixed[ti] && canMove(result, ti, -dx, -dy):
+			result[ti].X -= dx
+			result[ti].Y -= dy
+		case !fixed[si] && canMove(result, si, dx, dy):
+			result[si].X += dx
+			result[si].Y += dy
+		default:
+			continue
+		}
+		fixed[si] = true
+		fixed[ti] = true
+	}
+
+	return result
+}
+
+// alignmentOffset returns how far target's center is from source's across
+// the direction between them, if the two are side by side or stacked and
+// that offset is non-zero and within tolerance.
+func alignmentOffset(source, target diagram.Node, tolerance int) (dx, dy int, ok bool) {
+	separatedX := source.X+source.Width <= target.X || target.X+target.Width <= source.X
+	separatedY := source.Y+source.Height <= target.Y || target.Y+target.Height <= source.Y
+	offset := diagram.Point{X: target.Center().X - source.Center().X, Y: target.Center().Y - source.Center().Y}
+
+	switch {
+	case separatedX && !separatedY && offset.Y != 0 && Abs(offset.Y) <= tolerance:
+		return 0, offset.Y, true
+	case separatedY && !separatedX && offset.X != 0 && Abs(offset.X) <= tolerance:
+		return offset.X, 0, true
+	}
+	return 0, 0, false
+}
+
+// canMove reports whether nodes[i] can shift by (dx, dy) without leaving
+// the top-left quadrant or touching any other node (a one-cell gap is kept).
+func canMove(nodes []diagram.Node, i, dx, dy int) bool {
+	moved := nodes[i]
+	moved.X += dx
+	moved.Y += dy
+	if moved.X < 0 || moved.Y < 0 {
+		return false
+	}
+
+	for j, other := range nodes {
+		if j == i {
+			continue
+		}
+		if moved.X-1 < other.X+other.Width && other.X < moved.X+moved.Width+1 &&
+			moved.Y-1 < other.Y+other.Height && other.Y < moved.Y+moved.Height+1 {
+			return false
+		}
+	}
+	return true
+}
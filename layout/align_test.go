@@ -0,0 +1,103 @@
+package layout
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func TestAlignNearlyStraight(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []diagram.Node
+		want  map[int]diagram.Point // expected positions after alignment
+	}{
+		{
+			name: "side by side offset by one",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 9, Height: 3},
+				{ID: 2, X: 20, Y: 1, Width: 9, Height: 3},
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 20, Y: 0}},
+		},
+		{
+			name: "stacked offset by two",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 9, Height: 3},
+				{ID: 2, X: 2, Y: 8, Width: 9, Height: 3},
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 0, Y: 8}},
+		},
+		{
+			name: "offset beyond tolerance is left alone",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 9, Height: 3},
+				{ID: 2, X: 20, Y: 3, Width: 9, Height: 7},
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 20, Y: 3}},
+		},
+		{
+			name: "source moves when the target is boxed in",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 1, Width: 9, Height: 3},
+				{ID: 2, X: 20, Y: 0, Width: 9, Height: 3},
+				{ID: 3, X: 20, Y: 4, Width: 9, Height: 3}, // directly below the target
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 20, Y: 0}, 3: {X: 20, Y: 4}},
+		},
+		{
+			name: "pinned target stays and the source moves",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 9, Height: 3},
+				{ID: 2, X: 20, Y: 1, Width: 9, Height: 3, Hints: map[string]string{"y": "1"}},
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 1}, 2: {X: 20, Y: 1}},
+		},
+		{
+			name: "pinned on both ends is left alone",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 9, Height: 3, Hints: map[string]string{"x": "0"}},
+				{ID: 2, X: 20, Y: 1, Width: 9, Height: 3, Hints: map[string]string{"y": "1"}},
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 20, Y: 1}},
+		},
+		{
+			name: "source is not moved above the top edge",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 9, Height: 5},
+				{ID: 2, X: 20, Y: 0, Width: 9, Height: 3},
+				{ID: 3, X: 20, Y: 4, Width: 9, Height: 3}, // directly below the target
+			},
+			want: map[int]diagram.Point{1: {X: 0, Y: 0}, 2: {X: 20, Y: 0}, 3: {X: 20, Y: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns := []diagram.Connection{{From: 1, To: 2}}
+			original := append([]diagram.Node(nil), tt.nodes...)
+			result := AlignNearlyStraight(tt.nodes, conns, DefaultAlignTolerance)
+
+			for _, node := range result {
+				if want := tt.want[node.ID]; node.X != want.X || node.Y != want.Y {
+					t.Errorf("Node %d at (%d,%d), want (%d,%d)", node.ID, node.X, node.Y, want.X, want.Y)
+				}
+			}
+			for i := range original {
+				if tt.nodes[i].X != original[i].X || tt.nodes[i].Y != original[i].Y {
+					t.Errorf("Input node %d was modified", tt.nodes[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestAlignNearlyStraightDisabled(t *testing.T) {
+	nodes := []diagram.Node{
+		{ID: 1, X: 0, Y: 0, Width: 9, Height: 3},
+		{ID: 2, X: 20, Y: 1, Width: 9, Height: 3},
+	}
+	result := AlignNearlyStraight(nodes, []diagram.Connection{{From: 1, To: 2}}, 0)
+	if result[1].Y != 1 {
+		t.Errorf("Expected tolerance 0 to leave nodes alone, target moved to y=%d", result[1].Y)
+	}
+}
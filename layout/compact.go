@@ -0,0 +1,98 @@
+package layout
+
+import (
+	"edd/diagram"
+	"sort"
+)
+
+// DefaultCompactGap is the narrowest gap, in cells, that CompactColumns
+// leaves between columns of nodes.
+const DefaultCompactGap = 6
+
+// CompactColumns removes empty columns between nodes to reduce the overall
+// width of a layout. Each vertical band of the canvas that contains no node
+// is narrowed to what the connections crossing it need: gap cells when none
+// cross, two cells per crossing connection so parallel lines don't merge, and
+// room for the longest label drawn across it. Bands are never widened, and
+// the relative order of nodes is kept. The input slice is not modified.
+func CompactColumns(nodes []diagram.Node, connections []diagram.Connection, gap int) []diagram.Node {
+	result := make([]diagram.Node, len(nodes))
+	copy(result, nodes)
+	if len(result) < 2 {
+		return result
+	}
+
+	// Merge node extents into the occupied column ranges
+	order := make([]int, len(result))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return result[order[a]].X < result[order[b]].X
+	})
+
+	type columnRange struct{ start, end int }
+	var ranges []columnRange
+	rangeOf := make(map[int]int, len(result)) // Node ID to range index
+	for _, i := range order {
+		node := result[i]
+		last := len(ranges) - 1
+		if last >= 0 && node.X < ranges[last].end {
+			if end := node.X + node.Width; end > ranges[last].end {
+				ranges[last].end = end
+			}
+		} else {
+			ranges = append(ranges, columnRange{node.X, node.X + node.Width})
+			last++
+		}
+		rangeOf[node.ID] = last
+	}
+
+	// Shrink each band between neighbouring ranges, accumulating how far
+	// every range moves left
+	shift := make([]int, len(ranges))
+	for band := 0; band < len(ranges)-1; band++ {
+		width := ranges[band+1].start - ranges[band].end
+		need := bandWidth(band, rangeOf, connections, gap)
+		shift[band+1] = shift[band]
+		if need < width {
+			shift[band+1] += width - need
+		}
+	}
+
+	for i := range result {
+		result[i].X -= shift[rangeOf[result[i].ID]]
+	}
+	return result
+}
+
+// bandWidth returns how wide the empty band to the right of range band must
+// stay for the connections that cross it.
+func bandWidth(band int, rangeOf map[int]int, connections []diagram.Connection, gap int) int {
+	need := gap
+	crossings := 0
+	for _, conn := range connections {
+		from, ok := rangeOf[conn.From]
+		if !ok {
+			continue
+		}
+		to, ok := rangeOf[conn.To]
+		if !ok {
+			continue
+		}
+		if (from <= band) == (to <= band) {
+			continue
+		}
+		crossings++
+		if conn.Label != "" {
+			// Label plus a space and an arrow cell on each side
+			if w := len([]rune(conn.Label)) + 4; w > need {
+				need = w
+			}
+		}
+	}
+	if w := crossings*2 + 2; w > need {
+		need = w
+	}
+	return need
+}
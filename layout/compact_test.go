@@ -0,0 +1,105 @@
+package layout
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func layoutWidth(nodes []diagram.Node) int {
+	minX, maxX := nodes[0].X, nodes[0].X+nodes[0].Width
+	for _, node := range nodes[1:] {
+		if node.X < minX {
+			minX = node.X
+		}
+		if node.X+node.Width > maxX {
+			maxX = node.X + node.Width
+		}
+	}
+	return maxX - minX
+}
+
+func TestCompactColumnsSparseGraph(t *testing.T) {
+	// Three columns with a single node each plus a disconnected node, laid out
+	// with the horizontal layout's wide label spacing
+	nodes := []diagram.Node{
+		{ID: 1, Text: []string{"Start"}},
+		{ID: 2, Text: []string{"Middle"}},
+		{ID: 3, Text: []string{"End"}},
+		{ID: 4, Text: []string{"Alone"}},
+	}
+	connections := []diagram.Connection{
+		{From: 1, To: 2, Label: "validated"},
+		{From: 2, To: 3},
+	}
+
+	laidOut, err := NewHorizontalLayout().Layout(nodes, connections)
+	if err != nil {
+		t.Fatalf("Layout failed: %v", err)
+	}
+	compacted := CompactColumns(laidOut, connections, DefaultCompactGap)
+
+	before, after := layoutWidth(laidOut), layoutWidth(compacted)
+	if after >= before {
+		t.Fatalf("Expected compaction to reduce width, got %d before and %d after", before, after)
+	}
+
+	byID := make(map[int]diagram.Node)
+	for _, node := range compacted {
+		byID[node.ID] = node
+	}
+	gap := func(left, right int) int {
+		return byID[right].X - (byID[left].X + byID[left].Width)
+	}
+
+	// The labeled connection keeps room for its label
+	if got, want := gap(1, 2), len("validated")+4; got < want {
+		t.Errorf("Gap under labeled connection = %d, want at least %d", got, want)
+	}
+	// The unlabeled one shrinks to the minimum gap
+	if got := gap(2, 3); got != DefaultCompactGap {
+		t.Errorf("Gap under unlabeled connection = %d, want %d", got, DefaultCompactGap)
+	}
+
+	// Vertical positions and left-to-right order are untouched
+	for i := range laidOut {
+		if compacted[i].Y != laidOut[i].Y {
+			t.Errorf("Node %d moved vertically from %d to %d", laidOut[i].ID, laidOut[i].Y, compacted[i].Y)
+		}
+	}
+	if !(byID[1].X < byID[2].X && byID[2].X < byID[3].X) {
+		t.Errorf("Column order changed: %d, %d, %d", byID[1].X, byID[2].X, byID[3].X)
+	}
+}
+
+func TestCompactColumnsKeepsRoomForParallelConnections(t *testing.T) {
+	nodes := []diagram.Node{
+		{ID: 1, X: 0, Y: 0, Width: 7, Height: 3},
+		{ID: 2, X: 0, Y: 6, Width: 7, Height: 3},
+		{ID: 3, X: 0, Y: 12, Width: 7, Height: 3},
+		{ID: 4, X: 0, Y: 18, Width: 7, Height: 3},
+		{ID: 5, X: 40, Y: 9, Width: 7, Height: 3},
+	}
+	connections := []diagram.Connection{
+		{From: 1, To: 5}, {From: 2, To: 5}, {From: 3, To: 5}, {From: 4, To: 5},
+	}
+
+	compacted := CompactColumns(nodes, connections, DefaultCompactGap)
+
+	if got, want := compacted[4].X-7, 4*2+2; got != want {
+		t.Errorf("Gap with four crossing connections = %d, want %d", got, want)
+	}
+}
+
+func TestCompactColumnsNeverWidens(t *testing.T) {
+	nodes := []diagram.Node{
+		{ID: 1, X: 0, Y: 0, Width: 7, Height: 3},
+		{ID: 2, X: 10, Y: 0, Width: 7, Height: 3},
+	}
+	connections := []diagram.Connection{{From: 1, To: 2, Label: "a rather long label"}}
+
+	compacted := CompactColumns(nodes, connections, DefaultCompactGap)
+
+	if compacted[1].X != 10 {
+		t.Errorf("Expected an already narrow gap to be kept, node moved to X=%d", compacted[1].X)
+	}
+}
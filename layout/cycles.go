@@ -0,0 +1,85 @@
+package layout
+
+import "edd/diagram"
+
+// FindCycles returns the connections that lie on a directed cycle, as the
+// indices into connections of each group of nodes that can all reach one
+// another. A group may hold several cycles that share nodes, and a
+// connection from a node to itself counts as a cycle. Groups come in the
+// order of their first node in nodes, and the indices in each are ascending.
+func FindCycles(nodes []diagram.Node, connections []diagram.Connection) [][]int {
+	outgoing := make(map[int][]int)
+	for _, conn := range connections {
+		outgoing[conn.From] = append(outgoing[conn.From], conn.To)
+	}
+
+	// Tarjan's algorithm labels every node with its strongly connected
+	// component; a connection is on a cycle when both its ends share one
+	component := make(map[int]int)
+	index := make(map[int]int)
+	lowlink := make(map[int]int)
+	onStack := make(map[int]bool)
+	var stack []int
+	next := 0
+
+	var visit func(id int)
+	visit = func(id int) {
+		index[id], lowlink[id] = next, next
+		next++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, to := range outgoing[id] {
+			if _, seen := index[to]; !seen {
+				visit(to)
+				lowlink[id] = min(lowlink[id], lowlink[to])
+			} else if onStack[to] {
+				lowlink[id] = min(lowlink[id], index[to])
+			}
+		}
+
+		if lowlink[id] == index[id] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = id
+				if top == id {
+					break
+				}
+			}
+		}
+	}
+	for _, node := range nodes {
+		if _, seen := index[node.ID]; !seen {
+			visit(node.ID)
+		}
+	}
+
+	// Components in the order their first node was listed
+	var order []int
+	seen := make(map[int]bool)
+	for _, node := range nodes {
+		if c, ok := component[node.ID]; ok && !seen[c] {
+			seen[c] = true
+			order = append(order, c)
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i, conn := range connections {
+		from, fromOk := component[conn.From]
+		to, toOk := component[conn.To]
+		if fromOk && toOk && from == to {
+			groups[from] = append(groups[from], i)
+		}
+	}
+
+	var cycles [][]int
+	for _, c := range order {
+		if group := groups[c]; len(group) > 0 {
+			cycles = append(cycles, group)
+		}
+	}
+	return cycles
+}
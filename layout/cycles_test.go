@@ -0,0 +1,31 @@
+package layout
+
+import (
+	"edd/diagram"
+	"reflect"
+	"testing"
+)
+
+func TestFindCyclesReturnsTheEdgesOfEachLoop(t *testing.T) {
+	nodes := []diagram.Node{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	conns := []diagram.Connection{
+		{From: 1, To: 2},
+		{From: 2, To: 3},
+		{From: 3, To: 4}, // Leaves the loop
+		{From: 3, To: 1},
+	}
+	if got, want := FindCycles(nodes, conns), [][]int{{0, 1, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCycles = %v, want %v", got, want)
+	}
+
+	// Removing the edge back to the start leaves no cycle
+	if got := FindCycles(nodes, conns[:3]); len(got) != 0 {
+		t.Errorf("Expected no cycles in an acyclic chain, got %v", got)
+	}
+
+	// A second, separate loop and a self-loop are found as groups of their own
+	conns = append(conns, diagram.Connection{From: 4, To: 4})
+	if got, want := FindCycles(nodes, conns), [][]int{{0, 1, 3}, {4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCycles = %v, want %v", got, want)
+	}
+}
@@ -2,7 +2,7 @@ package layout
 
 import (
 	"edd/diagram"
-	"sort"
+	"unicode/utf8"
 )
 
 // HorizontalLayout implements a left-to-right layout algorithm for flowcharts.
@@ -13,6 +13,7 @@ type HorizontalLayout struct {
 	minNodeWidth      int
 	minNodeHeight     int
 	maxNodeWidth      int
+	seed              int64 // Tie-break ordering, see SetSeed
 }
 
 // NewHorizontalLayout creates a HorizontalLayout with default settings.
@@ -26,6 +27,12 @@ func NewHorizontalLayout() *HorizontalLayout {
 	}
 }
 
+// SetSeed changes how nodes that are otherwise interchangeable are ordered.
+// The default of 0 orders them by ID; see orderIDs.
+func (h *HorizontalLayout) SetSeed(seed int64) {
+	h.seed = seed
+}
+
 // Layout positions nodes in a left-to-right arrangement.
 func (h *HorizontalLayout) Layout(nodes []diagram.Node, connections []diagram.Connection) ([]diagram.Node, error) {
 	if len(nodes) == 0 {
@@ -63,6 +70,10 @@ func (h *HorizontalLayout) Layout(nodes []diagram.Node, connections []diagram.Co
 	// Assign nodes to columns (left to right)
 	columns := h.assignColumns(result, outgoing, incoming)
 
+	// Keep heavily weighted edges short and straight
+	columns = tightenHeavyEdges(columns, connections)
+	orderByWeight(columns, connections)
+
 	// Position nodes within each column
 	h.positionNodes(result, columns, nodeMap)
 
@@ -76,6 +87,10 @@ func (h *HorizontalLayout) Name() string {
 
 // calculateNodeDimensions sets the width and height based on text content.
 func (h *HorizontalLayout) calculateNodeDimensions(node *diagram.Node) {
+	if SizeTextNode(node) {
+		return
+	}
+
 	// Height is number of lines plus borders
 	node.Height = len(node.Text) + 2
 	if node.Height < h.minNodeHeight {
@@ -85,8 +100,8 @@ func (h *HorizontalLayout) calculateNodeDimensions(node *diagram.Node) {
 	// Width is longest line plus borders
 	maxWidth := 0
 	for _, line := range node.Text {
-		if len(line) > maxWidth {
-			maxWidth = len(line)
+		if width := utf8.RuneCountInString(line); width > maxWidth {
+			maxWidth = width
 		}
 	}
 
@@ -97,6 +112,7 @@ func (h *HorizontalLayout) calculateNodeDimensions(node *diagram.Node) {
 	if node.Width > h.maxNodeWidth {
 		node.Width = h.maxNodeWidth
 	}
+	ApplyFixedSize(node)
 }
 
 // assignColumns determines which horizontal column each node belongs to.
@@ -125,7 +141,7 @@ func (h *HorizontalLayout) assignColumns(nodes []diagram.Node, outgoing, incomin
 			queue = append(queue, nodeID)
 		}
 	}
-	sort.Ints(queue) // Deterministic ordering
+	orderIDs(queue, h.seed) // Deterministic ordering
 
 	columns := make([][]int, 0)
 	assigned := make(map[int]int)
@@ -161,7 +177,7 @@ func (h *HorizontalLayout) assignColumns(nodes []diagram.Node, outgoing, incomin
 			}
 		}
 
-		sort.Ints(nextQueue) // Deterministic ordering
+		orderIDs(nextQueue, h.seed) // Deterministic ordering
 		queue = nextQueue
 	}
 
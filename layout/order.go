@@ -0,0 +1,32 @@
+package layout
+
+import "sort"
+
+// orderIDs sorts node IDs that the layout considers equivalent (nodes sharing
+// a level or column) into the order they are placed. With seed 0 the order is
+// by ID, which keeps symmetric graphs laid out the same way from run to run
+// and release to release. Any other seed gives a different but equally
+// repeatable order, for trying alternative arrangements of the same graph.
+func orderIDs(ids []int, seed int64) {
+	if seed == 0 {
+		sort.Ints(ids)
+		return
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ri, rj := seededRank(ids[i], seed), seededRank(ids[j], seed)
+		if ri != rj {
+			return ri < rj
+		}
+		return ids[i] < ids[j]
+	})
+}
+
+// seededRank mixes a node ID with a seed (splitmix64's finalizer), so the
+// resulting order depends only on these two values and not on any library
+// random number generator.
+func seededRank(id int, seed int64) uint64 {
+	z := uint64(seed) + uint64(id)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,72 @@
+package layout
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+// symmetricTree returns a root with four interchangeable children
+func symmetricTree() ([]diagram.Node, []diagram.Connection) {
+	nodes := []diagram.Node{{ID: 1, Text: []string{"root"}}}
+	var conns []diagram.Connection
+	for id := 2; id <= 5; id++ {
+		nodes = append(nodes, diagram.Node{ID: id, Text: []string{"leaf"}})
+		conns = append(conns, diagram.Connection{From: 1, To: id})
+	}
+	return nodes, conns
+}
+
+// leafOrder returns the child IDs from left to right
+func leafOrder(t *testing.T, seed int64) []int {
+	nodes, conns := symmetricTree()
+	v := NewVerticalLayout()
+	v.SetSeed(seed)
+	result, err := v.Layout(nodes, conns)
+	if err != nil {
+		t.Fatalf("Layout failed: %v", err)
+	}
+
+	var order []int
+	for x := 0; len(order) < 4 && x < 1000; x++ {
+		for _, node := range result {
+			if node.ID != 1 && node.X == x {
+				order = append(order, node.ID)
+			}
+		}
+	}
+	return order
+}
+
+func TestVerticalLayoutSeedOrdering(t *testing.T) {
+	if got := leafOrder(t, 0); !equalInts(got, []int{2, 3, 4, 5}) {
+		t.Errorf("Expected default ordering by ID, got %v", got)
+	}
+
+	// A seed gives the same order every time
+	first := leafOrder(t, 42)
+	for i := 0; i < 5; i++ {
+		if got := leafOrder(t, 42); !equalInts(got, first) {
+			t.Fatalf("Seed 42 gave %v, then %v", first, got)
+		}
+	}
+
+	// Some seed must produce an order other than by ID
+	for seed := int64(1); seed <= 10; seed++ {
+		if !equalInts(leafOrder(t, seed), []int{2, 3, 4, 5}) {
+			return
+		}
+	}
+	t.Errorf("Expected seeds to reorder interchangeable nodes")
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
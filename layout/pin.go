@@ -0,0 +1,34 @@
+package layout
+
+import (
+	"edd/diagram"
+	"strconv"
+)
+
+// PinnedPosition returns the top-left corner a node's "x" and "y" hints pin
+// it to, in layout coordinates. Either hint may be left out to keep the
+// layout's choice on that axis; hasX and hasY report which ones are set.
+func PinnedPosition(node diagram.Node) (x, y int, hasX, hasY bool) {
+	x, errX := strconv.Atoi(node.Hints["x"])
+	y, errY := strconv.Atoi(node.Hints["y"])
+	return x, y, errX == nil, errY == nil
+}
+
+// ApplyPinnedPositions moves every node with "x" or "y" hints to the
+// position they give, after the layout engine has placed the rest. Pinned
+// nodes may overlap others; the hints are a manual override. The input slice
+// is not modified.
+func ApplyPinnedPositions(nodes []diagram.Node) []diagram.Node {
+	result := make([]diagram.Node, len(nodes))
+	copy(result, nodes)
+	for i := range result {
+		x, y, hasX, hasY := PinnedPosition(result[i])
+		if hasX {
+			result[i].X = x
+		}
+		if hasY {
+			result[i].Y = y
+		}
+	}
+	return result
+}
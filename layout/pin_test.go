@@ -0,0 +1,25 @@
+package layout
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func TestApplyPinnedPositions(t *testing.T) {
+	nodes := []diagram.Node{
+		{ID: 1, X: 0, Y: 0, Hints: map[string]string{"x": "12", "y": "-3"}},
+		{ID: 2, X: 5, Y: 5, Hints: map[string]string{"y": "20"}},
+		{ID: 3, X: 7, Y: 7, Hints: map[string]string{"x": "left"}},
+	}
+
+	result := ApplyPinnedPositions(nodes)
+	want := []diagram.Point{{X: 12, Y: -3}, {X: 5, Y: 20}, {X: 7, Y: 7}}
+	for i, node := range result {
+		if node.X != want[i].X || node.Y != want[i].Y {
+			t.Errorf("Node %d at %d,%d, want %d,%d", node.ID, node.X, node.Y, want[i].X, want[i].Y)
+		}
+	}
+	if nodes[0].X != 0 {
+		t.Errorf("Expected the input to be left alone")
+	}
+}
@@ -37,6 +37,25 @@ type MessagePosition struct {
 	Y     int
 	Label string
 	ConnectionID int // Reference to the original connection for hints
+	Index        int // Position of the connection in the diagram's Connections
+}
+
+// selfMessageExtra is the number of lines a self-message needs on top of
+// MessageSpacing, for the loop drawn down to the line below its arrow
+const selfMessageExtra = 2
+
+// SelfMessageLabelOffset is how far right of its lifeline a self-message's
+// label starts, clear of the loop
+const SelfMessageLabelOffset = 9
+
+// LabelStyleAbove is the "label-style" hint value that gives a message's
+// label a line of its own above the arrow, with a blank line between it and
+// the message before, so a label wider than the gap it spans crowds nothing
+const LabelStyleAbove = "above"
+
+// LabelAbove reports whether a message's label goes on its own line
+func LabelAbove(conn diagram.Connection) bool {
+	return conn.Label != "" && conn.Hints["label-style"] == LabelStyleAbove
 }
 
 // NewSequenceLayout creates a new sequence diagram layout engine
@@ -96,19 +115,27 @@ func (s *SequenceLayout) ComputePositions(d *diagram.Diagram) *SequencePositions
 	// Compute message positions
 	currentY := s.TopMargin + s.ParticipantHeight + s.MessageSpacing
 	
-	for _, conn := range d.Connections {
+	for _, index := range d.MessageOrder() {
+		conn := d.Connections[index]
 		fromPos, fromOk := positions.Participants[conn.From]
 		toPos, toOk := positions.Participants[conn.To]
 		
 		if fromOk && toOk {
+			if LabelAbove(conn) {
+				currentY++
+			}
 			positions.Messages = append(positions.Messages, MessagePosition{
 				FromX: fromPos.LifelineX,
 				ToX:   toPos.LifelineX,
 				Y:     currentY,
 				Label: conn.Label,
 				ConnectionID: conn.ID,
+				Index: index,
 			})
 			currentY += s.MessageSpacing
+			if conn.From == conn.To {
+				currentY += selfMessageExtra
+			}
 		}
 	}
 	
@@ -169,10 +196,31 @@ func (s *SequenceLayout) GetDiagramBounds(d *diagram.Diagram) (width, height int
 	if numParticipants > 0 {
 		width += s.LeftMargin  // Right margin
 	}
+
+	// Labels beside self-message loops may reach past the last participant
+	for _, msg := range s.ComputePositions(d).Messages {
+		if msg.FromX == msg.ToX && msg.Label != "" {
+			offset := SelfMessageLabelOffset
+			if LabelAbove(d.Connections[msg.Index]) {
+				offset = 2
+			}
+			if right := msg.FromX + offset + len([]rune(msg.Label)) + 1; right > width {
+				width = right
+			}
+		}
+	}
 	
 	// Calculate height based on number of messages
 	height = s.TopMargin + s.ParticipantHeight
 	height += len(d.Connections) * s.MessageSpacing
+	for _, conn := range d.Connections {
+		if conn.From == conn.To {
+			height += selfMessageExtra
+		}
+		if LabelAbove(conn) {
+			height++
+		}
+	}
 	height += 10 // Bottom margin
 	
 	return width, height
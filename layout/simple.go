@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"unicode/utf8"
 )
 
 // SimpleLayout implements a basic left-to-right layout algorithm.
@@ -78,7 +79,7 @@ func (s *SimpleLayout) Layout(nodes []diagram.Node, connections []diagram.Connec
 	}
 
 	// Detect connected components
-	components := s.detectComponents(result, outgoing, incoming)
+	components := diagram.ConnectedComponents(result, connections)
 
 	// Layout each component separately
 	xOffset := 0
@@ -152,6 +153,10 @@ func (s *SimpleLayout) SetHintStrength(strength float64) {
 
 // calculateNodeDimensions sets the width and height based on text content.
 func (s *SimpleLayout) calculateNodeDimensions(node *diagram.Node) {
+	if SizeTextNode(node) {
+		return
+	}
+
 	// Height is number of lines plus borders
 	node.Height = len(node.Text) + 2
 	if node.Height < s.minNodeHeight {
@@ -161,8 +166,8 @@ func (s *SimpleLayout) calculateNodeDimensions(node *diagram.Node) {
 	// Width is longest line plus borders
 	maxWidth := 0
 	for _, line := range node.Text {
-		if len(line) > maxWidth {
-			maxWidth = len(line)
+		if width := utf8.RuneCountInString(line); width > maxWidth {
+			maxWidth = width
 		}
 	}
 
@@ -173,6 +178,7 @@ func (s *SimpleLayout) calculateNodeDimensions(node *diagram.Node) {
 	if node.Width > s.maxNodeWidth {
 		node.Width = s.maxNodeWidth
 	}
+	ApplyFixedSize(node)
 }
 
 // applyStrongHints applies position and flow hints as strong post-processing
@@ -703,54 +709,6 @@ func (s *SimpleLayout) findBestLayerForNode(nodeID int, layers [][]int, assigned
 	return bestLayer
 }
 
-// detectComponents finds connected components using DFS.
-func (s *SimpleLayout) detectComponents(nodes []diagram.Node, outgoing, incoming map[int][]int) [][]int {
-	visited := make(map[int]bool)
-	components := make([][]int, 0)
-
-	// Create bidirectional adjacency for component detection
-	adjacent := make(map[int][]int)
-	for nodeID, successors := range outgoing {
-		for _, succ := range successors {
-			adjacent[nodeID] = append(adjacent[nodeID], succ)
-			adjacent[succ] = append(adjacent[succ], nodeID)
-		}
-	}
-
-	// DFS to find components
-	var dfs func(nodeID int, component *[]int)
-	dfs = func(nodeID int, component *[]int) {
-		if visited[nodeID] {
-			return
-		}
-		visited[nodeID] = true
-		*component = append(*component, nodeID)
-
-		for _, neighbor := range adjacent[nodeID] {
-			dfs(neighbor, component)
-		}
-	}
-
-	// Find all components
-	nodeIDs := make([]int, 0, len(nodes))
-	for _, node := range nodes {
-		nodeIDs = append(nodeIDs, node.ID)
-	}
-	sort.Ints(nodeIDs) // Process in order for determinism
-
-	for _, nodeID := range nodeIDs {
-		if !visited[nodeID] {
-			component := make([]int, 0)
-			dfs(nodeID, &component)
-			sort.Ints(component) // Sort for determinism
-			components = append(components, component)
-		}
-	}
-
-	return components
-}
-
-
 // assignGridLayout arranges nodes in a grid pattern for small cyclic graphs
 func (s *SimpleLayout) assignGridLayout(nodes []diagram.Node) [][]int {
 	if len(nodes) == 0 {
@@ -0,0 +1,59 @@
+package layout
+
+import (
+	"edd/diagram"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Smallest box a "width" or "height" hint can ask for: the borders, the
+// padding and one character of text
+const (
+	MinFixedWidth  = 5
+	MinFixedHeight = 3
+)
+
+// FixedSize returns the box size a node's "width" and "height" hints fix
+// it to, in columns and lines including the borders, or 0 for a dimension
+// sized to the text. Sizes below the smallest box are raised to it; text
+// nodes have no box and are never fixed.
+func FixedSize(node diagram.Node) (width, height int) {
+	if node.IsText() {
+		return 0, 0
+	}
+	if w, err := strconv.Atoi(node.Hints["width"]); err == nil && w > 0 {
+		width = max(w, MinFixedWidth)
+	}
+	if h, err := strconv.Atoi(node.Hints["height"]); err == nil && h > 0 {
+		height = max(h, MinFixedHeight)
+	}
+	return width, height
+}
+
+// ApplyFixedSize overrides the text-based size of a node with the one its
+// hints fix, if any
+func ApplyFixedSize(node *diagram.Node) {
+	width, height := FixedSize(*node)
+	if width > 0 {
+		node.Width = width
+	}
+	if height > 0 {
+		node.Height = height
+	}
+}
+
+// SizeTextNode sizes a text node to its text plus a blank ring around it,
+// where connections attach, since text nodes have no border or padding. It
+// reports whether the node is a text node, leaving other nodes untouched.
+func SizeTextNode(node *diagram.Node) bool {
+	if !node.IsText() {
+		return false
+	}
+	maxWidth := 0
+	for _, line := range node.Text {
+		maxWidth = max(maxWidth, utf8.RuneCountInString(line))
+	}
+	node.Width = maxWidth + 2
+	node.Height = len(node.Text) + 2
+	return true
+}
@@ -2,7 +2,7 @@ package layout
 
 import (
 	"edd/diagram"
-	"sort"
+	"unicode/utf8"
 )
 
 // VerticalLayout implements a top-to-bottom layout algorithm for flowcharts.
@@ -13,6 +13,7 @@ type VerticalLayout struct {
 	minNodeWidth      int
 	minNodeHeight     int
 	maxNodeWidth      int
+	seed              int64 // Tie-break ordering, see SetSeed
 }
 
 // NewVerticalLayout creates a VerticalLayout with default settings.
@@ -26,6 +27,12 @@ func NewVerticalLayout() *VerticalLayout {
 	}
 }
 
+// SetSeed changes how nodes that are otherwise interchangeable are ordered.
+// The default of 0 orders them by ID; see orderIDs.
+func (v *VerticalLayout) SetSeed(seed int64) {
+	v.seed = seed
+}
+
 // Layout positions nodes in a top-to-bottom arrangement.
 func (v *VerticalLayout) Layout(nodes []diagram.Node, connections []diagram.Connection) ([]diagram.Node, error) {
 	if len(nodes) == 0 {
@@ -63,6 +70,10 @@ func (v *VerticalLayout) Layout(nodes []diagram.Node, connections []diagram.Conn
 	// Assign nodes to levels (top to bottom)
 	levels := v.assignLevels(result, outgoing, incoming)
 
+	// Keep heavily weighted edges short and straight
+	levels = tightenHeavyEdges(levels, connections)
+	orderByWeight(levels, connections)
+
 	// Position nodes within each level
 	v.positionNodes(result, levels, nodeMap)
 
@@ -76,6 +87,10 @@ func (v *VerticalLayout) Name() string {
 
 // calculateNodeDimensions sets the width and height based on text content.
 func (v *VerticalLayout) calculateNodeDimensions(node *diagram.Node) {
+	if SizeTextNode(node) {
+		return
+	}
+
 	// Height is number of lines plus borders
 	node.Height = len(node.Text) + 2
 	if node.Height < v.minNodeHeight {
@@ -85,8 +100,8 @@ func (v *VerticalLayout) calculateNodeDimensions(node *diagram.Node) {
 	// Width is longest line plus borders
 	maxWidth := 0
 	for _, line := range node.Text {
-		if len(line) > maxWidth {
-			maxWidth = len(line)
+		if width := utf8.RuneCountInString(line); width > maxWidth {
+			maxWidth = width
 		}
 	}
 
@@ -97,6 +112,7 @@ func (v *VerticalLayout) calculateNodeDimensions(node *diagram.Node) {
 	if node.Width > v.maxNodeWidth {
 		node.Width = v.maxNodeWidth
 	}
+	ApplyFixedSize(node)
 }
 
 // assignLevels determines which vertical level (row) each node belongs to.
@@ -125,7 +141,7 @@ func (v *VerticalLayout) assignLevels(nodes []diagram.Node, outgoing, incoming m
 			queue = append(queue, nodeID)
 		}
 	}
-	sort.Ints(queue) // Deterministic ordering
+	orderIDs(queue, v.seed) // Deterministic ordering
 
 	levels := make([][]int, 0)
 	assigned := make(map[int]int)
@@ -161,7 +177,7 @@ func (v *VerticalLayout) assignLevels(nodes []diagram.Node, outgoing, incoming m
 			}
 		}
 
-		sort.Ints(nextQueue) // Deterministic ordering
+		orderIDs(nextQueue, v.seed) // Deterministic ordering
 		queue = nextQueue
 	}
 
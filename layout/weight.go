@@ -0,0 +1,159 @@
+package layout
+
+import (
+	"edd/diagram"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// EdgeWeight returns a connection's "weight" hint, or 1 when it has none or
+// the hint isn't a positive whole number. Layered layouts keep heavier edges
+// shorter and straighter at the expense of lighter ones.
+func EdgeWeight(conn diagram.Connection) int {
+	weight, err := strconv.Atoi(conn.Hints["weight"])
+	if err != nil || weight < 1 {
+		return 1
+	}
+	return weight
+}
+
+// heavyEdges returns the connections weighted above 1, heaviest first, with
+// ties in source then target order so the result is deterministic
+func heavyEdges(connections []diagram.Connection) []diagram.Connection {
+	var heavy []diagram.Connection
+	for _, conn := range connections {
+		if conn.From != conn.To && EdgeWeight(conn) > 1 {
+			heavy = append(heavy, conn)
+		}
+	}
+	sort.SliceStable(heavy, func(i, j int) bool {
+		wi, wj := EdgeWeight(heavy[i]), EdgeWeight(heavy[j])
+		if wi != wj {
+			return wi > wj
+		}
+		if heavy[i].From != heavy[j].From {
+			return heavy[i].From < heavy[j].From
+		}
+		return heavy[i].To < heavy[j].To
+	})
+	return heavy
+}
+
+// tightenHeavyEdges moves the source of each heavy edge that spans several
+// levels down to the level just before its target, when none of the source's
+// other successors are in the way and no heavier edge holds it in place.
+// Moved nodes join the end of their new level, and levels left empty are
+// dropped so no blank row or column is laid out.
+func tightenHeavyEdges(levels [][]int, connections []diagram.Connection) [][]int {
+	heavy := heavyEdges(connections)
+	if len(heavy) == 0 {
+		return levels
+	}
+
+	levelOf := make(map[int]int)
+	for i, level := range levels {
+		for _, id := range level {
+			levelOf[id] = i
+		}
+	}
+
+	moved := make(map[int]int) // node ID -> new level
+	for _, edge := range heavy {
+		from, to := levelOf[edge.From], levelOf[edge.To]
+		target := to - 1
+		if target <= from {
+			continue
+		}
+
+		canMove := true
+		for _, conn := range connections {
+			if conn.From == conn.To {
+				continue
+			}
+			if conn.From == edge.From && levelOf[conn.To] <= target {
+				canMove = false // Another successor would end up above or beside it
+			}
+			if conn.To == edge.From && EdgeWeight(conn) >= EdgeWeight(edge) {
+				canMove = false // An edge at least as heavy keeps it near its parent
+			}
+		}
+		if canMove {
+			levelOf[edge.From] = target
+			moved[edge.From] = target
+		}
+	}
+	if len(moved) == 0 {
+		return levels
+	}
+
+	result := make([][]int, len(levels))
+	for i, level := range levels {
+		for _, id := range level {
+			if _, ok := moved[id]; !ok {
+				result[i] = append(result[i], id)
+			}
+		}
+	}
+	for _, level := range levels {
+		for _, id := range level {
+			if _, ok := moved[id]; ok {
+				result[levelOf[id]] = append(result[levelOf[id]], id)
+			}
+		}
+	}
+	return slices.DeleteFunc(result, func(level []int) bool { return len(level) == 0 })
+}
+
+// orderByWeight reorders each level that a heavy edge enters by the weighted
+// average position of each node's parents in the level before it, so heavy
+// edges run as straight as the level allows. Nodes without parents there keep
+// their place. Levels no heavy edge enters are left alone.
+func orderByWeight(levels [][]int, connections []diagram.Connection) {
+	heavy := heavyEdges(connections)
+	if len(heavy) == 0 {
+		return
+	}
+
+	for i := 1; i < len(levels); i++ {
+		above := make(map[int]int)
+		for pos, id := range levels[i-1] {
+			above[id] = pos
+		}
+		inLevel := make(map[int]bool)
+		for _, id := range levels[i] {
+			inLevel[id] = true
+		}
+
+		entered := false
+		for _, edge := range heavy {
+			if _, ok := above[edge.From]; ok && inLevel[edge.To] {
+				entered = true
+				break
+			}
+		}
+		if !entered {
+			continue
+		}
+
+		keys := make(map[int]float64)
+		for pos, id := range levels[i] {
+			total, sum := 0, 0
+			for _, conn := range connections {
+				if parent, ok := above[conn.From]; ok && conn.To == id {
+					weight := EdgeWeight(conn)
+					total += weight
+					sum += weight * parent
+				}
+			}
+			if total > 0 {
+				keys[id] = float64(sum) / float64(total)
+			} else {
+				keys[id] = float64(pos)
+			}
+		}
+		sort.SliceStable(levels[i], func(a, b int) bool {
+			return keys[levels[i][a]] < keys[levels[i][b]]
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package layout
+
+import (
+	"edd/diagram"
+	"reflect"
+	"testing"
+)
+
+func TestEdgeWeight(t *testing.T) {
+	for value, want := range map[string]int{"": 1, "3": 3, "0": 1, "-2": 1, "heavy": 1} {
+		conn := diagram.Connection{Hints: map[string]string{"weight": value}}
+		if got := EdgeWeight(conn); got != want {
+			t.Errorf("EdgeWeight(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestHeavyEdgeShortensLayers(t *testing.T) {
+	// 5 -> 2 spans three levels by default, as 2 also sits below 1 -> 3 -> 4
+	nodes := []diagram.Node{
+		{ID: 1, Text: []string{"A"}}, {ID: 2, Text: []string{"B"}}, {ID: 3, Text: []string{"C"}},
+		{ID: 4, Text: []string{"D"}}, {ID: 5, Text: []string{"E"}},
+	}
+	conns := []diagram.Connection{{From: 1, To: 3}, {From: 3, To: 4}, {From: 4, To: 2}, {From: 5, To: 2}}
+
+	span := func(conns []diagram.Connection) int {
+		result, err := NewVerticalLayout().Layout(nodes, conns)
+		if err != nil {
+			t.Fatalf("Layout failed: %v", err)
+		}
+		y := make(map[int]int)
+		for _, node := range result {
+			y[node.ID] = node.Y
+		}
+		return y[2] - y[5]
+	}
+
+	light := span(conns)
+	conns[3].Hints = map[string]string{"weight": "5"}
+	heavy := span(conns)
+	if heavy >= light {
+		t.Errorf("Expected the heavy edge to span less height, got %d (default %d)", heavy, light)
+	}
+}
+
+func TestTightenHeavyEdgesDropsEmptyLevels(t *testing.T) {
+	// The chain 1 -> 2 -> 3 starts a level above 4, whose heavy edge to 3
+	// moves it down and leaves its level empty
+	levels := [][]int{{4}, {1}, {2}, {3}}
+	conns := []diagram.Connection{
+		{From: 1, To: 2}, {From: 2, To: 3},
+		{From: 4, To: 3, Hints: map[string]string{"weight": "5"}},
+	}
+
+	got := tightenHeavyEdges(levels, conns)
+	want := [][]int{{1}, {2, 4}, {3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected levels %v, got %v", want, got)
+	}
+}
+
+func TestHeavyEdgeOrdersChildUnderParent(t *testing.T) {
+	// Two roots, each with one child; by ID the children would swap sides
+	nodes := []diagram.Node{
+		{ID: 1, Text: []string{"A"}}, {ID: 2, Text: []string{"B"}},
+		{ID: 3, Text: []string{"C"}}, {ID: 4, Text: []string{"D"}},
+	}
+	conns := []diagram.Connection{{From: 2, To: 3, Hints: map[string]string{"weight": "4"}}, {From: 1, To: 4}}
+
+	result, err := NewVerticalLayout().Layout(nodes, conns)
+	if err != nil {
+		t.Fatalf("Layout failed: %v", err)
+	}
+	x := make(map[int]int)
+	for _, node := range result {
+		x[node.ID] = node.X
+	}
+	if x[3] < x[4] {
+		t.Errorf("Expected B's heavy child to be placed on B's side, got C at %d and D at %d", x[3], x[4])
+	}
+}
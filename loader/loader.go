@@ -0,0 +1,138 @@
+// Package loader reads diagram files for the edd commands: edd JSON, single
+// diagrams or multi-diagram collections, and any format the importer
+// registry can read.
+package loader
+
+import (
+	"edd/diagram"
+	"edd/export"
+	"edd/importer"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ASCIIQuotes replaces smart quotes in imported labels with plain ASCII ones,
+// for the commands' -ascii-quotes flag
+var ASCIIQuotes bool
+
+// NewRegistry returns an importer registry set up with the loader options
+func NewRegistry() *importer.ImporterRegistry {
+	registry := importer.NewImporterRegistry()
+	registry.SetASCIIQuotes(ASCIIQuotes)
+	return registry
+}
+
+// Load loads a diagram from a file, importing it from another format when
+// inputFormat names one or the file's extension belongs to an importer. For a
+// multi-diagram JSON file, block picks the diagram by its 1-based index; 0
+// loads the first. Either way the connections come back with unique IDs and
+// arrows.
+func Load(filename, inputFormat string, block int) (*diagram.Diagram, error) {
+	data, err := ReadSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+
+	// Prefer edd JSON embedded by -embed-source, it's lossless
+	if d, ok := export.ExtractEmbeddedSource(content); ok {
+		diagram.EnsureUniqueConnectionIDs(d)
+		return d, nil
+	}
+
+	if _, ok, err := diagram.ParseCollection(data); ok {
+		if err != nil {
+			return nil, err
+		}
+		return LoadCollectionDiagram(filename, max(block-1, 0))
+	}
+
+	registry := NewRegistry()
+	var d *diagram.Diagram
+	switch imp, extErr := registry.GetImporterForFile(filepath.Ext(filename), content); {
+	case inputFormat != "" && inputFormat != "json":
+		if d, err = registry.ImportWithFormat(content, inputFormat); err != nil {
+			return nil, fmt.Errorf("importing diagram: %w", err)
+		}
+	case inputFormat == "" && extErr == nil:
+		if d, err = registry.ImportWithFormat(content, imp.GetFormatName()); err != nil {
+			return nil, fmt.Errorf("importing diagram: %w", err)
+		}
+	default:
+		d = &diagram.Diagram{}
+		if err := json.Unmarshal(data, d); err != nil {
+			// Content that isn't JSON may still be in a format we can detect
+			if inputFormat == "json" {
+				return nil, fmt.Errorf("parsing JSON: %w", err)
+			}
+			if d, err = registry.Import(content); err != nil {
+				return nil, fmt.Errorf("failed to parse as JSON and import failed: %w", err)
+			}
+		} else if len(d.Nodes) == 0 {
+			return nil, fmt.Errorf("diagram has no nodes")
+		}
+	}
+
+	withArrows(d)
+	return d, nil
+}
+
+// ReadSource reads a diagram file with any byte order mark removed and line
+// endings normalized to LF, so files saved on Windows parse like any other
+func ReadSource(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	return []byte(importer.NormalizeSource(string(data))), nil
+}
+
+// IsCollection reports whether filename is a JSON file holding a top-level
+// "diagrams" array
+func IsCollection(filename string) bool {
+	data, err := ReadSource(filename)
+	if err != nil {
+		return false
+	}
+	_, ok, _ := diagram.ParseCollection(data)
+	return ok
+}
+
+// LoadCollectionDiagram loads the diagram at a 0-based index from a JSON file
+// holding a top-level "diagrams" array
+func LoadCollectionDiagram(filename string, index int) (*diagram.Diagram, error) {
+	data, err := ReadSource(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, ok, err := diagram.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%s does not contain a diagrams array", filename)
+	}
+
+	d, err := collection.Diagram(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.Nodes) == 0 {
+		return nil, fmt.Errorf("diagram %d has no nodes", index+1)
+	}
+
+	withArrows(d)
+	return d, nil
+}
+
+// withArrows gives every connection a unique ID and an arrow, as the editor
+// draws them
+func withArrows(d *diagram.Diagram) {
+	diagram.EnsureUniqueConnectionIDs(d)
+	for i := range d.Connections {
+		d.Connections[i].Arrow = true
+	}
+}
@@ -0,0 +1,70 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadImportsByExtension(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "flow.mmd")
+	os.WriteFile(file, []byte("graph TD\n    A[Start]\n    B[End]\n    A --> B\n"), 0644)
+
+	d, err := Load(file, "", 0)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(d.Nodes) != 2 || len(d.Connections) != 1 {
+		t.Fatalf("Expected 2 nodes and 1 connection, got %d and %d", len(d.Nodes), len(d.Connections))
+	}
+	if !d.Connections[0].Arrow {
+		t.Errorf("Expected imported connections to have arrows")
+	}
+}
+
+func TestLoadCollectionBlock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "diagrams.json")
+	os.WriteFile(file, []byte(`{"diagrams": [
+		{"nodes": [{"id": 1, "text": ["First"]}]},
+		{"nodes": [{"id": 1, "text": ["Second"]}]}
+	]}`), 0644)
+
+	if !IsCollection(file) {
+		t.Fatalf("Expected %s to be detected as a collection", file)
+	}
+	for block, want := range map[int]string{0: "First", 1: "First", 2: "Second"} {
+		d, err := Load(file, "", block)
+		if err != nil {
+			t.Fatalf("Load block %d failed: %v", block, err)
+		}
+		if got := d.Nodes[0].Text[0]; got != want {
+			t.Errorf("Block %d: expected %q, got %q", block, want, got)
+		}
+	}
+	if _, err := Load(file, "", 3); err == nil {
+		t.Errorf("Expected an error for a block past the end of the collection")
+	}
+}
+
+func TestLoadASCIIQuotes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quotes.mmd")
+	os.WriteFile(file, []byte("graph TD\n    A[“Quoted”]\n    B[Don’t]\n    A --> B\n"), 0644)
+
+	d, err := Load(file, "", 0)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := d.Nodes[0].Text[0]; got != "“Quoted”" {
+		t.Errorf("Expected smart quotes kept by default, got %q", got)
+	}
+
+	ASCIIQuotes = true
+	defer func() { ASCIIQuotes = false }()
+	d, err = Load(file, "", 0)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := d.Nodes[0].Text[0] + " " + d.Nodes[1].Text[0]; got != `"Quoted" Don't` {
+		t.Errorf("Expected ASCII quotes with ASCIIQuotes set, got %q", got)
+	}
+}
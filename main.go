@@ -5,44 +5,65 @@ import (
 	"edd/editor"
 	"edd/export"
 	"edd/importer"
+	"edd/loader"
 	"edd/markdown"
 	"edd/render"
 	"edd/terminal"
 	"edd/validation"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
+// layoutSeed is the -seed flag, kept for the editors started below main
+var layoutSeed int64
+
+// newEditorRenderer returns the renderer for a TUI editor, laying out
+// symmetric graphs with the -seed flag as exports do
+func newEditorRenderer() *editor.RealRenderer {
+	renderer := editor.NewRealRenderer()
+	renderer.SetLayoutSeed(layoutSeed)
+	return renderer
+}
+
 func main() {
 	// Define command line flags
 	var (
 		interactive   = flag.Bool("i", false, "Interactive TUI mode")
 		edit          = flag.Bool("edit", false, "Edit diagram in TUI (same as -i)")
 		validate      = flag.Bool("validate", false, "Run validation on the output")
-		debug         = flag.Bool("debug", false, "Show debug visualization with obstacles and ports")
+		debug         = flag.Bool("debug", false, "Show debug visualization with obstacles, ports and hub connection counts")
 		showObstacles = flag.Bool("show-obstacles", false, "Show virtual obstacles as dots in standard rendering")
+		diffRedraw    = flag.Bool("diff-redraw", false, "Redraw only the changed parts of the screen in the TUI, to cut flicker on slow terminals")
+		noEd          = flag.Bool("no-ed", terminal.HideEdFromEnv(), "Hide the Ed mascot in the TUI, giving its rows to the diagram (default from "+terminal.NoEdEnv+")")
+		asciiQuotes   = flag.Bool("ascii-quotes", false, "Replace smart quotes in imported labels with plain ASCII quotes")
 		help          = flag.Bool("help", false, "Show help")
+		showVersion   = flag.Bool("version", false, "Print the version, commit and build date, then exit")
+		commandFile   = flag.String("commands", "", "Run a file of : commands against the diagram without the TUI, then exit (- for stdin)")
+		seed          = flag.Int64("seed", 0, "Layout tie-break seed for symmetric graphs (0 = order by node ID)")
 
 		// Diagram type flag
 		diagramType = flag.String("type", "", "Initial diagram type: sequence or box (default: box)")
 
 		// Export flags
-		format     = flag.String("format", "ascii", "Export format: ascii, mermaid, plantuml")
-		outputFile = flag.String("o", "", "Output file (default: stdout)")
+		format      = flag.String("format", "ascii", "Export format: ascii, mermaid, plantuml, plantuml-component, layout-json, websequencediagrams, pages, stylesheet")
+		styleSheet  = flag.String("style", "", "Apply a style sheet written by -format stylesheet to the diagram before exporting")
+		outputFile  = flag.String("o", "", "Output file (default: stdout)")
+		embedSource = flag.Bool("embed-source", false, "Append the edd JSON source as a comment so the export can be re-imported")
+		pageSize    = flag.String("page-size", "80x50", "Page size as COLUMNSxLINES for -format pages")
+		sortByID    = flag.Bool("sort", false, "List flowchart nodes and connections in ID order in mermaid and plantuml exports, for stable diffs")
 
 		// Import flags
-		inputFormat = flag.String("input-format", "", "Input format: json, mermaid, plantuml, graphviz, d2 (auto-detect if not specified)")
+		inputFormat = flag.String("input-format", "", "Input format: json, mermaid, plantuml, graphviz, d2, ascii (auto-detect if not specified)")
 		importFormat = flag.String("import", "", "[Deprecated: use -input-format] Import from format: mermaid, plantuml, graphviz, d2")
 
 		// Markdown mode flags
 		markdownMode = flag.Bool("markdown", false, "Edit diagram blocks within markdown files")
-		blockIndex   = flag.Int("block", 0, "Which diagram block to edit (1-based index, 0 = show picker)")
+		blockIndex   = flag.Int("block", 0, "Which diagram block, or diagram of a multi-diagram JSON file, to edit (1-based index, 0 = show picker)")
 
 		// Demo mode flags
 		demo      = flag.Bool("demo", false, "Demo mode: replay stdin input with randomized timing")
@@ -63,11 +84,15 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -debug diagram.json\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -format mermaid diagram.json    # Export to Mermaid\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -format plantuml -o output.puml diagram.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -format mermaid -embed-source -o out.mmd diagram.json  # Re-importable export\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -format layout-json diagram.json  # Computed node boxes and line points\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s -format pages -page-size 80x50 diagram.json  # Tile a large diagram for printing\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -input-format mermaid diagram.mmd  # Import from Mermaid\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s diagram.mmd                        # Auto-detect format by extension\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -input-format mermaid <(cat file)  # Use process substitution\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -markdown README.md                 # Edit diagram block in markdown\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -markdown -block 2 README.md        # Edit 2nd diagram block\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s lint diagram.json                  # Report style warnings\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nInteractive Mode Commands:\n")
 		fmt.Fprintf(os.Stderr, "  :export mermaid [file]   # Export to Mermaid format\n")
 		fmt.Fprintf(os.Stderr, "  :export plantuml [file]  # Export to PlantUML format\n")
@@ -81,6 +106,16 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *showVersion {
+		fmt.Println(versionString())
+		os.Exit(0)
+	}
+
+	terminal.DiffRedraw = *diffRedraw
+	terminal.HideEd = *noEd
+	layoutSeed = *seed
+	loader.ASCIIQuotes = *asciiQuotes
+
 	// Get filename if provided
 	args := flag.Args()
 	var filename string
@@ -88,6 +123,28 @@ func main() {
 		filename = args[0]
 	}
 
+	// Handle lint subcommand
+	if len(args) == 2 && args[0] == "lint" {
+		inFmt := *inputFormat
+		if inFmt == "" {
+			inFmt = *importFormat
+		}
+		os.Exit(runLint(args[1], inFmt))
+	}
+
+	// Run a command script headlessly
+	if *commandFile != "" {
+		inFmt := *inputFormat
+		if inFmt == "" {
+			inFmt = *importFormat
+		}
+		if err := runCommandFile(*commandFile, filename, inFmt, *diagramType, *blockIndex); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Handle markdown mode
 	if *markdownMode && filename != "" {
 		// Check if this is extraction mode (non-interactive)
@@ -108,6 +165,15 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Multi-diagram JSON files are edited one diagram at a time
+	if filename != "" && (*interactive || *edit) && loader.IsCollection(filename) {
+		if err := runCollectionMode(filename, *blockIndex); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Handle interactive mode (including demo mode)
 	if *interactive || *edit || *demo || (len(args) == 0 && !*validate && !*debug && !*showObstacles) {
 		// Launch TUI (with demo settings if applicable)
@@ -141,17 +207,38 @@ func main() {
 	if inFmt == "" {
 		inFmt = *importFormat
 	}
-	diagram, err := loadDiagram(filename, inFmt)
+	diagram, err := loader.Load(filename, inFmt, *blockIndex)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading diagram: %v\n", err)
+		reportLoadError(filename, err)
 		os.Exit(1)
 	}
 
+	// Apply the style sheet over the diagram's own hints
+	if *styleSheet != "" {
+		data, err := ioutil.ReadFile(*styleSheet)
+		if err == nil {
+			var sheet export.StyleSheet
+			if sheet, err = export.ParseStyleSheet(data); err == nil {
+				err = sheet.Apply(diagram)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error applying style sheet: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Messages between unknown participants render wrongly, so always say so
+	participantIssues := validation.ValidateParticipants(diagram)
+	for _, issue := range participantIssues {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", issue)
+	}
+
 	// Parse export format
 	exportFormat, err := export.ParseFormat(*format)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Available formats: ascii, mermaid, plantuml\n")
+		fmt.Fprintf(os.Stderr, "Available formats: ascii, mermaid, plantuml, plantuml-component, layout-json, websequencediagrams, pages, stylesheet\n")
 		os.Exit(1)
 	}
 
@@ -161,6 +248,20 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error creating exporter: %v\n", err)
 		os.Exit(1)
 	}
+	if sorter, ok := exporter.(interface{ SetSortByID(bool) }); ok {
+		sorter.SetSortByID(*sortByID)
+	}
+	if paged, ok := exporter.(*export.PagedExporter); ok {
+		var width, height int
+		if _, err := fmt.Sscanf(*pageSize, "%dx%d", &width, &height); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid page size %q, want COLUMNSxLINES such as 80x50\n", *pageSize)
+			os.Exit(1)
+		}
+		if err := paged.SetPageSize(width, height); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	var output string
 
@@ -168,10 +269,14 @@ func main() {
 	if exportFormat == export.FormatASCII {
 		// Create renderer
 		renderer := render.NewRenderer()
+		renderer.SetLayoutSeed(*seed)
 
 		// Enable validation if requested
 		if *validate {
 			renderer.EnableValidation()
+			for _, issue := range validation.ValidateGraph(diagram) {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", issue)
+			}
 		}
 
 		// Enable debug mode if requested
@@ -199,6 +304,15 @@ func main() {
 		}
 	}
 
+	// Embed the editable source if requested
+	if *embedSource {
+		output, err = export.EmbedSource(output, exportFormat, diagram)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error embedding source: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Output the result
 	if *outputFile != "" {
 		// Write to file
@@ -223,135 +337,63 @@ func main() {
 			os.Exit(2) // Exit with error code to indicate validation issues
 		}
 	}
+	if *validate && len(participantIssues) > 0 {
+		os.Exit(2)
+	}
 }
 
-// loadDiagram loads a diagram from a file, potentially importing from other formats
-func loadDiagram(filename string, inputFormat string) (*diagram.Diagram, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("opening file: %w", err)
-	}
-	defer file.Close()
+// reportLoadError prints why a diagram failed to load. Import syntax errors
+// also show the offending source line with a caret under the column.
+func reportLoadError(filename string, err error) {
+	fmt.Fprintf(os.Stderr, "Error loading diagram: %v\n", err)
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("reading file: %w", err)
+	var importErr *importer.ImportError
+	if !errors.As(err, &importErr) {
+		return
 	}
-
-	// Check if we need to import from another format
-	ext := strings.ToLower(filepath.Ext(filename))
-
-	// If input format is explicitly specified, use it
-	if inputFormat != "" && inputFormat != "json" {
-		// Import from specified format
-		registry := importer.NewImporterRegistry()
-		d, err := registry.ImportWithFormat(string(data), inputFormat)
-		if err != nil {
-			return nil, fmt.Errorf("importing diagram: %w", err)
-		}
-
-		// Ensure all connections have unique IDs
-		diagram.EnsureUniqueConnectionIDs(d)
-
-		// Default arrows to true for all connections
-		for i := range d.Connections {
-			if !d.Connections[i].Arrow {
-				d.Connections[i].Arrow = true
-			}
-		}
-
-		return d, nil
+	data, readErr := loader.ReadSource(filename)
+	if readErr != nil {
+		return
 	}
-
-	// Auto-detect based on extension
-	needImport := ext != ".json" && ext != ""
-
-	// Known import extensions
-	importExtensions := map[string]bool{
-		".mmd":      true,
-		".mermaid":  true,
-		".puml":     true,
-		".plantuml": true,
-		".pu":       true,
-		".dot":      true,
-		".gv":       true,
-		".d2":       true,
+	lines := strings.Split(string(data), "\n")
+	if importErr.Line < 1 || importErr.Line > len(lines) {
+		return
 	}
-
-	if needImport && importExtensions[ext] {
-		// Import from another format
-		registry := importer.NewImporterRegistry()
-
-		// Auto-detect format
-		d, err := registry.Import(string(data))
-
-		if err != nil {
-			return nil, fmt.Errorf("importing diagram: %w", err)
-		}
-
-		// Ensure all connections have unique IDs
-		diagram.EnsureUniqueConnectionIDs(d)
-
-		// Default arrows to true for all connections
-		for i := range d.Connections {
-			if !d.Connections[i].Arrow {
-				d.Connections[i].Arrow = true
-			}
-		}
-
-		return d, nil
+	line := strings.TrimRight(lines[importErr.Line-1], "\r")
+	fmt.Fprintf(os.Stderr, "  %s:%d: %s\n", filename, importErr.Line, line)
+	if importErr.Col > 0 {
+		prefix := fmt.Sprintf("  %s:%d: ", filename, importErr.Line)
+		fmt.Fprintf(os.Stderr, "%s^\n", strings.Repeat(" ", len(prefix)+importErr.Col-1))
 	}
+}
 
-	// Parse as JSON
-	var d diagram.Diagram
-	if err := json.Unmarshal(data, &d); err != nil {
-		// If JSON parsing fails and it might be another format, try importing
-		if inputFormat != "" || importExtensions[ext] {
-			registry := importer.NewImporterRegistry()
-
-			var imported *diagram.Diagram
-			if inputFormat != "" {
-				imported, err = registry.ImportWithFormat(string(data), inputFormat)
-			} else {
-				imported, err = registry.Import(string(data))
-			}
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse as JSON and import failed: %w", err)
-			}
-
-			// Ensure all connections have unique IDs
-			diagram.EnsureUniqueConnectionIDs(imported)
-
-			// Default arrows to true for all connections
-			for i := range imported.Connections {
-				if !imported.Connections[i].Arrow {
-					imported.Connections[i].Arrow = true
-				}
-			}
-
-			return imported, nil
-		}
-		return nil, fmt.Errorf("parsing JSON: %w", err)
+// runLint prints style warnings for a diagram file and returns the exit code:
+// 0 when clean, 1 on load errors and 2 when there are warnings.
+func runLint(filename string, inputFormat string) int {
+	d, err := loader.Load(filename, inputFormat, 0)
+	if err != nil {
+		reportLoadError(filename, err)
+		return 1
 	}
 
-	// Basic validation
-	if len(d.Nodes) == 0 {
-		return nil, fmt.Errorf("diagram has no nodes")
+	var warnings []string
+	for _, issue := range validation.ValidateGraph(d) {
+		warnings = append(warnings, issue.String())
 	}
-
-	// Ensure all connections have unique IDs
-	diagram.EnsureUniqueConnectionIDs(&d)
-
-	// Default arrows to true for all connections
-	for i := range d.Connections {
-		// Default arrows to true if not explicitly set to false
-		if !d.Connections[i].Arrow {
-			d.Connections[i].Arrow = true
-		}
+	for _, warning := range validation.Lint(d) {
+		warnings = append(warnings, warning.String())
+	}
+	for _, issue := range validation.ValidateParticipants(d) {
+		warnings = append(warnings, issue.String())
 	}
 
-	return &d, nil
+	for _, warning := range warnings {
+		fmt.Printf("%s: %s\n", filename, warning)
+	}
+	if len(warnings) > 0 {
+		return 2
+	}
+	return 0
 }
 
 // runMarkdownExtraction extracts and exports a diagram from markdown without interaction
@@ -387,7 +429,7 @@ func runMarkdownExtraction(filename string, blockIndex int, format string, outpu
 	}
 
 	// Import the diagram from the block content
-	registry := importer.NewImporterRegistry()
+	registry := loader.NewRegistry()
 	var d *diagram.Diagram
 
 	if selectedBlock.Type != "" {
@@ -477,7 +519,7 @@ func runMarkdownMode(filename string, blockIndex int) error {
 		}
 
 		// Import the diagram from the block content
-		registry := importer.NewImporterRegistry()
+		registry := loader.NewRegistry()
 		var d *diagram.Diagram
 
 		// Try to import based on the block type
@@ -501,31 +543,24 @@ func runMarkdownMode(filename string, blockIndex int) error {
 			}
 		}
 
-		// Create a temporary file to store the diagram JSON
-		tempFile := fmt.Sprintf("/tmp/edd_markdown_%d.json", selectedIndex)
-		dataJSON, err := json.MarshalIndent(d, "", "  ")
-		if err != nil {
-			return fmt.Errorf("marshaling diagram to JSON: %w", err)
-		}
-		err = ioutil.WriteFile(tempFile, dataJSON, 0644)
+		// Edit through a temp file whose context points back into the markdown
+		// (using 1-based index for consistency)
+		tempFile, err := terminal.CreateContextTempFile(d, terminal.EditContext{
+			File:       filename,
+			BlockIndex: selectedIndex + 1,
+			BlockType:  selectedBlock.Type,
+		})
 		if err != nil {
-			return fmt.Errorf("writing temp file: %w", err)
-		}
-
-		// Store markdown context for later (using 1-based index for consistency)
-		err = ioutil.WriteFile(tempFile+".ctx", []byte(fmt.Sprintf("%s\n%d\n%s", filename, selectedIndex+1, selectedBlock.Type)), 0644)
-		if err != nil {
-			return fmt.Errorf("writing context file: %w", err)
+			return err
 		}
 
 		fmt.Printf("\n[Press :w to save back to markdown, :q to return to picker, :qq to exit]\n\n")
 
-		// Run interactive mode with the temp file
-		err = runInteractiveMode(tempFile, d.Type, nil, true) // true = markdown mode
-
-		// Clean up temp files
-		os.Remove(tempFile)
-		os.Remove(tempFile + ".ctx")
+		// Run interactive mode with the temp file, removing it however that ends
+		err = func() error {
+			defer tempFile.Remove()
+			return runInteractiveMode(tempFile.Path, d.Type, nil, true) // true = markdown mode
+		}()
 
 		if err != nil {
 			// Check if this is a special "quit to picker" signal
@@ -541,8 +576,109 @@ func runMarkdownMode(filename string, blockIndex int) error {
 	}
 }
 
+// runCollectionMode edits one diagram of a multi-diagram JSON file, showing a
+// picker when the file holds more than one and no block was requested
+func runCollectionMode(filename string, blockIndex int) error {
+	data, err := loader.ReadSource(filename)
+	if err != nil {
+		return err
+	}
+	collection, _, err := diagram.ParseCollection(data)
+	if err != nil {
+		return err
+	}
+
+	labels := make([]string, len(collection.Diagrams))
+	for i := range collection.Diagrams {
+		labels[i] = collection.Label(i)
+	}
+
+	for {
+		var selectedIndex int
+		if blockIndex > 0 {
+			selectedIndex = blockIndex - 1
+			// Reset blockIndex so we show the picker on next iteration
+			blockIndex = 0
+		} else if len(labels) > 1 {
+			selectedIndex, err = showDiagramPicker("Diagrams:", labels, func(i int) (*diagram.Diagram, error) {
+				return loader.LoadCollectionDiagram(filename, i)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Reload so edits saved from an earlier pass are picked up
+		d, err := loader.LoadCollectionDiagram(filename, selectedIndex)
+		if err != nil {
+			return err
+		}
+
+		// Edit through a temp file whose context points back into the collection
+		tempFile, err := terminal.CreateContextTempFile(d, terminal.EditContext{
+			File:       filename,
+			BlockIndex: selectedIndex + 1,
+			BlockType:  terminal.CollectionBlockType,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = func() error {
+			defer tempFile.Remove()
+			return runInteractiveMode(tempFile.Path, d.Type, nil, len(labels) > 1)
+		}()
+
+		if err != nil {
+			if err.Error() == "return_to_picker" {
+				continue
+			}
+			return err
+		}
+		return nil
+	}
+}
+
 // showMarkdownPicker displays an interactive picker menu with live preview
 func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
+	labels := make([]string, len(blocks))
+	for i, block := range blocks {
+		labels[i] = markdown.FormatBlockInfo(block, i)
+	}
+
+	return showDiagramPicker("Diagram Blocks:", labels, func(i int) (*diagram.Diagram, error) {
+		block := blocks[i]
+
+		// Import the diagram
+		registry := loader.NewRegistry()
+		var d *diagram.Diagram
+		var err error
+
+		if block.Type != "" {
+			d, err = registry.ImportWithFormat(block.Content, block.Type)
+		} else {
+			d, err = registry.Import(block.Content)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Ensure connections have unique IDs
+		diagram.EnsureUniqueConnectionIDs(d)
+
+		// Default arrows to true for all connections
+		for i := range d.Connections {
+			if !d.Connections[i].Arrow {
+				d.Connections[i].Arrow = true
+			}
+		}
+		return d, nil
+	})
+}
+
+// showDiagramPicker displays an interactive picker menu over labels, with a
+// live preview of the diagram load returns for the selected entry
+func showDiagramPicker(title string, labels []string, load func(int) (*diagram.Diagram, error)) (int, error) {
 	selected := 0
 
 	// Setup terminal for raw mode
@@ -572,13 +708,12 @@ func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
 		previewX := listWidth + 2
 
 		// Render list
-		fmt.Print("\033[1;1H\033[1mDiagram Blocks:\033[0m")
-		for i, block := range blocks {
+		fmt.Printf("\033[1;1H\033[1m%s\033[0m", title)
+		for i, info := range labels {
 			fmt.Printf("\033[%d;1H", i+3)
 			if i == selected {
 				fmt.Printf("\033[7m") // Reverse video for selection
 			}
-			info := markdown.FormatBlockInfo(block, i)
 			if len(info) > listWidth-2 {
 				info = info[:listWidth-5] + "..."
 			}
@@ -594,31 +729,10 @@ func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
 		}
 
 		// Render preview of selected diagram
-		if selected >= 0 && selected < len(blocks) {
-			block := blocks[selected]
-
-			// Import the diagram
-			registry := importer.NewImporterRegistry()
-			var d *diagram.Diagram
-			var err error
-
-			if block.Type != "" {
-				d, err = registry.ImportWithFormat(block.Content, block.Type)
-			} else {
-				d, err = registry.Import(block.Content)
-			}
+		if selected >= 0 && selected < len(labels) {
+			d, err := load(selected)
 
 			if err == nil {
-				// Ensure connections have unique IDs
-				diagram.EnsureUniqueConnectionIDs(d)
-
-				// Default arrows to true for all connections
-				for i := range d.Connections {
-					if !d.Connections[i].Arrow {
-						d.Connections[i].Arrow = true
-					}
-				}
-
 				// Render the diagram
 				renderer := render.NewRenderer()
 				output, err := renderer.Render(d)
@@ -668,7 +782,7 @@ func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
 					fmt.Printf("\033[3;%dH\033[KError rendering: %v", previewX, err)
 				}
 			} else {
-				fmt.Printf("\033[3;%dH\033[KError importing: %v", previewX, err)
+				fmt.Printf("\033[3;%dH\033[KError loading: %v", previewX, err)
 			}
 		}
 
@@ -697,7 +811,7 @@ func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
 					}
 					continue
 				case 'B': // Down
-					if selected < len(blocks)-1 {
+					if selected < len(labels)-1 {
 						selected++
 					}
 					continue
@@ -713,7 +827,7 @@ func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
 		case 13, 10: // Enter
 			return selected, nil
 		case 'j': // j for down
-			if selected < len(blocks)-1 {
+			if selected < len(labels)-1 {
 				selected++
 			}
 		case 'k': // k for up
@@ -724,11 +838,66 @@ func showMarkdownPicker(blocks []markdown.DiagramBlock) (int, error) {
 	}
 }
 
+// runCommandFile runs a script of : commands against the diagram in
+// filename, or a new diagram of the given type when the file doesn't exist
+// yet, so that the script's :w creates it. A multi-diagram JSON file needs
+// the diagram to edit picked with blockIndex, 1-based.
+func runCommandFile(script, filename, inputFormat, diagramType string, blockIndex int) error {
+	var in io.Reader = os.Stdin
+	if script != "-" {
+		f, err := os.Open(script)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	tui := editor.NewTUIEditor(newEditorRenderer())
+
+	// A diagram of a multi-diagram file is edited through a context temp
+	// file, so :w puts it back in its place rather than over the whole file
+	if filename != "" && loader.IsCollection(filename) {
+		if blockIndex < 1 {
+			return fmt.Errorf("%s is a multi-diagram file; pick the diagram to edit with -block", filename)
+		}
+		d, err := loader.LoadCollectionDiagram(filename, blockIndex-1)
+		if err != nil {
+			return fmt.Errorf("failed to load diagram: %w", err)
+		}
+		tempFile, err := terminal.CreateContextTempFile(d, terminal.EditContext{
+			File:       filename,
+			BlockIndex: blockIndex,
+			BlockType:  terminal.CollectionBlockType,
+		})
+		if err != nil {
+			return err
+		}
+		defer tempFile.Remove()
+		tui.SetDiagram(d)
+		return terminal.RunCommandFile(tui, in, tempFile.Path, os.Stdout)
+	}
+
+	if _, err := os.Stat(filename); filename != "" && err == nil {
+		d, err := loader.Load(filename, inputFormat, 0)
+		if err != nil {
+			return fmt.Errorf("failed to load diagram: %w", err)
+		}
+		tui.SetDiagram(d)
+	} else if diagramType != "" {
+		if diagramType != "sequence" && diagramType != "box" {
+			return fmt.Errorf("invalid diagram type: %s (must be 'sequence' or 'box')", diagramType)
+		}
+		tui.SetDiagram(&diagram.Diagram{Type: diagramType})
+	}
+	return terminal.RunCommandFile(tui, in, filename, os.Stdout)
+}
+
 // runInteractiveMode launches the TUI editor with optional demo mode
 // This is the main entry point for interactive editing
 func runInteractiveMode(filename string, diagramType string, demoSettings *terminal.DemoSettings, markdownMode ...bool) error {
 	// Create the real renderer
-	renderer := editor.NewRealRenderer()
+	renderer := newEditorRenderer()
 
 	// Create TUI editor
 	tui := editor.NewTUIEditor(renderer)
@@ -739,12 +908,15 @@ func runInteractiveMode(filename string, diagramType string, demoSettings *termi
 
 	// Load diagram if filename provided
 	if filename != "" {
-		// Use the main loadDiagram function which handles imports
-		d, err := loadDiagram(filename, "")
+		// Load through the shared loader, which handles imports
+		d, err := loader.Load(filename, "", 0)
 		if err != nil {
 			return fmt.Errorf("failed to load diagram: %w", err)
 		}
 		tui.SetDiagram(d)
+		if issues := validation.ValidateParticipants(d); len(issues) > 0 {
+			tui.SetCommandResult(fmt.Sprintf("Warning: %s", issues[0]))
+		}
 	} else if diagramType != "" {
 		// No file provided, but user specified a diagram type
 		d := &diagram.Diagram{
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"edd/diagram"
+	"edd/render"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVersionFlagPrintsInjectedVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the edd binary")
+	}
+	binary := filepath.Join(t.TempDir(), "edd")
+	build := exec.Command("go", "build", "-o", binary,
+		"-ldflags", "-X main.version=1.2.3 -X main.commit=abc1234 -X main.date=2026-01-02", ".")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v\n%s", err, output)
+	}
+
+	// No diagram file is needed, and nothing else runs
+	output, err := exec.Command(binary, "-version").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Expected -version to exit zero, got %v\n%s", err, output)
+	}
+	if got, want := strings.TrimSpace(string(output)), "edd 1.2.3 (commit abc1234, built 2026-01-02)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestCommandFileEditsOneDiagramOfACollection(t *testing.T) {
+	dir := t.TempDir()
+	collection := filepath.Join(dir, "diagrams.json")
+	source := `{"diagrams": [
+  {"metadata": {"name": "One"}, "nodes": [{"id": 1, "text": ["A"]}]},
+  {"metadata": {"name": "Two"}, "nodes": [{"id": 1, "text": ["B"]}]}
+]}`
+	if err := os.WriteFile(collection, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "script.edc")
+	if err := os.WriteFile(script, []byte("add C\nw\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without -block there's no telling which diagram to edit
+	if err := runCommandFile(script, collection, "", "", 0); err == nil || !strings.Contains(err.Error(), "-block") {
+		t.Errorf("Expected an error asking for -block, got %v", err)
+	}
+
+	if err := runCommandFile(script, collection, "", "", 1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok, err := diagram.ParseCollection(data)
+	if !ok || err != nil || len(c.Diagrams) != 2 {
+		t.Fatalf("Expected both diagrams kept (%v):\n%s", err, data)
+	}
+	if len(c.Diagrams[0].Nodes) != 2 || c.Diagrams[1].Metadata.Name != "Two" || len(c.Diagrams[1].Nodes) != 1 {
+		t.Errorf("Expected only the first diagram edited:\n%s", data)
+	}
+}
+
+func TestEditorRendererUsesSeedFlag(t *testing.T) {
+	// A diamond of interchangeable branches, laid out by the seed
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Root"}},
+			{ID: 2, Text: []string{"L"}},
+			{ID: 3, Text: []string{"R"}},
+			{ID: 4, Text: []string{"LL"}},
+			{ID: 5, Text: []string{"LR"}},
+			{ID: 6, Text: []string{"RL"}},
+			{ID: 7, Text: []string{"RR"}},
+			{ID: 8, Text: []string{"Join"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2}, {From: 1, To: 3},
+			{From: 2, To: 4}, {From: 2, To: 5},
+			{From: 3, To: 6}, {From: 3, To: 7},
+			{From: 4, To: 8}, {From: 5, To: 8}, {From: 6, To: 8}, {From: 7, To: 8},
+		},
+	}
+	defer func() { layoutSeed = 0 }()
+
+	layoutSeed = 0
+	unseeded, err := newEditorRenderer().Render(d)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	layoutSeed = 7
+	seeded, err := newEditorRenderer().Render(d)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	export := render.NewRenderer()
+	export.SetLayoutSeed(7)
+	exported, err := export.Render(d)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	if seeded == unseeded {
+		t.Errorf("Expected the seed to change the editor's layout:\n%s", seeded)
+	}
+	if strings.TrimRight(seeded, "\n") != strings.TrimRight(exported, "\n") {
+		t.Errorf("Expected the editor to lay out as the seeded export does:\n%s\nvs\n%s", seeded, exported)
+	}
+}
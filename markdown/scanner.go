@@ -21,20 +21,27 @@ type DiagramBlock struct {
 type Scanner struct {
 	content string
 	lines   []string
+	bom     bool // Content started with a UTF-8 byte order mark
+	crlf    bool // Content used Windows line endings
 }
 
-// NewScanner creates a new markdown scanner
+// NewScanner creates a new markdown scanner. A byte order mark and CRLF line
+// endings are accepted; blocks are found in the LF-normalized text, and
+// ReplaceBlock writes the file back with its original line endings.
 func NewScanner(content string) *Scanner {
-	return &Scanner{
-		content: content,
-		lines:   strings.Split(content, "\n"),
-	}
+	s := &Scanner{}
+	s.UpdateContent(content)
+	return s
 }
 
 // UpdateContent updates the scanner's internal content after a successful replacement
 func (s *Scanner) UpdateContent(newContent string) {
 	s.content = newContent
-	s.lines = strings.Split(newContent, "\n")
+	s.bom = strings.HasPrefix(newContent, "\uFEFF")
+	s.crlf = strings.Contains(newContent, "\r\n")
+	normalized := strings.TrimPrefix(newContent, "\uFEFF")
+	normalized = strings.ReplaceAll(normalized, "\r\n", "\n")
+	s.lines = strings.Split(normalized, "\n")
 }
 
 // FindDiagramBlocks finds all diagram code blocks in the markdown
@@ -169,7 +176,14 @@ func (s *Scanner) ReplaceBlock(block DiagramBlock, newContent string) (string, e
 		insertPos++
 	}
 
-	return strings.Join(newLines, "\n"), nil
+	result := strings.Join(newLines, "\n")
+	if s.crlf {
+		result = strings.ReplaceAll(result, "\n", "\r\n")
+	}
+	if s.bom {
+		result = "\uFEFF" + result
+	}
+	return result, nil
 }
 
 // GetContent returns the current markdown content
@@ -19,6 +19,7 @@ type AreaRouter struct {
 	pathFinder      diagram.AreaPathFinder
 	obstacleManager ObstacleManager
 	flowDirection   FlowDirection // Primary flow direction for exit point selection
+	softObstacles   func(diagram.Point) bool // Cells to avoid when another route exists
 }
 
 // NewAreaRouter creates a new area-based router with vertical flow (top-to-bottom)
@@ -35,6 +36,12 @@ func (ar *AreaRouter) SetFlowDirection(direction FlowDirection) {
 	ar.flowDirection = direction
 }
 
+// SetSoftObstacles sets cells that routes keep clear of when they can, such as
+// the labels of connections routed earlier. nil clears them.
+func (ar *AreaRouter) SetSoftObstacles(obstacles func(diagram.Point) bool) {
+	ar.softObstacles = obstacles
+}
+
 // RouteConnection finds a path from source edge to target edge
 func (ar *AreaRouter) RouteConnection(conn diagram.Connection, nodes []diagram.Node) (diagram.Path, error) {
 	// Find source and target nodes
@@ -157,8 +164,35 @@ func (ar *AreaRouter) RouteConnection(conn diagram.Connection, nodes []diagram.N
 		}
 	}
 	
-	// Find the path from source edge to target edge
-	finalPath, err := ar.pathFinder.FindPathToArea(startPoint, *targetNode, obstacleFunc)
+	// Sides forced by from-side/to-side hints override the choice above
+	fromSide, forceFrom := parseSideHint(conn.Hints["from-side"])
+	toSide, forceTo := parseSideHint(conn.Hints["to-side"])
+	if forceFrom {
+		startPoint = getEdgeCenterForPort(sourceNode, fromSide)
+	}
+
+	// Find the path from source edge to target edge, keeping clear of soft
+	// obstacles unless that leaves no route from the chosen edge
+	route := func(forceTo bool, toSide EdgeSide) (diagram.Path, error) {
+		if soft := ar.softObstacles; soft != nil {
+			avoiding := func(p diagram.Point) bool { return obstacleFunc(p) || soft(p) }
+			if path, err := ar.findPath(startPoint, forceFrom, fromSide, targetNode, forceTo, toSide, avoiding); err == nil {
+				return path, nil
+			}
+		}
+		return ar.findPath(startPoint, forceFrom, fromSide, targetNode, forceTo, toSide, obstacleFunc)
+	}
+	finalPath, err := route(forceTo, toSide)
+
+	// A route down (or up) to a box in another row that lands on its side is
+	// redrawn to enter the top (or bottom) edge at its center instead
+	if err == nil && !forceTo {
+		if side, ok := ar.verticalEntrySide(finalPath, sourceNode, targetNode); ok {
+			if path, entryErr := route(true, side); entryErr == nil {
+				finalPath = path
+			}
+		}
+	}
 	if err != nil {
 		// Fallback: try other edges if the chosen edge is blocked
 		alternativePoints := ar.getAlternativeStartPoints(sourceNode, startPoint)
@@ -189,6 +223,95 @@ func (ar *AreaRouter) RouteConnection(conn diagram.Connection, nodes []diagram.N
 	return finalPath, nil
 }
 
+// verticalEntrySide reports the edge a path should enter the target through
+// when, in vertical flow, the target sits wholly below or above the source but
+// the path ends beside it: North for a target below, South for one above.
+func (ar *AreaRouter) verticalEntrySide(path diagram.Path, sourceNode, targetNode *diagram.Node) (EdgeSide, bool) {
+	if ar.flowDirection != FlowVertical || len(path.Points) == 0 {
+		return North, false
+	}
+	end := path.Points[len(path.Points)-1]
+	if end.X >= targetNode.X && end.X < targetNode.X+targetNode.Width {
+		return North, false // Already enters through the top or bottom
+	}
+
+	switch {
+	case targetNode.Y > sourceNode.Y+sourceNode.Height:
+		return North, true
+	case targetNode.Y+targetNode.Height < sourceNode.Y:
+		return South, true
+	}
+	return North, false
+}
+
+// findPath routes from start to the target, honouring any forced sides
+func (ar *AreaRouter) findPath(start diagram.Point, forceFrom bool, fromSide EdgeSide, targetNode *diagram.Node, forceTo bool, toSide EdgeSide, obstacleFunc func(diagram.Point) bool) (diagram.Path, error) {
+	if forceFrom || forceTo {
+		return ar.routeBetweenSides(start, forceFrom, fromSide, targetNode, forceTo, toSide, obstacleFunc)
+	}
+	return ar.pathFinder.FindPathToArea(start, *targetNode, obstacleFunc)
+}
+
+// routeBetweenSides routes from start to the target when the from-side or
+// to-side hint fixes where the path leaves or enters. A forced side gets a
+// straight one-cell stub, so the line meets the box at a right angle and the
+// junction or arrowhead is drawn on that side.
+func (ar *AreaRouter) routeBetweenSides(start diagram.Point, forceFrom bool, fromSide EdgeSide, targetNode *diagram.Node, forceTo bool, toSide EdgeSide, obstacleFunc func(diagram.Point) bool) (diagram.Path, error) {
+	var points []diagram.Point
+	from := start
+	if forceFrom {
+		points = append(points, start)
+		from = stepOutward(start, fromSide)
+	}
+
+	if !forceTo {
+		path, err := ar.pathFinder.FindPathToArea(from, *targetNode, obstacleFunc)
+		if err != nil {
+			return diagram.Path{}, err
+		}
+		return diagram.Path{Points: append(points, path.Points...), Cost: path.Cost}, nil
+	}
+
+	end := getEdgeCenterForPort(targetNode, toSide)
+	path, err := ar.pathFinder.FindPath(from, stepOutward(end, toSide), obstacleFunc)
+	if err != nil {
+		return diagram.Path{}, err
+	}
+	points = append(points, path.Points...)
+	points = append(points, end)
+	return diagram.Path{Points: points, Cost: path.Cost}, nil
+}
+
+// parseSideHint converts a from-side/to-side hint value to an edge.
+func parseSideHint(value string) (EdgeSide, bool) {
+	switch value {
+	case "top":
+		return North, true
+	case "bottom":
+		return South, true
+	case "left":
+		return West, true
+	case "right":
+		return East, true
+	}
+	return North, false
+}
+
+// stepOutward moves p one cell away from a node across the given edge.
+func stepOutward(p diagram.Point, edge EdgeSide) diagram.Point {
+	switch edge {
+	case North:
+		p.Y--
+	case South:
+		p.Y++
+	case East:
+		p.X++
+	case West:
+		p.X--
+	}
+	return p
+}
+
 // getAlternativeStartPoints returns other edge points to try if the primary fails
 func (ar *AreaRouter) getAlternativeStartPoints(node *diagram.Node, exclude diagram.Point) []diagram.Point {
 	points := []diagram.Point{}
@@ -116,8 +116,9 @@ func (pc *PathCache) String() string {
 
 // CachedPathFinder wraps a PathFinder with caching functionality
 type CachedPathFinder struct {
-	finder PathFinder
-	cache  *PathCache
+	finder      PathFinder
+	cache       *PathCache
+	obstacleKey uint64 // Set by SetObstacleKey; 0 hashes obstacle cells
 }
 
 // NewCachedPathFinder creates a new cached path finder
@@ -134,8 +135,10 @@ func (cpf *CachedPathFinder) FindPath(start, end diagram.Point, obstacles func(d
 	// In production, this would need a more sophisticated approach
 	obstacleHash := cpf.hashObstacles(start, end, obstacles)
 	
-	// Check cache first
-	if path, found := cpf.cache.Get(start, end, obstacleHash); found {
+	// Check cache first. The hash only covers the area between the endpoints,
+	// so a node moved onto a cached detour leaves the hash unchanged; re-check
+	// the rest of the route before reusing it.
+	if path, found := cpf.cache.Get(start, end, obstacleHash); found && isDetourClear(path, start, end, obstacles) {
 		return path, nil
 	}
 	
@@ -151,23 +154,32 @@ func (cpf *CachedPathFinder) FindPath(start, end diagram.Point, obstacles func(d
 	return path, nil
 }
 
-// hashObstacles creates a simple hash of obstacles along the potential path
-// This is a simplified version - in production, you'd want a more robust approach
+// SetObstacleKey identifies the obstacles of the FindPath calls that follow,
+// for a caller that knows what they are made of, such as the router with its
+// node boxes and the routes already taken. Cached paths are then keyed on it
+// without looking at any cells. Zero goes back to hashing obstacle cells.
+func (cpf *CachedPathFinder) SetObstacleKey(key uint64) {
+	cpf.obstacleKey = key
+}
+
+// hashObstacles returns the key set by SetObstacleKey, or without one hashes
+// every obstacle cell in the bounding box of the start and end points. Every
+// cell counts, so an obstacle anywhere in the box, even one narrower than a
+// node, changes the hash.
 func (cpf *CachedPathFinder) hashObstacles(start, end diagram.Point, obstacles func(diagram.Point) bool) uint64 {
 	if obstacles == nil {
 		return 0
 	}
+	if cpf.obstacleKey != 0 {
+		return cpf.obstacleKey
+	}
 	
-	// Sample a few points along the potential path area
-	// This is a very simple hash and may have collisions
 	var hash uint64
 	minX, maxX := min(start.X, end.X), max(start.X, end.X)
 	minY, maxY := min(start.Y, end.Y), max(start.Y, end.Y)
 	
-	// Sample grid points in the bounding box
-	step := max(1, (maxX-minX+maxY-minY)/20) // Sample ~20 points
-	for x := minX; x <= maxX; x += step {
-		for y := minY; y <= maxY; y += step {
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
 			if obstacles(diagram.Point{X: x, Y: y}) {
 				// Simple hash combining
 				hash = hash*31 + uint64(x)*7 + uint64(y)*13
@@ -178,6 +190,44 @@ func (cpf *CachedPathFinder) hashObstacles(start, end diagram.Point, obstacles f
 	return hash
 }
 
+// isDetourClear reports whether the cells of a cached path that lie outside
+// the start/end bounding box are still free. Cells inside the box are already
+// covered by hashObstacles.
+func isDetourClear(path diagram.Path, start, end diagram.Point, obstacles func(diagram.Point) bool) bool {
+	if obstacles == nil {
+		return true
+	}
+
+	minX, maxX := min(start.X, end.X), max(start.X, end.X)
+	minY, maxY := min(start.Y, end.Y), max(start.Y, end.Y)
+	blocked := func(p diagram.Point) bool {
+		inside := p.X >= minX && p.X <= maxX && p.Y >= minY && p.Y <= maxY
+		return !inside && obstacles(p)
+	}
+
+	for i, p := range path.Points {
+		if blocked(p) {
+			return false
+		}
+		if i == 0 {
+			continue
+		}
+		// Walk the cells between consecutive points
+		prev := path.Points[i-1]
+		dir := segmentDirection(prev, p)
+		if dir.X != 0 && dir.Y != 0 {
+			continue // Not orthogonal; only the vertices can be checked
+		}
+		for c := prev; c != p; c = (diagram.Point{X: c.X + dir.X, Y: c.Y + dir.Y}) {
+			if blocked(c) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 // ClearCache clears the path cache
 func (cpf *CachedPathFinder) ClearCache() {
 	cpf.cache.Clear()
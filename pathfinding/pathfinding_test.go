@@ -2431,6 +2431,393 @@ func TestGetDirection(t *testing.T) {
 	}
 }
 
+// ==================== PATH SMOOTHING TESTS ====================
+
+// firstBendDistance returns how far a path travels before its first change of direction.
+func firstBendDistance(points []diagram.Point) int {
+	merged := MergeCollinearPoints(points)
+	if len(merged) < 2 {
+		return 0
+	}
+	return segmentLength(merged[0], merged[1])
+}
+
+func TestSmoothPath_MinStubBeforeFirstBend(t *testing.T) {
+	tests := []struct {
+		name    string
+		points  []diagram.Point
+		minStub int
+	}{
+		{
+			name:    "L-shape with short horizontal leg flips corner",
+			points:  []diagram.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 6}},
+			minStub: 3,
+		},
+		{
+			name:    "L-shape with short vertical leg flips corner",
+			points:  []diagram.Point{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 8, Y: 1}},
+			minStub: 4,
+		},
+		{
+			name:    "Z-shape slides middle segment",
+			points:  []diagram.Point{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 6, Y: 1}, {X: 6, Y: 8}},
+			minStub: 3,
+		},
+		{
+			name:    "Z-shape already long enough is untouched",
+			points:  []diagram.Point{{X: 0, Y: 0}, {X: 0, Y: 4}, {X: 6, Y: 4}, {X: 6, Y: 8}},
+			minStub: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smoothed := SmoothPath(tt.points, SmoothingOptions{MinStubLength: tt.minStub})
+
+			if got := firstBendDistance(smoothed); got < tt.minStub {
+				t.Errorf("First bend after %d cells, want at least %d (path %v)", got, tt.minStub, smoothed)
+			}
+			if smoothed[0] != tt.points[0] || smoothed[len(smoothed)-1] != tt.points[len(tt.points)-1] {
+				t.Errorf("Endpoints changed: got %v", smoothed)
+			}
+			if got, want := len(MergeCollinearPoints(smoothed)), len(MergeCollinearPoints(tt.points)); got != want {
+				t.Errorf("Bend count changed: got %d points, want %d", got, want)
+			}
+			for i := 0; i < len(smoothed)-1; i++ {
+				if smoothed[i].X != smoothed[i+1].X && smoothed[i].Y != smoothed[i+1].Y {
+					t.Errorf("Segment %d is diagonal: %v -> %v", i, smoothed[i], smoothed[i+1])
+				}
+			}
+		})
+	}
+}
+
+func TestSmoothPath_MergeCollinear(t *testing.T) {
+	points := []diagram.Point{
+		{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 0},
+		{X: 2, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 2},
+	}
+	want := []diagram.Point{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 2}, {X: 3, Y: 2}}
+
+	got := SmoothPath(points, SmoothingOptions{MergeCollinear: true})
+	if len(got) != len(want) {
+		t.Fatalf("Got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Point %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSmoothPath_ZeroOptionsUnchanged(t *testing.T) {
+	points := []diagram.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}}
+	got := SmoothPath(points, SmoothingOptions{})
+	if len(got) != len(points) {
+		t.Errorf("Zero options should leave path unchanged, got %v", got)
+	}
+}
+
+func TestRouter_StubHintMovesFirstBend(t *testing.T) {
+	nodes := []diagram.Node{
+		{ID: 1, X: 0, Y: 0, Width: 10, Height: 3},
+		{ID: 2, X: 15, Y: 10, Width: 10, Height: 3},
+		{ID: 3, X: 0, Y: 6, Width: 4, Height: 3},
+	}
+	stub := func(n string) diagram.Connection {
+		return diagram.Connection{From: 1, To: 2, Hints: map[string]string{"stub": n}}
+	}
+	// Leaves the bottom of node 1 and turns on the very next line
+	path := diagram.Path{Points: []diagram.Point{{X: 5, Y: 2}, {X: 5, Y: 3}, {X: 20, Y: 3}, {X: 20, Y: 10}}}
+
+	smoothed := smoothStub(path, stub("4"), nodes)
+	if got := firstBendDistance(smoothed.Points); got < 4 {
+		t.Errorf("First bend after %d cells, want at least 4 (path %v)", got, smoothed.Points)
+	}
+	if first, last := smoothed.Points[0], smoothed.Points[len(smoothed.Points)-1]; first != path.Points[0] || last != path.Points[3] {
+		t.Errorf("Endpoints changed: got %v", smoothed.Points)
+	}
+
+	// Without the hint, or when the stub would run into node 3, the route stays
+	if got := smoothStub(path, diagram.Connection{From: 1, To: 2}, nodes); len(got.Points) != 4 || got.Points[1] != path.Points[1] {
+		t.Errorf("Expected the route kept without a stub hint, got %v", got.Points)
+	}
+	blocked := diagram.Path{Points: []diagram.Point{{X: 1, Y: 2}, {X: 1, Y: 3}, {X: 20, Y: 3}, {X: 20, Y: 10}}}
+	if got := smoothStub(blocked, stub("4"), nodes); got.Points[1] != blocked.Points[1] {
+		t.Errorf("Expected the route kept where the stub would cross node 3, got %v", got.Points)
+	}
+}
+
+// ==================== REROUTE TESTS ====================
+
+// pathEntersNode reports whether any cell of the path lies inside the node's bounds
+func pathEntersNode(path diagram.Path, node diagram.Node) bool {
+	for i, p := range path.Points {
+		if i > 0 {
+			prev := path.Points[i-1]
+			dir := segmentDirection(prev, p)
+			for c := prev; c != p; c = (diagram.Point{X: c.X + dir.X, Y: c.Y + dir.Y}) {
+				if c.X >= node.X && c.X < node.X+node.Width && c.Y >= node.Y && c.Y < node.Y+node.Height {
+					return true
+				}
+			}
+		}
+		if p.X >= node.X && p.X < node.X+node.Width && p.Y >= node.Y && p.Y < node.Y+node.Height {
+			return true
+		}
+	}
+	return false
+}
+
+func newRerouteRouter() *Router {
+	pf := NewSmartPathFinder(PathCost{
+		StraightCost:  10,
+		TurnCost:      20,
+		ProximityCost: -5,
+		DirectionBias: 0,
+	})
+	return NewRouter(NewCachedPathFinder(pf, 100))
+}
+
+func TestRouter_ReroutesAroundMovedNode(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []diagram.Node
+		moved diagram.Node // node 3 after being moved into the edge's path
+	}{
+		{
+			name: "vertical route",
+			nodes: []diagram.Node{
+				{ID: 1, X: 10, Y: 0, Width: 10, Height: 3},
+				{ID: 2, X: 10, Y: 20, Width: 10, Height: 3},
+				{ID: 3, X: 40, Y: 10, Width: 10, Height: 3},
+			},
+			moved: diagram.Node{ID: 3, X: 8, Y: 10, Width: 14, Height: 3},
+		},
+		{
+			name: "L-shaped route",
+			nodes: []diagram.Node{
+				{ID: 1, X: 0, Y: 0, Width: 10, Height: 3},
+				{ID: 2, X: 30, Y: 20, Width: 10, Height: 3},
+				{ID: 3, X: 60, Y: 40, Width: 10, Height: 3},
+			},
+			moved: diagram.Node{ID: 3, X: 0, Y: 8, Width: 40, Height: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRerouteRouter()
+			conn := diagram.Connection{From: 1, To: 2}
+
+			before, err := router.RouteConnection(conn, tt.nodes)
+			if err != nil {
+				t.Fatalf("Initial route failed: %v", err)
+			}
+			if !pathEntersNode(before, tt.moved) {
+				t.Fatalf("Test setup: moved node should sit on the original route %v", before.Points)
+			}
+
+			nodes := append([]diagram.Node{}, tt.nodes...)
+			nodes[2] = tt.moved
+
+			after, err := router.RouteConnection(conn, nodes)
+			if err != nil {
+				t.Fatalf("Reroute failed: %v", err)
+			}
+			if pathEntersNode(after, tt.moved) {
+				t.Errorf("Route still crosses moved node: %v", after.Points)
+			}
+		})
+	}
+}
+
+func TestCachedPathFinder_RevalidatesCachedDetour(t *testing.T) {
+	finder := NewCachedPathFinder(NewAStarPathFinder(PathCost{
+		StraightCost: 10,
+		TurnCost:     5,
+	}), 10)
+	start, end := diagram.Point{X: 0, Y: 0}, diagram.Point{X: 0, Y: 10}
+
+	// A narrow node in the column forces a detour outside the endpoints' bounding box
+	narrow := func(p diagram.Point) bool {
+		return p.X >= -1 && p.X <= 1 && p.Y >= 4 && p.Y <= 6
+	}
+	first, err := finder.FindPath(start, end, narrow)
+	if err != nil {
+		t.Fatalf("FindPath failed: %v", err)
+	}
+
+	// Widening the node covers the detour without changing the cells in the column
+	wide := func(p diagram.Point) bool {
+		return p.X >= -6 && p.X <= 6 && p.Y >= 4 && p.Y <= 6
+	}
+	if isDetourClear(first, start, end, wide) {
+		t.Fatalf("Test setup: widened node should block the cached detour %v", first.Points)
+	}
+
+	second, err := finder.FindPath(start, end, wide)
+	if err != nil {
+		t.Fatalf("FindPath failed: %v", err)
+	}
+	if !isDetourClear(second, start, end, wide) {
+		t.Errorf("Cached path was reused through the widened node: %v", second.Points)
+	}
+}
+
+func TestCachedPathFinder_RechecksCellsBetweenHashSamples(t *testing.T) {
+	finder := NewCachedPathFinder(NewAStarPathFinder(PathCost{
+		StraightCost: 10,
+		TurnCost:     5,
+	}), 10)
+	start, end := diagram.Point{X: 0, Y: 0}, diagram.Point{X: 0, Y: 100}
+
+	// An obstacle on the cached straight route, between two of the cells a
+	// hash sampling every fifth cell would look at
+	obstacle := diagram.Node{X: -1, Y: 51, Width: 3, Height: 3}
+	blocked := func(p diagram.Point) bool {
+		return p.X >= obstacle.X && p.X < obstacle.X+obstacle.Width && p.Y >= obstacle.Y && p.Y < obstacle.Y+obstacle.Height
+	}
+
+	first, err := finder.FindPath(start, end, func(diagram.Point) bool { return false })
+	if err != nil {
+		t.Fatalf("FindPath failed: %v", err)
+	}
+	if !pathEntersNode(first, obstacle) {
+		t.Fatalf("Test setup: the obstacle should sit on the cached route %v", first.Points)
+	}
+
+	second, err := finder.FindPath(start, end, blocked)
+	if err != nil {
+		t.Fatalf("FindPath failed: %v", err)
+	}
+	if pathEntersNode(second, obstacle) {
+		t.Errorf("Cached path was reused through the obstacle: %v", second.Points)
+	}
+}
+
+func TestCachedPathFinder_ObstacleKeySkipsCellScan(t *testing.T) {
+	finder := NewCachedPathFinder(NewAStarPathFinder(PathCost{StraightCost: 10, TurnCost: 5}), 10)
+	calls := 0
+	obstacles := func(diagram.Point) bool {
+		calls++
+		return false
+	}
+
+	finder.SetObstacleKey(42)
+	if hash := finder.hashObstacles(diagram.Point{X: 0, Y: 0}, diagram.Point{X: 200, Y: 200}, obstacles); hash != 42 || calls != 0 {
+		t.Errorf("Expected the set key without looking at cells, got hash %d after %d cell checks", hash, calls)
+	}
+
+	finder.SetObstacleKey(0)
+	finder.hashObstacles(diagram.Point{X: 0, Y: 0}, diagram.Point{X: 2, Y: 2}, obstacles)
+	if calls != 9 {
+		t.Errorf("Expected every cell hashed without a key, got %d cell checks", calls)
+	}
+}
+
+func TestRouter_KeysCachedRoutesOnNodeBoxes(t *testing.T) {
+	finder := NewCachedPathFinder(NewSmartPathFinder(DefaultPathCost), 100)
+	router := NewRouter(finder)
+	nodes := []diagram.Node{
+		{ID: 1, X: 10, Y: 0, Width: 10, Height: 3},
+		{ID: 2, X: 10, Y: 20, Width: 10, Height: 3},
+		{ID: 3, X: 40, Y: 10, Width: 10, Height: 3},
+	}
+	// A forced to-side routes point to point, through the path cache
+	conns := []diagram.Connection{{ID: 0, From: 1, To: 2, Hints: map[string]string{"to-side": "top"}}}
+
+	if _, err := router.RouteConnections(conns, nodes); err != nil {
+		t.Fatalf("RouteConnections failed: %v", err)
+	}
+	hits, _, _, _ := finder.cache.Stats()
+	if _, err := router.RouteConnections(conns, nodes); err != nil {
+		t.Fatalf("RouteConnections failed: %v", err)
+	}
+	if again, _, _, _ := finder.cache.Stats(); again == hits {
+		t.Errorf("Expected routing the same boxes again to reuse cached paths")
+	}
+
+	// Moving a node onto the route changes the key, so it is routed afresh
+	moved := diagram.Node{ID: 3, X: 8, Y: 10, Width: 14, Height: 3}
+	nodes[2] = moved
+	paths, err := router.RouteConnections(conns, nodes)
+	if err != nil {
+		t.Fatalf("RouteConnections failed: %v", err)
+	}
+	if pathEntersNode(paths[0], moved) {
+		t.Errorf("Route still crosses moved node: %v", paths[0].Points)
+	}
+	if finder.obstacleKey != 0 {
+		t.Errorf("Expected the obstacle key cleared after routing")
+	}
+}
+
+// ==================== SIDE HINT TESTS ====================
+
+func TestRouter_SideHints(t *testing.T) {
+	// The target sits directly to the left of the source
+	source := diagram.Node{ID: 1, X: 40, Y: 10, Width: 10, Height: 3}
+	target := diagram.Node{ID: 2, X: 0, Y: 10, Width: 10, Height: 3}
+	nodes := []diagram.Node{source, target}
+
+	first := func(path diagram.Path) (diagram.Point, diagram.Point) {
+		return path.Points[0], path.Points[1]
+	}
+	last := func(path diagram.Path) (diagram.Point, diagram.Point) {
+		n := len(path.Points)
+		return path.Points[n-1], path.Points[n-2]
+	}
+
+	t.Run("no hint exits toward the target", func(t *testing.T) {
+		path, err := newRerouteRouter().RouteConnection(diagram.Connection{From: 1, To: 2}, nodes)
+		if err != nil {
+			t.Fatalf("RouteConnection() error = %v", err)
+		}
+		if start, _ := first(path); start.X != source.X {
+			t.Errorf("Expected the path to leave the left side (X=%d), starts at %v", source.X, start)
+		}
+	})
+
+	t.Run("from-side right", func(t *testing.T) {
+		conn := diagram.Connection{From: 1, To: 2, Hints: map[string]string{"from-side": "right"}}
+		path, err := newRerouteRouter().RouteConnection(conn, nodes)
+		if err != nil {
+			t.Fatalf("RouteConnection() error = %v", err)
+		}
+		start, next := first(path)
+		if start.X != source.X+source.Width-1 || next.X <= start.X || next.Y != start.Y {
+			t.Errorf("Expected the path to leave the right side heading right, got %v", path.Points)
+		}
+		if pathEntersNode(diagram.Path{Points: path.Points[1:]}, source) {
+			t.Errorf("Path doubles back through the source: %v", path.Points)
+		}
+	})
+
+	t.Run("to-side bottom", func(t *testing.T) {
+		conn := diagram.Connection{From: 1, To: 2, Hints: map[string]string{"from-side": "right", "to-side": "bottom"}}
+		path, err := newRerouteRouter().RouteConnection(conn, nodes)
+		if err != nil {
+			t.Fatalf("RouteConnection() error = %v", err)
+		}
+		end, prev := last(path)
+		if end.Y != target.Y+target.Height-1 || prev.Y <= end.Y || prev.X != end.X {
+			t.Errorf("Expected the path to enter the bottom side heading up, got %v", path.Points)
+		}
+	})
+
+	t.Run("unknown side is ignored", func(t *testing.T) {
+		conn := diagram.Connection{From: 1, To: 2, Hints: map[string]string{"from-side": "sideways"}}
+		path, err := newRerouteRouter().RouteConnection(conn, nodes)
+		if err != nil {
+			t.Fatalf("RouteConnection() error = %v", err)
+		}
+		if start, _ := first(path); start.X != source.X {
+			t.Errorf("Expected the default left exit, starts at %v", start)
+		}
+	})
+}
+
 // ==================== PORT MANAGER TESTS ====================
 
 // Helper function to get edge name for error messages
@@ -2639,4 +3026,58 @@ func TestPortManager_PortPoints(t *testing.T) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+func TestPriorityLaneIsRoutedAround(t *testing.T) {
+	// A above B, with C and D either side of the gap between them, so the
+	// straight C -> D line crosses the straight A -> B one
+	nodes := []diagram.Node{
+		{ID: 1, X: 20, Y: 0, Width: 9, Height: 3},
+		{ID: 2, X: 20, Y: 12, Width: 9, Height: 3},
+		{ID: 3, X: 0, Y: 6, Width: 8, Height: 3},
+		{ID: 4, X: 40, Y: 6, Width: 8, Height: 3},
+	}
+	route := func(priority bool) map[int]diagram.Path {
+		router := NewRouter(NewSmartPathFinder(PathCost{StraightCost: 10, TurnCost: 1000, InitialDirectionBonus: 50}))
+		router.SetPortManager(NewPortManager(nodes, 1))
+		connections := []diagram.Connection{{From: 3, To: 4}, {From: 1, To: 2}}
+		if priority {
+			connections[1].Hints = map[string]string{"priority": "true"}
+		}
+		paths, err := router.RouteConnections(connections, nodes)
+		if err != nil {
+			t.Fatalf("RouteConnections() error = %v", err)
+		}
+		return paths
+	}
+	straight := func(path diagram.Path) bool {
+		return len(SimplifyPath(path).Points) == 2
+	}
+	crosses := func(a, b diagram.Path) bool {
+		cells := make(map[diagram.Point]bool)
+		for _, p := range pathCells(a) {
+			cells[p] = true
+		}
+		for _, p := range pathCells(b) {
+			if cells[p] {
+				return true
+			}
+		}
+		return false
+	}
+
+	paths := route(false)
+	if !crosses(paths[0], paths[1]) {
+		t.Fatalf("Expected the lines to cross without a priority lane: %v and %v", paths[0].Points, paths[1].Points)
+	}
+
+	paths = route(true)
+	if !straight(paths[1]) {
+		t.Errorf("Expected the priority lane to be straight, got %v", SimplifyPath(paths[1]).Points)
+	}
+	if straight(paths[0]) {
+		t.Errorf("Expected the competing line to jog around the lane, got %v", SimplifyPath(paths[0]).Points)
+	}
+	if crosses(paths[0], paths[1]) {
+		t.Errorf("Expected the competing line to keep off the lane: %v and %v", paths[0].Points, paths[1].Points)
+	}
+}
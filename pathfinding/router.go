@@ -2,8 +2,13 @@ package pathfinding
 
 import (
 	"edd/diagram"
+	"encoding/binary"
 	"fmt"
+	"hash"
+	"hash/fnv"
 	"math"
+	"sort"
+	"strconv"
 )
 
 // RouterType defines the type of routing algorithm to use
@@ -24,6 +29,9 @@ type Router struct {
 	simpleRouter     *SimpleRouter
 	areaRouter       *AreaRouter
 	routerType       RouterType
+
+	// labelBounds estimates where a routed connection's label will be drawn
+	labelBounds func(path diagram.Path, label string) (diagram.Bounds, bool)
 }
 
 // NewRouter creates a new connection router.
@@ -75,6 +83,13 @@ func (r *Router) SetRouterType(routerType RouterType) {
 	r.routerType = routerType
 }
 
+// SetLabelBounds sets how the router estimates where a connection's label will
+// be drawn. Connections then route around the labels of connections routed
+// before them when another route exists. nil turns this off.
+func (r *Router) SetLabelBounds(labelBounds func(path diagram.Path, label string) (diagram.Bounds, bool)) {
+	r.labelBounds = labelBounds
+}
+
 // GetAreaRouter returns the area router if available
 func (r *Router) GetAreaRouter() *AreaRouter {
 	return r.areaRouter
@@ -218,11 +233,66 @@ func (r *Router) routeConnectionsWithDynamicObstacles(connections []diagram.Conn
 		}
 	}
 	
+	// Priority lanes are routed first, so they take the direct route, and
+	// everything else keeps off them
+	hasPriority := false
+	for _, item := range orderedConns {
+		hasPriority = hasPriority || isPriorityLane(item.conn)
+	}
+	sort.SliceStable(orderedConns, func(i, j int) bool {
+		return isPriorityLane(orderedConns[i].conn) && !isPriorityLane(orderedConns[j].conn)
+	})
+
+	// Labels of connections already routed, and the cells of priority lanes,
+	// are soft obstacles for the rest
+	var labelAreas []diagram.Bounds
+	lanes := make(map[diagram.Point]bool)
+	if r.areaRouter != nil && (r.labelBounds != nil || hasPriority) {
+		r.areaRouter.SetSoftObstacles(func(p diagram.Point) bool {
+			if lanes[p] {
+				return true
+			}
+			for _, area := range labelAreas {
+				if p.X >= area.Min.X && p.X <= area.Max.X && p.Y >= area.Min.Y && p.Y <= area.Max.Y {
+					return true
+				}
+			}
+			return false
+		})
+		defer r.areaRouter.SetSoftObstacles(nil)
+	}
+
+	// A caching path finder keys routes on what their obstacles are made of:
+	// the node boxes, the connection, and the routes and labels placed before
+	// it, which decide the ports taken and the soft obstacles
+	keyed, _ := r.pathFinder.(interface{ SetObstacleKey(uint64) })
+	obstacleKey := fnv.New64a()
+	if keyed != nil {
+		for _, node := range nodes {
+			hashInts(obstacleKey, node.ID, node.X, node.Y, node.Width, node.Height)
+		}
+		defer keyed.SetObstacleKey(0)
+	}
+
 	// Route each connection in order
 	// fmt.Println("\nRouting connections in order:")
 	for _, item := range orderedConns {
 		// fmt.Printf("%d. Connection %d (%d->%d) - distance: %.2f\n", i+1, item.conn.ID, item.conn.From, item.conn.To, math.Sqrt(distances[item.index]))
+		// Hand-built paths and fixed waypoints are used as they are
+		if item.conn.HasRawPath() {
+			paths[item.index] = item.conn.RawPath()
+			continue
+		}
+		if item.conn.HasWaypoints() {
+			paths[item.index] = item.conn.WaypointPath()
+			continue
+		}
+
 		// Route the connection
+		if keyed != nil {
+			hashInts(obstacleKey, item.conn.ID, item.conn.From, item.conn.To)
+			keyed.SetObstacleKey(obstacleKey.Sum64())
+		}
 		path, err := r.RouteConnection(item.conn, nodes)
 		if err != nil {
 			// On failure, release any ports we've reserved so far
@@ -241,7 +311,25 @@ func (r *Router) routeConnectionsWithDynamicObstacles(connections []diagram.Conn
 		}
 		
 		// Store the path
+		path = smoothStub(path, item.conn, nodes)
 		paths[item.index] = path
+		if isPriorityLane(item.conn) {
+			for _, cell := range pathCells(path) {
+				lanes[cell] = true
+			}
+		}
+
+		if r.labelBounds != nil && item.conn.Label != "" {
+			if area, ok := r.labelBounds(path, item.conn.Label); ok {
+				labelAreas = append(labelAreas, area)
+				hashInts(obstacleKey, area.Min.X, area.Min.Y, area.Max.X, area.Max.Y)
+			}
+		}
+		if keyed != nil {
+			for _, p := range path.Points {
+				hashInts(obstacleKey, p.X, p.Y)
+			}
+		}
 		
 		// The port manager automatically tracks occupied ports,
 		// so subsequent connections will avoid them
@@ -249,3 +337,68 @@ func (r *Router) routeConnectionsWithDynamicObstacles(connections []diagram.Conn
 	
 	return paths, nil
 }
+
+// hashInts writes values to h, each in a fixed eight bytes
+func hashInts(h hash.Hash64, values ...int) {
+	var buf [8]byte
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf[:], uint64(v))
+		h.Write(buf[:])
+	}
+}
+
+// smoothStub moves the first bend of a connection whose "stub" hint asks for
+// a run of at least that many cells before it turns, so the line leaves its
+// box cleanly. The route is kept as it was when the smoothed one would cut
+// through a node between its two ends, which sit on the edges of their boxes.
+func smoothStub(path diagram.Path, conn diagram.Connection, nodes []diagram.Node) diagram.Path {
+	stub, err := strconv.Atoi(conn.Hints["stub"])
+	if err != nil || stub <= 0 || len(path.Points) < 2 {
+		return path
+	}
+	smoothed := path
+	smoothed.Points = SmoothPath(path.Points, SmoothingOptions{MinStubLength: stub, MergeCollinear: true})
+
+	// The arrowhead needs the cell before the end to itself
+	n := len(smoothed.Points)
+	if segmentLength(smoothed.Points[n-2], smoothed.Points[n-1]) < 2 && segmentLength(path.Points[len(path.Points)-2], path.Points[len(path.Points)-1]) >= 2 {
+		return path
+	}
+	cells := pathCells(smoothed)
+	for _, cell := range cells[1 : len(cells)-1] {
+		for _, node := range nodes {
+			if cell.X >= node.X && cell.X < node.X+node.Width && cell.Y >= node.Y && cell.Y < node.Y+node.Height {
+				return path
+			}
+		}
+	}
+	return smoothed
+}
+
+// isPriorityLane reports whether a connection's "priority" hint asks for a
+// lane of its own that other connections are routed around
+func isPriorityLane(conn diagram.Connection) bool {
+	return conn.Hints["priority"] == "true"
+}
+
+// pathCells returns every cell a path passes through, filling in the
+// straight runs between its points
+func pathCells(path diagram.Path) []diagram.Point {
+	var cells []diagram.Point
+	for i, p := range path.Points {
+		if i == 0 {
+			cells = append(cells, p)
+			continue
+		}
+		q := path.Points[i-1]
+		for q.X != p.X {
+			q.X += sign(p.X - q.X)
+			cells = append(cells, q)
+		}
+		for q.Y != p.Y {
+			q.Y += sign(p.Y - q.Y)
+			cells = append(cells, q)
+		}
+	}
+	return cells
+}
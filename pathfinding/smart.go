@@ -42,7 +42,7 @@ func (s *SmartPathFinder) FindPath(start, end diagram.Point, obstacles func(diag
 	var obstacleHash uint64
 	if s.cacheEnabled && s.cache != nil {
 		obstacleHash = s.hashObstacles(start, end, obstacles)
-		if cachedPath, found := s.cache.Get(start, end, obstacleHash); found {
+		if cachedPath, found := s.cache.Get(start, end, obstacleHash); found && s.isPathClear(cachedPath, obstacles) {
 			return cachedPath, nil
 		}
 	}
@@ -0,0 +1,131 @@
+package pathfinding
+
+import (
+	"edd/diagram"
+	"edd/layout"
+)
+
+// SmoothingOptions controls optional clean-up of orthogonal routes.
+// The zero value leaves paths untouched.
+type SmoothingOptions struct {
+	// MinStubLength is the minimum number of cells a path travels before its first bend,
+	// so lines leave boxes cleanly instead of turning right at the edge.
+	MinStubLength int
+	// MergeCollinear drops intermediate points that lie on a straight run.
+	MergeCollinear bool
+}
+
+// IsZero reports whether the options would leave a path unchanged.
+func (o SmoothingOptions) IsZero() bool {
+	return o.MinStubLength <= 0 && !o.MergeCollinear
+}
+
+// SmoothPath applies the smoothing options to an orthogonal path.
+// The endpoints and the number of bends are preserved; only where the bends sit changes.
+// Enforcing a minimum stub returns the path in corner form (endpoints plus bends).
+func SmoothPath(points []diagram.Point, opts SmoothingOptions) []diagram.Point {
+	if len(points) < 2 || opts.IsZero() {
+		return points
+	}
+
+	result := make([]diagram.Point, len(points))
+	copy(result, points)
+
+	if opts.MergeCollinear || opts.MinStubLength > 0 {
+		result = MergeCollinearPoints(result)
+	}
+
+	if opts.MinStubLength > 0 {
+		result = ensureMinStub(result, opts.MinStubLength)
+	}
+
+	return result
+}
+
+// MergeCollinearPoints removes duplicate points and points in the middle of a straight run.
+func MergeCollinearPoints(points []diagram.Point) []diagram.Point {
+	if len(points) < 3 {
+		return points
+	}
+
+	merged := make([]diagram.Point, 0, len(points))
+	for _, p := range points {
+		if len(merged) > 0 && merged[len(merged)-1] == p {
+			continue
+		}
+		// Drop the previous point if it sits between its neighbours on the same heading
+		if len(merged) >= 2 {
+			a := merged[len(merged)-2]
+			b := merged[len(merged)-1]
+			if segmentDirection(a, b) == segmentDirection(b, p) {
+				merged[len(merged)-1] = p
+				continue
+			}
+		}
+		merged = append(merged, p)
+	}
+
+	return merged
+}
+
+// ensureMinStub moves the first bend of a corner-form path so that the first
+// segment is at least minStub cells long, where that can be done without adding bends.
+func ensureMinStub(points []diagram.Point, minStub int) []diagram.Point {
+	if len(points) < 3 {
+		return points
+	}
+
+	firstLen := segmentLength(points[0], points[1])
+	if firstLen >= minStub {
+		return points
+	}
+
+	dir := segmentDirection(points[0], points[1])
+
+	// Z-shaped: the third segment runs the same way as the first, so the
+	// middle segment can slide forward, borrowing length from the third.
+	if len(points) >= 4 && segmentDirection(points[2], points[3]) == dir {
+		shift := minStub - firstLen
+		if available := segmentLength(points[2], points[3]); shift > available {
+			shift = available
+		}
+		result := make([]diagram.Point, len(points))
+		copy(result, points)
+		result[1] = diagram.Point{X: result[1].X + dir.X*shift, Y: result[1].Y + dir.Y*shift}
+		result[2] = diagram.Point{X: result[2].X + dir.X*shift, Y: result[2].Y + dir.Y*shift}
+		return MergeCollinearPoints(result)
+	}
+
+	// L-shaped: flipping the corner keeps a single bend between the same
+	// endpoints and makes the old second leg the new stub.
+	if len(points) == 3 && segmentLength(points[1], points[2]) >= minStub {
+		start, end := points[0], points[2]
+		corner := diagram.Point{X: start.X, Y: end.Y}
+		if dir.Y != 0 {
+			corner = diagram.Point{X: end.X, Y: start.Y}
+		}
+		return []diagram.Point{start, corner, end}
+	}
+
+	return points
+}
+
+// segmentDirection returns the unit step from a to b along an orthogonal segment.
+func segmentDirection(a, b diagram.Point) diagram.Point {
+	return diagram.Point{X: sign(b.X - a.X), Y: sign(b.Y - a.Y)}
+}
+
+// segmentLength returns the number of cells between two points on an orthogonal segment.
+func segmentLength(a, b diagram.Point) int {
+	return layout.Abs(b.X-a.X) + layout.Abs(b.Y-a.Y)
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
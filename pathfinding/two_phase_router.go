@@ -235,6 +235,14 @@ func (r *TwoPhaseRouter) findRoughPath(conn diagram.Connection, sourceNode, targ
 		targetAngle -= 360
 	}
 	targetEdge := selectEdgeByAngle(targetAngle)
+
+	// from-side/to-side hints override the angle-based choice
+	if edge, ok := parseSideHint(conn.Hints["from-side"]); ok {
+		sourceEdge = edge
+	}
+	if edge, ok := parseSideHint(conn.Hints["to-side"]); ok {
+		targetEdge = edge
+	}
 	
 	return roughPath, sourceEdge, targetEdge, nil
 }
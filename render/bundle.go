@@ -0,0 +1,39 @@
+package render
+
+import (
+	"edd/diagram"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BundleCount returns the number of edges a connection stands for, from its
+// "count" hint, or 1 when it has none or the hint isn't a whole number above 1
+func BundleCount(conn diagram.Connection) int {
+	count, err := strconv.Atoi(conn.Hints["count"])
+	if err != nil || count < 2 {
+		return 1
+	}
+	return count
+}
+
+// ApplyBundleCounts returns a copy of the diagram in which each bundled
+// connection's label ends in "×N", or d itself when nothing is bundled.
+func ApplyBundleCounts(d *diagram.Diagram) *diagram.Diagram {
+	if d == nil {
+		return d
+	}
+
+	bundled := d
+	for i, conn := range d.Connections {
+		count := BundleCount(conn)
+		if count == 1 {
+			continue
+		}
+		if bundled == d {
+			bundled = d.Clone()
+		}
+		bundled.Connections[i].Label = strings.TrimSpace(fmt.Sprintf("%s ×%d", conn.Label, count))
+	}
+	return bundled
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"edd/diagram"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CollapsedGroups returns the group names listed, comma separated, in the
+// diagram's "collapsed" hint, in sorted order
+func CollapsedGroups(d *diagram.Diagram) []string {
+	if d == nil {
+		return nil
+	}
+	var groups []string
+	for _, name := range strings.Split(d.Hints["collapsed"], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			groups = append(groups, name)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// GroupNames returns the distinct "group" hints of the diagram's nodes, in
+// sorted order
+func GroupNames(d *diagram.Diagram) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, node := range d.Nodes {
+		if group := node.Hints["group"]; group != "" && !seen[group] {
+			seen[group] = true
+			groups = append(groups, group)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// CollapseGroups returns a copy of the diagram in which the members of each
+// collapsed group (nodes whose "group" hint is listed in the "collapsed"
+// hint) are replaced by one summary box marked "[+]", taking the ID of the
+// group's first member. Connections inside the group are hidden, and those
+// between the group and one other node are bundled into a single counted
+// connection. It also returns, for each connection of the result, the index
+// in d.Connections of the first connection it stands for. d itself is
+// returned when no group is collapsed.
+func CollapseGroups(d *diagram.Diagram) (*diagram.Diagram, []int) {
+	collapsed := make(map[string]bool)
+	for _, group := range CollapsedGroups(d) {
+		collapsed[group] = true
+	}
+
+	summaryOf := make(map[int]int) // member node ID -> summary node ID
+	summaries := make(map[string]int)
+	for _, node := range d.Nodes {
+		group := node.Hints["group"]
+		if !collapsed[group] {
+			continue
+		}
+		if _, ok := summaries[group]; !ok {
+			summaries[group] = node.ID
+		}
+		summaryOf[node.ID] = summaries[group]
+	}
+	if len(summaryOf) == 0 {
+		index := make([]int, len(d.Connections))
+		for i := range index {
+			index[i] = i
+		}
+		return d, index
+	}
+
+	result := d.Clone()
+	nodes, connections := result.Nodes, result.Connections
+	result.Nodes, result.Connections = nil, nil
+	for _, node := range nodes {
+		summary, ok := summaryOf[node.ID]
+		if !ok {
+			result.Nodes = append(result.Nodes, node)
+			continue
+		}
+		if summary != node.ID {
+			continue
+		}
+		group := node.Hints["group"]
+		result.Nodes = append(result.Nodes, diagram.Node{
+			ID:    node.ID,
+			Text:  []string{group + " [+]"},
+			Hints: map[string]string{"group": group},
+		})
+	}
+
+	type edge struct{ from, to int }
+	merged := make(map[edge]int) // remapped endpoints -> index in result
+	var index []int
+	for i, conn := range connections {
+		from, fromHidden := summaryOf[conn.From]
+		to, toHidden := summaryOf[conn.To]
+		if !fromHidden {
+			from = conn.From
+		}
+		if !toHidden {
+			to = conn.To
+		}
+		if !fromHidden && !toHidden {
+			result.Connections = append(result.Connections, conn)
+			index = append(index, i)
+			continue
+		}
+		if from == to && conn.From != conn.To {
+			continue // Inside a collapsed group
+		}
+
+		if existing, ok := merged[edge{from, to}]; ok {
+			total := BundleCount(result.Connections[existing]) + BundleCount(conn)
+			result.Connections[existing].Hints["count"] = strconv.Itoa(total)
+			continue
+		}
+		conn.From, conn.To = from, to
+		if conn.Hints == nil {
+			conn.Hints = make(map[string]string)
+		}
+		conn.Waypoints = nil // Drawn to the summary box, not the member
+		merged[edge{from, to}] = len(result.Connections)
+		result.Connections = append(result.Connections, conn)
+		index = append(index, i)
+	}
+	return result, index
+}
@@ -18,6 +18,10 @@ const (
 	StyleDim     = "\033[2m"
 	StyleItalic  = "\033[3m"
 	StyleReset   = "\033[0m"
+
+	// BackgroundLane is the dim grey background of shaded sequence diagram
+	// lanes
+	BackgroundLane = "\033[48;5;236m"
 )
 
 // ColorCanvas wraps a canvas and applies color to characters
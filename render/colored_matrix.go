@@ -8,12 +8,16 @@ import (
 // ColoredMatrixCanvas extends MatrixCanvas to support colored characters
 type ColoredMatrixCanvas struct {
 	*MatrixCanvas
-	colors [][]string // Color code for each position
-	styles [][]string // Style code for each position (e.g., bold)
+	colors      [][]string // Color code for each position
+	styles      [][]string // Style code for each position (e.g., bold)
+	backgrounds [][]string // Background color code for each position
 }
 
 // NewColoredMatrixCanvas creates a new colored matrix canvas
 func NewColoredMatrixCanvas(width, height int) *ColoredMatrixCanvas {
+	canvas := NewMatrixCanvas(width, height)
+	width, height = canvas.Size()
+
 	// Initialize color matrix
 	colors := make([][]string, height)
 	for i := range colors {
@@ -26,10 +30,17 @@ func NewColoredMatrixCanvas(width, height int) *ColoredMatrixCanvas {
 		styles[i] = make([]string, width)
 	}
 	
+	// Initialize background matrix
+	backgrounds := make([][]string, height)
+	for i := range backgrounds {
+		backgrounds[i] = make([]string, width)
+	}
+
 	return &ColoredMatrixCanvas{
-		MatrixCanvas: NewMatrixCanvas(width, height),
+		MatrixCanvas: canvas,
 		colors:       colors,
 		styles:       styles,
+		backgrounds:  backgrounds,
 	}
 }
 
@@ -88,6 +99,30 @@ func (c *ColoredMatrixCanvas) SetWithColorAndStyle(p diagram.Point, char rune, c
 	return nil
 }
 
+// SetColor changes the color of the character already at a position, leaving
+// the character and its style as they are
+func (c *ColoredMatrixCanvas) SetColor(p diagram.Point, color string) {
+	if p.Y >= 0 && p.Y < len(c.colors) && p.X >= 0 && p.X < len(c.colors[0]) {
+		c.colors[p.Y][p.X] = GetColorCode(color)
+	}
+}
+
+// SetBackground sets the background color code a position is drawn on,
+// leaving its character, color and style as they are
+func (c *ColoredMatrixCanvas) SetBackground(p diagram.Point, code string) {
+	if p.Y >= 0 && p.Y < len(c.backgrounds) && p.X >= 0 && p.X < len(c.backgrounds[0]) {
+		c.backgrounds[p.Y][p.X] = code
+	}
+}
+
+// BackgroundAt returns the background color code at a given position
+func (c *ColoredMatrixCanvas) BackgroundAt(p diagram.Point) string {
+	if p.Y >= 0 && p.Y < len(c.backgrounds) && p.X >= 0 && p.X < len(c.backgrounds[0]) {
+		return c.backgrounds[p.Y][p.X]
+	}
+	return ""
+}
+
 // ColoredString returns the canvas as a string with ANSI color codes
 func (c *ColoredMatrixCanvas) ColoredString() string {
 	var sb strings.Builder
@@ -95,21 +130,26 @@ func (c *ColoredMatrixCanvas) ColoredString() string {
 	for y := 0; y < c.height; y++ {
 		currentColor := ""
 		currentStyle := ""
+		currentBackground := ""
 		for x := 0; x < c.width; x++ {
 			char := c.matrix[y][x]
 			color := ""
 			style := ""
+			background := ""
 			if y < len(c.colors) && x < len(c.colors[y]) {
 				color = c.colors[y][x]
 			}
 			if y < len(c.styles) && x < len(c.styles[y]) {
 				style = c.styles[y][x]
 			}
+			if y < len(c.backgrounds) && x < len(c.backgrounds[y]) {
+				background = c.backgrounds[y][x]
+			}
 			
-			// Check if we need to change color or style
-			if color != currentColor || style != currentStyle {
+			// Check if we need to change color, style or background
+			if color != currentColor || style != currentStyle || background != currentBackground {
 				// Reset if we had any formatting
-				if currentColor != "" || currentStyle != "" {
+				if currentColor != "" || currentStyle != "" || currentBackground != "" {
 					sb.WriteString(ColorReset)
 				}
 				
@@ -121,9 +161,14 @@ func (c *ColoredMatrixCanvas) ColoredString() string {
 				if color != "" {
 					sb.WriteString(color)
 				}
+				// And the background behind it (if any)
+				if background != "" {
+					sb.WriteString(background)
+				}
 				
 				currentColor = color
 				currentStyle = style
+				currentBackground = background
 			}
 			
 			// Write the character
@@ -135,7 +180,7 @@ func (c *ColoredMatrixCanvas) ColoredString() string {
 		}
 		
 		// Reset formatting at end of line if needed
-		if currentColor != "" || currentStyle != "" {
+		if currentColor != "" || currentStyle != "" || currentBackground != "" {
 			sb.WriteString(ColorReset)
 		}
 		
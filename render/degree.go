@@ -0,0 +1,37 @@
+package render
+
+import (
+	"edd/diagram"
+	"fmt"
+)
+
+// hubDegree is the number of connection ends at which a node counts as a hub
+// and gets a degree badge in debug mode
+const hubDegree = 3
+
+// nodeDegrees counts, for each node ID, the connection ends attached to it.
+// A connection from a node to itself counts twice.
+func nodeDegrees(connections []diagram.Connection) map[int]int {
+	degrees := make(map[int]int)
+	for _, conn := range connections {
+		degrees[conn.From]++
+		degrees[conn.To]++
+	}
+	return degrees
+}
+
+// renderDegreeBadges marks each hub node with its connection count, e.g.
+// "(4)", just right of its top-right corner, so that the busiest nodes of a
+// dense diagram stand out while debugging
+func renderDegreeBadges(c Canvas, nodes []diagram.Node, connections []diagram.Connection) {
+	degrees := nodeDegrees(connections)
+	for _, node := range nodes {
+		degree := degrees[node.ID]
+		if degree < hubDegree {
+			continue
+		}
+		for i, r := range fmt.Sprintf("(%d)", degree) {
+			c.Set(diagram.Point{X: node.X + node.Width + i, Y: node.Y}, r)
+		}
+	}
+}
@@ -20,6 +20,17 @@ type FlowchartRenderer struct {
 	debugMode     bool
 	showObstacles bool
 
+	// Nearly aligned connected nodes are nudged into line by up to this many cells
+	alignTolerance int
+
+	// Layout reuse across renders of an unchanged diagram
+	horizontalLayout diagram.LayoutEngine
+	layoutCache      layoutCache
+	layoutSeed       int64
+
+	// Routes reused across viewport renders of an unchanged layout
+	routeCache routeCache
+
 	// Edit state for cursor display
 	editingNodeID int
 	editText      string
@@ -44,20 +55,46 @@ func NewFlowchartRenderer(caps TerminalCapabilities) *FlowchartRenderer {
 	// efficiently without excessive memory usage (100 * ~1KB per path = ~100KB)
 	cachedPathfinder := pathfinding.NewCachedPathFinder(pathfinder, 100)
 	
-	// Create router with pathfinder
+	// Create router with pathfinder, routing around labels placed earlier
+	labelRenderer := NewLabelRenderer()
 	router := pathfinding.NewRouter(cachedPathfinder)
+	router.SetLabelBounds(labelRenderer.LabelBounds)
 	
 	return &FlowchartRenderer{
-		layout:        layoutEngine,
-		pathfinder:    cachedPathfinder,
-		router:        router,
-		capabilities:  caps,
-		pathRenderer:  NewPathRenderer(caps),
-		nodeRenderer:  NewNodeRenderer(caps),
-		labelRenderer: NewLabelRenderer(),
-		debugMode:     false,
-		showObstacles: false,
+		layout:         layoutEngine,
+		pathfinder:     cachedPathfinder,
+		router:         router,
+		capabilities:   caps,
+		pathRenderer:   NewPathRenderer(caps),
+		nodeRenderer:   NewNodeRenderer(caps),
+		labelRenderer:  labelRenderer,
+		debugMode:      false,
+		showObstacles:  false,
+		alignTolerance: layout.DefaultAlignTolerance,
+		editingNodeID:  -1,
+	}
+}
+
+// SetAlignTolerance sets how many cells connected nodes of a diagram with
+// the "align" hint may be nudged to straighten a nearly aligned connection.
+// Zero disables the pass.
+func (r *FlowchartRenderer) SetAlignTolerance(cells int) {
+	r.alignTolerance = cells
+	r.clearLayoutCache()
+}
+
+// SetLayoutSeed changes how the layouts order nodes that are otherwise
+// interchangeable, such as siblings in a symmetric graph. The default of 0
+// orders them by ID; other seeds give alternative, repeatable arrangements.
+func (r *FlowchartRenderer) SetLayoutSeed(seed int64) {
+	r.layoutSeed = seed
+	if seeded, ok := r.layout.(interface{ SetSeed(int64) }); ok {
+		seeded.SetSeed(seed)
+	}
+	if seeded, ok := r.horizontalLayout.(interface{ SetSeed(int64) }); ok {
+		seeded.SetSeed(seed)
 	}
+	r.clearLayoutCache()
 }
 
 // CanRender returns true if this renderer can handle the given diagram type.
@@ -72,59 +109,20 @@ func (r *FlowchartRenderer) Render(d *diagram.Diagram) (string, error) {
 		return "", fmt.Errorf("diagram is nil")
 	}
 
-	// Step 1: Calculate node dimensions from their text content
-	nodes := CalculateNodeDimensions(d.Nodes)
+	// Draw collapsed groups as summary boxes, resolve theme colors into
+	// explicit hints, and show bundle counts
+	d, _ = CollapseGroups(d)
+	d = ApplyBundleCounts(ApplyTheme(d))
 
-	// Step 2: Choose layout based on diagram hints
-	layoutEngine := r.layout // Default to vertical
-	flowDirection := pathfinding.FlowVertical
-
-	if d.Hints != nil {
-		if layoutHint := d.Hints["layout"]; layoutHint == "horizontal" {
-			layoutEngine = layout.NewHorizontalLayout()
-			flowDirection = pathfinding.FlowHorizontal
-		}
-	}
-
-	// Step 3: Run layout algorithm to position nodes
-	layoutNodes, err := layoutEngine.Layout(nodes, d.Connections)
+	// Steps 1-3: Size nodes, choose a layout from the diagram hints, position
+	// the nodes and straighten nearly aligned connections (cached between renders)
+	layoutNodes, flowDirection, err := r.layoutDiagram(d)
 	if err != nil {
 		return "", fmt.Errorf("layout failed: %w", err)
 	}
 
 	// Step 3.1: Adjust dimensions for node being edited (so box grows in real-time)
-	if r.editingNodeID >= 0 {
-		for i := range layoutNodes {
-			if layoutNodes[i].ID == r.editingNodeID {
-				// Split edit text into lines
-				lines := strings.Split(r.editText, "\n")
-
-				// Recalculate width - find the longest line
-				maxWidth := 0
-				for _, line := range lines {
-					lineWidth := len([]rune(line)) + 1 // +1 for cursor character
-					if lineWidth > maxWidth {
-						maxWidth = lineWidth
-					}
-				}
-				minWidth := maxWidth + 4 // text + padding
-				if minWidth < 8 {
-					minWidth = 8
-				}
-				if minWidth > layoutNodes[i].Width {
-					layoutNodes[i].Width = minWidth
-				}
-
-				// Recalculate height for multi-line text
-				minHeight := len(lines) + 2 // lines + borders
-				if minHeight > layoutNodes[i].Height {
-					layoutNodes[i].Height = minHeight
-				}
-
-				break
-			}
-		}
-	}
+	r.growEditedNode(layoutNodes)
 
 	// Step 3.2: Set flow direction on the router
 	if areaRouter := r.router.GetAreaRouter(); areaRouter != nil {
@@ -167,7 +165,17 @@ func (r *FlowchartRenderer) Render(d *diagram.Diagram) (string, error) {
 	if err := r.renderToCanvas(d, layoutNodes, paths, offsetCanvas); err != nil {
 		return "", fmt.Errorf("failed to render to canvas: %w", err)
 	}
-	
+
+	// Step 6.1: Fill the empty cells with a background grid if asked for
+	if columns, lines, ok := gridSpacing(d); ok {
+		boxes := make([]diagram.Node, len(layoutNodes))
+		for i, node := range layoutNodes {
+			node.X -= bounds.Min.X
+			node.Y -= bounds.Min.Y
+			boxes[i] = node
+		}
+		drawGrid(c, diagram.Point{}, columns, lines, boxes)
+	}
 	
 	// Step 7: Convert canvas to string output
 	var output string
@@ -179,9 +187,134 @@ func (r *FlowchartRenderer) Render(d *diagram.Diagram) (string, error) {
 		output = c.String()
 	}
 	
+	// Step 8: Append the legend, if the diagram defines one, and frame the lot
+	output = appendFrame(appendLegend(output, d, needsColor), d)
+	
 	return output, nil
 }
 
+// growEditedNode widens and heightens the box of the node being edited to fit
+// the edit text and cursor, so the box grows as the user types
+func (r *FlowchartRenderer) growEditedNode(layoutNodes []diagram.Node) {
+	if r.editingNodeID < 0 {
+		return
+	}
+	for i := range layoutNodes {
+		if layoutNodes[i].ID == r.editingNodeID {
+			// Split edit text into lines
+			lines := strings.Split(r.editText, "\n")
+
+			// Recalculate width - find the longest line
+			maxWidth := 0
+			for _, line := range lines {
+				lineWidth := len([]rune(line)) + 1 // +1 for cursor character
+				if lineWidth > maxWidth {
+					maxWidth = lineWidth
+				}
+			}
+			minWidth := maxWidth + 4 // text + padding
+			if minWidth < 8 {
+				minWidth = 8
+			}
+			if minWidth > layoutNodes[i].Width {
+				layoutNodes[i].Width = minWidth
+			}
+
+			// Recalculate height for multi-line text
+			minHeight := len(lines) + 2 // lines + borders
+			if minHeight > layoutNodes[i].Height {
+				layoutNodes[i].Height = minHeight
+			}
+
+			break
+		}
+	}
+}
+
+// Geometry lays out and routes the diagram without drawing it. It returns the
+// nodes with their computed position and size, and each connection's path
+// keyed by its index in d.Connections, in the coordinates of the rendered
+// output. Connections hidden inside a collapsed group have no path.
+func (r *FlowchartRenderer) Geometry(d *diagram.Diagram) ([]diagram.Node, map[int]diagram.Path, error) {
+	if d == nil {
+		return nil, nil, fmt.Errorf("diagram is nil")
+	}
+	d, connIndex := CollapseGroups(d)
+	d = ApplyBundleCounts(d)
+
+	layoutNodes, flowDirection, err := r.layoutDiagram(d)
+	if err != nil {
+		return nil, nil, fmt.Errorf("layout failed: %w", err)
+	}
+	if areaRouter := r.router.GetAreaRouter(); areaRouter != nil {
+		areaRouter.SetFlowDirection(flowDirection)
+	}
+	r.router.SetPortManager(pathfinding.NewPortManager(layoutNodes, 1))
+
+	paths, err := r.router.RouteConnections(d.Connections, layoutNodes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connection routing failed: %w", err)
+	}
+
+	// Shift everything so the top-left of the rendered output is 0,0
+	bounds := CalculateBounds(layoutNodes, paths)
+	for i := range layoutNodes {
+		layoutNodes[i].X -= bounds.Min.X
+		layoutNodes[i].Y -= bounds.Min.Y
+	}
+	shifted := make(map[int]diagram.Path, len(paths))
+	for i, path := range paths {
+		points := make([]diagram.Point, len(path.Points))
+		for j, point := range path.Points {
+			points[j] = diagram.Point{X: point.X - bounds.Min.X, Y: point.Y - bounds.Min.Y}
+		}
+		shifted[connIndex[i]] = diagram.Path{Points: points, Cost: path.Cost, Metadata: path.Metadata}
+	}
+	return layoutNodes, shifted, nil
+}
+
+// Waypoints returns the current route of the connection at the given index
+// in d.Connections reduced to its end points and corners, in the layout's
+// coordinates, ready to be stored as the connection's Waypoints.
+func (r *FlowchartRenderer) Waypoints(d *diagram.Diagram, index int) ([]diagram.Point, error) {
+	if d == nil {
+		return nil, fmt.Errorf("diagram is nil")
+	}
+	if index < 0 || index >= len(d.Connections) {
+		return nil, fmt.Errorf("no connection %d", index)
+	}
+	d, connIndex := CollapseGroups(d)
+	d = ApplyBundleCounts(d)
+	collapsedIndex := -1
+	for i, original := range connIndex {
+		if original == index {
+			collapsedIndex = i
+		}
+	}
+	if collapsedIndex < 0 {
+		return nil, fmt.Errorf("connection %d is hidden in a collapsed group", index)
+	}
+
+	layoutNodes, flowDirection, err := r.layoutDiagram(d)
+	if err != nil {
+		return nil, fmt.Errorf("layout failed: %w", err)
+	}
+	if areaRouter := r.router.GetAreaRouter(); areaRouter != nil {
+		areaRouter.SetFlowDirection(flowDirection)
+	}
+	r.router.SetPortManager(pathfinding.NewPortManager(layoutNodes, 1))
+
+	paths, err := r.router.RouteConnections(d.Connections, layoutNodes)
+	if err != nil {
+		return nil, fmt.Errorf("connection routing failed: %w", err)
+	}
+	path, ok := paths[collapsedIndex]
+	if !ok || len(path.Points) < 2 {
+		return nil, fmt.Errorf("connection %d has no route", index)
+	}
+	return pathfinding.SimplifyPath(path).Points, nil
+}
+
 // RenderWithPositions renders the diagram and returns node positions and connection paths
 // This is needed by the TUI for jump label positioning
 func (r *FlowchartRenderer) RenderWithPositions(d *diagram.Diagram) (map[int]diagram.Point, map[int]diagram.Path, string, error) {
@@ -191,22 +324,9 @@ func (r *FlowchartRenderer) RenderWithPositions(d *diagram.Diagram) (map[int]dia
 		return nil, nil, "", err
 	}
 
-	// We need to re-layout to get positions (TODO: optimize by caching)
-	nodes := CalculateNodeDimensions(d.Nodes)
-
-	// Choose layout based on hints
-	var layoutEngine diagram.LayoutEngine
-	var flowDirection pathfinding.FlowDirection
-
-	if d.Hints != nil && d.Hints["layout"] == "horizontal" {
-		layoutEngine = layout.NewHorizontalLayout()
-		flowDirection = pathfinding.FlowHorizontal
-	} else {
-		layoutEngine = r.layout
-		flowDirection = pathfinding.FlowVertical
-	}
-
-	layoutNodes, err := layoutEngine.Layout(nodes, d.Connections)
+	// Re-layout to get positions; Render has just cached the result
+	d, connIndex := CollapseGroups(d)
+	layoutNodes, flowDirection, err := r.layoutDiagram(d)
 	if err != nil {
 		return nil, nil, output, nil // Return output even if we can't get positions
 	}
@@ -251,7 +371,7 @@ func (r *FlowchartRenderer) RenderWithPositions(d *diagram.Diagram) (map[int]dia
 				Y: point.Y - bounds.Min.Y,
 			}
 		}
-		adjustedPaths[i] = adjustedPath
+		adjustedPaths[connIndex[i]] = adjustedPath
 	}
 
 	return positions, adjustedPaths, output, nil
@@ -259,11 +379,8 @@ func (r *FlowchartRenderer) RenderWithPositions(d *diagram.Diagram) (map[int]dia
 
 // GetBounds returns the required canvas size for the diagram
 func (r *FlowchartRenderer) GetBounds(d *diagram.Diagram) (width, height int) {
-	// Calculate node dimensions
-	nodes := CalculateNodeDimensions(d.Nodes)
-	
-	// Run layout to get positions
-	layoutNodes, err := r.layout.Layout(nodes, d.Connections)
+	// Size and position the nodes
+	layoutNodes, _, err := r.layoutDiagram(d)
 	if err != nil {
 		// Return a default size on error
 		return 80, 24
@@ -317,6 +434,12 @@ func (r *FlowchartRenderer) renderToCanvas(d *diagram.Diagram, layoutNodes []dia
 	// Note: connectionsWithArrows may not maintain the same order as d.Connections if some connections failed to route
 	for _, cwa := range connectionsWithArrows {
 		hasArrow := cwa.ArrowType == pathfinding.ArrowEnd || cwa.ArrowType == pathfinding.ArrowBoth
+
+		// Hand-built paths are drawn cell by cell, exactly as given
+		if cwa.Connection.HasRawPath() {
+			drawRawPath(offsetCanvas, cwa.Connection.Path)
+			continue
+		}
 		
 		// Check if this connection has hints - use the connection from cwa, not d.Connections[i]
 		if cwa.Connection.Hints != nil && len(cwa.Connection.Hints) > 0 {
@@ -328,50 +451,26 @@ func (r *FlowchartRenderer) renderToCanvas(d *diagram.Diagram, layoutNodes []dia
 		}
 	}
 	
-	// Step 6: Render connection labels after all paths are drawn
-	// This ensures labels are placed on top of the lines
-	// Track rendered labels to avoid overlaps (simple collision detection)
-	type labelBounds struct {
-		minX, maxX, minY, maxY int
+	// Step 5.1: Lengthen arrowheads to two cells if the diagram asks for it,
+	// once every line is in place
+	if d.Hints["arrowheads"] == "wide" {
+		for _, cwa := range connectionsWithArrows {
+			if cwa.Connection.HasRawPath() {
+				continue
+			}
+			if cwa.ArrowType == pathfinding.ArrowEnd || cwa.ArrowType == pathfinding.ArrowBoth {
+				r.pathRenderer.RenderWideArrowShaft(offsetCanvas, cwa.Path)
+			}
+		}
 	}
-	renderedLabels := []labelBounds{}
 
+	// Step 6: Render connection labels after all paths are drawn
+	// This ensures labels are placed on top of the lines, each moved clear
+	// of the labels drawn before it
+	labels := NewLabelPlacer(r.labelRenderer)
 	for _, cwa := range connectionsWithArrows {
 		if cwa.Connection.Label != "" {
-			// Estimate label bounds for collision detection
-			labelText := cwa.Connection.Label
-			if len(labelText) > 40 {
-				labelText = labelText[:38] + ".."
-			}
-			labelLen := len(labelText) + 2 // +2 for brackets
-
-			// Estimate position (middle of path)
-			if len(cwa.Path.Points) >= 2 {
-				midIdx := len(cwa.Path.Points) / 2
-				labelY := cwa.Path.Points[midIdx].Y
-				labelX := cwa.Path.Points[midIdx].X - labelLen/2
-
-				// Check for overlap with existing labels
-				overlaps := false
-				for _, existing := range renderedLabels {
-					if labelY >= existing.minY-1 && labelY <= existing.maxY+1 {
-						if labelX+labelLen > existing.minX-2 && labelX < existing.maxX+2 {
-							overlaps = true
-							break
-						}
-					}
-				}
-
-				if !overlaps {
-					r.labelRenderer.RenderLabel(offsetCanvas, cwa.Path, cwa.Connection.Label, LabelMiddle)
-					renderedLabels = append(renderedLabels, labelBounds{
-						minX: labelX,
-						maxX: labelX + labelLen,
-						minY: labelY,
-						maxY: labelY,
-					})
-				}
-			}
+			labels.RenderStyledLabel(offsetCanvas, cwa.Path, cwa.Connection.Label, LabelMiddle, cwa.Connection.Hints["label-color"], cwa.Connection.Hints["label-style"])
 		}
 	}
 	
@@ -379,10 +478,24 @@ func (r *FlowchartRenderer) renderToCanvas(d *diagram.Diagram, layoutNodes []dia
 	if r.showObstacles {
 		r.renderObstacleDots(offsetCanvas, layoutNodes, d.Connections, paths)
 	}
+
+	// Step 8: Badge hub nodes with their connection count when debugging
+	if r.debugMode || r.showObstacles {
+		renderDegreeBadges(offsetCanvas, layoutNodes, d.Connections)
+	}
 	
 	return nil
 }
 
+// drawRawPath writes each cell of a hand-built path to the canvas
+func drawRawPath(c Canvas, cells []diagram.PathCell) {
+	for _, cell := range cells {
+		if r := []rune(cell.Rune); len(r) > 0 {
+			c.Set(diagram.Point{X: cell.X, Y: cell.Y}, r[0])
+		}
+	}
+}
+
 // EnableDebug enables debug mode to show obstacle visualization.
 func (r *FlowchartRenderer) EnableDebug() {
 	r.debugMode = true
@@ -393,6 +506,16 @@ func (r *FlowchartRenderer) EnableObstacleVisualization() {
 	r.showObstacles = true
 }
 
+// SetObstacleVisualization turns the virtual obstacle dots on or off
+func (r *FlowchartRenderer) SetObstacleVisualization(on bool) {
+	r.showObstacles = on
+}
+
+// ObstacleVisualization reports whether virtual obstacles are being shown
+func (r *FlowchartRenderer) ObstacleVisualization() bool {
+	return r.showObstacles
+}
+
 // GetRouter returns the router instance for external configuration
 func (r *FlowchartRenderer) GetRouter() *pathfinding.Router {
 	return r.router
@@ -401,6 +524,7 @@ func (r *FlowchartRenderer) GetRouter() *pathfinding.Router {
 // SetRouterType sets the type of router to use
 func (r *FlowchartRenderer) SetRouterType(routerType pathfinding.RouterType) {
 	r.router.SetRouterType(routerType)
+	r.routeCache = routeCache{}
 }
 
 // SetEditState sets the editing state for cursor display
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"edd/diagram"
+	"strings"
+	"unicode/utf8"
+)
+
+// frameTitle returns the title shown in the top edge of a framed diagram: its
+// "title" hint, or else its metadata name.
+func frameTitle(d *diagram.Diagram) string {
+	if title := strings.TrimSpace(d.Hints["title"]); title != "" {
+		return title
+	}
+	return strings.TrimSpace(d.Metadata.Name)
+}
+
+// appendFrame draws a border around rendered output when the diagram's
+// "frame" hint is "true", with the diagram's title set into the top edge like
+// a window. The frame is fitted to the content: blank rows and the shared
+// left margin are dropped and the border sits one space out from the widest
+// line.
+func appendFrame(output string, d *diagram.Diagram) string {
+	if d == nil || d.Hints["frame"] != "true" {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	margin := -1
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if indent := len(line) - len(strings.TrimLeft(line, " ")); margin < 0 || indent < margin {
+			margin = indent
+		}
+	}
+	width := 0
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = line[margin:]
+		}
+		if w := visibleWidth(lines[i]); w > width {
+			width = w
+		}
+	}
+
+	width = frameWidth(d, width)
+	var sb strings.Builder
+	sb.WriteString(frameTopEdge(d, width) + "\n")
+	for _, line := range lines {
+		sb.WriteString(frameLine(line, width) + "\n")
+	}
+	sb.WriteString(frameBottomEdge(d, width))
+	return sb.String()
+}
+
+// frameWidth returns the width of the framed lines for content width columns
+// wide, widened if need be to fit the title into the top edge.
+func frameWidth(d *diagram.Diagram, width int) int {
+	return max(width, StringWidth(frameTitleEdge(d))-1)
+}
+
+// frameTitleEdge returns the start of the frame's top edge holding the title,
+// or "" for an untitled frame.
+func frameTitleEdge(d *diagram.Diagram) string {
+	if title := frameTitle(d); title != "" {
+		return "─ " + title + " "
+	}
+	return ""
+}
+
+// frameTopEdge returns the frame's top line, around lines of the given
+// frameWidth.
+func frameTopEdge(d *diagram.Diagram, width int) string {
+	top := frameTitleEdge(d)
+	return "┌" + top + strings.Repeat("─", width+2-StringWidth(top)) + "┐"
+}
+
+// frameBottomEdge returns the frame's bottom line, around lines of the given
+// frameWidth.
+func frameBottomEdge(d *diagram.Diagram, width int) string {
+	return "└" + strings.Repeat("─", width+2) + "┘"
+}
+
+// frameLine returns one line of content between the frame's sides, padded to
+// the given frameWidth.
+func frameLine(line string, width int) string {
+	return "│ " + line + strings.Repeat(" ", max(0, width-visibleWidth(line))) + " │"
+}
+
+// visibleWidth returns the display width of s, not counting ANSI escape
+// sequences.
+func visibleWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\033' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += UnicodeWidth(r)
+		i += size - 1
+	}
+	return width
+}
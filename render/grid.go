@@ -0,0 +1,72 @@
+package render
+
+import (
+	"edd/diagram"
+	"fmt"
+	"strconv"
+)
+
+// gridMark is drawn at each grid point that no part of the diagram covers
+const gridMark = '·'
+
+// gridSpacing reads the diagram's "grid" hint: "true" for a mark every 4
+// columns and 2 lines, which looks square in most terminal fonts, "N" for
+// every N columns and lines, or "NxM" for every N columns and M lines. ok is
+// false when the grid is off or the hint isn't understood.
+func gridSpacing(d *diagram.Diagram) (columns, lines int, ok bool) {
+	if d == nil {
+		return 0, 0, false
+	}
+	hint := d.Hints["grid"]
+	switch hint {
+	case "":
+		return 0, 0, false
+	case "true":
+		return 4, 2, true
+	}
+	if n, err := strconv.Atoi(hint); err == nil {
+		return n, n, n > 0
+	}
+	var rest string
+	if n, _ := fmt.Sscanf(hint, "%dx%d%s", &columns, &lines, &rest); n != 2 {
+		return 0, 0, false
+	}
+	return columns, lines, columns > 0 && lines > 0
+}
+
+// drawGrid marks every grid point of the canvas, counted from its top-left
+// corner, like graph paper behind the diagram. origin is where the canvas's
+// top-left cell lies on the grid, for a canvas holding only part of it. Only empty cells outside the
+// given boxes are marked, so no glyph is overwritten and nothing lands inside
+// a node. A single space between two glyphs, such as the gap between words of
+// a label, is left alone too.
+func drawGrid(c Canvas, origin diagram.Point, columns, lines int, boxes []diagram.Node) {
+	width, height := c.Size()
+	empty := func(x, y int) bool {
+		if x < 0 || x >= width {
+			return true
+		}
+		r := c.Get(diagram.Point{X: x, Y: y})
+		return r == ' ' || r == 0
+	}
+	for y := gridStart(origin.Y, lines); y < height; y += lines {
+	cells:
+		for x := gridStart(origin.X, columns); x < width; x += columns {
+			if !empty(x, y) || (!empty(x-1, y) && !empty(x+1, y)) {
+				continue
+			}
+			for _, box := range boxes {
+				if x >= box.X && x < box.X+box.Width && y >= box.Y && y < box.Y+box.Height {
+					continue cells
+				}
+			}
+			c.Set(diagram.Point{X: x, Y: y}, gridMark)
+		}
+	}
+}
+
+// gridStart returns the first cell at or after 0 that lies on a grid line,
+// for a canvas starting at origin on a grid of the given spacing
+func gridStart(origin, spacing int) int {
+	return ((spacing-origin%spacing)%spacing + spacing) % spacing
+}
@@ -44,7 +44,12 @@ func (m *CharacterMerger) Merge(existing, new rune) rune {
 	if isArrow(new) {
 		return new
 	}
-	
+
+	// A wide arrowhead's shaft replaces the plain line it extends
+	if arrowShaftLines[new] == existing {
+		return new
+	}
+
 	// Check the merge map
 	if merged, ok := m.mergeMap[mergePair{existing, new}]; ok {
 		return merged
@@ -64,7 +69,15 @@ func (m *CharacterMerger) Merge(existing, new rune) rune {
 	return existing
 }
 
+// arrowShaftLines maps the shaft characters of wide arrowheads to the plain
+// line they extend
+var arrowShaftLines = map[rune]rune{
+	'━': '─',
+	'┃': '│',
+}
+
 // isShadow checks if a character is a shadow character
+
 func isShadow(r rune) bool {
 	return r == '░' || r == '▒' || r == '▓'
 }
@@ -156,6 +169,15 @@ func (m *CharacterMerger) initializeMergeRules() {
 	m.mergeMap[mergePair{'│', '┤'}] = '┤'  // vertical + right branch = right branch
 	m.mergeMap[mergePair{'─', '┬'}] = '┬'  // horizontal + top branch = top branch
 	m.mergeMap[mergePair{'─', '┴'}] = '┴'  // horizontal + bottom branch = bottom branch
+
+	// Heavy connections branch from light box borders and cross light lines
+	m.mergeMap[mergePair{'│', '┝'}] = '┝'
+	m.mergeMap[mergePair{'│', '┥'}] = '┥'
+	m.mergeMap[mergePair{'─', '┰'}] = '┰'
+	m.mergeMap[mergePair{'─', '┸'}] = '┸'
+	m.mergeMap[mergePair{'─', '┃'}] = '╂'
+	m.mergeMap[mergePair{'│', '━'}] = '┿'
+	m.mergeMap[mergePair{'━', '┃'}] = '╋'
 	
 	// Rounded corners - treat similar to regular corners
 	// When a rounded corner is placed on a line, it should remain as the corner
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"edd/diagram"
+)
+
+// labelGap is the number of blank columns kept between two labels on a row
+const labelGap = 1
+
+// maxLabelStack is how many rows above or below its line a label may be
+// stacked when no spot along its segment is clear
+const maxLabelStack = 4
+
+// LabelPlacer draws the labels of a diagram's connections one after another,
+// keeping each clear of those drawn before it. A label that would overlap
+// another is nudged along its segment, and when the segment has no clear spot
+// left, stacked on the nearest row above or below that has room.
+type LabelPlacer struct {
+	renderer *LabelRenderer
+	placed   []diagram.Bounds
+}
+
+// NewLabelPlacer creates a placer that draws labels with the given renderer
+func NewLabelPlacer(lr *LabelRenderer) *LabelPlacer {
+	return &LabelPlacer{renderer: lr}
+}
+
+// Placed returns the cells, inclusive, covered by each label drawn so far
+func (p *LabelPlacer) Placed() []diagram.Bounds {
+	return p.placed
+}
+
+// RenderStyledLabel renders a label like LabelRenderer.RenderStyledLabel,
+// moved clear of the labels this placer has already drawn
+func (p *LabelPlacer) RenderStyledLabel(c Canvas, path diagram.Path, label string, position LabelPosition, color, style string) {
+	lr := p.renderer
+	if label == "" || len(path.Points) < 2 {
+		return
+	}
+
+	// Format the label
+	formattedLabel := lr.formatLabel(label)
+
+	// Find the best segment for the label (prefer horizontal segments)
+	var segment *Segment
+	if style == LabelStyleInlineBox {
+		boxed := lr.formatInlineBoxLabel(label)
+		if segment = lr.findInlineBoxSegment(path, boxed); segment != nil {
+			formattedLabel = boxed
+		}
+	}
+	if segment == nil {
+		segment = lr.findBestSegmentForLabel(path, formattedLabel, position)
+	}
+	if segment == nil {
+		// If no suitable segment found, try with relaxed constraints
+		segment = lr.findAnySegmentForLabel(path, formattedLabel, position)
+		if segment == nil {
+			return // Still no suitable segment
+		}
+	}
+
+	// Render the label inline on the segment
+	labelLen := len([]rune(formattedLabel))
+	var x, y int
+	switch {
+	case segment.IsHorizontal:
+		x, y = p.horizontalSpot(c, segment, labelLen)
+		lr.drawHorizontalLabel(c, x, y, formattedLabel)
+	case segment.IsVertical:
+		x, y = lr.verticalLabelSpot(c, segment, labelLen, func(x, y, n int) bool {
+			return lr.isFree(c, x, y, n) && !p.collides(x, y, n)
+		})
+		for i, ch := range []rune(formattedLabel) {
+			lr.forceSet(c, diagram.Point{X: x + i, Y: y}, ch)
+		}
+	default:
+		return
+	}
+	p.placed = append(p.placed, diagram.Bounds{Min: diagram.Point{X: x, Y: y}, Max: diagram.Point{X: x + labelLen - 1, Y: y}})
+
+	if color != "" {
+		colorSetter, canColor := c.(interface {
+			SetColor(diagram.Point, string)
+		})
+		for i := 0; canColor && i < labelLen; i++ {
+			colorSetter.SetColor(diagram.Point{X: x + i, Y: y}, color)
+		}
+	}
+}
+
+// horizontalSpot chooses where a label on a horizontal segment goes: its
+// usual spot if that is clear, else the nearest clear spot along the segment,
+// else the nearest row above or below with room. A label with nowhere clear
+// keeps its usual spot.
+func (p *LabelPlacer) horizontalSpot(c Canvas, segment *Segment, labelLen int) (x, y int) {
+	x0, y0 := horizontalLabelOrigin(segment, labelLen)
+	if !p.collides(x0, y0, labelLen) {
+		return x0, y0
+	}
+
+	// Slide along the segment, keeping a line character at either end of an
+	// inline label and the middle of one above the line over the segment
+	start := min(segment.Start.X, segment.End.X)
+	end := max(segment.Start.X, segment.End.X)
+	first, last := start+1, end-labelLen
+	if y0 != segment.Start.Y {
+		first, last = start-labelLen/2, end-labelLen/2
+	}
+	for d := 1; x0-d >= first || x0+d <= last; d++ {
+		for _, x := range []int{x0 + d, x0 - d} {
+			if x >= first && x <= last && !p.collides(x, y0, labelLen) {
+				return x, y0
+			}
+		}
+	}
+
+	// Stack on the nearest row with room, preferring blank rows
+	for _, blankOnly := range []bool{true, false} {
+		for k := 1; k <= maxLabelStack; k++ {
+			for _, y := range []int{y0 - k, y0 + k} {
+				if p.collides(x0, y, labelLen) || !p.onCanvas(c, x0, y, labelLen) {
+					continue
+				}
+				if !blankOnly || p.renderer.isFree(c, x0, y, labelLen) {
+					return x0, y
+				}
+			}
+		}
+	}
+	return x0, y0
+}
+
+// collides reports whether n cells from (x, y) would touch a label already
+// placed, or come within labelGap columns of one on the same row
+func (p *LabelPlacer) collides(x, y, n int) bool {
+	for _, b := range p.placed {
+		if y >= b.Min.Y && y <= b.Max.Y && x <= b.Max.X+labelGap && x+n-1 >= b.Min.X-labelGap {
+			return true
+		}
+	}
+	return false
+}
+
+// onCanvas reports whether n cells from (x, y) all lie on the canvas
+func (p *LabelPlacer) onCanvas(c Canvas, x, y, n int) bool {
+	width, height := c.Size()
+	cx, cy := p.renderer.canvasX(c, x), p.renderer.canvasY(c, y)
+	return cx >= 0 && cx+n <= width && cy >= 0 && cy < height
+}
@@ -38,25 +38,26 @@ type Segment struct {
 
 // RenderLabel renders a label on a path at the specified position
 func (lr *LabelRenderer) RenderLabel(c Canvas, path diagram.Path, label string, position LabelPosition) {
-	if label == "" || len(path.Points) < 2 {
-		return
-	}
+	lr.RenderLabelWithColor(c, path, label, position, "")
+}
 
-	// Format the label
-	formattedLabel := lr.formatLabel(label)
+// LabelStyleInlineBox is the "label-style" hint value that boxes a label
+// into the line itself, like ──[ HTTP ]──, on the path's longest horizontal
+// segment with room for it
+const LabelStyleInlineBox = "inline-box"
 
-	// Find the best segment for the label (prefer horizontal segments)
-	segment := lr.findBestSegmentForLabel(path, formattedLabel, position)
-	if segment == nil {
-		// If no suitable segment found, try with relaxed constraints
-		segment = lr.findAnySegmentForLabel(path, formattedLabel, position)
-		if segment == nil {
-			return // Still no suitable segment
-		}
-	}
-	
-	// Render the label inline on the segment
-	lr.renderInlineLabel(c, segment, formattedLabel)
+// RenderLabelWithColor renders a label like RenderLabel and then draws its
+// characters in the given color, whatever color the line beneath them has.
+// An empty color leaves the label colored as the cells it was written over.
+func (lr *LabelRenderer) RenderLabelWithColor(c Canvas, path diagram.Path, label string, position LabelPosition, color string) {
+	lr.RenderStyledLabel(c, path, label, position, color, "")
+}
+
+// RenderStyledLabel renders a label like RenderLabelWithColor in the given
+// "label-style". An inline-box label that no horizontal segment has room
+// for is drawn the default way instead.
+func (lr *LabelRenderer) RenderStyledLabel(c Canvas, path diagram.Path, label string, position LabelPosition, color, style string) {
+	NewLabelPlacer(lr).RenderStyledLabel(c, path, label, position, color, style)
 }
 
 // findBestSegmentForLabel finds the best segment in the path to place a label
@@ -65,13 +66,14 @@ func (lr *LabelRenderer) findBestSegmentForLabel(path diagram.Path, label string
 		return nil
 	}
 
-	labelLen := len(label)
+	labelLen := len([]rune(label))
 	minSegmentLen := labelLen + 2 // Need space for label plus minimal padding
 
 	// Combine consecutive segments in the same direction
 	segments := lr.combineConsecutiveSegments(path)
-	
-	// Find the longest suitable segment
+	preferVertical := dominantOrientationIsVertical(segments)
+
+	// Find the longest suitable segment, favouring the path's dominant orientation
 	var bestSegment *Segment
 	var bestLength int
 	
@@ -79,25 +81,46 @@ func (lr *LabelRenderer) findBestSegmentForLabel(path diagram.Path, label string
 		var segLen int
 		if seg.IsHorizontal {
 			segLen = layout.Abs(seg.End.X - seg.Start.X)
+			if segLen < minSegmentLen {
+				continue // Inline labels need room along the line
+			}
 		} else if seg.IsVertical {
 			segLen = layout.Abs(seg.End.Y - seg.Start.Y)
+			if segLen < 2 {
+				continue // Side labels only need a row clear of the endpoints
+			}
 		} else {
 			continue // Skip diagonal segments
 		}
 		
-		// Prefer longer segments and horizontal over vertical
-		if segLen >= minSegmentLen {
-			if bestSegment == nil || segLen > bestLength || (segLen == bestLength && seg.IsHorizontal && !bestSegment.IsHorizontal) {
-				tempSeg := seg
-				bestSegment = &tempSeg
-				bestLength = segLen
-			}
+		preferred := seg.IsVertical == preferVertical
+		bestPreferred := bestSegment != nil && bestSegment.IsVertical == preferVertical
+		if bestSegment == nil || (preferred && !bestPreferred) || (preferred == bestPreferred && segLen > bestLength) {
+			tempSeg := seg
+			bestSegment = &tempSeg
+			bestLength = segLen
 		}
 	}
 
 	return bestSegment
 }
 
+// findInlineBoxSegment returns the longest horizontal stretch of the path
+// long enough to draw an inline-box label within the line, keeping at least
+// two line characters on either side of it, or nil if there is none
+func (lr *LabelRenderer) findInlineBoxSegment(path diagram.Path, label string) *Segment {
+	var best *Segment
+	bestLength := 0
+	for _, seg := range lr.combineConsecutiveSegments(path) {
+		length := layout.Abs(seg.End.X - seg.Start.X)
+		if seg.IsHorizontal && length >= len([]rune(label))+4 && length > bestLength {
+			tempSeg := seg
+			best, bestLength = &tempSeg, length
+		}
+	}
+	return best
+}
+
 // combineConsecutiveSegments combines consecutive path segments that go in the same direction
 func (lr *LabelRenderer) combineConsecutiveSegments(path diagram.Path) []Segment {
 	if len(path.Points) < 2 {
@@ -144,6 +167,20 @@ func (lr *LabelRenderer) combineConsecutiveSegments(path diagram.Path) []Segment
 	return segments
 }
 
+// dominantOrientationIsVertical reports whether a path covers more distance
+// vertically than horizontally.
+func dominantOrientationIsVertical(segments []Segment) bool {
+	horizontal, vertical := 0, 0
+	for _, seg := range segments {
+		if seg.IsHorizontal {
+			horizontal += layout.Abs(seg.End.X - seg.Start.X)
+		} else if seg.IsVertical {
+			vertical += layout.Abs(seg.End.Y - seg.Start.Y)
+		}
+	}
+	return vertical > horizontal
+}
+
 // getSegmentDirection returns a simple direction indicator for a segment
 func getSegmentDirection(from, to diagram.Point) string {
 	dx := to.X - from.X
@@ -188,39 +225,9 @@ func (lr *LabelRenderer) findAnySegmentForLabel(path diagram.Path, label string,
 	return nil
 }
 
-// renderInlineLabel renders a label inline on a segment, replacing the line characters
-func (lr *LabelRenderer) renderInlineLabel(c Canvas, segment *Segment, label string) {
-	if segment.IsHorizontal {
-		lr.renderHorizontalInlineLabel(c, segment, label)
-	} else if segment.IsVertical {
-		lr.renderVerticalInlineLabel(c, segment, label)
-	}
-}
-
-// renderHorizontalInlineLabel renders a label on a horizontal segment
-// For long segments: render inline on the path
-// For short segments: render above the path to avoid overlap
-func (lr *LabelRenderer) renderHorizontalInlineLabel(c Canvas, segment *Segment, label string) {
-	labelLen := len(label)
-	segmentLen := layout.Abs(segment.End.X - segment.Start.X)
-
-	// Calculate center of segment
-	minX := min(segment.Start.X, segment.End.X)
-	centerX := minX + segmentLen/2
-
-	var labelStartX, labelY int
-
-	// If segment is long enough, render inline. Otherwise render above.
-	if segmentLen >= labelLen + 4 {
-		// Long segment - render inline, centered, replacing the line
-		labelStartX = centerX - labelLen/2
-		labelY = segment.Start.Y
-	} else {
-		// Short segment - render above the path to avoid truncation
-		labelStartX = centerX - labelLen/2
-		labelY = segment.Start.Y - 1
-	}
-
+// drawHorizontalLabel writes a label along row y from column x, replacing
+// any line characters beneath it
+func (lr *LabelRenderer) drawHorizontalLabel(c Canvas, x, y int, label string) {
 	// Try to get direct matrix access
 	var matrix [][]rune
 	var xOffset, yOffset int
@@ -243,10 +250,10 @@ func (lr *LabelRenderer) renderHorizontalInlineLabel(c Canvas, segment *Segment,
 	
 	if matrix != nil {
 		// Direct matrix access to force overwrite
-		actualY := labelY + yOffset
+		actualY := y + yOffset
 		if actualY >= 0 && actualY < len(matrix) {
-			for i, ch := range label {
-				actualX := labelStartX + i + xOffset
+			for i, ch := range []rune(label) {
+				actualX := x + i + xOffset
 				if actualX >= 0 && actualX < len(matrix[actualY]) {
 					matrix[actualY][actualX] = ch
 				}
@@ -254,92 +261,185 @@ func (lr *LabelRenderer) renderHorizontalInlineLabel(c Canvas, segment *Segment,
 		}
 	} else {
 		// Fallback to normal Set
-		for i, ch := range label {
-			pos := diagram.Point{X: labelStartX + i, Y: labelY}
+		for i, ch := range []rune(label) {
+			pos := diagram.Point{X: x + i, Y: y}
 			c.Set(pos, ch)
 		}
 	}
 }
 
+// horizontalLabelOrigin returns where a label starts on a horizontal segment:
+// centered inline when the segment is long enough, otherwise the row above.
+// The text always reads left to right, and when it can't be centered exactly
+// it leans towards where the line comes from, whichever way the line runs.
+func horizontalLabelOrigin(segment *Segment, labelLen int) (x, y int) {
+	lo, hi := min(segment.Start.X, segment.End.X), max(segment.Start.X, segment.End.X)
+	x = lo + (hi-lo)/2 - labelLen/2
+	if segment.Start.X > segment.End.X {
+		// Mirror the rightward placement so a leftward label sits the same
+		// distance from where its line starts
+		x = lo + hi - (x + labelLen - 1)
+	}
+
+	if hi-lo >= labelLen+4 {
+		return x, segment.Start.Y
+	}
+	return x, segment.Start.Y - 1
+}
+
+// verticalLabelRow returns the row a label beside a vertical segment would
+// ideally take: the middle one, leaning towards where the line comes from
+// when there are two
+func verticalLabelRow(segment *Segment) int {
+	return segment.Start.Y + (segment.End.Y-segment.Start.Y)/2
+}
+
+// LabelBounds estimates the cells, inclusive, that RenderLabel will cover for
+// a label on the path. Labels beside vertical segments are assumed to take
+// their preferred spot to the right of the line's middle row.
+func (lr *LabelRenderer) LabelBounds(path diagram.Path, label string) (diagram.Bounds, bool) {
+	if label == "" || len(path.Points) < 2 {
+		return diagram.Bounds{}, false
+	}
+
+	formattedLabel := lr.formatLabel(label)
+	segment := lr.findBestSegmentForLabel(path, formattedLabel, LabelMiddle)
+	if segment == nil {
+		segment = lr.findAnySegmentForLabel(path, formattedLabel, LabelMiddle)
+		if segment == nil {
+			return diagram.Bounds{}, false
+		}
+	}
+
+	labelLen := len([]rune(formattedLabel))
+	var x, y int
+	switch {
+	case segment.IsHorizontal:
+		x, y = horizontalLabelOrigin(segment, labelLen)
+	case segment.IsVertical:
+		x, y = segment.Start.X+2, verticalLabelRow(segment)
+	default:
+		return diagram.Bounds{}, false
+	}
+	return diagram.Bounds{Min: diagram.Point{X: x, Y: y}, Max: diagram.Point{X: x + labelLen - 1, Y: y}}, true
+}
+
 // formatLabel formats the label text, truncating if necessary
 func (lr *LabelRenderer) formatLabel(label string) string {
 	label = strings.TrimSpace(label)
 	
 	// Truncate if too long
-	if len(label) > lr.maxLabelLength {
-		label = label[:lr.maxLabelLength-2] + ".."
+	if runes := []rune(label); len(runes) > lr.maxLabelLength {
+		label = string(runes[:lr.maxLabelLength-2]) + ".."
 	}
 
 	// Add brackets around the label
 	return "[" + label + "]"
 }
 
-// renderVerticalInlineLabel renders a label on a vertical segment
-// Labels on vertical segments are rendered HORIZONTALLY next to the path, not vertically along it
-func (lr *LabelRenderer) renderVerticalInlineLabel(c Canvas, segment *Segment, label string) {
-	segmentLen := layout.Abs(segment.End.Y - segment.Start.Y)
+// formatInlineBoxLabel formats the label for the inline-box style, padded
+// inside its brackets
+func (lr *LabelRenderer) formatInlineBoxLabel(label string) string {
+	formatted := lr.formatLabel(label)
+	return "[ " + formatted[1:len(formatted)-1] + " ]"
+}
 
-	// Calculate Y position (centered on the segment)
+// verticalLabelSpot chooses where a label beside a vertical segment goes.
+// Labels on vertical segments are rendered HORIZONTALLY next to the path, not vertically along it.
+// The label goes to the right of the line if there is room, otherwise to the left, starting
+// from the middle row and moving outwards until a row is found that free accepts.
+func (lr *LabelRenderer) verticalLabelSpot(c Canvas, segment *Segment, labelLen int, free func(x, y, n int) bool) (x, y int) {
 	minY := min(segment.Start.Y, segment.End.Y)
-	labelY := minY + segmentLen/2
+	maxY := max(segment.Start.Y, segment.End.Y)
+	centerY := verticalLabelRow(segment)
 
-	// Try to get direct matrix access to check bounds and render
-	var matrix [][]rune
-	var xOffset, yOffset int
-	var canvasWidth int
+	// Moving off the middle row, try towards the line's end first
+	step := 1
+	if segment.End.Y < segment.Start.Y {
+		step = -1
+	}
 
-	// Check if we can access the canvas as a MatrixCanvas for direct access
-	if mc, ok := c.(*MatrixCanvas); ok {
-		matrix = mc.Matrix()
-		xOffset = 0
-		yOffset = 0
-		if len(matrix) > 0 {
-			canvasWidth = len(matrix[0])
-		}
-	} else if oc, ok := c.(interface{
-		Matrix() [][]rune
-		Offset() diagram.Point
-	}); ok {
-		// Handle offsetCanvas case
-		matrix = oc.Matrix()
-		offset := oc.Offset()
-		xOffset = -offset.X
-		yOffset = -offset.Y
-		if len(matrix) > 0 {
-			canvasWidth = len(matrix[0])
+	// Candidate positions, preferring the right-hand side of the line
+	right := segment.Start.X + 2
+	left := segment.Start.X - labelLen - 1
+
+	for offset := 0; offset <= maxY-minY; offset++ {
+		for _, y := range []int{centerY + step*offset, centerY - step*offset} {
+			// Stay clear of the endpoints, where the line meets a node
+			if y <= minY || y >= maxY {
+				continue
+			}
+			if free(right, y, labelLen) {
+				return right, y
+			}
+			if free(left, y, labelLen) {
+				return left, y
+			}
 		}
 	}
 
-	// Calculate label position - try right of path first, then left if no room
-	labelX := segment.Start.X + 2 // Try 2 chars to the right
-	labelEndX := labelX + len(label)
+	// Nothing is clear; fall back to whichever side fits on the canvas
+	width, _ := c.Size()
+	if lr.canvasX(c, right+labelLen) >= width {
+		return max(left, lr.canvasOrigin(c)), centerY
+	}
+	return right, centerY
+}
 
-	// Check if label fits to the right
-	if matrix != nil && labelEndX+xOffset >= canvasWidth {
-		// No room on right, try left instead
-		labelX = segment.Start.X - len(label) - 2
-		if labelX < 0 {
-			labelX = 0 // Clamp to left edge
+// isFree reports whether the n cells starting at (x, y) are all blank and on the canvas.
+func (lr *LabelRenderer) isFree(c Canvas, x, y, n int) bool {
+	width, height := c.Size()
+	if lr.canvasX(c, x) < 0 || lr.canvasX(c, x+n) > width {
+		return false
+	}
+	if cy := lr.canvasY(c, y); cy < 0 || cy >= height {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if c.Get(diagram.Point{X: x + i, Y: y}) != ' ' {
+			return false
 		}
 	}
+	return true
+}
 
-	if matrix != nil && len(matrix) > 0 {
-		// Direct matrix access - render label horizontally
-		actualY := labelY + yOffset
-		if actualY >= 0 && actualY < len(matrix) {
-			for i, ch := range label {
-				actualX := labelX + i + xOffset
-				if actualX >= 0 && actualX < len(matrix[actualY]) {
-					matrix[actualY][actualX] = ch
-				}
-			}
-		}
-	} else {
-		// Fallback to normal Set - render horizontally
-		for i, ch := range label {
-			pos := diagram.Point{X: labelX + i, Y: labelY}
-			c.Set(pos, ch)
-		}
+// canvasX and canvasY translate diagram coordinates to canvas cells.
+func (lr *LabelRenderer) canvasX(c Canvas, x int) int {
+	return x - lr.canvasOrigin(c)
+}
+
+func (lr *LabelRenderer) canvasY(c Canvas, y int) int {
+	if oc, ok := c.(interface{ Offset() diagram.Point }); ok {
+		return y - oc.Offset().Y
+	}
+	return y
+}
+
+// canvasOrigin returns the diagram X coordinate of the canvas's first column.
+func (lr *LabelRenderer) canvasOrigin(c Canvas) int {
+	if oc, ok := c.(interface{ Offset() diagram.Point }); ok {
+		return oc.Offset().X
+	}
+	return 0
+}
+
+// forceSet writes a character directly to the canvas matrix when possible, so
+// labels replace line characters instead of being merged with them.
+func (lr *LabelRenderer) forceSet(c Canvas, p diagram.Point, ch rune) {
+	var matrix [][]rune
+	if mc, ok := c.(*MatrixCanvas); ok {
+		matrix = mc.Matrix()
+	} else if oc, ok := c.(interface{ Matrix() [][]rune }); ok {
+		matrix = oc.Matrix()
+	}
+	if matrix == nil {
+		c.Set(p, ch)
+		return
+	}
+
+	x, y := lr.canvasX(c, p.X), lr.canvasY(c, p.Y)
+	if y >= 0 && y < len(matrix) && x >= 0 && x < len(matrix[y]) {
+		matrix[y][x] = ch
 	}
 }
 
@@ -0,0 +1,158 @@
+package render
+
+import (
+	"edd/diagram"
+	"edd/layout"
+	"edd/pathfinding"
+	"hash/fnv"
+	"io"
+	"strconv"
+)
+
+// layoutCache remembers the last layout result so repeated renders of an
+// unchanged diagram (every keystroke in the editor) skip the layout pass.
+type layoutCache struct {
+	valid     bool
+	structure uint64 // Everything the layout depends on except node text
+	text      uint64
+	engine    diagram.LayoutEngine
+	nodes     []diagram.Node
+}
+
+// layoutDiagram sizes and positions the diagram's nodes, choosing the layout
+// engine from the diagram hints, wrapping node text to the "wrap" hint and
+// cutting it short at the "truncate" hint, fitting it to fixed
+// "width"/"height" hints, compacting columns when the "compact" hint is set,
+// moving nodes pinned by "x"/"y" hints and then, when the "align" hint is
+// set, straightening nearly aligned connections. Results are cached on the layout inputs, so the returned
+// slice is a copy the caller may modify.
+func (r *FlowchartRenderer) layoutDiagram(d *diagram.Diagram) ([]diagram.Node, pathfinding.FlowDirection, error) {
+	engine := r.layout
+	flowDirection := pathfinding.FlowVertical
+	if d.Hints != nil && d.Hints["layout"] == "horizontal" {
+		if r.horizontalLayout == nil {
+			horizontal := layout.NewHorizontalLayout()
+			horizontal.SetSeed(r.layoutSeed)
+			r.horizontalLayout = horizontal
+		}
+		engine = r.horizontalLayout
+		flowDirection = pathfinding.FlowHorizontal
+	}
+
+	compact := d.Hints != nil && d.Hints["compact"] == "true"
+	alignTolerance := 0
+	if d.Hints != nil && d.Hints["align"] == "true" {
+		alignTolerance = r.alignTolerance
+	}
+
+	nodes := CalculateNodeDimensions(ApplyFixedSizes(ApplyWrap(d)).Nodes)
+	structure, text := layoutKeys(nodes, d.Connections, alignTolerance, compact)
+
+	if c := &r.layoutCache; c.valid && c.structure == structure && c.engine == engine {
+		result := append([]diagram.Node(nil), c.nodes...)
+		if c.text != text {
+			// Only text changed and every box kept its size, so the
+			// positions still hold; carry the new text over
+			for i := range result {
+				result[i].Text = nodes[i].Text
+			}
+			c.nodes = append(c.nodes[:0], result...)
+			c.text = text
+		}
+		return result, flowDirection, nil
+	}
+
+	layoutNodes, err := engine.Layout(nodes, d.Connections)
+	if err != nil {
+		return nil, flowDirection, err
+	}
+	if compact {
+		layoutNodes = layout.CompactColumns(layoutNodes, d.Connections, layout.DefaultCompactGap)
+	}
+	layoutNodes = layout.ApplyPinnedPositions(layoutNodes)
+	layoutNodes = layout.AlignNearlyStraight(layoutNodes, d.Connections, alignTolerance)
+
+	r.layoutCache = layoutCache{
+		valid:     true,
+		structure: structure,
+		text:      text,
+		engine:    engine,
+		nodes:     append([]diagram.Node(nil), layoutNodes...),
+	}
+	return layoutNodes, flowDirection, nil
+}
+
+// LayoutNodes returns the diagram's nodes sized and positioned as the next
+// render will draw them, in layout coordinates (the ones "x"/"y" hints use).
+// Members of collapsed groups are left out in favor of their summary box.
+func (r *FlowchartRenderer) LayoutNodes(d *diagram.Diagram) ([]diagram.Node, error) {
+	d, _ = CollapseGroups(d)
+	nodes, _, err := r.layoutDiagram(d)
+	return nodes, err
+}
+
+// clearLayoutCache forces the next render to run the layout again.
+func (r *FlowchartRenderer) clearLayoutCache() {
+	r.layoutCache = layoutCache{}
+	r.routeCache = routeCache{}
+}
+
+// layoutKeys hashes everything a layout engine reads. The structure key
+// covers the layout options, node identity, size, position and hints, plus
+// connection endpoints and hints, and label lengths when compacting; node
+// text only affects layout through size, so it is hashed separately.
+func layoutKeys(nodes []diagram.Node, connections []diagram.Connection, tolerance int, compact bool) (structure, text uint64) {
+	h := fnv.New64a()
+	th := fnv.New64a()
+	scratch := make([]byte, 0, 24)
+	writeInt := func(v int) {
+		scratch = append(strconv.AppendInt(scratch[:0], int64(v), 10), 0)
+		h.Write(scratch)
+	}
+
+	writeInt(tolerance)
+	if compact {
+		writeInt(1)
+	} else {
+		writeInt(0)
+	}
+	for _, node := range nodes {
+		writeInt(node.ID)
+		writeInt(node.X)
+		writeInt(node.Y)
+		writeInt(node.Width)
+		writeInt(node.Height)
+		writeInt(int(hashHints(node.Hints)))
+
+		th.Write([]byte{1}) // Node separator
+		for _, line := range node.Text {
+			io.WriteString(th, line)
+			th.Write([]byte{0})
+		}
+	}
+	writeInt(-1)
+	for _, conn := range connections {
+		writeInt(conn.From)
+		writeInt(conn.To)
+		if compact {
+			// Compaction keeps room for labels, measured by their text
+			io.WriteString(h, conn.Label)
+			h.Write([]byte{0})
+		}
+		writeInt(int(hashHints(conn.Hints)))
+	}
+	return h.Sum64(), th.Sum64()
+}
+
+// hashHints hashes a hint map independently of iteration order.
+func hashHints(hints map[string]string) uint64 {
+	var sum uint64
+	for k, v := range hints {
+		h := fnv.New64a()
+		io.WriteString(h, k)
+		h.Write([]byte{0})
+		io.WriteString(h, v)
+		sum += h.Sum64()
+	}
+	return sum
+}
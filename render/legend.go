@@ -0,0 +1,109 @@
+package render
+
+import (
+	"edd/diagram"
+	"sort"
+	"strings"
+)
+
+// legendSample returns a short line segment drawn the way a connection with
+// the given legend key is drawn.
+func legendSample(key string) string {
+	switch key {
+	case "dashed":
+		return "╌╌╌"
+	case "dotted":
+		return "···"
+	case "double":
+		return "═══"
+	case "thick":
+		return "━━━"
+	default:
+		return "───"
+	}
+}
+
+// legendKeysInUse returns the legend keys that match a color or style used by
+// at least one connection, in sorted order.
+func legendKeysInUse(d *diagram.Diagram) []string {
+	used := make(map[string]bool)
+	for _, conn := range d.Connections {
+		if conn.Hints == nil {
+			continue
+		}
+		if color := conn.Hints["color"]; color != "" {
+			used[color] = true
+		}
+		if style := conn.Hints["style"]; style != "" {
+			used[style] = true
+		}
+		if IsThick(conn.Hints) {
+			used["thick"] = true
+		}
+	}
+
+	var keys []string
+	for key := range d.Legend {
+		if used[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RenderLegend draws a boxed legend explaining each connection color or style
+// listed in the diagram's Legend that is actually used. It returns an empty
+// string when there is nothing to show. With colored set, samples for color
+// keys are wrapped in the matching ANSI codes.
+func RenderLegend(d *diagram.Diagram, colored bool) string {
+	if d == nil || len(d.Legend) == 0 {
+		return ""
+	}
+
+	keys := legendKeysInUse(d)
+	if len(keys) == 0 {
+		return ""
+	}
+
+	keyWidth := 0
+	for _, key := range keys {
+		if w := StringWidth(key); w > keyWidth {
+			keyWidth = w
+		}
+	}
+
+	// Plain rows, used for width calculation
+	rows := make([]string, len(keys))
+	inner := StringWidth(" Legend ") + 1
+	for i, key := range keys {
+		rows[i] = legendSample(key) + " " + key + strings.Repeat(" ", keyWidth-StringWidth(key)) + "  " + d.Legend[key]
+		if w := StringWidth(rows[i]) + 2; w > inner {
+			inner = w
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("┌─ Legend " + strings.Repeat("─", inner-StringWidth(" Legend ")-1) + "┐\n")
+	for i, key := range keys {
+		row := rows[i]
+		padding := strings.Repeat(" ", inner-StringWidth(row)-2)
+		if code := GetColorCode(key); colored && code != "" {
+			sample := legendSample(key)
+			row = code + sample + ColorReset + strings.TrimPrefix(row, sample)
+		}
+		sb.WriteString("│ " + row + padding + " │\n")
+	}
+	sb.WriteString("└" + strings.Repeat("─", inner) + "┘")
+
+	return sb.String()
+}
+
+// appendLegend adds the diagram's legend below rendered output, if it has one.
+func appendLegend(output string, d *diagram.Diagram, colored bool) string {
+	legend := RenderLegend(d, colored)
+	if legend == "" {
+		return output
+	}
+	return output + "\n\n" + legend
+}
@@ -66,9 +66,12 @@ type MatrixCanvas struct {
 }
 
 // NewMatrixCanvas creates a new canvas with the specified dimensions.
+// Non-positive dimensions give an empty canvas that ignores drawing, rather
+// than a nil one.
 func NewMatrixCanvas(width, height int) *MatrixCanvas {
-	if width <= 0 || height <= 0 {
-		return nil
+	width, height = max(width, 0), max(height, 0)
+	if width == 0 || height == 0 {
+		width, height = 0, 0
 	}
 	
 	// Initialize matrix
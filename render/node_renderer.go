@@ -3,6 +3,7 @@ package render
 import (
 	"edd/diagram"
 	"strings"
+	"unicode/utf8"
 )
 
 // NodeRenderer handles rendering of nodes with various styles and hints
@@ -61,6 +62,19 @@ func (r *NodeRenderer) RenderNodeWithHints(canvas Canvas, node diagram.Node, hin
 		nodeColor = hints["color"]
 	}
 	
+	// Text nodes are just their text, centered, with no border
+	if node.IsText() {
+		centered := make(map[string]string, len(hints)+1)
+		for k, v := range hints {
+			centered[k] = v
+		}
+		centered["text-align"] = "center"
+		if centered["textColor"] == "" {
+			centered["textColor"] = nodeColor
+		}
+		return r.drawText(canvas, node, centered)
+	}
+	
 	// Draw the box border
 	if err := r.drawBox(canvas, node, style, nodeColor); err != nil {
 		return err
@@ -154,7 +168,7 @@ func (r *NodeRenderer) drawText(canvas Canvas, node diagram.Node, hints map[stri
 		
 		// Calculate starting position for centered text
 		if isCenter {
-			textWidth := len(line)
+			textWidth := utf8.RuneCountInString(line)
 			availableWidth := node.Width - 2 // minus borders
 			if textWidth < availableWidth {
 				// Center the text
@@ -167,7 +181,7 @@ func (r *NodeRenderer) drawText(canvas Canvas, node diagram.Node, hints map[stri
 		}
 		
 		// Draw the text
-		for j, ch := range line {
+		for j, ch := range []rune(line) {
 			if x+j < node.X+node.Width-1 { // Keep text within borders
 				pos := diagram.Point{X: x + j, Y: y}
 				r.setCharWithStyle(canvas, pos, ch, textColor, isBold, isItalic)
@@ -176,7 +190,7 @@ func (r *NodeRenderer) drawText(canvas Canvas, node diagram.Node, hints map[stri
 		
 		// For left-aligned text, add space after text if there's room
 		if !isCenter {
-			textEnd := x + len(line)
+			textEnd := x + utf8.RuneCountInString(line)
 			if textEnd < node.X+node.Width-1 {
 				r.setCharWithStyle(canvas, diagram.Point{X: textEnd, Y: y}, ' ', textColor, isBold, isItalic)
 			}
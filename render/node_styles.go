@@ -44,6 +44,22 @@ var NodeStyles = map[string]NodeStyle{
 		Horizontal:  '━',
 		Vertical:    '┃',
 	},
+	"dashed": {
+		TopLeft:     '┌',
+		TopRight:    '┐',
+		BottomLeft:  '└',
+		BottomRight: '┘',
+		Horizontal:  '┄',
+		Vertical:    '┆',
+	},
+	"dotted": {
+		TopLeft:     '┌',
+		TopRight:    '┐',
+		BottomLeft:  '└',
+		BottomRight: '┘',
+		Horizontal:  '┈',
+		Vertical:    '┊',
+	},
 	"ascii": {
 		TopLeft:     '+',
 		TopRight:    '+',
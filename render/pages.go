@@ -0,0 +1,103 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Page is one fixed-size window of rendered output, for printing a diagram
+// too large for a single sheet.
+type Page struct {
+	Row, Col int    // 0-based position of the page in the grid of pages
+	Content  string // The page's lines, trailing spaces trimmed
+}
+
+// Paginate slices rendered output into pages of at most width columns by
+// height lines, laid out left to right and then top to bottom. Every page of
+// the grid is returned, blank ones included, so the printed sheets tile the
+// whole canvas. A wide character cut by a page edge is blanked on both sides
+// so that columns stay aligned across pages.
+func Paginate(output string, width, height int) []Page {
+	if width < 1 || height < 1 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	rows := make([][]string, len(lines))
+	columns := 0
+	for i, line := range lines {
+		rows[i] = lineCells(line)
+		if len(rows[i]) > columns {
+			columns = len(rows[i])
+		}
+	}
+	if columns == 0 {
+		return nil
+	}
+
+	var pages []Page
+	for top, row := 0, 0; top < len(rows); top, row = top+height, row+1 {
+		for left, col := 0, 0; left < columns; left, col = left+width, col+1 {
+			var sb strings.Builder
+			for y := top; y < top+height && y < len(rows); y++ {
+				if y > top {
+					sb.WriteString("\n")
+				}
+				sb.WriteString(strings.TrimRight(sliceCells(rows[y], left, left+width), " "))
+			}
+			pages = append(pages, Page{Row: row, Col: col, Content: sb.String()})
+		}
+	}
+	return pages
+}
+
+// lineCells splits a line into its display columns. A wide character
+// occupies its column and leaves an empty string in the next, and ANSI
+// escape sequences stay attached to the character they precede.
+func lineCells(line string) []string {
+	var cells []string
+	escape := ""
+	for i := 0; i < len(line); {
+		if line[i] == '\033' {
+			end := strings.IndexByte(line[i:], 'm')
+			if end < 0 {
+				break
+			}
+			escape += line[i : i+end+1]
+			i += end + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		cells = append(cells, escape+line[i:i+size])
+		escape = ""
+		if UnicodeWidth(r) == 2 {
+			cells = append(cells, "")
+		}
+		i += size
+	}
+	return cells
+}
+
+// sliceCells joins the columns of cells from start up to end, blanking the
+// halves of wide characters cut by either edge
+func sliceCells(cells []string, start, end int) string {
+	var sb strings.Builder
+	colored := false
+	for x := start; x < end && x < len(cells); x++ {
+		cell := cells[x]
+		switch {
+		case cell == "" && x == start:
+			cell = " " // Second half of a wide character cut by the left edge
+		case x+1 == end && x+1 < len(cells) && cells[x+1] == "":
+			cell = " " // Wide character cut by the right edge
+		}
+		if strings.Contains(cell, "\033") {
+			colored = true
+		}
+		sb.WriteString(cell)
+	}
+	if colored {
+		sb.WriteString(ColorReset)
+	}
+	return sb.String()
+}
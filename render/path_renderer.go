@@ -52,6 +52,10 @@ type LineStyle struct {
 	ArrowDown  rune
 	ArrowLeft  rune
 	ArrowRight rune
+
+	// Shaft characters drawn behind wide arrowheads
+	ArrowShaftHorizontal rune
+	ArrowShaftVertical   rune
 }
 
 // NewPathRenderer creates a new path renderer with the given capabilities.
@@ -89,6 +93,9 @@ func (r *PathRenderer) RenderPathWithHints(canvas Canvas, path diagram.Path, has
 			r.hintStyle = style
 			r.applyHintStyle(style)
 		}
+		if IsThick(hints) {
+			r.applyThickness()
+		}
 		if color, ok := hints["color"]; ok {
 			r.hintColor = color
 		}
@@ -186,6 +193,46 @@ func (r *PathRenderer) applyHintStyle(style string) {
 	}
 }
 
+// IsThick reports whether a connection's hints ask for a heavy line: a
+// "thickness" of "thick", or the "thick" style Mermaid's ==> imports as
+func IsThick(hints map[string]string) bool {
+	return hints["thickness"] == "thick" || hints["style"] == "thick"
+}
+
+// applyThickness switches the line style to heavy lines, keeping a dashed
+// or dotted pattern. Double lines have no heavy form and are left alone.
+// Where a heavy line leaves a box, its branch is heavy on the line's side
+// only, so the box border stays light.
+func (r *PathRenderer) applyThickness() {
+	if r.hintStyle == "double" {
+		return
+	}
+	if r.caps.UnicodeLevel < UnicodeFull {
+		if r.style.Horizontal == '-' {
+			r.style.Horizontal = '=' // ASCII lines read heavier doubled
+		}
+		return
+	}
+	switch r.hintStyle {
+	case "dashed":
+		r.style.Horizontal = '╍' // Box drawing heavy double dash
+		r.style.Vertical = '╏'
+	case "dotted":
+		r.style.Horizontal = '•'
+		r.style.Vertical = '•'
+	default:
+		r.style.Horizontal = '━'
+		r.style.Vertical = '┃'
+	}
+	r.style.TopLeft = '┗'
+	r.style.TopRight = '┛'
+	r.style.BottomLeft = '┏'
+	r.style.BottomRight = '┓'
+	r.style.TeeRight = '┝'
+	r.style.TeeLeft = '┥'
+	r.style.TeeDown = '┰'
+	r.style.TeeUp = '┸'
+}
 
 // RenderPathWithOptions draws a path with additional rendering options.
 func (r *PathRenderer) RenderPathWithOptions(canvas Canvas, path diagram.Path, hasArrow bool, isConnection bool) error {
@@ -226,6 +273,13 @@ func (r *PathRenderer) RenderPathWithOptions(canvas Canvas, path diagram.Path, h
 		if i == 0 && isConnection && !isClosed {
 			existing := canvas.Get(from)
 			// Check if we're starting from a box edge (could be clean or already a branch)
+			// Broken node borders (dashed/dotted) branch the same way as solid ones
+			switch existing {
+			case '┆', '┊':
+				existing = '│'
+			case '┄', '┈':
+				existing = '─'
+			}
 			if existing == '│' || existing == '─' || existing == '├' || existing == '┤' || existing == '┬' || existing == '┴' {
 				// Don't draw the line at the first point - let it stay as box edge
 				// This prevents │ + ─ = ┼ when we want │ + ─ = ├
@@ -307,6 +361,44 @@ func (r *PathRenderer) RenderPathWithOptions(canvas Canvas, path diagram.Path, h
 }
 
 
+// RenderWideArrowShaft lengthens the arrowhead at the end of path to two
+// cells by turning the line cell behind it into a heavy shaft. It runs after
+// every connection is drawn, and only replaces a plain straight line, so
+// junctions and corners next to the head are left alone.
+func (r *PathRenderer) RenderWideArrowShaft(canvas Canvas, path diagram.Path) {
+	points := path.Points
+	if len(points) < 2 {
+		return
+	}
+	last, prev := points[len(points)-1], points[len(points)-2]
+	dir := diagram.Point{X: sign(last.X - prev.X), Y: sign(last.Y - prev.Y)}
+	if dir.X != 0 && dir.Y != 0 {
+		return
+	}
+
+	// The head sits one cell before the endpoint; the shaft one before that
+	shaft := diagram.Point{X: last.X - 2*dir.X, Y: last.Y - 2*dir.Y}
+	line, shaftChar := r.style.Horizontal, r.style.ArrowShaftHorizontal
+	if dir.X == 0 {
+		line, shaftChar = r.style.Vertical, r.style.ArrowShaftVertical
+	}
+	if shaftChar == 0 || canvas.Get(shaft) != line {
+		return
+	}
+	r.setWithColor(canvas, shaft, shaftChar)
+}
+
+// sign returns -1, 0 or 1 according to the sign of v
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
 // drawSegmentSkippingCorners draws a line segment while skipping any positions marked as corners
 // If skipFirst is true, skip drawing at the first point (used for connection starts)
 func (r *PathRenderer) drawSegmentSkippingCorners(canvas Canvas, from, to diagram.Point, corners map[diagram.Point]rune, drawArrow bool) error {
@@ -519,6 +611,9 @@ func selectLineStyle(caps TerminalCapabilities) LineStyle {
 			ArrowDown:  '▼',
 			ArrowLeft:  '◀',
 			ArrowRight: '▶',
+			// Wide arrowhead shafts
+			ArrowShaftHorizontal: '━',
+			ArrowShaftVertical:   '┃',
 		}
 	case UnicodeBasic:
 		return LineStyle{
@@ -541,6 +636,9 @@ func selectLineStyle(caps TerminalCapabilities) LineStyle {
 			ArrowDown:  'v',
 			ArrowLeft:  '<',
 			ArrowRight: '>',
+			// Wide arrowhead shafts
+			ArrowShaftHorizontal: '=',
+			ArrowShaftVertical:   '|',
 		}
 	default: // UnicodeNone (ASCII)
 		return LineStyle{
@@ -563,6 +661,9 @@ func selectLineStyle(caps TerminalCapabilities) LineStyle {
 			ArrowDown:  'v',
 			ArrowLeft:  '<',
 			ArrowRight: '>',
+			// Wide arrowhead shafts
+			ArrowShaftHorizontal: '=',
+			ArrowShaftVertical:   '|',
 		}
 	}
 }
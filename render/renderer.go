@@ -66,6 +66,14 @@ func (r *Renderer) EnableObstacleVisualization() {
 	}
 }
 
+// SetLayoutSeed changes the tie-break ordering used by the flowchart layouts.
+// The default of 0 orders interchangeable nodes by ID.
+func (r *Renderer) SetLayoutSeed(seed int64) {
+	if r.flowchartRenderer != nil {
+		r.flowchartRenderer.SetLayoutSeed(seed)
+	}
+}
+
 // GetRouter returns the router instance for external configuration
 func (r *Renderer) GetRouter() interface{} {
 	// Return the flowchart renderer's router for backward compatibility
@@ -4,6 +4,7 @@ import (
 	"edd/diagram"
 	"edd/layout"
 	"fmt"
+	"sort"
 )
 
 // SequenceRenderer handles rendering of sequence diagrams
@@ -35,6 +36,10 @@ func (r *SequenceRenderer) Render(d *diagram.Diagram) (string, error) {
 		return "", fmt.Errorf("diagram is nil")
 	}
 	
+	// Wrap participant names and resolve participant and theme colors into
+	// explicit hints
+	d = ApplyTheme(ApplyParticipantColors(ApplyWrap(d)))
+	
 	// Get bounds
 	width, height := r.GetBounds(d)
 	if width <= 0 || height <= 0 {
@@ -49,12 +54,19 @@ func (r *SequenceRenderer) Render(d *diagram.Diagram) (string, error) {
 	if err := r.RenderToCanvas(d, c); err != nil {
 		return "", fmt.Errorf("failed to render sequence diagram: %w", err)
 	}
+	if columns, lines, ok := gridSpacing(d); ok {
+		var boxes []diagram.Node
+		for _, pos := range r.layout.ComputePositions(d).Participants {
+			boxes = append(boxes, diagram.Node{X: pos.X, Y: pos.Y, Width: pos.Width, Height: pos.Height})
+		}
+		drawGrid(c, diagram.Point{}, columns, lines, boxes)
+	}
 	
 	// Return colored output if using colored canvas
 	if coloredCanvas, ok := c.(*ColoredMatrixCanvas); ok {
-		return coloredCanvas.ColoredString(), nil
+		return appendFrame(appendLegend(coloredCanvas.ColoredString(), d, true), d), nil
 	}
-	return c.String(), nil
+	return appendFrame(appendLegend(c.String(), d, false), d), nil
 }
 
 // RenderToCanvas draws a complete sequence diagram to the provided canvas
@@ -99,10 +111,61 @@ func (r *SequenceRenderer) RenderToCanvas(d *diagram.Diagram, c Canvas) error {
 	if err := r.drawMessages(d, positions, c); err != nil {
 		return fmt.Errorf("failed to draw messages: %w", err)
 	}
+
+	// Shade lanes last, behind whatever was drawn
+	r.shadeLanes(d, positions, c)
 	
 	return nil
 }
 
+// shadeLanes gives every other participant's lane, the columns nearer its
+// lifeline than any other, a dim background below the participant boxes when
+// the diagram's "lanes" hint is "true". Only blank cells and the lane's own
+// lifeline are shaded, so messages and their labels stand out from it.
+func (r *SequenceRenderer) shadeLanes(d *diagram.Diagram, positions *layout.SequencePositions, c Canvas) {
+	if d.Hints["lanes"] != "true" || len(positions.Participants) == 0 {
+		return
+	}
+	shader, ok := c.(interface {
+		SetBackground(diagram.Point, string)
+	})
+	if !ok {
+		return
+	}
+
+	var lifelines []int
+	top := -1
+	for _, pos := range positions.Participants {
+		lifelines = append(lifelines, pos.LifelineX)
+		if bottom := pos.Y + pos.Height; top < 0 || bottom < top {
+			top = bottom
+		}
+	}
+	sort.Ints(lifelines)
+
+	width, height := c.Size()
+	for i := 0; i < len(lifelines); i += 2 {
+		// A lane reaches halfway to the lifelines either side of it
+		left, right := 0, width-1
+		if i > 0 {
+			left = (lifelines[i-1]+lifelines[i])/2 + 1
+		}
+		if i < len(lifelines)-1 {
+			right = (lifelines[i] + lifelines[i+1]) / 2
+		}
+		for y := top; y < height; y++ {
+			for x := left; x <= right; x++ {
+				p := diagram.Point{X: x, Y: y}
+				ch := c.Get(p)
+				onLifeline := layout.Abs(x-lifelines[i]) <= 1 && (ch == '│' || ch == '┆' || ch == '·')
+				if ch == 0 || ch == ' ' || onLifeline {
+					shader.SetBackground(p, BackgroundLane)
+				}
+			}
+		}
+	}
+}
+
 // drawLifelines draws vertical dashed lines from each participant
 func (r *SequenceRenderer) drawLifelines(d *diagram.Diagram, positions *layout.SequencePositions, c Canvas) error {
 	// Get diagram bounds to know how far down to draw
@@ -344,6 +407,13 @@ func (r *SequenceRenderer) drawArrow(c Canvas, fromX, toX, y int, leftToRight bo
 	default:
 		lineChar = '─'
 	}
+	dash := '─'
+	if IsThick(hints) {
+		dash = '━'
+		if lineChar == '─' {
+			lineChar = dash
+		}
+	}
 	
 	// Get color from hints if available
 	color := ""
@@ -394,7 +464,7 @@ func (r *SequenceRenderer) drawArrow(c Canvas, fromX, toX, y int, leftToRight bo
 			switch style {
 			case "dashed":
 				if (x-startX)%3 == 0 || (x-startX)%3 == 1 {
-					charToDraw = '─'
+					charToDraw = dash
 				} else {
 					continue // Skip this position for gap
 				}
@@ -426,13 +496,13 @@ func (r *SequenceRenderer) drawArrow(c Canvas, fromX, toX, y int, leftToRight bo
 		}
 	}
 	
-	// Draw label above the arrow if present (always use default color for text)
+	// Draw label above the arrow if present, in the default color unless the
+	// message has a label-color of its own
 	if label != "" {
-		labelX := (fromX + toX) / 2 - len(label)/2
-		for i, ch := range label {
-			// Force default color by using empty string (no color)
+		labelX := (fromX+toX)/2 - len([]rune(label))/2
+		for i, ch := range []rune(label) {
 			if coloredCanvas, ok := c.(*ColoredMatrixCanvas); ok {
-				coloredCanvas.SetWithColor(diagram.Point{X: labelX + i, Y: y - 1}, ch, "")
+				coloredCanvas.SetWithColor(diagram.Point{X: labelX + i, Y: y - 1}, ch, hints["label-color"])
 			} else {
 				c.Set(diagram.Point{X: labelX + i, Y: y - 1}, ch)
 			}
@@ -467,6 +537,9 @@ func (r *SequenceRenderer) drawSelfMessage(c Canvas, x, y int, label string, hin
 	default:
 		lineChar = '─'
 	}
+	if IsThick(hints) && lineChar == '─' {
+		lineChar = '━'
+	}
 	
 	// Helper to set with or without color
 	setChar := func(p diagram.Point, ch rune) {
@@ -497,10 +570,20 @@ func (r *SequenceRenderer) drawSelfMessage(c Canvas, x, y int, label string, hin
 	setChar(diagram.Point{X: x + 1, Y: y + 2}, '◀')
 	// The lifeline at position x will be preserved
 	
-	// Label
+	// Label beside the loop, level with its right side, or on a line of its
+	// own above the loop
 	if label != "" {
-		for i, ch := range label {
-			c.Set(diagram.Point{X: x + 1 + i, Y: y - 1}, ch)
+		labelX, labelY := x+layout.SelfMessageLabelOffset, y+1
+		if hints["label-style"] == layout.LabelStyleAbove {
+			labelX, labelY = x+2, y-1
+		}
+		for i, ch := range []rune(label) {
+			p := diagram.Point{X: labelX + i, Y: labelY}
+			if labelColor := hints["label-color"]; labelColor != "" {
+				r.setWithColor(c, p, ch, labelColor)
+			} else {
+				c.Set(p, ch)
+			}
 		}
 	}
 }
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"edd/diagram"
+	"edd/layout"
+)
+
+// ApplyFixedSizes returns a copy of the diagram in which the text of every
+// node with "width" or "height" hints is clipped to fit its fixed box, or d
+// itself when no node has them. Lines too wide for the box are cut short
+// with an ellipsis, as is the last line that fits when there are too many.
+func ApplyFixedSizes(d *diagram.Diagram) *diagram.Diagram {
+	fixed := false
+	for _, node := range d.Nodes {
+		if width, height := layout.FixedSize(node); width > 0 || height > 0 {
+			fixed = true
+			break
+		}
+	}
+	if !fixed {
+		return d
+	}
+
+	result := d.Clone()
+	for i, node := range result.Nodes {
+		width, height := layout.FixedSize(node)
+		text := node.Text
+		if lines := height - 2; height > 0 && len(text) > lines {
+			text = append(append([]string(nil), text[:lines-1]...), text[lines-1]+"…")
+		}
+		if width > 0 {
+			fitted := make([]string, len(text))
+			for j, line := range text {
+				fitted[j] = FitText(line, width-4, "…")
+			}
+			text = fitted
+		}
+		result.Nodes[i].Text = text
+	}
+	return result
+}
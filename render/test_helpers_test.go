@@ -0,0 +1,52 @@
+package render
+
+import (
+	"edd/validation"
+	"strings"
+	"testing"
+)
+
+// TestValidator provides canvas assertions for render tests.
+type TestValidator struct {
+	t *testing.T
+}
+
+// NewTestValidator creates a validator for the given test.
+func NewTestValidator(t *testing.T) *TestValidator {
+	return &TestValidator{t: t}
+}
+
+// AssertCanvasEquals checks that the canvas renders exactly the expected text,
+// ignoring trailing whitespace on each line.
+func (v *TestValidator) AssertCanvasEquals(c Canvas, expected string) {
+	v.t.Helper()
+	got := trimLines(c.String())
+	want := trimLines(strings.TrimPrefix(expected, "\n"))
+	if got != want {
+		v.t.Errorf("Canvas mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// ValidateMatrix checks that line characters in the matrix connect properly.
+func (v *TestValidator) ValidateMatrix(matrix [][]rune) {
+	v.t.Helper()
+	lines := make([]string, len(matrix))
+	for i, row := range matrix {
+		lines[i] = string(row)
+	}
+	for _, err := range validation.NewLineValidator().Validate(strings.Join(lines, "\n")) {
+		v.t.Errorf("Invalid line drawing: %s", err)
+	}
+}
+
+// trimLines strips trailing spaces from each line and drops trailing blank lines.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,117 @@
+package render
+
+import "edd/diagram"
+
+// Theme is a named color palette applied to nodes and connections that don't
+// set their own color. A diagram selects a theme with the "theme" hint.
+type Theme struct {
+	Name            string
+	NodeColor       string
+	ConnectionColor string
+}
+
+// Themes holds the built-in color themes
+var Themes = map[string]Theme{
+	"default": {Name: "default"},
+	"dark":    {Name: "dark", NodeColor: "cyan", ConnectionColor: "white"},
+	"light":   {Name: "light", NodeColor: "blue", ConnectionColor: "magenta"},
+	"ocean":   {Name: "ocean", NodeColor: "blue", ConnectionColor: "cyan"},
+	"forest":  {Name: "forest", NodeColor: "green", ConnectionColor: "yellow"},
+	"sunset":  {Name: "sunset", NodeColor: "magenta", ConnectionColor: "red"},
+}
+
+// themeOrder is the order themes are cycled through
+var themeOrder = []string{"default", "dark", "light", "ocean", "forest", "sunset"}
+
+// ThemeNames returns the built-in theme names in cycling order
+func ThemeNames() []string {
+	names := make([]string, len(themeOrder))
+	copy(names, themeOrder)
+	return names
+}
+
+// GetTheme returns the theme with the given name
+func GetTheme(name string) (Theme, bool) {
+	theme, ok := Themes[name]
+	return theme, ok
+}
+
+// NextTheme returns the theme after the given one in cycling order, wrapping around
+func NextTheme(name string) string {
+	for i, n := range themeOrder {
+		if n == name {
+			return themeOrder[(i+1)%len(themeOrder)]
+		}
+	}
+	return themeOrder[0]
+}
+
+// ApplyTheme resolves the diagram's theme into explicit color hints.
+// It returns the diagram unchanged when no theme is set, otherwise a copy
+// where nodes and connections without a color pick up the theme's colors.
+func ApplyTheme(d *diagram.Diagram) *diagram.Diagram {
+	if d == nil || d.Hints == nil {
+		return d
+	}
+
+	theme, ok := GetTheme(d.Hints["theme"])
+	if !ok || (theme.NodeColor == "" && theme.ConnectionColor == "") {
+		return d
+	}
+
+	themed := d.Clone()
+	if theme.NodeColor != "" {
+		for i := range themed.Nodes {
+			if themed.Nodes[i].Hints == nil {
+				themed.Nodes[i].Hints = make(map[string]string)
+			}
+			if themed.Nodes[i].Hints["color"] == "" {
+				themed.Nodes[i].Hints["color"] = theme.NodeColor
+			}
+		}
+	}
+	if theme.ConnectionColor != "" {
+		for i := range themed.Connections {
+			if themed.Connections[i].Hints == nil {
+				themed.Connections[i].Hints = make(map[string]string)
+			}
+			if themed.Connections[i].Hints["color"] == "" {
+				themed.Connections[i].Hints["color"] = theme.ConnectionColor
+			}
+		}
+	}
+
+	return themed
+}
+
+// ApplyParticipantColors gives each message without its own color the color
+// of the participant sending it, when the diagram's "message-color" hint is
+// "participant". It returns the diagram unchanged when the mode is off,
+// otherwise a copy. Apply it before ApplyTheme so a theme's connection color
+// only fills messages from participants without a color.
+func ApplyParticipantColors(d *diagram.Diagram) *diagram.Diagram {
+	if d == nil || d.Hints["message-color"] != "participant" {
+		return d
+	}
+
+	colors := make(map[int]string)
+	for _, node := range d.Nodes {
+		if color := node.Hints["color"]; color != "" {
+			colors[node.ID] = color
+		}
+	}
+
+	colored := d.Clone()
+	for i := range colored.Connections {
+		conn := &colored.Connections[i]
+		color, ok := colors[conn.From]
+		if !ok || conn.Hints["color"] != "" {
+			continue
+		}
+		if conn.Hints == nil {
+			conn.Hints = make(map[string]string)
+		}
+		conn.Hints["color"] = color
+	}
+	return colored
+}
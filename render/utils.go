@@ -2,6 +2,8 @@ package render
 
 import (
 	"edd/diagram"
+	"edd/layout"
+	"unicode/utf8"
 )
 
 // CalculateNodeDimensions determines the width and height of nodes based on their text content.
@@ -10,17 +12,22 @@ func CalculateNodeDimensions(nodes []diagram.Node) []diagram.Node {
 	copy(result, nodes)
 	
 	for i := range result {
+		if layout.SizeTextNode(&result[i]) {
+			continue
+		}
+
 		maxWidth := 0
 		for _, line := range result[i].Text {
-			if len(line) > maxWidth {
-				maxWidth = len(line)
+			if width := utf8.RuneCountInString(line); width > maxWidth {
+				maxWidth = width
 			}
 		}
-		
+
 		// Add padding: 2 chars for borders + 2 chars for internal padding
 		result[i].Width = maxWidth + 4
 		// Height: number of lines + 2 for borders
 		result[i].Height = len(result[i].Text) + 2
+		layout.ApplyFixedSize(&result[i])
 	}
 	
 	return result
@@ -84,6 +91,11 @@ func CalculateBounds(nodes []diagram.Node, paths map[int]diagram.Path) diagram.B
 
 // HasColorHints checks if any nodes or connections have color or style hints.
 func HasColorHints(d *diagram.Diagram) bool {
+	// Shaded lanes are drawn in a background color
+	if d.Type == string(diagram.DiagramTypeSequence) && d.Hints["lanes"] == "true" {
+		return true
+	}
+
 	// Check nodes
 	for _, node := range d.Nodes {
 		if node.Hints != nil {
@@ -111,6 +123,9 @@ func HasColorHints(d *diagram.Diagram) bool {
 			if _, hasBold := conn.Hints["bold"]; hasBold {
 				return true
 			}
+			if _, hasLabelColor := conn.Hints["label-color"]; hasLabelColor {
+				return true
+			}
 		}
 	}
 	
@@ -158,7 +173,9 @@ func (oc *OffsetCanvas) SetWithColor(p diagram.Point, char rune, color string) e
 		Y: p.Y - oc.offset.Y,
 	}
 	// Try to set with color if the underlying canvas supports it
-	if coloredCanvas, ok := oc.canvas.(*ColoredMatrixCanvas); ok {
+	if coloredCanvas, ok := oc.canvas.(interface {
+		SetWithColor(diagram.Point, rune, string) error
+	}); ok {
 		return coloredCanvas.SetWithColor(translated, char, color)
 	}
 	// Fall back to regular set
@@ -173,13 +190,28 @@ func (oc *OffsetCanvas) SetWithColorAndStyle(p diagram.Point, char rune, color s
 		Y: p.Y - oc.offset.Y,
 	}
 	// Try to set with color and style if the underlying canvas supports it
-	if coloredCanvas, ok := oc.canvas.(*ColoredMatrixCanvas); ok {
+	if coloredCanvas, ok := oc.canvas.(interface {
+		SetWithColorAndStyle(diagram.Point, rune, string, string) error
+	}); ok {
 		return coloredCanvas.SetWithColorAndStyle(translated, char, color, style)
 	}
 	// Fall back to regular set
 	return oc.canvas.Set(translated, char)
 }
 
+// SetColor recolors the character at the given position if the underlying
+// canvas supports color.
+func (oc *OffsetCanvas) SetColor(p diagram.Point, color string) {
+	if coloredCanvas, ok := oc.canvas.(interface{ SetColor(diagram.Point, string) }); ok {
+		coloredCanvas.SetColor(diagram.Point{X: p.X - oc.offset.X, Y: p.Y - oc.offset.Y}, color)
+	}
+}
+
+// Offset returns the diagram coordinate drawn at the canvas origin.
+func (oc *OffsetCanvas) Offset() diagram.Point {
+	return oc.offset
+}
+
 // Size returns the size of the underlying canvas
 func (oc *OffsetCanvas) Size() (width, height int) {
 	return oc.canvas.Size()
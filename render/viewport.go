@@ -0,0 +1,504 @@
+package render
+
+import (
+	"edd/diagram"
+	"edd/pathfinding"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Viewport is a window onto the canvas of a box diagram, in the coordinates
+// of Render's output before the legend and frame are added: the top-left cell
+// of the output is 0,0.
+type Viewport struct {
+	X, Y          int
+	Width, Height int
+}
+
+// viewportBand is the height in lines of the horizontal bands the route cache
+// files nodes and connections under, so a viewport only looks at the few
+// bands it overlaps
+const viewportBand = 32
+
+// routeCache remembers the connection routes of the last layout drawn through
+// a viewport, along with what ViewportLayout.Render needs to find the parts
+// of the diagram inside a window without visiting the rest.
+type routeCache struct {
+	valid  bool
+	key    uint64 // Node boxes, connections and flow direction routed
+	paths  map[int]diagram.Path
+	bounds diagram.Bounds
+
+	// Extents reached by each node and connection, including shadows and
+	// labels, and the bands of lines those extents cover
+	nodeExtents []diagram.Bounds
+	connExtents map[int]diagram.Bounds
+	nodeBands   map[int][]int
+	connBands   map[int][]int
+}
+
+// ViewportLayout is a box diagram laid out and routed once, to be drawn one
+// window at a time, for diagrams too large to draw in full on every frame.
+type ViewportLayout struct {
+	renderer *FlowchartRenderer
+	diagram  *diagram.Diagram // Collapsed and themed, as it is drawn
+	connMap  []int            // Index in the original d.Connections of each connection
+	nodes    []diagram.Node
+	routes   routeCache
+	color    bool // Whether the diagram needs a colored canvas
+
+	legend []string       // Lines of the legend drawn below the canvas, if any
+	frame  *viewportFrame // Where the frame sits, for a framed diagram
+}
+
+// viewportFrame places the frame of a framed diagram on the canvas, fitted to
+// the content as appendFrame fits it to the full render
+type viewportFrame struct {
+	top, bottom int // First and last canvas lines inside the frame
+	margin      int // Blank columns dropped from the left of each line
+	width       int // Width the lines inside the frame are padded to
+}
+
+// LayoutViewport lays out the diagram and routes its connections, ready for
+// ViewportLayout.Render. The routes are cached, so laying out an unchanged
+// diagram again only costs the pass over it that spots changes.
+func (r *FlowchartRenderer) LayoutViewport(d *diagram.Diagram) (*ViewportLayout, error) {
+	if d == nil {
+		return nil, fmt.Errorf("diagram is nil")
+	}
+	d, connMap := CollapseGroups(d)
+	d = ApplyBundleCounts(ApplyTheme(d))
+	layoutNodes, routes, err := r.routedLayout(d)
+	if err != nil {
+		return nil, err
+	}
+	v := &ViewportLayout{renderer: r, diagram: d, connMap: connMap, nodes: layoutNodes, routes: *routes, color: HasColorHints(d)}
+	if legend := RenderLegend(d, v.color); legend != "" {
+		v.legend = strings.Split(legend, "\n")
+	}
+	if d.Hints["frame"] == "true" {
+		v.frame = v.fitFrame()
+	}
+	return v, nil
+}
+
+// fitFrame fits the frame to the node boxes, their shadows and the routes, as
+// appendFrame fits it to the drawn cells. Only a label reaching past every
+// box and line can leave the frame a little tighter than the full render's.
+func (v *ViewportLayout) fitFrame() *viewportFrame {
+	bounds := v.routes.bounds
+	content := diagram.Bounds{Min: diagram.Point{X: bounds.Width(), Y: bounds.Height()}, Max: diagram.Point{X: -1, Y: -1}}
+	include := func(x, y int) {
+		x, y = x-bounds.Min.X, y-bounds.Min.Y
+		content.Min.X, content.Min.Y = min(content.Min.X, x), min(content.Min.Y, y)
+		content.Max.X, content.Max.Y = max(content.Max.X, x), max(content.Max.Y, y)
+	}
+	for _, node := range v.nodes {
+		right, bottom := node.X+node.Width-1, node.Y+node.Height-1
+		if node.Hints["shadow"] != "" {
+			right, bottom = right+1, bottom+1
+		}
+		include(node.X, node.Y)
+		include(right, bottom)
+	}
+	for _, path := range v.routes.paths {
+		for _, p := range path.Points {
+			include(p.X, p.Y)
+		}
+	}
+	if content.Max.X < 0 {
+		return &viewportFrame{top: 0, bottom: -1, width: frameWidth(v.diagram, 0)}
+	}
+
+	frame := &viewportFrame{top: content.Min.Y, bottom: content.Max.Y, margin: content.Min.X, width: content.Max.X - content.Min.X + 1}
+	if len(v.legend) > 0 {
+		// The legend starts in the first column and the blank line above it
+		// keeps every line down to it
+		frame.width += frame.margin
+		frame.margin = 0
+		frame.bottom = bounds.Height() - 1
+		for _, line := range v.legend {
+			frame.width = max(frame.width, visibleWidth(line))
+		}
+	}
+	frame.width = frameWidth(v.diagram, frame.width)
+	return frame
+}
+
+// Lines returns the number of lines in Render's full output for the diagram,
+// counting the legend and frame
+func (v *ViewportLayout) Lines() int {
+	_, height := v.Size()
+	if v.frame != nil {
+		height = v.frame.bottom - v.frame.top + 1 + 2
+	}
+	if len(v.legend) > 0 {
+		height += 1 + len(v.legend)
+	}
+	return height
+}
+
+// RenderLines draws lines top to top+count of Render's full output for the
+// diagram, legend and frame included, drawing only the part of the canvas
+// those lines show
+func (v *ViewportLayout) RenderLines(top, count int) (string, error) {
+	width, height := v.Size()
+	total := v.Lines()
+	first, last := 0, height-1 // Canvas lines in the output
+	offset := 0                // Output line of canvas line first
+	if v.frame != nil {
+		first, last, offset = v.frame.top, v.frame.bottom, 1
+	}
+	top, end := max(top, 0), min(top+count, total)
+
+	// Draw the canvas lines in range in one go
+	var canvas []string
+	from, to := max(first, top-offset+first), min(last, end-1-offset+first)
+	if from <= to {
+		window, err := v.Render(Viewport{Y: from, Width: width, Height: to - from + 1})
+		if err != nil {
+			return "", err
+		}
+		canvas = strings.Split(window, "\n")
+	}
+
+	var lines []string
+	for i := top; i < end; i++ {
+		if v.frame != nil && i == 0 {
+			lines = append(lines, frameTopEdge(v.diagram, v.frame.width))
+			continue
+		}
+		if v.frame != nil && i == total-1 {
+			lines = append(lines, frameBottomEdge(v.diagram, v.frame.width))
+			continue
+		}
+
+		var line string
+		switch row := i - offset + first; {
+		case row <= last:
+			line = canvas[row-from]
+		case row > last+1:
+			// The legend, below a blank line
+			line = v.legend[row-last-2]
+		}
+		if v.frame != nil {
+			line = frameLine(cutMargin(strings.TrimRight(line, " "), v.frame.margin), v.frame.width)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// cutMargin drops up to margin leading spaces from a line
+func cutMargin(line string, margin int) string {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	return line[min(indent, margin):]
+}
+
+// Size returns the size of the whole canvas in columns and lines
+func (v *ViewportLayout) Size() (width, height int) {
+	return v.routes.bounds.Width(), v.routes.bounds.Height()
+}
+
+// Positions returns the top-left corner of each node, keyed by node ID, in
+// output coordinates
+func (v *ViewportLayout) Positions() map[int]diagram.Point {
+	bounds := v.routes.bounds
+	positions := make(map[int]diagram.Point, len(v.nodes))
+	for _, node := range v.nodes {
+		positions[node.ID] = diagram.Point{X: node.X - bounds.Min.X, Y: node.Y - bounds.Min.Y}
+	}
+	return positions
+}
+
+// Paths returns each connection's route keyed by its index in the original
+// diagram's connections, in output coordinates. Connections hidden inside a
+// collapsed group have no path.
+func (v *ViewportLayout) Paths() map[int]diagram.Path {
+	bounds := v.routes.bounds
+	paths := make(map[int]diagram.Path, len(v.routes.paths))
+	for i, path := range v.routes.paths {
+		points := make([]diagram.Point, len(path.Points))
+		for j, point := range path.Points {
+			points[j] = diagram.Point{X: point.X - bounds.Min.X, Y: point.Y - bounds.Min.Y}
+		}
+		paths[v.connMap[i]] = diagram.Path{Points: points, Cost: path.Cost, Metadata: path.Metadata}
+	}
+	return paths
+}
+
+// Render draws the part of the diagram inside the viewport. Only the nodes,
+// connections and labels reaching into the window are visited, so the cost
+// follows the size of the viewport rather than of the diagram. The result is
+// the viewport's lines of Render's output, without the legend and frame that
+// surround the whole diagram. A label can differ only where a connection
+// wholly outside the window would have crowded it out.
+func (v *ViewportLayout) Render(view Viewport) (string, error) {
+	if view.Width < 1 || view.Height < 1 {
+		return "", fmt.Errorf("viewport must have a positive size, got %dx%d", view.Width, view.Height)
+	}
+
+	// Pick out what reaches into the window, keeping the drawing order
+	routes := &v.routes
+	bounds := routes.bounds
+	window := diagram.Bounds{
+		Min: diagram.Point{X: bounds.Min.X + view.X, Y: bounds.Min.Y + view.Y},
+		Max: diagram.Point{X: bounds.Min.X + view.X + view.Width, Y: bounds.Min.Y + view.Y + view.Height},
+	}
+	var nodes []diagram.Node
+	for _, i := range routes.inWindow(routes.nodeBands, window, func(i int) diagram.Bounds { return routes.nodeExtents[i] }) {
+		nodes = append(nodes, v.nodes[i])
+	}
+	visible := *v.diagram
+	visible.Connections = nil
+	paths := make(map[int]diagram.Path)
+	for _, i := range routes.inWindow(routes.connBands, window, func(i int) diagram.Bounds { return routes.connExtents[i] }) {
+		paths[len(visible.Connections)] = routes.paths[i]
+		visible.Connections = append(visible.Connections, v.diagram.Connections[i])
+	}
+
+	c := CreateCanvas(view.Width, view.Height, v.color)
+	windowed := &windowCanvas{canvas: c, origin: diagram.Point{X: view.X, Y: view.Y}, width: bounds.Width(), height: bounds.Height()}
+	if err := v.renderer.renderToCanvas(&visible, nodes, paths, NewOffsetCanvas(windowed, bounds.Min)); err != nil {
+		return "", fmt.Errorf("failed to render to canvas: %w", err)
+	}
+
+	if columns, lines, ok := gridSpacing(v.diagram); ok {
+		boxes := make([]diagram.Node, len(nodes))
+		for i, node := range nodes {
+			node.X -= window.Min.X
+			node.Y -= window.Min.Y
+			boxes[i] = node
+		}
+		drawGrid(c, diagram.Point{X: view.X, Y: view.Y}, columns, lines, boxes)
+	}
+
+	if coloredCanvas, ok := c.(*ColoredMatrixCanvas); ok {
+		return coloredCanvas.ColoredString(), nil
+	}
+	return c.String(), nil
+}
+
+// routedLayout lays out the diagram and routes its connections, reusing the
+// routes of the last call when the node boxes and connections are unchanged.
+// Routes are stored in layout coordinates, as renderToCanvas draws them.
+func (r *FlowchartRenderer) routedLayout(d *diagram.Diagram) ([]diagram.Node, *routeCache, error) {
+	layoutNodes, flowDirection, err := r.layoutDiagram(d)
+	if err != nil {
+		return nil, nil, fmt.Errorf("layout failed: %w", err)
+	}
+	r.growEditedNode(layoutNodes)
+
+	key := routeKey(layoutNodes, d.Connections, flowDirection)
+	if c := &r.routeCache; c.valid && c.key == key {
+		return layoutNodes, c, nil
+	}
+
+	if areaRouter := r.router.GetAreaRouter(); areaRouter != nil {
+		areaRouter.SetFlowDirection(flowDirection)
+	}
+	r.router.SetPortManager(pathfinding.NewPortManager(layoutNodes, 1))
+	paths, err := r.router.RouteConnections(d.Connections, layoutNodes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connection routing failed: %w", err)
+	}
+
+	c := routeCache{
+		valid:       true,
+		key:         key,
+		paths:       paths,
+		bounds:      CalculateBounds(layoutNodes, paths),
+		nodeExtents: make([]diagram.Bounds, len(layoutNodes)),
+		connExtents: make(map[int]diagram.Bounds, len(paths)),
+		nodeBands:   make(map[int][]int),
+		connBands:   make(map[int][]int),
+	}
+	for i, node := range layoutNodes {
+		// One cell more to the right and below for the shadow
+		extent := diagram.Bounds{Min: diagram.Point{X: node.X, Y: node.Y}, Max: diagram.Point{X: node.X + node.Width + 1, Y: node.Y + node.Height + 1}}
+		c.nodeExtents[i] = extent
+		fileInBands(c.nodeBands, i, extent)
+	}
+	for i, path := range paths {
+		if len(path.Points) == 0 {
+			continue
+		}
+		extent := diagram.Bounds{Min: path.Points[0], Max: path.Points[0]}
+		for _, p := range path.Points[1:] {
+			extent.Min.X, extent.Min.Y = min(extent.Min.X, p.X), min(extent.Min.Y, p.Y)
+			extent.Max.X, extent.Max.Y = max(extent.Max.X, p.X), max(extent.Max.Y, p.Y)
+		}
+		// Labels sit beside or just above the line
+		reach := len([]rune(d.Connections[i].Label)) + 6
+		extent.Min.X -= reach
+		extent.Max.X += reach + 1
+		extent.Min.Y--
+		extent.Max.Y += 2
+		c.connExtents[i] = extent
+		fileInBands(c.connBands, i, extent)
+	}
+	r.routeCache = c
+	return layoutNodes, &r.routeCache, nil
+}
+
+// inWindow returns, in ascending order, the indexes filed in the bands the
+// window overlaps whose extent meets the window
+func (c *routeCache) inWindow(bands map[int][]int, window diagram.Bounds, extent func(int) diagram.Bounds) []int {
+	seen := make(map[int]bool)
+	var found []int
+	for band := bandOf(window.Min.Y); band <= bandOf(window.Max.Y-1); band++ {
+		for _, i := range bands[band] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			e := extent(i)
+			if e.Min.X < window.Max.X && e.Max.X > window.Min.X && e.Min.Y < window.Max.Y && e.Max.Y > window.Min.Y {
+				found = append(found, i)
+			}
+		}
+	}
+	sort.Ints(found)
+	return found
+}
+
+// fileInBands files index i under every band the extent covers
+func fileInBands(bands map[int][]int, i int, extent diagram.Bounds) {
+	for band := bandOf(extent.Min.Y); band <= bandOf(extent.Max.Y-1); band++ {
+		bands[band] = append(bands[band], i)
+	}
+}
+
+// bandOf returns the band holding line y, rounding down for negative lines
+func bandOf(y int) int {
+	if y < 0 {
+		return (y+1)/viewportBand - 1
+	}
+	return y / viewportBand
+}
+
+// routeKey hashes everything the router reads: the node boxes, the
+// connections with their hints, labels, waypoints and raw paths, and the flow
+// direction.
+func routeKey(nodes []diagram.Node, connections []diagram.Connection, flow pathfinding.FlowDirection) uint64 {
+	h := fnv.New64a()
+	scratch := make([]byte, 0, 24)
+	writeInt := func(v int) {
+		scratch = append(strconv.AppendInt(scratch[:0], int64(v), 10), 0)
+		h.Write(scratch)
+	}
+
+	writeInt(int(flow))
+	for _, node := range nodes {
+		writeInt(node.ID)
+		writeInt(node.X)
+		writeInt(node.Y)
+		writeInt(node.Width)
+		writeInt(node.Height)
+	}
+	writeInt(-1)
+	for _, conn := range connections {
+		writeInt(conn.From)
+		writeInt(conn.To)
+		writeInt(int(hashHints(conn.Hints)))
+		io.WriteString(h, conn.Label)
+		writeInt(len(conn.Waypoints))
+		for _, p := range conn.Waypoints {
+			writeInt(p.X)
+			writeInt(p.Y)
+		}
+		writeInt(len(conn.Path))
+		for _, cell := range conn.Path {
+			writeInt(cell.X)
+			writeInt(cell.Y)
+			io.WriteString(h, cell.Rune)
+		}
+	}
+	return h.Sum64()
+}
+
+// windowCanvas holds one window of a larger canvas. It reports the size of
+// the whole canvas, so renderers place things exactly as they would on it,
+// but keeps only the cells inside the window; the rest read as blank.
+type windowCanvas struct {
+	canvas        Canvas
+	origin        diagram.Point // Cell of the whole canvas at the window's top-left
+	width, height int           // Size of the whole canvas
+}
+
+// inside translates a cell of the whole canvas to the window
+func (w *windowCanvas) inside(p diagram.Point) (diagram.Point, bool) {
+	q := diagram.Point{X: p.X - w.origin.X, Y: p.Y - w.origin.Y}
+	width, height := w.canvas.Size()
+	return q, q.X >= 0 && q.X < width && q.Y >= 0 && q.Y < height
+}
+
+// Size returns the size of the whole canvas
+func (w *windowCanvas) Size() (width, height int) {
+	return w.width, w.height
+}
+
+// Get returns the character at the given cell, blank outside the window
+func (w *windowCanvas) Get(p diagram.Point) rune {
+	if q, ok := w.inside(p); ok {
+		return w.canvas.Get(q)
+	}
+	return ' '
+}
+
+// Set places a character at the given cell, dropping it outside the window
+func (w *windowCanvas) Set(p diagram.Point, char rune) error {
+	if p.X < 0 || p.X >= w.width || p.Y < 0 || p.Y >= w.height {
+		return ErrOutOfBounds
+	}
+	if q, ok := w.inside(p); ok {
+		return w.canvas.Set(q, char)
+	}
+	return nil
+}
+
+// SetWithColor sets a colored character if the window's canvas supports color
+func (w *windowCanvas) SetWithColor(p diagram.Point, char rune, color string) error {
+	if coloredCanvas, ok := w.canvas.(*ColoredMatrixCanvas); ok {
+		if q, inside := w.inside(p); inside {
+			return coloredCanvas.SetWithColor(q, char, color)
+		}
+		return nil
+	}
+	return w.Set(p, char)
+}
+
+// SetWithColorAndStyle sets a colored, styled character if the window's
+// canvas supports color
+func (w *windowCanvas) SetWithColorAndStyle(p diagram.Point, char rune, color string, style string) error {
+	if coloredCanvas, ok := w.canvas.(*ColoredMatrixCanvas); ok {
+		if q, inside := w.inside(p); inside {
+			return coloredCanvas.SetWithColorAndStyle(q, char, color, style)
+		}
+		return nil
+	}
+	return w.Set(p, char)
+}
+
+// SetColor recolors the character at the given cell if it is in the window
+func (w *windowCanvas) SetColor(p diagram.Point, color string) {
+	if coloredCanvas, ok := w.canvas.(*ColoredMatrixCanvas); ok {
+		if q, inside := w.inside(p); inside {
+			coloredCanvas.SetColor(q, color)
+		}
+	}
+}
+
+// Clear clears the window
+func (w *windowCanvas) Clear() {
+	w.canvas.Clear()
+}
+
+// String returns the window's cells as a string
+func (w *windowCanvas) String() string {
+	return w.canvas.String()
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"edd/diagram"
+	"strconv"
+)
+
+// WrapWidth returns the width in columns that node text is wrapped to, from
+// the diagram's "wrap" hint, or 0 when text is drawn as written
+func WrapWidth(d *diagram.Diagram) int {
+	return widthHint(d, "wrap")
+}
+
+// TruncateWidth returns the width in columns that lines of node text are cut
+// short at, from the diagram's "truncate" hint, or 0 when they never are
+func TruncateWidth(d *diagram.Diagram) int {
+	return widthHint(d, "truncate")
+}
+
+// widthHint reads a diagram hint holding a positive number of columns
+func widthHint(d *diagram.Diagram, key string) int {
+	if d == nil {
+		return 0
+	}
+	width, err := strconv.Atoi(d.Hints[key])
+	if err != nil || width < 1 {
+		return 0
+	}
+	return width
+}
+
+// ApplyWrap returns a copy of the diagram in which every line of node text
+// wider than WrapWidth is wrapped at word boundaries, and every line still
+// wider than TruncateWidth is cut short with an ellipsis, or d itself when
+// neither is set. Blank lines are kept, and a single word wider than the wrap
+// width, such as a URL, is broken across lines.
+func ApplyWrap(d *diagram.Diagram) *diagram.Diagram {
+	width, truncate := WrapWidth(d), TruncateWidth(d)
+	if width == 0 && truncate == 0 {
+		return d
+	}
+
+	wrapped := d.Clone()
+	for i, node := range wrapped.Nodes {
+		var text []string
+		for _, line := range node.Text {
+			if width == 0 || StringWidth(line) <= width {
+				text = append(text, line)
+				continue
+			}
+			text = append(text, WrapTextMode(line, width, WrapModeChar)...)
+		}
+		if truncate > 0 {
+			for j, line := range text {
+				text[j] = FitText(line, truncate, "…")
+			}
+		}
+		wrapped.Nodes[i].Text = text
+	}
+	return wrapped
+}
@@ -0,0 +1,38 @@
+// Package templates provides prebuilt diagram skeletons to start editing from
+package templates
+
+import (
+	"edd/diagram"
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Names returns the names of the built-in templates, in sorted order
+func Names() []string {
+	entries, _ := files.ReadDir(".")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns a fresh copy of the template with the given name
+func Get(name string) (*diagram.Diagram, bool) {
+	data, err := files.ReadFile(name + ".json")
+	if err != nil {
+		return nil, false
+	}
+	var d diagram.Diagram
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, false
+	}
+	return &d, true
+}
@@ -0,0 +1,42 @@
+package templates
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func TestTemplatesAreValid(t *testing.T) {
+	names := Names()
+	if len(names) < 3 {
+		t.Fatalf("Expected the built-in templates, got %v", names)
+	}
+	for _, name := range names {
+		d, ok := Get(name)
+		if !ok {
+			t.Errorf("%s: failed to load", name)
+			continue
+		}
+		if d.Type != "box" && d.Type != string(diagram.DiagramTypeSequence) {
+			t.Errorf("%s: unexpected type %q", name, d.Type)
+		}
+		ids := make(map[int]bool)
+		for _, node := range d.Nodes {
+			ids[node.ID] = true
+		}
+		for _, conn := range d.Connections {
+			if !ids[conn.From] || !ids[conn.To] {
+				t.Errorf("%s: connection %d -> %d references a missing node", name, conn.From, conn.To)
+			}
+		}
+	}
+
+	// Each call returns a copy, so edits never leak into the next insert
+	d, _ := Get("three-tier")
+	d.Nodes[0].Text[0] = "changed"
+	if again, _ := Get("three-tier"); again.Nodes[0].Text[0] == "changed" {
+		t.Errorf("Expected Get to return a fresh copy")
+	}
+	if _, ok := Get("missing"); ok {
+		t.Errorf("Expected no template called missing")
+	}
+}
@@ -0,0 +1,96 @@
+package terminal
+
+import (
+	"edd/diagram"
+	"edd/export"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// contextFilePrefix starts the name of every temp file that edits a diagram
+// held in another file, a markdown block or one diagram of a collection
+const contextFilePrefix = "edd_markdown_"
+
+// EditContext records where a diagram being edited through a temp file came
+// from, so :w can save it back there
+type EditContext struct {
+	File       string // Markdown or collection file the diagram belongs to
+	BlockIndex int    // 1-based index of the block or diagram within File
+	BlockType  string // Markdown block type, or CollectionBlockType
+}
+
+// ContextTempFile is a temp copy of a diagram with its EditContext beside it.
+// Each one has a unique name, readable only by the current user, so
+// concurrent sessions never share or guess each other's files.
+type ContextTempFile struct {
+	Path string // The diagram JSON, passed to the editor as its file
+}
+
+// CreateContextTempFile writes d to a new temp file and ctx to a context file
+// beside it. Call Remove when editing ends, typically deferred so the files
+// go even if the editor panics.
+func CreateContextTempFile(d *diagram.Diagram, ctx EditContext) (*ContextTempFile, error) {
+	data, err := export.MarshalJSON(d, export.TempJSONIndentFromEnv())
+	if err != nil {
+		return nil, fmt.Errorf("marshaling diagram to JSON: %w", err)
+	}
+
+	// os.CreateTemp picks an unused name and opens it 0600
+	file, err := os.CreateTemp("", contextFilePrefix+"*.json")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp file: %w", err)
+	}
+	tf := &ContextTempFile{Path: file.Name()}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		tf.Remove()
+		return nil, fmt.Errorf("writing temp file: %w", err)
+	}
+
+	ctxFile, err := os.OpenFile(tf.Path+".ctx", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		tf.Remove()
+		return nil, fmt.Errorf("creating context file: %w", err)
+	}
+	_, err = fmt.Fprintf(ctxFile, "%s\n%d\n%s", ctx.File, ctx.BlockIndex, ctx.BlockType)
+	if closeErr := ctxFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		tf.Remove()
+		return nil, fmt.Errorf("writing context file: %w", err)
+	}
+	return tf, nil
+}
+
+// Remove deletes the temp file and its context file
+func (tf *ContextTempFile) Remove() {
+	os.Remove(tf.Path)
+	os.Remove(tf.Path + ".ctx")
+}
+
+// ReadEditContext returns the context saved beside a temp file made by
+// CreateContextTempFile. ok is false for any other file.
+func ReadEditContext(filename string) (ctx EditContext, ok bool) {
+	if !strings.HasPrefix(filepath.Base(filename), contextFilePrefix) {
+		return EditContext{}, false
+	}
+	data, err := os.ReadFile(filename + ".ctx")
+	if err != nil {
+		return EditContext{}, false
+	}
+
+	// Context: source file\nblock index\nblock type
+	lines := strings.Split(string(data), "\n")
+	if len(lines) < 3 {
+		return EditContext{}, false
+	}
+	index, _ := strconv.Atoi(lines[1])
+	return EditContext{File: lines[0], BlockIndex: index, BlockType: lines[2]}, true
+}
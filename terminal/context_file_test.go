@@ -0,0 +1,81 @@
+package terminal
+
+import (
+	"edd/diagram"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentContextTempFilesDontCollide(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// Two sessions editing the first block of different files at once
+	const sessions = 2
+	files := make([]*ContextTempFile, sessions)
+	errs := make([]error, sessions)
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			d := &diagram.Diagram{Nodes: []diagram.Node{{ID: 1, Text: []string{fmt.Sprintf("Session %d", i)}}}}
+			files[i], errs[i] = CreateContextTempFile(d, EditContext{
+				File:       fmt.Sprintf("doc%d.md", i),
+				BlockIndex: 1,
+				BlockType:  "mermaid",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, tf := range files {
+		if errs[i] != nil {
+			t.Fatalf("Session %d: %v", i, errs[i])
+		}
+		if i > 0 && tf.Path == files[0].Path {
+			t.Fatalf("Expected distinct temp files, both are %s", tf.Path)
+		}
+
+		data, err := os.ReadFile(tf.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("Session %d", i); !strings.Contains(string(data), want) {
+			t.Errorf("Expected session %d's diagram in %s, got %s", i, tf.Path, data)
+		}
+		ctx, ok := ReadEditContext(tf.Path)
+		if want := (EditContext{File: fmt.Sprintf("doc%d.md", i), BlockIndex: 1, BlockType: "mermaid"}); !ok || ctx != want {
+			t.Errorf("Expected context %+v, got %+v (ok=%v)", want, ctx, ok)
+		}
+		for _, path := range []string{tf.Path, tf.Path + ".ctx"} {
+			if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+				t.Errorf("Expected %s to be private to the user, got %v %v", path, info.Mode(), err)
+			}
+		}
+	}
+
+	// Ending one session leaves the other's files alone
+	files[0].Remove()
+	for _, path := range []string{files[0].Path, files[0].Path + ".ctx"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", path, err)
+		}
+	}
+	if _, ok := ReadEditContext(files[1].Path); !ok {
+		t.Errorf("Expected the other session's context to survive")
+	}
+	files[1].Remove()
+}
+
+func TestReadEditContextIgnoresOtherFiles(t *testing.T) {
+	path := t.TempDir() + "/diagram.json"
+	if err := os.WriteFile(path+".ctx", []byte("doc.md\n1\nmermaid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ReadEditContext(path); ok {
+		t.Errorf("Expected a file edd didn't create to have no context")
+	}
+}
@@ -0,0 +1,179 @@
+package terminal
+
+import (
+	"edd/render"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DiffRedraw makes the TUI redraw only the parts of the screen that changed
+// since the last frame, instead of clearing and reprinting the whole screen,
+// which flickers on slow terminals
+var DiffRedraw bool
+
+// cell is one column of a frame on screen
+type cell struct {
+	style string // SGR escape sequences in effect, "" for the default style
+	ch    string // The character, "" for the second column of a wide one
+}
+
+// blankCell is what the screen shows where a frame draws nothing
+var blankCell = cell{ch: " "}
+
+// FrameDiffer remembers the last frame written to the terminal and turns the
+// next one into the escape sequences that repaint only the cells that differ
+type FrameDiffer struct {
+	prev  [][]cell
+	valid bool         // prev is what the screen shows
+	dirty map[int]bool // Rows drawn over since, to repaint in full
+}
+
+// NewFrameDiffer creates a differ whose first frame is drawn in full
+func NewFrameDiffer() *FrameDiffer {
+	return &FrameDiffer{dirty: make(map[int]bool)}
+}
+
+// Invalidate makes the next frame clear the screen and draw in full, for when
+// something else has drawn on it or it has been resized
+func (f *FrameDiffer) Invalidate() {
+	f.valid = false
+}
+
+// InvalidateRows makes the next frame repaint the 0-based rows first to last
+// in full, for when overlays have been drawn over them
+func (f *FrameDiffer) InvalidateRows(first, last int) {
+	for row := first; row <= last; row++ {
+		f.dirty[row] = true
+	}
+}
+
+// Diff returns what to write to the terminal to turn the previous frame on
+// screen into frame: the whole frame after a clear the first time or after
+// Invalidate, and otherwise cursor moves and the changed cells only, which
+// is nothing at all when the frame hasn't changed
+func (f *FrameDiffer) Diff(frame string) string {
+	next := parseFrame(frame)
+	prev, valid, dirty := f.prev, f.valid, f.dirty
+	f.prev, f.valid, f.dirty = next, true, make(map[int]bool)
+	if !valid {
+		return "\033[H\033[2J" + frame
+	}
+
+	var sb strings.Builder
+	style := ""
+	write := func(c cell) {
+		if c.style != style {
+			sb.WriteString(render.ColorReset + c.style)
+			style = c.style
+		}
+		sb.WriteString(c.ch)
+	}
+
+	for y := 0; y < max(len(prev), len(next)); y++ {
+		before, after := rowAt(prev, y), rowAt(next, y)
+		if dirty[y] {
+			sb.WriteString(fmt.Sprintf("\033[%d;1H", y+1))
+			for _, c := range after {
+				write(c)
+			}
+			if style != "" {
+				sb.WriteString(render.ColorReset)
+				style = ""
+			}
+			sb.WriteString("\033[K")
+			continue
+		}
+
+		width := max(len(before), len(after))
+		for x := 0; x < width; {
+			if cellAt(before, x) == cellAt(after, x) {
+				x++
+				continue
+			}
+
+			// Repaint from the start of a wide character cut by the change
+			changed := x
+			if x > 0 && cellAt(after, x).ch == "" {
+				x--
+			}
+			sb.WriteString(fmt.Sprintf("\033[%d;%dH", y+1, x+1))
+			for ; x < width && (x <= changed || cellAt(before, x) != cellAt(after, x) || cellAt(after, x).ch == ""); x++ {
+				if c := cellAt(after, x); c.ch != "" {
+					write(c)
+				}
+			}
+		}
+	}
+	if style != "" {
+		sb.WriteString(render.ColorReset)
+	}
+	return sb.String()
+}
+
+// rowAt returns row y of a frame, or nil below its last line
+func rowAt(rows [][]cell, y int) []cell {
+	if y < len(rows) {
+		return rows[y]
+	}
+	return nil
+}
+
+// cellAt returns column x of a row, blank past its end
+func cellAt(row []cell, x int) cell {
+	if x < len(row) {
+		return row[x]
+	}
+	return blankCell
+}
+
+// parseFrame splits rendered output into rows of cells, resolving the SGR
+// color sequences in it to the style of each cell
+func parseFrame(frame string) [][]cell {
+	var rows [][]cell
+	style := "" // Colors carry over line breaks until reset
+	for _, line := range strings.Split(frame, "\n") {
+		var row []cell
+		for i := 0; i < len(line); {
+			if line[i] == '\033' {
+				end := i + 1
+				if end < len(line) && line[end] == '[' {
+					end++
+					for end < len(line) && (line[end] < 0x40 || line[end] > 0x7E) {
+						end++
+					}
+				}
+				if end >= len(line) {
+					break
+				}
+				if seq := line[i : end+1]; line[end] == 'm' {
+					if seq == "\033[0m" || seq == "\033[m" {
+						style = ""
+					} else {
+						style += seq
+					}
+				}
+				i = end + 1
+				continue
+			}
+
+			r, size := utf8.DecodeRuneInString(line[i:])
+			switch render.UnicodeWidth(r) {
+			case 0:
+				if last := len(row) - 1; last >= 0 && r != '\r' {
+					if row[last].ch == "" {
+						last-- // Second column of a wide character
+					}
+					row[last].ch += line[i : i+size] // Combining mark
+				}
+			case 2:
+				row = append(row, cell{style: style, ch: line[i : i+size]}, cell{style: style})
+			default:
+				row = append(row, cell{style: style, ch: line[i : i+size]})
+			}
+			i += size
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
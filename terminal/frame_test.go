@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"edd/render"
+	"strings"
+	"testing"
+)
+
+func TestFrameDifferRedrawsOnlyChangedCells(t *testing.T) {
+	frame := strings.Join([]string{
+		"╭───────╮",
+		"│ " + render.ColorBlue + "Start" + render.ColorReset + " │",
+		"╰───┬───╯",
+		"    ▼",
+		"  世界",
+	}, "\n")
+
+	screen := NewFrameDiffer()
+	full := screen.Diff(frame)
+	if !strings.HasPrefix(full, "\033[H\033[2J") || !strings.HasSuffix(full, frame) {
+		t.Fatalf("Expected the first frame cleared and drawn in full, got %q", full)
+	}
+
+	if out := screen.Diff(frame); out != "" {
+		t.Errorf("Expected nothing written for an unchanged frame, got %q", out)
+	}
+
+	// One character of the colored text changes
+	changed := strings.Replace(frame, "Start", "Stark", 1)
+	out := screen.Diff(changed)
+	if want := "\033[2;7H" + render.ColorReset + render.ColorBlue + "k" + render.ColorReset; out != want {
+		t.Errorf("Expected just the changed cell, got %q want %q", out, want)
+	}
+	if len(out) >= len(changed)/4 {
+		t.Errorf("Expected a single-cell change to cost far less than the frame, got %d bytes for a %d byte frame", len(out), len(changed))
+	}
+
+	// A wide character is repainted whole, and removed text is blanked
+	changed = strings.Replace(changed, "世界", "世间", 1)
+	changed = strings.Replace(changed, "    ▼", "", 1)
+	out = screen.Diff(changed)
+	if !strings.Contains(out, "\033[5;5H间") {
+		t.Errorf("Expected the changed wide character repainted from its first column, got %q", out)
+	}
+	if !strings.Contains(out, "\033[4;5H ") {
+		t.Errorf("Expected the removed arrow blanked, got %q", out)
+	}
+
+	// Rows drawn over by overlays are repainted in full
+	screen.InvalidateRows(0, 0)
+	if out := screen.Diff(changed); out != "\033[1;1H╭───────╮\033[K" {
+		t.Errorf("Expected the invalidated row repainted, got %q", out)
+	}
+
+	screen.Invalidate()
+	if out := screen.Diff(changed); !strings.HasPrefix(out, "\033[H\033[2J") {
+		t.Errorf("Expected a full redraw after Invalidate, got %q", out)
+	}
+}
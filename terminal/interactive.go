@@ -31,6 +31,20 @@ type DemoSettings struct {
 	LineDelay int // Extra delay between lines in ms
 }
 
+// NoEdEnv names the environment variable that turns the Ed mascot off when
+// set to a true value such as 1
+const NoEdEnv = "EDD_NO_ED"
+
+// HideEd turns off the Ed mascot and its animation, for demos, recordings
+// and terminals where it only gets in the way
+var HideEd bool
+
+// HideEdFromEnv reports whether EDD_NO_ED asks for the mascot to be off
+func HideEdFromEnv() bool {
+	hide, _ := strconv.ParseBool(os.Getenv(NoEdEnv))
+	return hide
+}
+
 // RunTUILoop runs the terminal UI loop with an already-configured TUI editor
 // This is called from main.go after setting up the editor
 func RunTUILoop(tui *editor.TUIEditor, filename string, demoSettings *DemoSettings) error {
@@ -51,17 +65,13 @@ func RunTUILoop(tui *editor.TUIEditor, filename string, demoSettings *DemoSettin
 		fmt.Print("\033[?25h")
 	}()
 
-	// Handle signals in background
-	go func() {
-		<-sigChan
-		// Restore terminal and exit cleanly
-		restoreTerminal()
-		fmt.Print("\033[?25h")
-		os.Exit(0)
-	}()
+	if HideEd {
+		tui.SetShowEd(false)
+	}
 
-	// Run the interactive loop
-	return runInteractiveLoop(tui, filename, demoSettings)
+	// Run the interactive loop; a signal ends it like a quit, so the
+	// caller's deferred cleanup, such as removing temp files, still runs
+	return runInteractiveLoop(tui, filename, demoSettings, sigChan)
 }
 
 
@@ -120,7 +130,7 @@ func launchExternalEditor(tui *editor.TUIEditor) error {
 
 	// Write current diagram to temp file
 	d := tui.GetDiagram()
-	data, err := json.MarshalIndent(d, "", "  ")
+	data, err := export.MarshalJSON(d, export.TempJSONIndentFromEnv())
 	if err != nil {
 		tmpFile.Close()
 		return fmt.Errorf("failed to marshal diagram: %w", err)
@@ -318,7 +328,7 @@ func clearStdinBuffer() {
 // Special error to signal return to picker
 var ErrReturnToPicker = fmt.Errorf("return_to_picker")
 
-func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *DemoSettings) error {
+func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *DemoSettings, interrupt <-chan os.Signal) error {
 	// Debug: Log that we're starting the interactive loop
 	if f, err := os.OpenFile("/tmp/edd_startup.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
 		fmt.Fprintf(f, "=== runInteractiveLoop started ===\n")
@@ -405,32 +415,39 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 	)
 
 	// Animation ticker - update Ed every 200ms
-	animTicker := time.NewTicker(200 * time.Millisecond)
-	defer animTicker.Stop()
+	animTicks, stopAnimation := edAnimation(tui)
+	defer stopAnimation()
 
 	// Main render loop
 	var buf bytes.Buffer
 	var lastOutput string
 	needsFullRedraw := true
 	lastWidth, lastHeight := getTerminalSize()
+	screen := NewFrameDiffer()
 
 	// Helper function to do a full redraw
 	fullRedraw := func() {
 		// Update terminal size
 		width, height := getTerminalSize()
 		tui.SetTerminalSize(width, height)
+		if width != lastWidth || height != lastHeight {
+			screen.Invalidate()
+		}
 		lastWidth, lastHeight = width, height
 
 		// Buffer all output to reduce flicker
 		buf.Reset()
 
-		// Clear and move to home - this prevents artifacts
-		buf.WriteString("\033[H\033[2J")
-
 		// Render current state
 		output := tui.Render()
 		lastOutput = output
 
+		// Clear and redraw everything unless only the changes are wanted and
+		// no overlays are drawn over the diagram
+		if !DiffRedraw || tui.GetMode() == editor.ModeJump || tui.GetMode() == editor.ModeHintMenu {
+			screen.Invalidate()
+		}
+
 		// Debug: Log if we're editing a connection and whether cursor is visible
 		if tui.GetMode() == editor.ModeEdit && tui.GetSelectedConnection() >= 0 {
 			if f, err := os.OpenFile("/tmp/edd_cursor_debug.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
@@ -446,7 +463,7 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 			}
 		}
 
-		buf.WriteString(output)
+		buf.WriteString(screen.Diff(output))
 
 		// Write main content first
 		fmt.Print(buf.String())
@@ -467,23 +484,34 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 			}
 		}
 
+		// Overlay node IDs on their boxes, repainting those rows next frame
+		for _, pos := range drawNodeIDs(tui, lastOutput) {
+			screen.InvalidateRows(pos.ViewportY-1, pos.ViewportY-1)
+		}
+
 		// Draw hint menu if in hint mode
 		if tui.GetMode() == editor.ModeHintMenu {
 			hintDisplay := tui.GetHintMenuDisplay()
 			fmt.Print(hintDisplay)
 		}
 
-		// Show status line
-		showStatusLine(tui, filename, demoPlayer)
+		// Presenting shows only the slide, without the status line or Ed
+		if tui.GetMode() != editor.ModePresent {
+			// Show status line
+			showStatusLine(tui, filename, demoPlayer)
 
-		// Draw Ed (but not in JSON mode)
-		if tui.GetMode() != editor.ModeJSON {
-			drawEd(tui)
+			// Draw Ed (but not in JSON mode)
+			if tui.GetMode() != editor.ModeJSON {
+				drawEd(tui)
+			}
 		}
 
 		// Hide terminal cursor - we draw our own cursor character in the diagram
 		fmt.Print("\033[?25l")
 
+		// Ed and the status line cover the bottom rows
+		screen.InvalidateRows(height-5, height-1)
+
 		needsFullRedraw = false
 	}
 
@@ -499,6 +527,9 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 
 		// Handle input or animation
 		select {
+		case <-interrupt:
+			return nil // Exit completely, as for :qq
+
 		case keyEvent := <-keyChan:
 			// Check for demo control keys in normal mode
 			if !demoPlayer.IsPlaying() && tui.GetMode() == editor.ModeNormal {
@@ -536,6 +567,9 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 			}
 
 			// Normal key handling
+			if drawsOnScreen(tui.GetMode(), keyEvent) {
+				screen.Invalidate()
+			}
 			quitType := handleKeyEvent(tui, keyEvent, &filename, demoPlayer)
 			if quitType == 1 {
 				return ErrReturnToPicker // Return to picker
@@ -553,6 +587,9 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 
 		case demoKeyEvent := <-demoChan:
 			// Handle demo input just like real input
+			if drawsOnScreen(tui.GetMode(), demoKeyEvent) {
+				screen.Invalidate()
+			}
 			quitType := handleKeyEvent(tui, demoKeyEvent, &filename, demoPlayer)
 			if quitType == 1 {
 				return ErrReturnToPicker // Return to picker
@@ -561,11 +598,11 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 			}
 			needsFullRedraw = true
 
-		case <-animTicker.C:
+		case <-animTicks:
 			// Animate Ed
 			tui.AnimateEd()
 			// Only update Ed and status line, not the whole screen
-			if tui.GetMode() != editor.ModeJSON {
+			if tui.GetMode() != editor.ModeJSON && tui.GetMode() != editor.ModePresent {
 				// Redraw status line (Ed's state might have changed)
 				showStatusLine(tui, filename, demoPlayer)
 				// Draw Ed at new position
@@ -573,6 +610,11 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 			}
 
 		default:
+			// Redraw once a :goto highlight has run its course
+			if tui.ExpireFlash() {
+				needsFullRedraw = true
+			}
+
 			// Non-blocking - allows us to check for resize and redraw
 			if needsFullRedraw {
 				fullRedraw()
@@ -583,6 +625,30 @@ func runInteractiveLoop(tui *editor.TUIEditor, filename string, demoSettings *De
 	}
 }
 
+// edAnimation returns the ticks that advance Ed's animation and a function
+// that stops them. With the mascot turned off nothing ticks.
+func edAnimation(tui *editor.TUIEditor) (<-chan time.Time, func()) {
+	if !tui.ShowsEd() {
+		return nil, func() {}
+	}
+	ticker := time.NewTicker(200 * time.Millisecond)
+	return ticker.C, ticker.Stop
+}
+
+// drawsOnScreen reports whether handling a key in the given mode may draw on
+// the terminal outside of the rendered frame (help, the external editor,
+// save and export messages, jump labels and menus), so that the next frame
+// must be drawn in full
+func drawsOnScreen(mode editor.Mode, keyEvent editor.KeyEvent) bool {
+	switch mode {
+	case editor.ModeNormal:
+		return !keyEvent.IsSpecial() && (keyEvent.Rune == 'E' || keyEvent.Rune == '?' || keyEvent.Rune == 'h')
+	case editor.ModeInsert, editor.ModeEdit:
+		return false
+	}
+	return true
+}
+
 // handleKeyEvent processes a key event from either real input or demo playback
 // Returns: 0 = no quit, 1 = quit to picker, 2 = quit completely
 func handleKeyEvent(tui *editor.TUIEditor, keyEvent editor.KeyEvent, filename *string, demoPlayer *demo.Player) int {
@@ -607,6 +673,8 @@ func handleKeyEvent(tui *editor.TUIEditor, keyEvent editor.KeyEvent, filename *s
 			return handleCommandMode(tui, key, filename)
 		case editor.ModeJSON:
 			handleJSONMode(tui, key)
+		case editor.ModePresent:
+			tui.HandleKey(key)
 		case editor.ModeHintMenu:
 			tui.HandleHintMenuInput(key)
 		}
@@ -668,53 +736,55 @@ func executeSave(tui *editor.TUIEditor, filename string) {
 	// Get the diagram
 	d := tui.GetDiagram()
 
-	// Check if this is a markdown context (temp file with .ctx file)
-	if strings.HasPrefix(filename, "/tmp/edd_markdown_") {
-		// Check for context file
-		ctxFile := filename + ".ctx"
-		if ctxData, err := ioutil.ReadFile(ctxFile); err == nil {
-			// Parse context: markdown_file\nblock_index\nblock_type
-			lines := strings.Split(string(ctxData), "\n")
-			if len(lines) >= 3 {
-				markdownFile := lines[0]
-				blockIndex, _ := strconv.Atoi(lines[1])
-				blockType := lines[2]
-
-				// Save back to markdown
-			if err := SaveToMarkdown(d, markdownFile, blockIndex, blockType); err != nil {
-					fmt.Fprintf(os.Stderr, "\nError saving to markdown: %v", err)
-					return
-				}
-				// Write a visible success message to the command result
-				fmt.Fprintf(os.Stderr, "\nSaved to %s", markdownFile)
-				tui.SetCommandResult(fmt.Sprintf("Saved to %s", markdownFile))
-				tui.SetHasChanges(false) // Clear the changes flag after successful save
+	// Diagrams edited through a context temp file are saved back to their source
+	if ctx, ok := ReadEditContext(filename); ok {
+		// Diagrams from a multi-diagram JSON file go back into its array
+		if ctx.BlockType == CollectionBlockType {
+			if err := SaveToCollection(d, ctx.File, ctx.BlockIndex); err != nil {
+				tui.SetCommandResult(fmt.Sprintf("Error saving to %s: %v", ctx.File, err))
 				return
 			}
+			tui.SetCommandResult(fmt.Sprintf("Saved to %s", ctx.File))
+			tui.SetHasChanges(false)
+			return
 		}
-	}
-
-	// Normal JSON save
-	// Ensure unique connection IDs before saving
-	diagram.EnsureUniqueConnectionIDs(d)
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(d, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "\nError saving: %v", err)
+		// Save back to markdown
+		if err := SaveToMarkdown(d, ctx.File, ctx.BlockIndex, ctx.BlockType); err != nil {
+			fmt.Fprintf(os.Stderr, "\nError saving to markdown: %v", err)
+			return
+		}
+		// Write a visible success message to the command result
+		fmt.Fprintf(os.Stderr, "\nSaved to %s", ctx.File)
+		tui.SetCommandResult(fmt.Sprintf("Saved to %s", ctx.File))
+		tui.SetHasChanges(false) // Clear the changes flag after successful save
 		return
 	}
 
-	// Write to file
-	err = ioutil.WriteFile(filename, data, 0644)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "\nError writing file: %v", err)
+	// Normal JSON save
+	if err := writeDiagramFile(d, filename); err != nil {
+		fmt.Fprintf(os.Stderr, "\n%v", err)
 		return
 	}
 
 	fmt.Fprintf(os.Stderr, "\nSaved to %s", filename)
 }
 
+// writeDiagramFile saves the diagram as JSON, first making its connection
+// IDs unique
+func writeDiagramFile(d *diagram.Diagram, filename string) error {
+	diagram.EnsureUniqueConnectionIDs(d)
+
+	data, err := export.MarshalJSON(d, export.JSONIndentFromEnv())
+	if err != nil {
+		return fmt.Errorf("Error saving: %v", err)
+	}
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("Error writing file: %v", err)
+	}
+	return nil
+}
+
 // SaveToMarkdown saves the diagram back to the markdown file
 // Exported for testing purposes
 func SaveToMarkdown(d *diagram.Diagram, markdownFile string, blockIndex int, blockType string) error {
@@ -777,6 +847,41 @@ func SaveToMarkdown(d *diagram.Diagram, markdownFile string, blockIndex int, blo
 	return nil
 }
 
+// CollectionBlockType marks an editing context whose diagram came from the
+// "diagrams" array of a multi-diagram JSON file rather than a markdown block
+const CollectionBlockType = "collection"
+
+// SaveToCollection writes d back into the diagrams array of a multi-diagram
+// JSON file at the given 1-based index, leaving the other diagrams untouched
+func SaveToCollection(d *diagram.Diagram, collectionFile string, index int) error {
+	content, err := ioutil.ReadFile(collectionFile)
+	if err != nil {
+		return fmt.Errorf("failed to read collection file: %w", err)
+	}
+
+	collection, ok, err := diagram.ParseCollection(content)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s no longer contains a diagrams array", collectionFile)
+	}
+
+	diagram.EnsureUniqueConnectionIDs(d)
+	if err := collection.Replace(index-1, d); err != nil {
+		return err
+	}
+
+	data, err := export.MarshalJSON(collection, export.JSONIndentFromEnv())
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection: %w", err)
+	}
+	if err := ioutil.WriteFile(collectionFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write collection file: %w", err)
+	}
+	return nil
+}
+
 // executeExport handles the actual export of the diagram
 func executeExport(tui *editor.TUIEditor, format, filename string) {
 	// Get the diagram
@@ -796,8 +901,14 @@ func executeExport(tui *editor.TUIEditor, format, filename string) {
 		return
 	}
 
-	// Export the diagram
-	output, err := exporter.Export(d)
+	// Export the diagram; ascii is what the editor has drawn, so it matches
+	// the screen even where the editor's drawing differs from the CLI's
+	var output string
+	if exportFormat == export.FormatASCII {
+		output, err = tui.LastRender()
+	} else {
+		output, err = exporter.Export(d)
+	}
 	if err != nil {
 		tui.SetCommandResult("Export failed: " + err.Error())
 		return
@@ -1000,6 +1111,14 @@ func drawJumpLabels(tui *editor.TUIEditor, output string) {
 	fmt.Print(labelOutput)
 }
 
+// drawNodeIDs draws the node ID overlay, if on, returning where it drew
+func drawNodeIDs(tui *editor.TUIEditor, output string) []editor.LabelPosition {
+	hasScrollIndicator := strings.HasPrefix(output, "[↑")
+	positions := tui.CalculateNodeIDPositions(hasScrollIndicator)
+	fmt.Print(editor.RenderLabelsToString(positions))
+	return positions
+}
+
 func drawConnectionLabels(tui *editor.TUIEditor) {
 	// Get connection labels from TUI
 	labels := tui.GetConnectionLabels()
@@ -1047,6 +1166,13 @@ func drawConnectionLabels(tui *editor.TUIEditor) {
 					labelPoint = path.Points[labelIndex]
 				}
 
+				// Move the label onto the visible part of the connection when
+				// its usual spot is scrolled off
+				labelPoint, ok = tui.ConnectionLabelPoint(connIndex, labelPoint)
+				if !ok {
+					continue
+				}
+
 				// Try to find a clear spot near this point
 				offsets := []struct{ dx, dy int }{
 					{0, 0},  // On the line (preferred - labels should be on arrows)
@@ -1206,7 +1332,17 @@ func drawInsertionLabels(tui *editor.TUIEditor) {
 }
 
 func drawEd(tui *editor.TUIEditor) {
-	// Draw Ed in bottom-right corner using ANSI positioning
+	fmt.Print(edOverlay(tui))
+}
+
+// edOverlay returns the escape sequences that draw Ed in the bottom-right
+// corner, or nothing when the mascot is turned off
+func edOverlay(tui *editor.TUIEditor) string {
+	if !tui.ShowsEd() {
+		return ""
+	}
+	var sb strings.Builder
+
 	mode := tui.GetMode()
 	frame := tui.GetEddFrame()
 
@@ -1239,34 +1375,35 @@ func drawEd(tui *editor.TUIEditor) {
 	// In edit mode, don't save/restore cursor - let positionCursor handle it
 	// In other modes, save cursor before drawing Ed so we don't affect other overlays
 	if mode != editor.ModeEdit && mode != editor.ModeInsert {
-		fmt.Print("\033[s")
+		sb.WriteString("\033[s")
 	}
 
 	// Draw Ed's box - position above status line
 	// We need to position Ed carefully to avoid the status line
 
 	// Top of box (4 lines from bottom)
-	fmt.Print("\033[999;999H") // Go to bottom-right
-	fmt.Print("\033[4A")       // Move up 4 lines from bottom
-	fmt.Print("\033[20D")      // Move left 20 chars from right edge
-	fmt.Printf("%s╭────╮%s", color, reset)
+	sb.WriteString("\033[999;999H") // Go to bottom-right
+	sb.WriteString("\033[4A")       // Move up 4 lines from bottom
+	sb.WriteString("\033[20D")      // Move left 20 chars from right edge
+	fmt.Fprintf(&sb, "%s╭────╮%s", color, reset)
 
 	// Ed's face and mode (3 lines from bottom)
-	fmt.Print("\033[999;999H") // Go to bottom-right again
-	fmt.Print("\033[3A")       // Move up 3 lines from bottom
-	fmt.Print("\033[20D")      // Move left 20 chars from right edge
-	fmt.Printf("%s│%s│%s %s", color, frame, reset, mode)
+	sb.WriteString("\033[999;999H") // Go to bottom-right again
+	sb.WriteString("\033[3A")       // Move up 3 lines from bottom
+	sb.WriteString("\033[20D")      // Move left 20 chars from right edge
+	fmt.Fprintf(&sb, "%s│%s│%s %s", color, frame, reset, mode)
 
 	// Bottom of box (2 lines from bottom)
-	fmt.Print("\033[999;999H") // Go to bottom-right again
-	fmt.Print("\033[2A")       // Move up 2 lines from bottom
-	fmt.Print("\033[20D")      // Move left 20 chars from right edge
-	fmt.Printf("%s╰────╯%s", color, reset)
+	sb.WriteString("\033[999;999H") // Go to bottom-right again
+	sb.WriteString("\033[2A")       // Move up 2 lines from bottom
+	sb.WriteString("\033[20D")      // Move left 20 chars from right edge
+	fmt.Fprintf(&sb, "%s╰────╯%s", color, reset)
 
 	// Restore cursor position (but not in edit mode - positionCursor will set it)
 	if mode != editor.ModeEdit && mode != editor.ModeInsert {
-		fmt.Print("\033[u")
+		sb.WriteString("\033[u")
 	}
+	return sb.String()
 }
 
 func showStatusLine(tui *editor.TUIEditor, filename string, demoPlayer *demo.Player) {
@@ -1501,6 +1638,10 @@ func GetTerminalSize() (int, int) {
 		}
 	}
 
+	// Some terminals report a zero size rather than failing
+	if ws.Col == 0 || ws.Row == 0 {
+		return 80, 24
+	}
 	return int(ws.Col), int(ws.Row)
 }
 
@@ -1532,6 +1673,8 @@ func showHelp() {
 	fmt.Println("  G     - Go to bottom")
 	fmt.Println("  Ctrl+U - Scroll up half page")
 	fmt.Println("  Ctrl+D - Scroll down half page")
+	fmt.Println("  ] / [ - Select next/previous connected node")
+	fmt.Println("  Enter - Step on to the selected node's neighbors")
 	fmt.Println()
 	fmt.Println("  P     - Play demo (from demo.json)")
 	fmt.Println("  R     - Create example demo.json")
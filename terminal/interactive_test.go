@@ -0,0 +1,70 @@
+package terminal
+
+import (
+	"edd/editor"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEdCanBeTurnedOff(t *testing.T) {
+	tui := editor.NewTUIEditor(editor.NewRealRenderer())
+
+	overlay := edOverlay(tui)
+	if !strings.Contains(overlay, "╭────╮") || !strings.Contains(overlay, tui.GetEddFrame()) {
+		t.Fatalf("Expected Ed drawn by default, got %q", overlay)
+	}
+	ticks, stop := edAnimation(tui)
+	if ticks == nil {
+		t.Errorf("Expected Ed's animation to tick by default")
+	}
+	stop()
+
+	tui.SetShowEd(false)
+	if overlay := edOverlay(tui); overlay != "" {
+		t.Errorf("Expected no mascot or mode indicator drawn, got %q", overlay)
+	}
+	if ticks, stop := edAnimation(tui); ticks != nil {
+		t.Errorf("Expected no animation ticker without Ed")
+	} else {
+		stop()
+	}
+}
+
+func TestHideEdFromEnv(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "1": true, "true": true, "0": false, "nope": false} {
+		t.Setenv(NoEdEnv, value)
+		if got := HideEdFromEnv(); got != want {
+			t.Errorf("%s=%q: expected %v, got %v", NoEdEnv, value, want, got)
+		}
+	}
+}
+
+func TestInteractiveLoopReturnsOnSignal(t *testing.T) {
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+
+	// The loop must return, not exit the process, so deferred cleanup runs
+	done := make(chan error, 1)
+	go func() {
+		done <- runInteractiveLoop(editor.NewTUIEditor(editor.NewRealRenderer()), "", nil, interrupt)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a signal to end the loop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the loop to return after a signal")
+	}
+}
@@ -0,0 +1,78 @@
+package terminal
+
+import (
+	"bufio"
+	"edd/diagram"
+	"edd/editor"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RunCommandFile runs a script of : commands against the editor without a
+// terminal, one command per line with or without its leading colon. Blank
+// lines and lines starting with # are skipped. :w saves to filename unless
+// given a file of its own, and a context temp file for a diagram of a
+// multi-diagram JSON file saves back into the collection. :export writes its
+// file, or to out when given no file, and :q ends the script early. The
+// first command that fails stops the script with an error naming its line.
+func RunCommandFile(tui *editor.TUIEditor, script io.Reader, filename string, out io.Writer) error {
+	scanner := bufio.NewScanner(script)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), ":")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		tui.ExecuteCommand(line)
+		if text := tui.GetViewText(); text != "" {
+			fmt.Fprintln(out, strings.TrimRight(text, "\n"))
+			tui.SetMode(editor.ModeNormal)
+		}
+		if requested, saveFilename := tui.GetSaveRequest(); requested {
+			if saveFilename == "" {
+				saveFilename = filename
+			}
+			if saveFilename == "" {
+				return fmt.Errorf("line %d: no file to save to", number)
+			}
+			savedTo, err := saveScriptDiagram(tui.GetDiagram(), saveFilename)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", number, err)
+			}
+			tui.SetCommandResult("Saved to " + savedTo)
+		}
+		if format, exportFilename := tui.GetExportRequest(); format != "" {
+			executeExport(tui, format, exportFilename)
+		}
+
+		if result := tui.GetCommandResult(); scriptCommandFailed(result) {
+			return fmt.Errorf("line %d: %s: %s", number, line, result)
+		}
+		if tui.GetQuitRequest() {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+// saveScriptDiagram writes the script's diagram to filename, or back into its
+// place in a multi-diagram JSON file when filename is the context temp file
+// of one of its diagrams, and returns the file written
+func saveScriptDiagram(d *diagram.Diagram, filename string) (string, error) {
+	if ctx, ok := ReadEditContext(filename); ok && ctx.BlockType == CollectionBlockType {
+		return ctx.File, SaveToCollection(d, ctx.File, ctx.BlockIndex)
+	}
+	return filename, writeDiagramFile(d, filename)
+}
+
+// scriptCommandFailed reports whether a command result is an error, which
+// stops a script rather than being shown in the status line and forgotten
+func scriptCommandFailed(result string) bool {
+	for _, prefix := range []string{"Error", "Usage:", "Unknown", "Unsaved changes", "Export failed", "Write error", "Clipboard error"} {
+		if strings.HasPrefix(result, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package terminal
+
+import (
+	"bytes"
+	"edd/diagram"
+	"edd/editor"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCommandFileBuildsAndExportsDiagram(t *testing.T) {
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "pipeline.json")
+	art := filepath.Join(dir, "pipeline.txt")
+	script := `# A client calling an API backed by a database
+:add Client
+add API
+add Database\nprimary
+connect 1 2 calls
+connect 2 3
+hint 2->3 style dashed
+w
+export mermaid
+export ascii ` + art + `
+`
+
+	var out bytes.Buffer
+	tui := editor.NewTUIEditor(editor.NewRealRenderer())
+	if err := RunCommandFile(tui, strings.NewReader(script), saved, &out); err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+
+	// :export with no file writes to the output
+	for _, want := range []string{"graph TD", "N1[Client]", "-->|calls|", "-.->"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the Mermaid output:\n%s", want, out.String())
+		}
+	}
+
+	// :w saves to the diagram file
+	data, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d diagram.Diagram
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Nodes) != 3 || len(d.Connections) != 2 || d.Connections[0].Label != "calls" {
+		t.Errorf("Unexpected saved diagram: %+v", d)
+	}
+	if got := d.Nodes[2].Text; len(got) != 2 || got[1] != "primary" {
+		t.Errorf("Expected a two-line node, got %q", got)
+	}
+
+	rendered, err := os.ReadFile(art)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rendered), "Client") || !strings.Contains(string(rendered), "╎") {
+		t.Errorf("Expected the exported drawing:\n%s", rendered)
+	}
+}
+
+func TestRunCommandFileStopsAtFailingLine(t *testing.T) {
+	tui := editor.NewTUIEditor(editor.NewRealRenderer())
+	script := "add One\n\nconnect 1 7\nadd Never\n"
+
+	err := RunCommandFile(tui, strings.NewReader(script), "", &bytes.Buffer{})
+	if err == nil || err.Error() != "line 3: connect 1 7: Error: no node with ID 7" {
+		t.Fatalf("Expected the failing line reported, got %v", err)
+	}
+	if got := len(tui.GetDiagram().Nodes); got != 1 {
+		t.Errorf("Expected the script to stop at the failure, got %d nodes", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"edd/diagram"
 	"edd/export"
 	"edd/importer"
+	"edd/render"
 	"strings"
 	"testing"
 )
@@ -429,4 +430,72 @@ func TestComplexDiagramRoundTrip(t *testing.T) {
 			t.Errorf("Connection count changed: %d -> %d", len(complex.Connections), len(reimported.Connections))
 		}
 	})
-}
\ No newline at end of file
+}
+
+// TestASCIIRoundTrip renders a box diagram and imports the text back
+func TestASCIIRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+	}{
+		{"vertical", ""},
+		{"horizontal", "horizontal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := &diagram.Diagram{
+				Type: "box",
+				Nodes: []diagram.Node{
+					{ID: 0, Text: []string{"Client"}},
+					{ID: 1, Text: []string{"API", "Server"}},
+				},
+				Connections: []diagram.Connection{
+					{From: 0, To: 1, Arrow: true, Label: "request"},
+				},
+			}
+			if tt.layout != "" {
+				original.Hints = map[string]string{"layout": tt.layout}
+			}
+
+			renderer := render.NewFlowchartRenderer(render.TerminalCapabilities{UnicodeLevel: render.UnicodeFull})
+			rendered, err := renderer.Render(original)
+			if err != nil {
+				t.Fatalf("Failed to render: %v", err)
+			}
+
+			registry := importer.NewImporterRegistry()
+			reimported, err := registry.Import(rendered)
+			if err != nil {
+				t.Fatalf("Failed to reimport: %v\n%s", err, rendered)
+			}
+
+			if len(reimported.Nodes) != 2 {
+				t.Fatalf("Expected 2 nodes, got %d:\n%s", len(reimported.Nodes), rendered)
+			}
+			byText := make(map[string]int)
+			for _, node := range reimported.Nodes {
+				byText[strings.Join(node.Text, " ")] = node.ID
+			}
+			client, ok := byText["Client"]
+			if !ok {
+				t.Fatalf("Client node missing, got %v", byText)
+			}
+			server, ok := byText["API Server"]
+			if !ok {
+				t.Fatalf("API Server node missing, got %v", byText)
+			}
+
+			if len(reimported.Connections) != 1 {
+				t.Fatalf("Expected 1 connection, got %d:\n%s", len(reimported.Connections), rendered)
+			}
+			conn := reimported.Connections[0]
+			if conn.From != client || conn.To != server || !conn.Arrow {
+				t.Errorf("Expected arrow from Client to API Server, got %+v", conn)
+			}
+			if conn.Label != "request" {
+				t.Errorf("Expected label %q, got %q:\n%s", "request", conn.Label, rendered)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package validation
+
+import (
+	"edd/diagram"
+	"fmt"
+	"sort"
+)
+
+// GraphIssueKind categorizes a structural problem found in a diagram's graph.
+type GraphIssueKind string
+
+const (
+	// IssueOrphan marks a node with no connections at all.
+	IssueOrphan GraphIssueKind = "orphan"
+	// IssueUnreachable marks a connected node that no root can reach by following arrows.
+	IssueUnreachable GraphIssueKind = "unreachable"
+)
+
+// GraphIssue describes a structural problem with a single node.
+type GraphIssue struct {
+	NodeID  int
+	Kind    GraphIssueKind
+	Message string
+}
+
+// String returns a human-readable description of the issue.
+func (i GraphIssue) String() string {
+	return fmt.Sprintf("node %d (%s): %s", i.NodeID, i.Kind, i.Message)
+}
+
+// ValidateGraph checks a flowchart for nodes that cannot be reached from any
+// source. Roots are nodes with no inbound connections (self-loops don't count);
+// every connected node that no root reaches along connection direction is
+// reported as unreachable. Nodes with no connections at all are reported as
+// orphans instead. Sequence diagrams are not checked.
+func ValidateGraph(d *diagram.Diagram) []GraphIssue {
+	if d == nil || d.Type == string(diagram.DiagramTypeSequence) {
+		return nil
+	}
+
+	nodeIDs := make(map[int]bool, len(d.Nodes))
+	for _, node := range d.Nodes {
+		nodeIDs[node.ID] = true
+	}
+
+	inDegree := make(map[int]int)
+	connected := make(map[int]bool)
+	adjacency := make(map[int][]int)
+	for _, conn := range d.Connections {
+		if !nodeIDs[conn.From] || !nodeIDs[conn.To] {
+			continue
+		}
+		connected[conn.From] = true
+		connected[conn.To] = true
+		if conn.From == conn.To {
+			continue
+		}
+		adjacency[conn.From] = append(adjacency[conn.From], conn.To)
+		inDegree[conn.To]++
+	}
+
+	// Walk forward from every root
+	reached := make(map[int]bool)
+	var stack []int
+	for _, node := range d.Nodes {
+		if connected[node.ID] && inDegree[node.ID] == 0 {
+			reached[node.ID] = true
+			stack = append(stack, node.ID)
+		}
+	}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range adjacency[current] {
+			if !reached[next] {
+				reached[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+
+	var issues []GraphIssue
+	for _, node := range d.Nodes {
+		switch {
+		case !connected[node.ID]:
+			issues = append(issues, GraphIssue{
+				NodeID:  node.ID,
+				Kind:    IssueOrphan,
+				Message: "node has no connections",
+			})
+		case !reached[node.ID]:
+			issues = append(issues, GraphIssue{
+				NodeID:  node.ID,
+				Kind:    IssueUnreachable,
+				Message: "node cannot be reached from any starting node",
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].NodeID < issues[j].NodeID
+	})
+
+	return issues
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"edd/diagram"
+	"testing"
+)
+
+func TestValidateGraph(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodes       int
+		connections [][2]int
+		want        map[int]GraphIssueKind
+	}{
+		{
+			name:        "linear flow has no issues",
+			nodes:       3,
+			connections: [][2]int{{0, 1}, {1, 2}},
+			want:        map[int]GraphIssueKind{},
+		},
+		{
+			name:  "node only entered via back edge is reachable",
+			nodes: 4,
+			// 0 -> 1 -> 2 -> 3, and 3 -> 1 loops back
+			connections: [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 1}},
+			want:        map[int]GraphIssueKind{},
+		},
+		{
+			name:  "cycle with no root is unreachable",
+			nodes: 4,
+			// 0 -> 1 is the main flow; 2 <-> 3 loop on their own
+			connections: [][2]int{{0, 1}, {2, 3}, {3, 2}},
+			want: map[int]GraphIssueKind{
+				2: IssueUnreachable,
+				3: IssueUnreachable,
+			},
+		},
+		{
+			name:        "isolated node is an orphan, not unreachable",
+			nodes:       3,
+			connections: [][2]int{{0, 1}},
+			want: map[int]GraphIssueKind{
+				2: IssueOrphan,
+			},
+		},
+		{
+			name:        "self-loop does not count as an inbound path",
+			nodes:       3,
+			connections: [][2]int{{0, 1}, {2, 2}},
+			want:        map[int]GraphIssueKind{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &diagram.Diagram{}
+			for i := 0; i < tt.nodes; i++ {
+				d.Nodes = append(d.Nodes, diagram.Node{ID: i, Text: []string{"n"}})
+			}
+			for _, c := range tt.connections {
+				d.Connections = append(d.Connections, diagram.Connection{From: c[0], To: c[1]})
+			}
+
+			issues := ValidateGraph(d)
+			if len(issues) != len(tt.want) {
+				t.Fatalf("Got %d issues %v, want %d", len(issues), issues, len(tt.want))
+			}
+			for _, issue := range issues {
+				if kind, ok := tt.want[issue.NodeID]; !ok || kind != issue.Kind {
+					t.Errorf("Unexpected issue %v", issue)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateGraph_SkipsSequenceDiagrams(t *testing.T) {
+	d := &diagram.Diagram{
+		Type:  "sequence",
+		Nodes: []diagram.Node{{ID: 0}, {ID: 1}},
+	}
+	if issues := ValidateGraph(d); len(issues) != 0 {
+		t.Errorf("Expected no issues for sequence diagram, got %v", issues)
+	}
+}
@@ -0,0 +1,143 @@
+package validation
+
+import (
+	"edd/diagram"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LintCategory groups style warnings reported by Lint.
+type LintCategory string
+
+const (
+	// LintEmptyText marks a node with no visible text.
+	LintEmptyText LintCategory = "empty-text"
+	// LintLongLabel marks a node line or connection label longer than MaxLabelLength.
+	LintLongLabel LintCategory = "long-label"
+	// LintSelfLoop marks a flowchart connection whose endpoints are the same node.
+	LintSelfLoop LintCategory = "self-loop"
+	// LintDuplicateConnection marks a flowchart connection repeating an earlier one.
+	LintDuplicateConnection LintCategory = "duplicate-connection"
+	// LintSequenceHint marks a sequence-only hint used in a box diagram.
+	LintSequenceHint LintCategory = "sequence-hint"
+)
+
+// MaxLabelLength is the longest node line or connection label, in characters,
+// that Lint accepts without a warning.
+const MaxLabelLength = 60
+
+// Hints that only the sequence renderer understands
+var (
+	sequenceNodeHints       = []string{"lifeline-color", "lifeline-style"}
+	sequenceConnectionHints = []string{"activate", "activate_source", "deactivate"}
+)
+
+// LintWarning describes a style issue with a node or a connection. Exactly one
+// of NodeID and Connection refers to the offending element: NodeID is -1 for
+// connection warnings, Connection is -1 for node warnings.
+type LintWarning struct {
+	Category   LintCategory
+	NodeID     int
+	Connection int // Index into Diagram.Connections
+	Message    string
+}
+
+// String returns a human-readable description of the warning.
+func (w LintWarning) String() string {
+	if w.Connection >= 0 {
+		return fmt.Sprintf("connection %d (%s): %s", w.Connection, w.Category, w.Message)
+	}
+	return fmt.Sprintf("node %d (%s): %s", w.NodeID, w.Category, w.Message)
+}
+
+// Lint checks a diagram for style issues that don't stop it from rendering
+// but are probably mistakes: empty nodes, overly long labels, self-loops and
+// repeated connections in flowcharts, and sequence-only hints in box diagrams.
+// Node warnings come first, ordered by node ID, followed by connection
+// warnings in connection order.
+func Lint(d *diagram.Diagram) []LintWarning {
+	if d == nil {
+		return nil
+	}
+
+	sequence := d.Type == string(diagram.DiagramTypeSequence)
+
+	var nodeWarnings []LintWarning
+	for _, node := range d.Nodes {
+		warn := func(category LintCategory, format string, args ...interface{}) {
+			nodeWarnings = append(nodeWarnings, LintWarning{
+				Category:   category,
+				NodeID:     node.ID,
+				Connection: -1,
+				Message:    fmt.Sprintf(format, args...),
+			})
+		}
+
+		if strings.TrimSpace(strings.Join(node.Text, "")) == "" {
+			warn(LintEmptyText, "node has no text")
+		}
+		for i, line := range node.Text {
+			if n := utf8.RuneCountInString(line); n > MaxLabelLength {
+				warn(LintLongLabel, "line %d is %d characters long (max %d)", i+1, n, MaxLabelLength)
+			}
+		}
+		if !sequence {
+			for _, hint := range sequenceHintsIn(node.Hints, sequenceNodeHints) {
+				warn(LintSequenceHint, "hint %q only applies to sequence diagrams", hint)
+			}
+		}
+	}
+
+	sort.SliceStable(nodeWarnings, func(i, j int) bool {
+		return nodeWarnings[i].NodeID < nodeWarnings[j].NodeID
+	})
+
+	var connWarnings []LintWarning
+	seen := make(map[[2]int]int)
+	for i, conn := range d.Connections {
+		warn := func(category LintCategory, format string, args ...interface{}) {
+			connWarnings = append(connWarnings, LintWarning{
+				Category:   category,
+				NodeID:     -1,
+				Connection: i,
+				Message:    fmt.Sprintf(format, args...),
+			})
+		}
+
+		if n := utf8.RuneCountInString(conn.Label); n > MaxLabelLength {
+			warn(LintLongLabel, "label is %d characters long (max %d)", n, MaxLabelLength)
+		}
+		if sequence {
+			continue
+		}
+
+		// Sequence diagrams legitimately repeat messages and send them to self
+		if conn.From == conn.To {
+			warn(LintSelfLoop, "connects node %d to itself", conn.From)
+		}
+		key := [2]int{conn.From, conn.To}
+		if first, ok := seen[key]; ok {
+			warn(LintDuplicateConnection, "repeats connection %d (%d -> %d)", first, conn.From, conn.To)
+		} else {
+			seen[key] = i
+		}
+		for _, hint := range sequenceHintsIn(conn.Hints, sequenceConnectionHints) {
+			warn(LintSequenceHint, "hint %q only applies to sequence diagrams", hint)
+		}
+	}
+
+	return append(nodeWarnings, connWarnings...)
+}
+
+// sequenceHintsIn returns the keys from candidates that are set in hints.
+func sequenceHintsIn(hints map[string]string, candidates []string) []string {
+	var found []string
+	for _, key := range candidates {
+		if _, ok := hints[key]; ok {
+			found = append(found, key)
+		}
+	}
+	return found
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"edd/diagram"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	long := strings.Repeat("x", MaxLabelLength+1)
+
+	tests := []struct {
+		name     string
+		diagram  diagram.Diagram
+		category LintCategory
+		nodeID   int
+		conn     int
+	}{
+		{
+			name: "empty text",
+			diagram: diagram.Diagram{
+				Nodes: []diagram.Node{{ID: 1, Text: []string{"  "}}},
+			},
+			category: LintEmptyText,
+			nodeID:   1,
+			conn:     -1,
+		},
+		{
+			name: "long node line",
+			diagram: diagram.Diagram{
+				Nodes: []diagram.Node{{ID: 2, Text: []string{"ok", long}}},
+			},
+			category: LintLongLabel,
+			nodeID:   2,
+			conn:     -1,
+		},
+		{
+			name: "long connection label",
+			diagram: diagram.Diagram{
+				Nodes:       []diagram.Node{{ID: 1, Text: []string{"A"}}, {ID: 2, Text: []string{"B"}}},
+				Connections: []diagram.Connection{{From: 1, To: 2, Label: long}},
+			},
+			category: LintLongLabel,
+			nodeID:   -1,
+			conn:     0,
+		},
+		{
+			name: "self-loop in flowchart",
+			diagram: diagram.Diagram{
+				Nodes:       []diagram.Node{{ID: 1, Text: []string{"A"}}},
+				Connections: []diagram.Connection{{From: 1, To: 1}},
+			},
+			category: LintSelfLoop,
+			nodeID:   -1,
+			conn:     0,
+		},
+		{
+			name: "duplicate connection",
+			diagram: diagram.Diagram{
+				Nodes:       []diagram.Node{{ID: 1, Text: []string{"A"}}, {ID: 2, Text: []string{"B"}}},
+				Connections: []diagram.Connection{{From: 1, To: 2}, {From: 1, To: 2}},
+			},
+			category: LintDuplicateConnection,
+			nodeID:   -1,
+			conn:     1,
+		},
+		{
+			name: "sequence node hint in box diagram",
+			diagram: diagram.Diagram{
+				Nodes: []diagram.Node{{ID: 3, Text: []string{"A"}, Hints: map[string]string{"lifeline-style": "dashed"}}},
+			},
+			category: LintSequenceHint,
+			nodeID:   3,
+			conn:     -1,
+		},
+		{
+			name: "sequence connection hint in box diagram",
+			diagram: diagram.Diagram{
+				Nodes:       []diagram.Node{{ID: 1, Text: []string{"A"}}, {ID: 2, Text: []string{"B"}}},
+				Connections: []diagram.Connection{{From: 1, To: 2, Hints: map[string]string{"activate": "true"}}},
+			},
+			category: LintSequenceHint,
+			nodeID:   -1,
+			conn:     0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := Lint(&tt.diagram)
+			if len(warnings) != 1 {
+				t.Fatalf("Got %d warnings %v, want 1", len(warnings), warnings)
+			}
+			w := warnings[0]
+			if w.Category != tt.category || w.NodeID != tt.nodeID || w.Connection != tt.conn {
+				t.Errorf("Got %+v, want category %s node %d connection %d", w, tt.category, tt.nodeID, tt.conn)
+			}
+			if !strings.Contains(w.String(), string(tt.category)) {
+				t.Errorf("Expected %q to mention its category", w.String())
+			}
+		})
+	}
+}
+
+func TestLint_SequenceDiagrams(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: "sequence",
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"A"}, Hints: map[string]string{"lifeline-color": "red"}},
+			{ID: 2, Text: []string{"B"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2, Hints: map[string]string{"activate": "true"}},
+			{From: 1, To: 2},
+			{From: 2, To: 2},
+		},
+	}
+	if warnings := Lint(d); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for sequence diagram, got %v", warnings)
+	}
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"edd/diagram"
+	"fmt"
+)
+
+// ParticipantIssue describes a sequence diagram message sent from or to a
+// participant that the diagram doesn't declare.
+type ParticipantIssue struct {
+	Connection  int // Index into Diagram.Connections
+	Participant int // The undeclared node ID
+	Message     string
+}
+
+// String returns a human-readable description of the issue.
+func (i ParticipantIssue) String() string {
+	return fmt.Sprintf("connection %d (undeclared-participant): %s", i.Connection, i.Message)
+}
+
+// ValidateParticipants checks that every message of a sequence diagram runs
+// between declared participants. A message naming an unknown node has no
+// lifeline to start or end on and is drawn wrongly, which usually points to
+// an import bug. Other diagram types are not checked.
+func ValidateParticipants(d *diagram.Diagram) []ParticipantIssue {
+	if d == nil || d.Type != string(diagram.DiagramTypeSequence) {
+		return nil
+	}
+
+	declared := make(map[int]bool, len(d.Nodes))
+	for _, node := range d.Nodes {
+		declared[node.ID] = true
+	}
+
+	var issues []ParticipantIssue
+	for i, conn := range d.Connections {
+		if !declared[conn.From] {
+			issues = append(issues, ParticipantIssue{
+				Connection:  i,
+				Participant: conn.From,
+				Message:     fmt.Sprintf("message is sent from undeclared participant %d", conn.From),
+			})
+		}
+		if !declared[conn.To] && conn.To != conn.From {
+			issues = append(issues, ParticipantIssue{
+				Connection:  i,
+				Participant: conn.To,
+				Message:     fmt.Sprintf("message is sent to undeclared participant %d", conn.To),
+			})
+		}
+	}
+	return issues
+}
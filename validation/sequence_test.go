@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"edd/diagram"
+	"strings"
+	"testing"
+)
+
+func TestValidateParticipants(t *testing.T) {
+	d := &diagram.Diagram{
+		Type: string(diagram.DiagramTypeSequence),
+		Nodes: []diagram.Node{
+			{ID: 1, Text: []string{"Alice"}},
+			{ID: 2, Text: []string{"Bob"}},
+		},
+		Connections: []diagram.Connection{
+			{From: 1, To: 2, Label: "hello"},
+			{From: 2, To: 7, Label: "forward"},
+			{From: 9, To: 9, Label: "think"},
+			{From: 2, To: 1, Label: "reply"},
+		},
+	}
+
+	issues := ValidateParticipants(d)
+	if len(issues) != 2 {
+		t.Fatalf("Expected 2 issues, got %v", issues)
+	}
+	if issues[0].Connection != 1 || issues[0].Participant != 7 || !strings.Contains(issues[0].Message, "sent to undeclared participant 7") {
+		t.Errorf("Unexpected first issue: %+v", issues[0])
+	}
+	if issues[1].Connection != 2 || issues[1].Participant != 9 || !strings.Contains(issues[1].Message, "sent from undeclared participant 9") {
+		t.Errorf("Expected a self message reported once, got %+v", issues[1])
+	}
+	if got := issues[0].String(); got != "connection 1 (undeclared-participant): message is sent to undeclared participant 7" {
+		t.Errorf("Unexpected string %q", got)
+	}
+
+	// Box diagrams are not checked
+	d.Type = "box"
+	if issues := ValidateParticipants(d); len(issues) != 0 {
+		t.Errorf("Expected no issues for a box diagram, got %v", issues)
+	}
+}